@@ -8,15 +8,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 
 	mux "github.com/gorilla/mux"
 	_ "github.com/ketsuna-org/sovrabase/docs"
+	"github.com/ketsuna-org/sovrabase/internal/api/handlers"
 	"github.com/ketsuna-org/sovrabase/internal/api/routes"
 	"github.com/ketsuna-org/sovrabase/internal/config"
+	"github.com/ketsuna-org/sovrabase/internal/database"
 	"github.com/ketsuna-org/sovrabase/internal/middleware"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+	"github.com/ketsuna-org/sovrabase/internal/storage"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -32,6 +37,62 @@ func main() {
 		log.Fatalf("failed to load config from %s: %v", configPath, err)
 	}
 
+	// Setup Data API
+	orch, err := orchestrator.NewOrchestrator(&cfg.Orchestrator)
+	if err != nil {
+		log.Fatalf("failed to create orchestrator: %v", err)
+	}
+	databaseManager := database.NewManager(orch, &cfg.DataAPI)
+	defer databaseManager.Close()
+	databaseManager.StartTrashPurge(cfg.DataAPI.TrashPurgeInterval)
+	handlers.SetDatabaseManager(databaseManager)
+	handlers.SetOrchestrator(orch)
+	handlers.SetJWTSecret(cfg.DataAPI.JWTSecret)
+	handlers.SetSuperUser(cfg.SuperUser.Username, cfg.SuperUser.Password)
+
+	// Masking profiles live in the internal database, out of reach of the project keys
+	maskingStore, err := database.NewMaskingStore(context.Background(), &cfg.InternalDB)
+	if err != nil {
+		log.Printf("⚠️ Masking profiles kept in memory: %v", err)
+		maskingStore = database.NewMemoryMaskingStore()
+	}
+	maskingProfiles := database.NewMaskingProfiles(maskingStore, database.NewConfiguredCompliance(cfg.Organizations))
+	handlers.SetMaskingProfiles(maskingProfiles)
+
+	// The audit log lives in the internal database too, so that audited keys cannot rewrite it
+	auditLog, err := database.NewAuditLog(context.Background(), &cfg.InternalDB)
+	if err != nil {
+		log.Printf("⚠️ Audit log kept in memory: %v", err)
+		auditLog = database.NewMemoryAuditLog()
+	}
+	handlers.SetAuditLog(auditLog)
+
+	// Setup Storage: file metadata live in the internal database
+	if store, err := storage.NewStore(context.Background(), &cfg.InternalDB); err != nil {
+		log.Printf("⚠️ Storage disabled: %v", err)
+	} else if backend, err := storage.NewBackend(&cfg.Storage); err != nil {
+		log.Fatalf("failed to create storage backend: %v", err)
+	} else {
+		storageService := storage.NewService(store, backend)
+		defer storageService.Close()
+		storageService.StartUploadPurge(cfg.Storage.UploadPurgeInterval)
+		handlers.SetStorage(storageService)
+	}
+
+	// Expired database branches are deleted by the reconciler
+	reconciler := orchestrator.NewReconciler(orch, cfg.Orchestrator.ReconcileInterval, func(dbID string) {
+		owner, err := databaseManager.Owner(context.Background(), dbID)
+		databaseManager.Release(dbID)
+		if err == nil {
+			err = maskingProfiles.Forget(context.Background(), owner, dbID)
+		}
+		if err != nil {
+			log.Printf("⚠️ Failed to delete the masking profile of %s: %v", dbID, err)
+		}
+	})
+	reconciler.Start()
+	defer reconciler.Stop()
+
 	// Setup HTTP Server
 
 	router := mux.NewRouter()
@@ -832,6 +832,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
+                "description": "Requires a project administrator: the super user of the server (HTTP Basic authentication) or an API key of the project carrying the admin permission. The key is a token signed for the project and is only returned once. It only carries the permissions listed in the request (sql, branches, masking, admin), which grant access beyond the data API (see docs/sql.md); only the super user can grant admin. Without expires_at, the key is valid for one year.",
                 "consumes": [
                     "application/json"
                 ],
@@ -866,6 +867,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models_project.APIKey"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
@@ -1141,17 +1166,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/data/{db_id}/collections": {
+        "/project/{id}/data/{db_id}/audit": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Returns the audited operations of the database, such as raw SQL statements, most recent first. Pass the id of the last entry as before to get the next page. The log is kept in the control plane and only API keys can read it.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "List Collections",
+                "summary": "List Audit Log",
                 "parameters": [
                     {
                         "type": "string",
@@ -1166,26 +1195,72 @@ const docTemplate = `{
                         "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only return entries older than this id",
+                        "name": "before",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.AuditEntry"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/collections/{collection}": {
-            "get": {
+        "/project/{id}/data/{db_id}/batch": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Operations (insert, update, upsert, delete) run in order, possibly across collections. If one fails, every operation is rolled back and the response gives the index of the failing operation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Get Collection Data",
+                "summary": "Run several write operations atomically",
                 "parameters": [
                     {
                         "type": "string",
@@ -1203,28 +1278,68 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Collection Name",
-                        "name": "collection",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Operations to run",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.OperationResult"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/project/{id}/data/{db_id}/collections": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Delete Collection",
+                "summary": "List Collections",
                 "parameters": [
                     {
                         "type": "string",
@@ -1242,24 +1357,30 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Collection Name",
-                        "name": "collection",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Creates a table with typed columns, primary key, defaults, foreign keys and check constraints (expressed with the filter grammar of docs/filters.md). The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1269,7 +1390,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Update Collection",
+                "summary": "Create Collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -1287,39 +1408,63 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Collection Name",
-                        "name": "collection",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     },
                     {
-                        "description": "Collection update data",
+                        "description": "Collection definition",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateCollectionRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateCollectionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Table"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/transactions/begin": {
-            "post": {
+        "/project/{id}/data/{db_id}/collections/{collection}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Returns the columns, constraints and relations of the collection, read from the Postgres catalog, along with a JSON Schema of its documents.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Start a new transaction (Usefull for postgres Database only)",
+                "summary": "Get Collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -1334,26 +1479,47 @@ const docTemplate = `{
                         "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.CollectionSchema"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/data/{db_id}/transactions/{tx_id}/commit": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "The change is recorded in the migration history of the database.",
                 "tags": [
                     "Database"
                 ],
-                "summary": "Commit every subsequent query you did between !",
+                "summary": "Delete Collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -1371,30 +1537,53 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Transaction ID",
-                        "name": "tx_id",
+                        "description": "Collection Name",
+                        "name": "collection",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/data/{db_id}/transactions/{tx_id}/rollback": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Renames the collection. The change is recorded in the migration history of the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Rollback modification you did (during a Transaction only !)",
+                "summary": "Update Collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -1412,26 +1601,63 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Transaction ID",
-                        "name": "tx_id",
+                        "description": "Collection Name",
+                        "name": "collection",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Collection update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateCollectionRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Table"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/{collection}/delete": {
+        "/project/{id}/data/{db_id}/collections/{collection}/columns": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1441,7 +1667,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Delete a Batch with specific filters ! (Combine query and delete at once !)",
+                "summary": "Add Column",
                 "parameters": [
                     {
                         "type": "string",
@@ -1465,33 +1691,61 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "IDs to delete",
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Column definition",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ColumnDefinition"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Table"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/{collection}/indexes": {
-            "get": {
+        "/project/{id}/data/{db_id}/collections/{collection}/columns/{column}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "The change is recorded in the migration history of the database.",
                 "tags": [
                     "Database"
                 ],
-                "summary": "Get a List of index",
+                "summary": "Drop Column",
                 "parameters": [
                     {
                         "type": "string",
@@ -1513,20 +1767,46 @@ const docTemplate = `{
                         "name": "collection",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column Name",
+                        "name": "column",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
-            "post": {
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Omitted fields are left unchanged. The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1536,7 +1816,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Post a new index !",
+                "summary": "Alter Column",
                 "parameters": [
                     {
                         "type": "string",
@@ -1560,33 +1840,71 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Index creation data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateIndexRequest"
-                        }
+                        "type": "string",
+                        "description": "Column Name",
+                        "name": "column",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Column changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.AlterColumnRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Table"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/{collection}/indexes/{index_id}": {
-            "delete": {
+        "/project/{id}/data/{db_id}/collections/{collection}/documents": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Documents are returned in pages of ` + "`" + `limit` + "`" + ` items; pass the returned ` + "`" + `next_cursor` + "`" + ` as ` + "`" + `cursor` + "`" + ` to fetch the next page.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Remove an Index !",
+                "summary": "List Documents",
                 "parameters": [
                     {
                         "type": "string",
@@ -1611,36 +1929,122 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Index ID",
-                        "name": "index_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of columns to return",
+                        "name": "select",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of sort columns, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of documents (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of documents to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "description": "Include the total number of documents",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Related documents to embed: comma-separated relations nested with dots (author,comments.author), or a JSON array of relations with select, filter, sort and limit",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exclude",
+                            "include",
+                            "only"
+                        ],
+                        "type": "string",
+                        "description": "Documents of the trash to read, for a collection with soft delete",
+                        "name": "trashed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response: 304 without body if the page is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Page"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Hash of the page"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Page unchanged"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/{collection}/insert": {
-            "post": {
+        "/project/{id}/data/{db_id}/collections/{collection}/export": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Streams the documents of the collection as CSV, NDJSON or Parquet, as they are read. The filter query parameter is a JSON object in the filter grammar of docs/filters.md. An error raised once the file has started is reported by aborting the response. The masking profile of the database, if any, is applied to the exported columns. See docs/bulk.md.",
                 "produces": [
-                    "application/json"
+                    "application/x-ndjson",
+                    "text/csv",
+                    "application/vnd.apache.parquet"
                 ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Insert data in the database !",
+                "summary": "Export documents",
                 "parameters": [
                     {
                         "type": "string",
@@ -1664,39 +2068,99 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Data to insert",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.InsertDataRequest"
-                        }
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "description": "File format, from the Accept header by default, then ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter, as a JSON object",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated columns to export",
+                        "name": "select",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort columns, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of documents, all by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Export without the masking profile (requires the masking permission)",
+                        "name": "unmasked",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/data/{db_id}/{collection}/query": {
+            },
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Streams the documents matching the query as CSV, NDJSON or Parquet, as they are read. An error raised once the file has started is reported by aborting the response. The masking profile of the database, if any, is applied to the exported columns. See docs/bulk.md.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/x-ndjson",
+                    "text/csv",
+                    "application/vnd.apache.parquet"
                 ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Query the database on a collection aka table (Select QUERY only)",
+                "summary": "Export a query",
                 "parameters": [
                     {
                         "type": "string",
@@ -1720,39 +2184,64 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Query parameters",
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Export query",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.QueryCollectionRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ExportRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/data/{db_id}/{collection}/upsert": {
-            "post": {
+        "/project/{id}/data/{db_id}/collections/{collection}/indexes": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reads the indexes of the collection from pg_indexes, with their size and usage statistics.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Inserting or updating !",
+                "summary": "Get a List of index",
                 "parameters": [
                     {
                         "type": "string",
@@ -1776,33 +2265,50 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Data to upsert",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpsertDataRequest"
-                        }
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Index"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/data/{db_id}/{collection}/{doc_id}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Creates a Postgres index with column order, partial predicate (filter grammar of docs/filters.md), operator classes, full-text expressions and pgvector HNSW or IVFFlat indexes. Outside a transaction the index is built with CREATE INDEX CONCURRENTLY so writes are not blocked. The change is recorded in the migration history of the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "GET un document spécifique",
+                "summary": "Post a new index !",
                 "parameters": [
                     {
                         "type": "string",
@@ -1827,28 +2333,60 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "doc_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Index creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateIndexRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Index"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/project/{id}/data/{db_id}/collections/{collection}/indexes/{index_id}": {
             "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Outside a transaction the index is dropped with DROP INDEX CONCURRENTLY. The change is recorded in the migration history of the database.",
                 "tags": [
                     "Database"
                 ],
-                "summary": "DELETE un document spécifique",
+                "summary": "Remove an Index !",
                 "parameters": [
                     {
                         "type": "string",
@@ -1873,34 +2411,51 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "doc_id",
+                        "description": "Index name",
+                        "name": "index_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/project/{id}/data/{db_id}/collections/{collection}/policies": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "UPDATE un document spécifique",
+                "summary": "List Policies",
                 "parameters": [
                     {
                         "type": "string",
@@ -1925,51 +2480,29 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "doc_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Document update data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateDocumentRequest"
-                        }
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/project/{id}/databases": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "tags": [
-                    "Database"
-                ],
-                "summary": "List Databases",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Policy"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -1979,6 +2512,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
+                "description": "Compiles an access rule to a Postgres row-level security policy. Rules use the filter grammar of docs/filters.md, where {\"$auth\": \"uid\"}, {\"$auth\": \"role\"} or {\"$auth\": \"\u003cclaim\u003e\"} stand for the end user's token claims. Requests with an end-user token are then filtered by the database itself. The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1988,7 +2522,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Create Database",
+                "summary": "Create Policy",
                 "parameters": [
                     {
                         "type": "string",
@@ -1998,33 +2532,81 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Database creation data",
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Policy definition",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreatePolicyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Policy"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/databases/{db_id}": {
-            "get": {
+        "/project/{id}/data/{db_id}/collections/{collection}/policies/{policy}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Row-level security stays enabled on the collection: without any policy, end users can no longer access it. The change is recorded in the migration history of the database.",
                 "tags": [
                     "Database"
                 ],
-                "summary": "Get Database",
+                "summary": "Delete Policy",
                 "parameters": [
                     {
                         "type": "string",
@@ -2039,24 +2621,61 @@ const docTemplate = `{
                         "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Policy name",
+                        "name": "policy",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/project/{id}/data/{db_id}/collections/{collection}/search": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Delete Database",
+                "summary": "Get Search Column",
                 "parameters": [
                     {
                         "type": "string",
@@ -2071,20 +2690,43 @@ const docTemplate = `{
                         "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SearchIndex"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
-            "patch": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Adds a generated tsvector column (search_vector) computed from text and json columns, with a GIN index. Searches without explicit columns use it. The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2094,7 +2736,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Update Database",
+                "summary": "Enable Full-Text Search",
                 "parameters": [
                     {
                         "type": "string",
@@ -2111,33 +2753,66 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Database update data",
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Search column definition",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateDatabaseRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SearchIndexRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SearchIndex"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/databases/{db_id}/backup": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Drops the search_vector column and its index. The change is recorded in the migration history of the database.",
                 "tags": [
                     "Database"
                 ],
-                "summary": "Get Database Backups",
+                "summary": "Disable Full-Text Search",
                 "parameters": [
                     {
                         "type": "string",
@@ -2152,30 +2827,54 @@ const docTemplate = `{
                         "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/project/{id}/data/{db_id}/collections/{collection}/soft-delete": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Database"
                 ],
-                "summary": "Create Database Backup",
+                "summary": "Get Soft Delete Settings",
                 "parameters": [
                     {
                         "type": "string",
@@ -2192,29 +2891,41 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Backup creation data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBackupRequest"
-                        }
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SoftDelete"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/databases/{db_id}/restore": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Deletes then set deleted_at instead of removing rows, reads hide trashed documents, and trashed documents older than retention_days are purged. The deleted_at column is added if missing. The change is recorded in the migration history of the database.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2224,7 +2935,7 @@ const docTemplate = `{
                 "tags": [
                     "Database"
                 ],
-                "summary": "Restore Database",
+                "summary": "Enable Soft Delete",
                 "parameters": [
                     {
                         "type": "string",
@@ -2241,33 +2952,66 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Restore data",
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Trash settings",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.RestoreDatabaseRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SoftDeleteRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SoftDelete"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/functions": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Deletes remove rows again. The trash must be empty; the deleted_at column is kept. The change is recorded in the migration history of the database.",
                 "tags": [
-                    "Functions"
+                    "Database"
                 ],
-                "summary": "List Functions",
+                "summary": "Disable Soft Delete",
                 "parameters": [
                     {
                         "type": "string",
@@ -2275,98 +3019,61 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            },
-            "post": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Functions"
-                ],
-                "summary": "Create Function",
-                "parameters": [
+                    },
                     {
                         "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
-                    {
-                        "description": "Function creation data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateFunctionRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/project/{id}/functions/{function_id}": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "tags": [
-                    "Functions"
-                ],
-                "summary": "Get Function",
-                "parameters": [
                     {
                         "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Collection Name",
+                        "name": "collection",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Function ID",
-                        "name": "function_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/project/{id}/data/{db_id}/masking": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Functions"
+                    "Database"
                 ],
-                "summary": "Delete Function",
+                "summary": "Get Masking Profile",
                 "parameters": [
                     {
                         "type": "string",
@@ -2377,24 +3084,40 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Function ID",
-                        "name": "function_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.MaskingProfile"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
-            "patch": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Masking rules are applied per column to the data leaving the database: database branches and exports. The profile is enforced when the organization of the project requires it (RGPD compliance): it cannot be bypassed with unmasked, and a replacement must keep masking every existing column already masked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2402,9 +3125,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Functions"
+                    "Database"
                 ],
-                "summary": "Update Function",
+                "summary": "Set Masking Profile",
                 "parameters": [
                     {
                         "type": "string",
@@ -2415,45 +3138,59 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Function ID",
-                        "name": "function_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Function update data",
+                        "description": "Masking rules",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateFunctionRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.MaskingProfileRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.MaskingProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/project/{id}/functions/{function_id}/invoke": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "An enforced profile cannot be deleted.",
                 "tags": [
-                    "Functions"
+                    "Database"
                 ],
-                "summary": "Invoke Function",
+                "summary": "Delete Masking Profile",
                 "parameters": [
                     {
                         "type": "string",
@@ -2464,39 +3201,47 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Function ID",
-                        "name": "function_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Function invocation arguments",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.InvokeFunctionRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/functions/{function_id}/logs": {
+        "/project/{id}/data/{db_id}/migrations": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Every schema change made through the API is recorded. With format=sql, the history is returned as a SQL script that can be replayed on another database.",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
                 "tags": [
-                    "Functions"
+                    "Database"
                 ],
-                "summary": "Execution logs from a function (List of call by usages..)",
+                "summary": "List Migrations",
                 "parameters": [
                     {
                         "type": "string",
@@ -2507,30 +3252,56 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Function ID",
-                        "name": "function_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "sql"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Migration"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/logs": {
+        "/project/{id}/data/{db_id}/openapi.json": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Generates an OpenAPI 3.1 document describing the data API routes of every collection of the database, with JSON Schemas of their documents, to generate typed clients.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Logs of the project (Only include Organisations logs, not clients calls)",
+                "summary": "Get the OpenAPI document of a database",
                 "parameters": [
                     {
                         "type": "string",
@@ -2538,57 +3309,51 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/project/{id}/members": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "tags": [
-                    "Projects"
-                ],
-                "summary": "Get Project Members",
-                "parameters": [
+                    },
                     {
                         "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/project/{id}/data/{db_id}/sql": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Runs a parameterised SQL statement in its own transaction, with a statement timeout and a row limit. Only API keys carrying the sql permission are accepted. Results are streamed as JSON or CSV; an error raised while streaming is reported in the error field (JSON) or the X-SQL-Error trailer (CSV). Every statement is written to the audit log of the database. See docs/sql.md.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Add Project Member",
+                "summary": "Execute SQL",
                 "parameters": [
                     {
                         "type": "string",
@@ -2598,33 +3363,71 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Member data",
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Statement and parameters",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.AddProjectMemberRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SQLRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SQLOutcome"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/members/{user_id}": {
-            "delete": {
+        "/project/{id}/data/{db_id}/transactions/begin": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "tags": [
-                    "Projects"
+                "description": "The transaction holds a dedicated connection until it is committed or rolled back. Send its ID in the X-Transaction-ID header of data operations to run them inside it. An idle transaction is rolled back after idle_timeout seconds.",
+                "produces": [
+                    "application/json"
                 ],
-                "summary": "Delete a member of the project",
+                "tags": [
+                    "Database"
+                ],
+                "summary": "Start a new transaction (Usefull for postgres Database only)",
                 "parameters": [
                     {
                         "type": "string",
@@ -2635,34 +3438,45 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.TransactionResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/project/{id}/data/{db_id}/transactions/{tx_id}/commit": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Update Project Member",
+                "summary": "Commit every subsequent query you did between !",
                 "parameters": [
                     {
                         "type": "string",
@@ -2673,39 +3487,49 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "user_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Member update data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateProjectMemberRequest"
-                        }
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tx_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, commit successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/metrics": {
-            "get": {
+        "/project/{id}/data/{db_id}/transactions/{tx_id}/rollback": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Retrieve effective Metrics !",
+                "summary": "Rollback modification you did (during a Transaction only !)",
                 "parameters": [
                     {
                         "type": "string",
@@ -2713,48 +3537,53 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/project/{id}/realtime": {
-            "get": {
-                "tags": [
-                    "Realtime"
-                ],
-                "summary": "Both Websocket connection",
-                "parameters": [
+                    },
                     {
                         "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction ID",
+                        "name": "tx_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Body content, rollback successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/channels": {
-            "get": {
+        "/project/{id}/data/{db_id}/{collection}/aggregate": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Computes count, sum, avg, min and max, optionally grouped by columns. Filter selects the aggregated documents and having the returned groups, both in the filter grammar of docs/filters.md; having and sort apply to group columns and aggregate aliases. See docs/aggregations.md.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "List Channels",
+                "summary": "Aggregate a collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -2762,14 +3591,67 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Aggregation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.AggregateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "type": "object",
+                                    "additionalProperties": true
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/project/{id}/data/{db_id}/{collection}/delete": {
             "post": {
                 "security": [
                     {
@@ -2783,9 +3665,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Create Channel",
+                "summary": "Delete a Batch with specific filters ! (Combine query and delete at once !)",
                 "parameters": [
                     {
                         "type": "string",
@@ -2795,33 +3677,73 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Channel creation data",
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "IDs and/or filter of the documents to delete",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateChannelRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/channels/{channel_id}": {
-            "delete": {
+        "/project/{id}/data/{db_id}/{collection}/import": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Streams a CSV file (with a header row) or NDJSON file into the collection with Postgres COPY, without buffering it. Rows with unknown columns, invalid values or missing required values are rejected and reported; the other rows are inserted in a single transaction. The import is rolled back with a 422 when more than max_rejected rows are rejected. See docs/bulk.md.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Delete Channel",
+                "summary": "Import documents",
                 "parameters": [
                     {
                         "type": "string",
@@ -2832,20 +3754,96 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "csv or ndjson, from the Content-Type by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV field delimiter, a comma by default",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "error",
+                            "skip",
+                            "update"
+                        ],
+                        "type": "string",
+                        "description": "Documents whose primary key exists: error (default), skip or update",
+                        "name": "on_conflict",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rejected rows tolerated before the import is rolled back, unlimited by default",
+                        "name": "max_rejected",
+                        "in": "query"
+                    },
+                    {
+                        "description": "CSV or NDJSON file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.ImportResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.ImportResult"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/channels/{channel_id}/broadcast": {
+        "/project/{id}/data/{db_id}/{collection}/insert": {
             "post": {
                 "security": [
                     {
@@ -2859,9 +3857,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Broadcast Message",
+                "summary": "Insert data in the database !",
                 "parameters": [
                     {
                         "type": "string",
@@ -2872,39 +3870,77 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Channel ID",
-                        "name": "channel_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Broadcast message data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BroadcastMessageRequest"
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Data to insert (a document or an array of documents)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.InsertDataRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/presence/{channel}": {
-            "get": {
+        "/project/{id}/data/{db_id}/{collection}/purge": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Permanently deletes the trashed documents listed by ids and/or matching the filter. An empty filter ({}) empties the trash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Get Presence",
+                "summary": "Purge trashed documents",
                 "parameters": [
                     {
                         "type": "string",
@@ -2915,26 +3951,68 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Channel Name",
-                        "name": "channel",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "IDs and/or filter of the documents to purge",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.TrashRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/presence/{channel}/track": {
+        "/project/{id}/data/{db_id}/{collection}/query": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "The filter grammar ($eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $like, $ilike, $null, $and, $or and \"column-\u003ejson-\u003epath\" keys) is described in docs/filters.md. With nearest, documents are the k nearest neighbours of a vector on a pgvector column, ordered by distance (docs/vectors.md). With expand, related documents are embedded by following foreign keys (docs/relations.md).",
                 "consumes": [
                     "application/json"
                 ],
@@ -2942,9 +4020,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Track Presence",
+                "summary": "Query the database on a collection aka table (Select QUERY only)",
                 "parameters": [
                     {
                         "type": "string",
@@ -2955,39 +4033,74 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Channel Name",
-                        "name": "channel",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Presence tracking data",
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Query parameters",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.TrackPresenceRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.QueryCollectionRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Page"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/realtime/presence/{channel}/untrack": {
-            "delete": {
+        "/project/{id}/data/{db_id}/{collection}/restore": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Clears deleted_at on the trashed documents listed by ids and/or matching the filter. An empty filter ({}) restores the whole trash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Realtime"
+                    "Database"
                 ],
-                "summary": "Untrack Presence",
+                "summary": "Restore trashed documents",
                 "parameters": [
                     {
                         "type": "string",
@@ -2998,30 +4111,78 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Channel Name",
-                        "name": "channel",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "IDs and/or filter of the documents to restore",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.TrashRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/roles": {
-            "get": {
+        "/project/{id}/data/{db_id}/{collection}/search": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Matches documents with Postgres tsvector/tsquery and returns them by decreasing rank, with optional highlighted snippets. Without columns, the managed search column of the collection is used. The search grammar is described in docs/search.md.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "List Project Roles",
+                "summary": "Full-text search in a collection",
                 "parameters": [
                     {
                         "type": "string",
@@ -3029,14 +4190,60 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Search parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SearchRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SearchResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/project/{id}/data/{db_id}/{collection}/upsert": {
             "post": {
                 "security": [
                     {
@@ -3050,9 +4257,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Create new Role",
+                "summary": "Inserting or updating !",
                 "parameters": [
                     {
                         "type": "string",
@@ -3062,33 +4269,74 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Role creation data",
+                        "type": "string",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Data to upsert (a document or an array of documents)",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateRoleRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpsertDataRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/roles/{role_id}": {
+        "/project/{id}/data/{db_id}/{collection}/{doc_id}": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Get a specific role in a project.",
+                "summary": "GET un document spécifique",
                 "parameters": [
                     {
                         "type": "string",
@@ -3099,18 +4347,68 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Role ID",
-                        "name": "role_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            },
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "doc_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Related documents to embed: comma-separated relations nested with dots (author,comments.author), or a JSON array of relations with select, filter, sort and limit",
+                        "name": "expand",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the document, for If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "delete": {
                 "security": [
                     {
@@ -3118,9 +4416,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Remove a role",
+                "summary": "DELETE un document spécifique",
                 "parameters": [
                     {
                         "type": "string",
@@ -3131,15 +4429,53 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Role ID",
-                        "name": "role_id",
+                        "description": "Database ID",
+                        "name": "db_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "doc_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete the document if its ETag is one of these",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "No Content"
+                        "description": "No Body content, delete successful."
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -3149,16 +4485,19 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
+                "description": "The body is {\"data\": {...}} with application/json, an array of operations with application/json-patch+json (RFC 6902) or the changes themselves with application/merge-patch+json (RFC 7396). A patch that cannot be applied returns 409.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/json-patch+json",
+                    "application/merge-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Projects"
+                    "Database"
                 ],
-                "summary": "Update a role",
+                "summary": "UPDATE un document spécifique",
                 "parameters": [
                     {
                         "type": "string",
@@ -3169,29 +4508,85 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Role ID",
-                        "name": "role_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Role update data",
+                        "type": "string",
+                        "description": "Collection Name",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Run the operation inside this transaction",
+                        "name": "X-Transaction-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "doc_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update the document if its ETag is one of these",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Document update data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateRoleRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateDocumentRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated document"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/project/{id}/storage/buckets": {
+        "/project/{id}/databases": {
             "get": {
                 "security": [
                     {
@@ -3199,9 +4594,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Get Storage Buckets",
+                "summary": "List Databases",
                 "parameters": [
                     {
                         "type": "string",
@@ -3230,9 +4625,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Create Storage Bucket",
+                "summary": "Create Database",
                 "parameters": [
                     {
                         "type": "string",
@@ -3242,12 +4637,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Bucket creation data",
+                        "description": "Database creation data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateStorageBucketRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseRequest"
                         }
                     }
                 ],
@@ -3258,7 +4653,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/storage/buckets/{bucket_id}": {
+        "/project/{id}/databases/{db_id}": {
             "get": {
                 "security": [
                     {
@@ -3266,9 +4661,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Get Storage Bucket",
+                "summary": "Get Database",
                 "parameters": [
                     {
                         "type": "string",
@@ -3279,8 +4674,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
@@ -3298,9 +4693,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Delete Storage Bucket",
+                "summary": "Delete Database",
                 "parameters": [
                     {
                         "type": "string",
@@ -3311,8 +4706,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
@@ -3322,19 +4717,23 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            }
-        },
-        "/project/{id}/storage/buckets/{bucket_id}/files": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Get Bucket Files",
+                "summary": "Update Database",
                 "parameters": [
                     {
                         "type": "string",
@@ -3345,10 +4744,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Database update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateDatabaseRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -3356,20 +4764,19 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/project/{id}/databases/{db_id}/backup": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "consumes": [
-                    "multipart/form-data"
-                ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Upload File",
+                "summary": "Get Database Backups",
                 "parameters": [
                     {
                         "type": "string",
@@ -3380,8 +4787,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     }
@@ -3391,9 +4798,7 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            }
-        },
-        "/project/{id}/storage/buckets/{bucket_id}/files/delete-batch": {
+            },
             "post": {
                 "security": [
                     {
@@ -3407,9 +4812,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Delete files in a batch operations !",
+                "summary": "Create Database Backup",
                 "parameters": [
                     {
                         "type": "string",
@@ -3420,18 +4825,18 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "File IDs to delete",
+                        "description": "Backup creation data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteFilesRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBackupRequest"
                         }
                     }
                 ],
@@ -3442,17 +4847,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}": {
-            "get": {
+        "/project/{id}/databases/{db_id}/branches": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "description": "Copies the schema and data of the database (pg_dump restored into a new container) into a branch with its own credentials: a role owning the branch database, without superuser privileges. The branch ID is the database ID followed by the branch name. With ttl_seconds, the branch is deleted once expired. The masking profile of the database, if any, is applied when the data is read from the source, so unmasked values never reach the branch. Only API keys carrying the branches permission are accepted. See docs/branches.md.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Get File",
+                "summary": "Create Database Branch",
                 "parameters": [
                     {
                         "type": "string",
@@ -3463,65 +4875,63 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "File ID",
-                        "name": "file_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Branch name and lifetime",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBranchRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "tags": [
-                    "Storage"
-                ],
-                "summary": "Delete File",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Project ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.DatabaseBranchResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
-                        "in": "path",
-                        "required": true
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "File ID",
-                        "name": "file_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/project/{id}/databases/{db_id}/restore": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
@@ -3534,9 +4944,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Storage"
+                    "Database"
                 ],
-                "summary": "Update metadata informations only !",
+                "summary": "Restore Database",
                 "parameters": [
                     {
                         "type": "string",
@@ -3547,25 +4957,18 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "File ID",
-                        "name": "file_id",
+                        "description": "Database ID",
+                        "name": "db_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Metadata update data",
+                        "description": "Restore data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateFileMetadataRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.RestoreDatabaseRequest"
                         }
                     }
                 ],
@@ -3576,7 +4979,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/info": {
+        "/project/{id}/functions": {
             "get": {
                 "security": [
                     {
@@ -3584,9 +4987,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Storage"
+                    "Functions"
                 ],
-                "summary": "Get File Info",
+                "summary": "List Functions",
                 "parameters": [
                     {
                         "type": "string",
@@ -3594,20 +4997,6 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "File ID",
-                        "name": "file_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -3615,19 +5004,23 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            }
-        },
-        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/public-url": {
+            },
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Storage"
+                    "Functions"
                 ],
-                "summary": "Create Public URL",
+                "summary": "Create Function",
                 "parameters": [
                     {
                         "type": "string",
@@ -3637,18 +5030,13 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Bucket ID",
-                        "name": "bucket_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "File ID",
-                        "name": "file_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Function creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateFunctionRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -3658,7 +5046,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/user": {
+        "/project/{id}/functions/{function_id}": {
             "get": {
                 "security": [
                     {
@@ -3666,9 +5054,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Auth"
+                    "Functions"
                 ],
-                "summary": "Get Project User",
+                "summary": "Get Function",
                 "parameters": [
                     {
                         "type": "string",
@@ -3676,6 +5064,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Function ID",
+                        "name": "function_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -3691,9 +5086,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Auth"
+                    "Functions"
                 ],
-                "summary": "Delete Project User",
+                "summary": "Delete Function",
                 "parameters": [
                     {
                         "type": "string",
@@ -3701,6 +5096,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Function ID",
+                        "name": "function_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -3708,19 +5110,23 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            }
-        },
-        "/project/{id}/webhooks": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Webhook"
+                    "Functions"
                 ],
-                "summary": "Get Webhooks",
+                "summary": "Update Function",
                 "parameters": [
                     {
                         "type": "string",
@@ -3728,6 +5134,22 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Function ID",
+                        "name": "function_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Function update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateFunctionRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -3735,7 +5157,9 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            },
+            }
+        },
+        "/project/{id}/functions/{function_id}/invoke": {
             "post": {
                 "security": [
                     {
@@ -3749,9 +5173,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Webhook"
+                    "Functions"
                 ],
-                "summary": "Create Webhook",
+                "summary": "Invoke Function",
                 "parameters": [
                     {
                         "type": "string",
@@ -3761,12 +5185,19 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Webhook creation data",
+                        "type": "string",
+                        "description": "Function ID",
+                        "name": "function_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Function invocation arguments",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateWebhookRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.InvokeFunctionRequest"
                         }
                     }
                 ],
@@ -3777,7 +5208,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/project/{id}/webhooks/{webhook_id}": {
+        "/project/{id}/functions/{function_id}/logs": {
             "get": {
                 "security": [
                     {
@@ -3785,9 +5216,9 @@ const docTemplate = `{
                     }
                 ],
                 "tags": [
-                    "Webhook"
+                    "Functions"
                 ],
-                "summary": "Get Webhook",
+                "summary": "Execution logs from a function (List of call by usages..)",
                 "parameters": [
                     {
                         "type": "string",
@@ -3798,8 +5229,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Webhook ID",
-                        "name": "webhook_id",
+                        "description": "Function ID",
+                        "name": "function_id",
                         "in": "path",
                         "required": true
                     }
@@ -3809,17 +5240,19 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/project/{id}/logs": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
                 "tags": [
-                    "Webhook"
+                    "Projects"
                 ],
-                "summary": "Delete Webhook",
+                "summary": "Logs of the project (Only include Organisations logs, not clients calls)",
                 "parameters": [
                     {
                         "type": "string",
@@ -3827,11 +5260,31 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Get Project Members",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Webhook ID",
-                        "name": "webhook_id",
+                        "description": "Project ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -3842,7 +5295,7 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
@@ -3855,9 +5308,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Webhook"
+                    "Projects"
                 ],
-                "summary": "Update Webhook",
+                "summary": "Add Project Member",
                 "parameters": [
                     {
                         "type": "string",
@@ -3867,19 +5320,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Webhook ID",
-                        "name": "webhook_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Webhook update data",
+                        "description": "Member data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateWebhookRequest"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.AddProjectMemberRequest"
                         }
                     }
                 ],
@@ -3890,68 +5336,2959 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
-            "get": {
+        "/project/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Delete a member of the project",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Projects"
+                ],
+                "summary": "Update Project Member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateProjectMemberRequest"
+                        }
+                    }
                 ],
-                "summary": "Get User",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Retrieve effective Metrics !",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime": {
+            "get": {
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Both Websocket connection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/channels": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "List Channels",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Create Channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Channel creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models_user.User"
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateChannelRequest"
                         }
                     }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/channels/{channel_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Delete Channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/channels/{channel_id}/broadcast": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Broadcast Message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "channel_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Broadcast message data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BroadcastMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/presence/{channel}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Get Presence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel Name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/presence/{channel}/track": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Track Presence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel Name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Presence tracking data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.TrackPresenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/realtime/presence/{channel}/untrack": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Realtime"
+                ],
+                "summary": "Untrack Presence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel Name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "List Project Roles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Create new Role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/roles/{role_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Get a specific role in a project.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role ID",
+                        "name": "role_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Remove a role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role ID",
+                        "name": "role_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Projects"
+                ],
+                "summary": "Update a role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role ID",
+                        "name": "role_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get Storage Buckets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Bucket"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "The bucket name identifies it in the storage routes: 2 to 63 lowercase letters, digits, dots, dashes or underscores. max_file_size limits the size of each file, in bytes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Create Storage Bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bucket creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateStorageBucketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Bucket"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get Storage Bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Bucket"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Only buckets without files nor resumable uploads can be deleted.",
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Delete Storage Bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Omitted settings are unchanged. A lower max_file_size only applies to the next uploads.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Update Storage Bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bucket settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateStorageBucketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Bucket"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/files": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get Bucket Files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list files whose name starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of files (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of files to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Object"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Streams the file part of a multipart form to the storage backend. The name and metadata fields must precede the file part; fields after it are ignored. The file is named after the name field, else after its file name; a name may contain folders (users/42.png). An existing file with the same name is replaced only with upsert. Large files over unreliable connections should use resumable uploads.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Upload File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name in the bucket",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Metadata, as a JSON object",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File content",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing file with the same name",
+                        "name": "upsert",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/files/delete-batch": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Missing files are ignored; the response counts the deleted files.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Delete files in a batch operations !",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File IDs to delete",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteFilesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads the content of the file, with its content type. Range requests get 206 Partial Content, and conditional requests (If-None-Match on the ETag, If-Modified-Since) 304 Not Modified. Responses are private to the client, cached as set by the bucket cache_control. JPEG, PNG and GIF images can be transformed with the width, height, resize, crop, format and quality parameters; each variant is computed once, then kept until the file is replaced.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Image width in pixels, at most 2500",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Image height in pixels, at most 2500",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cover",
+                            "contain",
+                            "fill"
+                        ],
+                        "type": "string",
+                        "description": "With both width and height: cover (default, cropped), contain or fill",
+                        "name": "resize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Area of the original image kept before resizing: x,y,width,height in pixels",
+                        "name": "crop",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "jpeg",
+                            "png",
+                            "webp"
+                        ],
+                        "type": "string",
+                        "description": "Image format, the original one by default (png for gif)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "JPEG quality from 1 to 100 (default 80), rejected for png and webp, which are lossless",
+                        "name": "quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Delete File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replaces the metadata of the file; its content is unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Update metadata informations only !",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Metadata update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateFileMetadataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/info": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get File Info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Object"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/public-url": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns an HMAC-signed URL giving access to the file without an API key until it expires, on the unauthenticated /storage/signed route. download serves the file as an attachment, named after filename. Anyone holding the URL can download the file: share it like a secret.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Create Public URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Expiry and content disposition",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateSignedURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SignedURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/upload-url": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns an HMAC-signed URL letting a client without an API key, such as a browser, upload the named file with PUT until it expires. The bucket size limit applies; an existing file is replaced only with upsert.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Create Signed Upload URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File name and expiry",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateSignedUploadURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SignedURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "tus creation: Upload-Length gives the file size, Upload-Metadata its name (name or filename), content type (content_type or filetype) and metadata (a JSON object), as comma-separated \"key base64(value)\" pairs. The response Location is the upload URL, to send the content to with PATCH. The file name is checked now: an existing file answers 409 unless upsert.",
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Create Resumable Upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "File size in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "tus metadata, with at least the file name",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "tus version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing file with the same name",
+                        "name": "upsert",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "tus discovery: supported version, extensions and, when the bucket has one, maximum file size.",
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Resumable Upload Capabilities",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "tus termination: the received content is deleted. Unfinished uploads are also deleted once expired.",
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Cancel Resumable Upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "tus offset retrieval: Upload-Offset gives the bytes received, to resume the upload from.",
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get Resumable Upload Offset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "tus core: the body, sent as application/offset+octet-stream, is appended at Upload-Offset, which must be the current offset. Bytes received before a connection loss are kept. Once the whole content is received, the file is created and its ID returned in the File-Id header.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Resume Upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the content",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "tus version (1.0.0)",
+                        "name": "Tus-Resumable",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/project/{id}/user": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get Project User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Delete Project User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Get Webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Create Webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook creation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/project/{id}/webhooks/{webhook_id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Get Webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Delete Webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook"
+                ],
+                "summary": "Update Webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/storage/public/{id}/{bucket_id}/{name}": {
+            "get": {
+                "description": "Unauthenticated, for the files of public buckets only. Files are addressed by name and responses are public, so that reverse proxies and CDNs can cache them as set by the bucket cache_control (default max-age=3600). Range and conditional requests, and image transformations, are supported as for Get File. Files whose type could run script, such as text/html or image/svg+xml, are served as attachments.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Get Public File",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name, with its folders",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Image width in pixels, at most 2500",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Image height in pixels, at most 2500",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cover",
+                            "contain",
+                            "fill"
+                        ],
+                        "type": "string",
+                        "description": "With both width and height: cover (default, cropped), contain or fill",
+                        "name": "resize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Area of the original image kept before resizing: x,y,width,height in pixels",
+                        "name": "crop",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "jpeg",
+                            "png",
+                            "webp"
+                        ],
+                        "type": "string",
+                        "description": "Image format, the original one by default (png for gif)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "JPEG quality from 1 to 100 (default 80), rejected for png and webp, which are lossless",
+                        "name": "quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Partial Content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested Range Not Satisfiable"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/signed/{id}/{bucket_id}": {
+            "put": {
+                "description": "Unauthenticated: the signature grants the upload of the named file until it expires. The body is the raw file content, typed by Content-Type. The URL is created with the upload-url route; changing any of its parameters invalidates it.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Upload File With Signed URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name in the bucket",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace an existing file with the same name",
+                        "name": "upsert",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiry, as a Unix timestamp",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 signature",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_storage.Object"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/signed/{id}/{bucket_id}/{file_id}": {
+            "get": {
+                "description": "Unauthenticated: the signature grants access to the file until it expires. The URL is created with the public-url route; changing any of its parameters invalidates it. Range and conditional requests are supported as for Get File.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Storage"
+                ],
+                "summary": "Download File With Signed URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Project ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket ID",
+                        "name": "bucket_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File ID",
+                        "name": "file_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiry, as a Unix timestamp",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Content-Disposition of the response",
+                        "name": "disposition",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 signature",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get User",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models_user.User"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Update User",
+                "parameters": [
+                    {
+                        "description": "User update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models_user.User"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_ketsuna-org_sovrabase_internal_database.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Nature de l'opération, ex: AuditActionSQL",
+                    "type": "string"
+                },
+                "actor": {
+                    "description": "Sujet du jeton à l'origine de l'opération",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Erreur renvoyée, vide si l'opération a réussi",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "read_only": {
+                    "type": "boolean"
+                },
+                "row_count": {
+                    "type": "integer"
+                },
+                "statement": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.CollectionSchema": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.ColumnSchema"
+                    }
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "constraints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Constraint"
+                    }
+                },
+                "json_schema": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primary_key": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Relation"
+                    }
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "soft_delete": {
+                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SoftDelete"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Column": {
+            "type": "object",
+            "properties": {
+                "has_default": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nullable": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.ColumnSchema": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "default": {
+                    "type": "string"
+                },
+                "has_default": {
+                    "type": "boolean"
+                },
+                "identity": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nullable": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Constraint": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "definition": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.ImportResult": {
+            "type": "object",
+            "properties": {
+                "aborted": {
+                    "description": "L'import a été annulé, MaxRejected étant dépassé",
+                    "type": "boolean"
+                },
+                "imported": {
+                    "description": "Documents insérés ou mis à jour",
+                    "type": "integer"
+                },
+                "rejected": {
+                    "description": "Lignes rejetées",
+                    "type": "integer"
+                },
+                "rejected_rows": {
+                    "description": "Les MaxReportedRejects premières lignes rejetées",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.RejectedRow"
+                    }
+                },
+                "skipped": {
+                    "description": "Documents existants ignorés, avec ImportConflictSkip",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Index": {
+            "type": "object",
+            "properties": {
+                "definition": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "scans": {
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "tuples_read": {
+                    "type": "integer"
+                },
+                "unique": {
+                    "type": "boolean"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.MaskingProfile": {
+            "type": "object",
+            "properties": {
+                "enforced": {
+                    "description": "Imposé par la conformité de l'organisation (RGPD) : le masquage ne peut être ni ignoré ni retiré",
+                    "type": "boolean"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.MaskingRule"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.MaskingRule": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "type": "string",
+                    "example": "users"
+                },
+                "column": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "hash",
+                        "redact",
+                        "fake_email",
+                        "keep_format"
+                    ],
+                    "example": "fake_email"
+                },
+                "value": {
+                    "description": "Valeur de remplacement de redact, NULL si omise"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Migration": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "statements": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.OperationResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Page": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "distances": {
+                    "description": "Distance de chaque document, avec Query.Nearest",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Policy": {
+            "type": "object",
+            "properties": {
+                "check": {
+                    "description": "Lignes acceptées en écriture, Using par défaut",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "check_sql": {
+                    "type": "string"
+                },
+                "command": {
+                    "description": "Opération couverte, \"all\" par défaut",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Rôle du projet concerné, tous les utilisateurs si vide",
+                    "type": "string"
+                },
+                "using": {
+                    "description": "Lignes visibles, modifiables ou supprimables",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "using_sql": {
+                    "description": "Expressions SQL générées, lues dans le catalogue",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.RejectedRow": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Relation": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "outgoing",
+                        "incoming"
+                    ]
+                },
+                "foreign_collection": {
+                    "type": "string"
+                },
+                "foreign_columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "on_delete": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.SQLOutcome": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Étiquette de commande, ex: \"INSERT 0 3\"",
+                    "type": "string"
+                },
+                "row_count": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "Des lignes au-delà de MaxRows n'ont pas été renvoyées",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.SearchHit": {
+            "type": "object",
+            "properties": {
+                "document": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "highlights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "rank": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.SearchIndex": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "type": "string"
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.SearchResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SearchHit"
+                    }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
-                        "Bearer": []
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.SoftDelete": {
+            "type": "object",
+            "properties": {
+                "retention_days": {
+                    "description": "Jours avant la suppression définitive, 0 pour conserver indéfiniment",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_database.Table": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.Column"
                     }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "User"
-                ],
-                "summary": "Update User",
-                "parameters": [
-                    {
-                        "description": "User update data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.UpdateUserRequest"
-                        }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primary_key": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models_user.User"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "soft_delete": {
+                    "description": "Corbeille, voir TrashColumn",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_database.SoftDelete"
                         }
-                    }
+                    ]
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.AddOrganizationMemberRequest": {
             "type": "object",
             "required": [
@@ -3984,6 +8321,118 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.AggregateField": {
+            "type": "object",
+            "required": [
+                "function"
+            ],
+            "properties": {
+                "alias": {
+                    "description": "Defaults to \u003cfunction\u003e_\u003ccolumn\u003e",
+                    "type": "string",
+                    "example": "total"
+                },
+                "column": {
+                    "description": "Empty for count(*)",
+                    "type": "string",
+                    "example": "amount"
+                },
+                "distinct": {
+                    "description": "Aggregate distinct values only",
+                    "type": "boolean",
+                    "example": false
+                },
+                "function": {
+                    "type": "string",
+                    "enum": [
+                        "count",
+                        "sum",
+                        "avg",
+                        "min",
+                        "max"
+                    ],
+                    "example": "sum"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.AggregateRequest": {
+            "type": "object",
+            "properties": {
+                "aggregates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.AggregateField"
+                    }
+                },
+                "filter": {
+                    "description": "Documents to aggregate",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "status"
+                    ]
+                },
+                "having": {
+                    "description": "Groups to return, on group columns and aggregate aliases",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "sort": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-count"
+                    ]
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.AlterColumnRequest": {
+            "type": "object",
+            "properties": {
+                "default": {},
+                "default_function": {
+                    "type": "string",
+                    "enum": [
+                        "now",
+                        "current_date",
+                        "current_timestamp",
+                        "gen_random_uuid"
+                    ]
+                },
+                "drop_default": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "headline"
+                },
+                "not_null": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "varchar(200)"
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteFilesRequest": {
             "type": "object",
             "required": [
@@ -3999,16 +8448,88 @@ const docTemplate = `{
             }
         },
         "github_com_ketsuna-org_sovrabase_internal_models.BatchDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.BatchErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "données invalides: colonne inconnue \"titel\""
+                },
+                "index": {
+                    "description": "Position of the failing operation",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.BatchOperation": {
             "type": "object",
             "required": [
-                "ids"
+                "collection",
+                "op"
             ],
             "properties": {
+                "collection": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "data": {
+                    "description": "Document(s) for insert and upsert, changes for update"
+                },
+                "filter": {
+                    "description": "Documents targeted by delete",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "id": {
+                    "description": "Document targeted by update or delete",
+                    "type": "string",
+                    "example": "42"
+                },
                 "ids": {
+                    "description": "Documents targeted by delete",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "insert",
+                        "update",
+                        "upsert",
+                        "delete"
+                    ],
+                    "example": "insert"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.BatchRequest": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.BatchOperation"
+                    }
                 }
             }
         },
@@ -4021,6 +8542,70 @@ const docTemplate = `{
                 "message": {}
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.CheckConstraint": {
+            "type": "object",
+            "required": [
+                "filter"
+            ],
+            "properties": {
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "name": {
+                    "type": "string",
+                    "example": "positive_price"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.ColumnDefinition": {
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "check": {
+                    "description": "Filter every row must match",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "default": {},
+                "default_function": {
+                    "type": "string",
+                    "enum": [
+                        "now",
+                        "current_date",
+                        "current_timestamp",
+                        "gen_random_uuid"
+                    ]
+                },
+                "identity": {
+                    "description": "Auto-incremented integer",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "not_null": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "references": {
+                    "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ForeignKeyDefinition"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "text"
+                },
+                "unique": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.CreateAPIKeyRequest": {
             "type": "object",
             "required": [
@@ -4046,8 +8631,7 @@ const docTemplate = `{
                         "type": "string"
                     },
                     "example": [
-                        "read",
-                        "write"
+                        "sql"
                     ]
                 }
             }
@@ -4097,24 +8681,77 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_ketsuna-org_sovrabase_internal_models.CreateChannelRequest": {
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateChannelRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "chat_room"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateCollectionRequest": {
+            "type": "object",
+            "required": [
+                "columns",
+                "name"
+            ],
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.CheckConstraint"
+                    }
+                },
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ColumnDefinition"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "posts"
+                },
+                "primary_key": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "id"
+                    ]
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBackupRequest": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
+                "description": {
                     "type": "string",
-                    "example": "chat_room"
+                    "example": "Daily backup"
                 }
             }
         },
-        "github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBackupRequest": {
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateDatabaseBranchRequest": {
             "type": "object",
             "properties": {
-                "description": {
+                "name": {
+                    "description": "Suffix of the branch ID, generated if empty",
                     "type": "string",
-                    "example": "Daily backup"
+                    "example": "pr-123"
+                },
+                "ttl_seconds": {
+                    "description": "Lifetime of the branch, 0 to keep it until deleted",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "unmasked": {
+                    "description": "Copy the data without the masking profile (requires the masking permission)",
+                    "type": "boolean"
                 }
             }
         },
@@ -4159,21 +8796,48 @@ const docTemplate = `{
         "github_com_ketsuna-org_sovrabase_internal_models.CreateIndexRequest": {
             "type": "object",
             "required": [
-                "fields",
-                "name"
+                "fields"
             ],
             "properties": {
                 "fields": {
-                    "type": "object",
-                    "additionalProperties": true
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.IndexField"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "btree",
+                        "hash",
+                        "gin",
+                        "gist",
+                        "brin",
+                        "hnsw",
+                        "ivfflat"
+                    ],
+                    "example": "btree"
                 },
                 "name": {
+                    "description": "Generated from the collection and columns when empty",
                     "type": "string",
                     "example": "idx_email"
                 },
                 "unique": {
                     "type": "boolean",
                     "example": true
+                },
+                "where": {
+                    "description": "Partial index predicate, in the filter language",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "with": {
+                    "description": "Build parameters: m and ef_construction (hnsw), lists (ivfflat)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
                 }
             }
         },
@@ -4206,6 +8870,44 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.CreatePolicyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "check": {
+                    "description": "Rows the end user can write, defaults to using",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "command": {
+                    "type": "string",
+                    "enum": [
+                        "all",
+                        "select",
+                        "insert",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "all"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "owner_rows"
+                },
+                "role": {
+                    "description": "Project role the rule applies to, every end user when empty",
+                    "type": "string",
+                    "example": "owner"
+                },
+                "using": {
+                    "description": "Rows the end user can read, update or delete, in the filter language",
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.CreateProjectRequest": {
             "type": "object",
             "required": [
@@ -4255,12 +8957,64 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateSignedURLRequest": {
+            "type": "object",
+            "properties": {
+                "download": {
+                    "description": "Serve the file as an attachment rather than inline",
+                    "type": "boolean",
+                    "example": false
+                },
+                "expires_in": {
+                    "description": "Seconds, default 3600, at most 604800 (7 days)",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "filename": {
+                    "description": "File name proposed to the browser, default the last segment of the file name",
+                    "type": "string",
+                    "example": "report.pdf"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.CreateSignedUploadURLRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_in": {
+                    "description": "Seconds, default 3600, at most 604800 (7 days)",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "name": {
+                    "type": "string",
+                    "example": "users/42.png"
+                },
+                "upsert": {
+                    "description": "Replace an existing file with the same name",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.CreateStorageBucketRequest": {
             "type": "object",
             "required": [
                 "name"
             ],
             "properties": {
+                "cache_control": {
+                    "description": "Cache-Control directives of the served files, without public or private",
+                    "type": "string",
+                    "example": "max-age=86400"
+                },
+                "max_file_size": {
+                    "description": "Bytes, 0 for no limit",
+                    "type": "integer",
+                    "example": 10485760
+                },
                 "name": {
                     "type": "string",
                     "example": "images"
@@ -4329,21 +9083,333 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_ketsuna-org_sovrabase_internal_models.InsertDataRequest": {
+        "github_com_ketsuna-org_sovrabase_internal_models.DatabaseBranchResponse": {
+            "type": "object",
+            "properties": {
+                "connection_string": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "database": {
+                    "type": "string",
+                    "example": "staging_pr_123"
+                },
+                "expires_at": {
+                    "description": "Absent when the branch does not expire",
+                    "type": "string"
+                },
+                "host": {
+                    "type": "string",
+                    "example": "localhost"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "staging-pr-123"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "example": "staging"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "string",
+                    "example": "5434"
+                },
+                "user": {
+                    "type": "string",
+                    "example": "staging_pr_123_owner"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "ressource introuvable: la collection \"posts\" n'existe pas"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.ExpandRequest": {
+            "type": "object",
+            "required": [
+                "relation"
+            ],
+            "properties": {
+                "as": {
+                    "description": "Key of the embedded documents, relation by default",
+                    "type": "string",
+                    "example": "latest_comments"
+                },
+                "expand": {
+                    "description": "Relations of the related documents",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ExpandRequest"
+                    }
+                },
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "limit": {
+                    "description": "Referencing documents only, 100 by default and 1000 at most",
+                    "type": "integer",
+                    "example": 5
+                },
+                "relation": {
+                    "description": "Foreign key column, constraint name or related collection",
+                    "type": "string",
+                    "example": "comments"
+                },
+                "select": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "id",
+                        "body"
+                    ]
+                },
+                "sort": {
+                    "description": "Referencing documents only",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-created_at"
+                    ]
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.ExportRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "format": {
+                    "description": "Defaults to the Accept header, then ndjson",
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "ndjson",
+                        "parquet"
+                    ],
+                    "example": "parquet"
+                },
+                "limit": {
+                    "description": "All documents when zero",
+                    "type": "integer",
+                    "example": 100000
+                },
+                "select": {
+                    "description": "All columns when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "id",
+                        "title",
+                        "created_at"
+                    ]
+                },
+                "sort": {
+                    "description": "Primary key order by default",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-created_at"
+                    ]
+                },
+                "unmasked": {
+                    "description": "Export the data without the masking profile (requires the masking permission)",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.ForeignKeyDefinition": {
+            "type": "object",
+            "required": [
+                "collection",
+                "column"
+            ],
+            "properties": {
+                "collection": {
+                    "type": "string",
+                    "example": "users"
+                },
+                "column": {
+                    "type": "string",
+                    "example": "id"
+                },
+                "on_delete": {
+                    "type": "string",
+                    "enum": [
+                        "no action",
+                        "restrict",
+                        "cascade",
+                        "set null",
+                        "set default"
+                    ],
+                    "example": "cascade"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.IndexField": {
+            "type": "object",
+            "required": [
+                "column"
+            ],
+            "properties": {
+                "column": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "language": {
+                    "description": "Index to_tsvector(language, column) for full-text search",
+                    "type": "string",
+                    "example": "english"
+                },
+                "nulls": {
+                    "type": "string",
+                    "enum": [
+                        "first",
+                        "last"
+                    ],
+                    "example": "last"
+                },
+                "opclass": {
+                    "type": "string",
+                    "enum": [
+                        "jsonb_ops",
+                        "jsonb_path_ops",
+                        "array_ops",
+                        "tsvector_ops",
+                        "gin_trgm_ops",
+                        "gist_trgm_ops",
+                        "text_pattern_ops",
+                        "varchar_pattern_ops",
+                        "vector_cosine_ops",
+                        "vector_l2_ops",
+                        "vector_ip_ops",
+                        "halfvec_cosine_ops",
+                        "halfvec_l2_ops",
+                        "halfvec_ip_ops"
+                    ]
+                },
+                "order": {
+                    "type": "string",
+                    "enum": [
+                        "asc",
+                        "desc"
+                    ],
+                    "example": "asc"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.InsertDataRequest": {
+            "type": "object",
+            "required": [
+                "data"
+            ],
+            "properties": {
+                "data": {}
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.InvokeFunctionRequest": {
+            "type": "object",
+            "properties": {
+                "args": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.MaskingProfileRequest": {
+            "type": "object",
+            "required": [
+                "rules"
+            ],
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.MaskingRule"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.MaskingRule": {
+            "type": "object",
+            "required": [
+                "collection",
+                "column",
+                "strategy"
+            ],
+            "properties": {
+                "collection": {
+                    "type": "string",
+                    "example": "users"
+                },
+                "column": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "strategy": {
+                    "type": "string",
+                    "enum": [
+                        "hash",
+                        "redact",
+                        "fake_email",
+                        "keep_format"
+                    ],
+                    "example": "fake_email"
+                },
+                "value": {
+                    "description": "Replacement value of redact, null when omitted"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.NearestQuery": {
             "type": "object",
             "required": [
-                "data"
+                "column",
+                "vector"
             ],
             "properties": {
-                "data": {}
-            }
-        },
-        "github_com_ketsuna-org_sovrabase_internal_models.InvokeFunctionRequest": {
-            "type": "object",
-            "properties": {
-                "args": {
-                    "type": "object",
-                    "additionalProperties": true
+                "column": {
+                    "type": "string",
+                    "example": "embedding"
+                },
+                "distance": {
+                    "type": "string",
+                    "enum": [
+                        "cosine",
+                        "l2",
+                        "inner_product"
+                    ],
+                    "example": "cosine"
+                },
+                "max_distance": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "vector": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
                 }
             }
         },
@@ -4415,6 +9481,24 @@ const docTemplate = `{
         "github_com_ketsuna-org_sovrabase_internal_models.QueryCollectionRequest": {
             "type": "object",
             "properties": {
+                "count": {
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "estimated"
+                    ],
+                    "example": "estimated"
+                },
+                "cursor": {
+                    "type": "string"
+                },
+                "expand": {
+                    "description": "Expand embeds related documents, following foreign keys (docs/relations.md)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.ExpandRequest"
+                    }
+                },
                 "filter": {
                     "type": "object",
                     "additionalProperties": true
@@ -4423,13 +9507,48 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 10
                 },
+                "nearest": {
+                    "description": "Nearest returns the documents closest to a vector, ordered by distance",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.NearestQuery"
+                        }
+                    ]
+                },
                 "offset": {
                     "type": "integer",
                     "example": 0
                 },
+                "select": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "id",
+                        "title",
+                        "created_at"
+                    ]
+                },
                 "sort": {
-                    "type": "object",
-                    "additionalProperties": true
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "-created_at",
+                        "name"
+                    ]
+                },
+                "trashed": {
+                    "description": "Trashed reads the documents of the trash of a collection with soft delete (docs/trash.md)",
+                    "type": "string",
+                    "enum": [
+                        "exclude",
+                        "include",
+                        "only"
+                    ],
+                    "example": "exclude"
                 }
             }
         },
@@ -4477,6 +9596,219 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.SQLRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "format": {
+                    "description": "Defaults to the Accept header, then json",
+                    "type": "string",
+                    "enum": [
+                        "json",
+                        "csv"
+                    ],
+                    "example": "json"
+                },
+                "max_rows": {
+                    "description": "Rows returned at most, 1000 by default and 100000 at most",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "params": {
+                    "type": "array",
+                    "items": {}
+                },
+                "query": {
+                    "type": "string",
+                    "example": "SELECT id, title FROM posts WHERE views \u003e $1"
+                },
+                "read_only": {
+                    "description": "Run the statement in a read-only transaction",
+                    "type": "boolean",
+                    "example": true
+                },
+                "timeout_ms": {
+                    "description": "Statement timeout, 10s by default and 5 minutes at most",
+                    "type": "integer",
+                    "example": 5000
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.SearchHighlight": {
+            "type": "object",
+            "properties": {
+                "max_fragments": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_words": {
+                    "type": "integer",
+                    "example": 35
+                },
+                "min_words": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "start_sel": {
+                    "type": "string",
+                    "example": "\u003cmark\u003e"
+                },
+                "stop_sel": {
+                    "type": "string",
+                    "example": "\u003c/mark\u003e"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.SearchIndexRequest": {
+            "type": "object",
+            "required": [
+                "columns"
+            ],
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "title",
+                        "body"
+                    ]
+                },
+                "language": {
+                    "type": "string",
+                    "example": "english"
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.SearchRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "columns": {
+                    "description": "Searches the managed search column when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "title",
+                        "body"
+                    ]
+                },
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "highlight": {
+                    "description": "Returns highlighted snippets of each column",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_ketsuna-org_sovrabase_internal_models.SearchHighlight"
+                        }
+                    ]
+                },
+                "language": {
+                    "description": "Text search configuration, simple by default",
+                    "type": "string",
+                    "example": "english"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "min_rank": {
+                    "type": "number",
+                    "example": 0.01
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "websearch",
+                        "plain",
+                        "phrase",
+                        "raw"
+                    ],
+                    "example": "websearch"
+                },
+                "normalization": {
+                    "description": "ts_rank normalization bit mask (0 to 63)",
+                    "type": "integer",
+                    "example": 0
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "query": {
+                    "type": "string",
+                    "example": "postgres -mysql"
+                },
+                "ranking": {
+                    "type": "string",
+                    "enum": [
+                        "rank",
+                        "cover"
+                    ],
+                    "example": "rank"
+                },
+                "select": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "id",
+                        "title"
+                    ]
+                },
+                "weights": {
+                    "description": "Weight (A to D) of each column",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.SignedURLResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "download or upload",
+                    "type": "string",
+                    "example": "download"
+                },
+                "url": {
+                    "description": "Path and query, relative to the API base URL",
+                    "type": "string",
+                    "example": "/storage/signed/my-project/avatars/3f2a9c1e7b4d8e6f0a1b2c3d4e5f6a7b?expires=1767225600\u0026signature=9b1c..."
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.SoftDeleteRequest": {
+            "type": "object",
+            "properties": {
+                "retention_days": {
+                    "description": "Days before trashed documents are purged, 0 to keep them",
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.TrackPresenceRequest": {
             "type": "object",
             "required": [
@@ -4492,6 +9824,35 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.TransactionResponse": {
+            "type": "object",
+            "properties": {
+                "idle_timeout": {
+                    "description": "Seconds of inactivity before the transaction is rolled back",
+                    "type": "integer",
+                    "example": 30
+                },
+                "transaction_id": {
+                    "type": "string",
+                    "example": "3f2b9c0e8a4d4c1b9e6f7a2d5c8b1e04"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_models.TrashRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.UpdateAPIKeyRequest": {
             "type": "object",
             "properties": {
@@ -4654,6 +10015,25 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_ketsuna-org_sovrabase_internal_models.UpdateStorageBucketRequest": {
+            "type": "object",
+            "properties": {
+                "cache_control": {
+                    "description": "Empty for the default directives",
+                    "type": "string",
+                    "example": "max-age=31536000, immutable"
+                },
+                "max_file_size": {
+                    "description": "Bytes, 0 for no limit",
+                    "type": "integer",
+                    "example": 52428800
+                },
+                "public": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
         "github_com_ketsuna-org_sovrabase_internal_models.UpdateUserRequest": {
             "type": "object",
             "properties": {
@@ -4720,6 +10100,10 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "key": {
+                    "description": "Signed key, only returned on creation",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -4811,6 +10195,80 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_storage.Bucket": {
+            "type": "object",
+            "properties": {
+                "cache_control": {
+                    "description": "Directives Cache-Control des fichiers servis, vide pour celles par défaut",
+                    "type": "string",
+                    "example": "max-age=86400"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "max_file_size": {
+                    "description": "Taille maximale d'un fichier en octets, 0 sans limite",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "name": {
+                    "type": "string",
+                    "example": "avatars"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "public": {
+                    "description": "Fichiers lisibles sans authentification",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_ketsuna-org_sovrabase_internal_storage.Object": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string",
+                    "example": "avatars"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "etag": {
+                    "description": "Empreinte SHA-256 du contenu",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f2a9c1e7b4d8e6f0a1b2c3d4e5f6a7b"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
+                    "type": "string",
+                    "example": "users/42.png"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 48213
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/database"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+)
+
+// bulkContentTypes maps the bulk formats to their media type
+var bulkContentTypes = map[string]string{
+	database.FormatCSV:     "text/csv; charset=utf-8",
+	database.FormatNDJSON:  "application/x-ndjson",
+	database.FormatParquet: "application/vnd.apache.parquet",
+}
+
+// bulkMediaTypes maps the media types accepted in Content-Type and Accept to a bulk format
+var bulkMediaTypes = map[string]string{
+	"text/csv":                       database.FormatCSV,
+	"application/x-ndjson":           database.FormatNDJSON,
+	"application/ndjson":             database.FormatNDJSON,
+	"application/jsonl":              database.FormatNDJSON,
+	"application/vnd.apache.parquet": database.FormatParquet,
+}
+
+// ImportCollectionHandler imports documents into a collection
+// @Summary Import documents
+// @Description Streams a CSV file (with a header row) or NDJSON file into the collection with Postgres COPY, without buffering it. Rows with unknown columns, invalid values or missing required values are rejected and reported; the other rows are inserted in a single transaction. The import is rolled back with a 422 when more than max_rejected rows are rejected. See docs/bulk.md.
+// @Tags Database
+// @Security Bearer
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param format query string false "csv or ndjson, from the Content-Type by default" Enums(csv, ndjson)
+// @Param delimiter query string false "CSV field delimiter, a comma by default"
+// @Param on_conflict query string false "Documents whose primary key exists: error (default), skip or update" Enums(error, skip, update)
+// @Param max_rejected query int false "Rejected rows tolerated before the import is rolled back, unlimited by default"
+// @Param file body string true "CSV or NDJSON file"
+// @Success 200 {object} database.ImportResult
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} database.ImportResult
+// @Router /project/{id}/data/{db_id}/{collection}/import [post]
+func ImportCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	imp := database.Import{
+		Format:      values.Get("format"),
+		OnConflict:  values.Get("on_conflict"),
+		MaxRejected: -1,
+	}
+	if imp.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		imp.Format = bulkMediaTypes[mediaType]
+	}
+	if value := values.Get("delimiter"); value != "" {
+		delimiter, size := utf8.DecodeRuneInString(value)
+		if size != len(value) {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid delimiter: " + value})
+			return
+		}
+		imp.Delimiter = delimiter
+	}
+	if value := values.Get("max_rejected"); value != "" {
+		var err error
+		if imp.MaxRejected, err = strconv.Atoi(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid max_rejected: " + value})
+			return
+		}
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	result, err := database.ImportDocuments(r.Context(), db, table, r.Body, imp)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if result.Aborted {
+		writeJSON(w, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ExportCollectionHandler exports the documents of a collection
+// @Summary Export documents
+// @Description Streams the documents of the collection as CSV, NDJSON or Parquet, as they are read. The filter query parameter is a JSON object in the filter grammar of docs/filters.md. An error raised once the file has started is reported by aborting the response. The masking profile of the database, if any, is applied to the exported columns. See docs/bulk.md.
+// @Tags Database
+// @Security Bearer
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Produce application/vnd.apache.parquet
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param format query string false "File format, from the Accept header by default, then ndjson" Enums(csv, ndjson, parquet)
+// @Param filter query string false "Filter, as a JSON object"
+// @Param select query string false "Comma-separated columns to export"
+// @Param sort query string false "Comma-separated sort columns, prefixed with - for descending order"
+// @Param limit query int false "Maximum number of documents, all by default"
+// @Param unmasked query bool false "Export without the masking profile (requires the masking permission)"
+// @Success 200 {file} file
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/export [get]
+func ExportCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	req := models.ExportRequest{
+		Format: values.Get("format"),
+		Select: splitList(values.Get("select")),
+		Sort:   splitList(values.Get("sort")),
+	}
+	if value := values.Get("filter"); value != "" {
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req.Filter); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid filter: " + err.Error()})
+			return
+		}
+	}
+	if value := values.Get("limit"); value != "" {
+		var err error
+		if req.Limit, err = strconv.Atoi(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid limit: " + value})
+			return
+		}
+	}
+	if value := values.Get("unmasked"); value != "" {
+		var err error
+		if req.Unmasked, err = strconv.ParseBool(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid unmasked: " + value})
+			return
+		}
+	}
+
+	exportCollection(w, r, req)
+}
+
+// QueryExportHandler exports the result of a query on a collection
+// @Summary Export a query
+// @Description Streams the documents matching the query as CSV, NDJSON or Parquet, as they are read. An error raised once the file has started is reported by aborting the response. The masking profile of the database, if any, is applied to the exported columns. See docs/bulk.md.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Produce application/vnd.apache.parquet
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.ExportRequest true "Export query"
+// @Success 200 {file} file
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/export [post]
+func QueryExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.ExportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	exportCollection(w, r, req)
+}
+
+// exportCollection streams the export of a collection in the response
+func exportCollection(w http.ResponseWriter, r *http.Request, req models.ExportRequest) {
+	if req.Format == "" {
+		req.Format = database.FormatNDJSON
+		for _, accepted := range splitList(r.Header.Get("Accept")) {
+			mediaType, _, _ := mime.ParseMediaType(accepted)
+			if format, ok := bulkMediaTypes[mediaType]; ok {
+				req.Format = format
+				break
+			}
+		}
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	masking, err := requestMasking(r, mux.Vars(r)["db_id"], req.Unmasked)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	exp := database.Export{
+		Format:  req.Format,
+		Filter:  req.Filter,
+		Select:  req.Select,
+		Sort:    req.Sort,
+		Limit:   req.Limit,
+		Masking: masking,
+	}
+	out := &exportWriter{w: w, format: req.Format, filename: table.Name}
+	count, err := database.ExportDocuments(r.Context(), db, table, exp, out)
+	if err == nil {
+		err = database.CommitSession(r.Context(), db)
+	}
+	if err == nil {
+		out.start() // An empty NDJSON export writes no bytes
+		return
+	}
+	if !out.started {
+		writeError(w, err)
+		return
+	}
+
+	// The status is sent: abort the response so the client does not take a truncated file for a complete one
+	log.Printf("❌ Export of %s aborted after %d documents: %v", table.Name, count, err)
+	panic(http.ErrAbortHandler)
+}
+
+// exportWriter sends the headers of an export with its first bytes, so that an error
+// raised before can still be answered with an error status
+type exportWriter struct {
+	w        http.ResponseWriter
+	format   string
+	filename string
+	started  bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	e.start()
+	return e.w.Write(p)
+}
+
+// start sends the headers of the export, once
+func (e *exportWriter) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.w.Header().Set("Content-Type", bulkContentTypes[e.format])
+	e.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fmt.Sprintf("%s.%s", e.filename, e.format)}))
+	e.w.WriteHeader(http.StatusOK)
+}
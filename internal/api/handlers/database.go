@@ -1,11 +1,63 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ketsuna-org/sovrabase/internal/auth"
+	"github.com/ketsuna-org/sovrabase/internal/database"
 	"github.com/ketsuna-org/sovrabase/internal/models"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
 )
 
+// transactionHeader names the transaction a data operation runs in
+const transactionHeader = "X-Transaction-ID"
+
+// Media types of the patch bodies accepted by UpdateDocumentHandler
+const (
+	jsonPatchMediaType  = "application/json-patch+json"
+	mergePatchMediaType = "application/merge-patch+json"
+)
+
+// databaseManager holds the connection pools of the managed databases
+var databaseManager *database.Manager
+
+// SetDatabaseManager registers the manager used by the data API handlers
+func SetDatabaseManager(manager *database.Manager) {
+	databaseManager = manager
+}
+
+// databaseOrchestrator manages the containers of the databases
+var databaseOrchestrator orchestrator.Orchestrator
+
+// SetOrchestrator registers the orchestrator used by the database management handlers
+func SetOrchestrator(o orchestrator.Orchestrator) {
+	databaseOrchestrator = o
+}
+
+// branchNamePattern matches the names of database branches
+var branchNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// jwtSecret signs the end-user tokens and API keys accepted by the data API
+var jwtSecret []byte
+
+// SetJWTSecret registers the secret used to verify end-user tokens and sign API keys
+func SetJWTSecret(secret string) {
+	jwtSecret = []byte(secret)
+}
+
 // CreateDatabaseHandler creates a new database for a project
 // @Summary Create Database
 // @Tags Database
@@ -90,18 +142,195 @@ func RestoreDatabaseHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// GetCollectionHandler gets data from a collection
-// @Summary Get Collection Data
+// CreateDatabaseBranchHandler clones a database into a new ephemeral database
+// @Summary Create Database Branch
+// @Description Copies the schema and data of the database (pg_dump restored into a new container) into a branch with its own credentials: a role owning the branch database, without superuser privileges. The branch ID is the database ID followed by the branch name. With ttl_seconds, the branch is deleted once expired. The masking profile of the database, if any, is applied when the data is read from the source, so unmasked values never reach the branch. Only API keys carrying the branches permission are accepted. See docs/branches.md.
 // @Tags Database
 // @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param request body models.CreateDatabaseBranchRequest true "Branch name and lifetime"
+// @Success 201 {object} models.DatabaseBranchResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/databases/{db_id}/branches [post]
+func CreateDatabaseBranchHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateDatabaseBranchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if databaseOrchestrator == nil || databaseManager == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	if _, err := requestAPIKey(r, auth.PermissionBranches); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkDatabase(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		buf := make([]byte, 4)
+		if _, err := rand.Read(buf); err != nil {
+			writeError(w, err)
+			return
+		}
+		name = hex.EncodeToString(buf)
+	}
+	if !branchNamePattern.MatchString(name) {
+		writeError(w, fmt.Errorf("%w: branch name must be 1 to 32 lowercase letters, digits or dashes", database.ErrInvalid))
+		return
+	}
+	if req.TTLSeconds < 0 {
+		writeError(w, fmt.Errorf("%w: ttl_seconds cannot be negative", database.ErrInvalid))
+		return
+	}
+
+	dbID := mux.Vars(r)["db_id"]
+	options := &orchestrator.BranchOptions{
+		TTL:   time.Duration(req.TTLSeconds) * time.Second,
+		Roles: []string{database.EndUserRole},
+	}
+
+	// Masked collections are read masked from the source: unmasked data never reaches the branch
+	masking, err := requestMasking(r, dbID, req.Unmasked)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if masking != nil {
+		pool, err := databaseManager.Pool(r.Context(), dbID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if options.Masked, err = masking.Tables(r.Context(), pool); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	info, err := databaseOrchestrator.BranchDatabase(r.Context(), dbID, dbID+"-"+name, options)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// The profile follows the branch, so that its own exports stay masked
+	if err := maskingProfiles.Copy(r.Context(), info.OwnerID, dbID, info.ProjectID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	branch := models.DatabaseBranchResponse{
+		ID:               info.ProjectID,
+		ParentID:         info.ParentID,
+		Host:             info.Host,
+		Port:             info.Port,
+		Database:         info.Database,
+		User:             info.User,
+		Password:         info.Password,
+		ConnectionString: info.ConnectionString,
+		CreatedAt:        info.CreatedAt,
+	}
+	if !info.ExpiresAt.IsZero() {
+		branch.ExpiresAt = &info.ExpiresAt
+	}
+
+	writeJSON(w, http.StatusCreated, branch)
+}
+
+// GetCollectionHandler describes a collection
+// @Summary Get Collection
+// @Description Returns the columns, constraints and relations of the collection, read from the Postgres catalog, along with a JSON Schema of its documents.
+// @Tags Database
+// @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Success 200
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 200 {object} database.CollectionSchema
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/collections/{collection} [get]
 func GetCollectionHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get collection logic
-	w.WriteHeader(http.StatusOK)
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	schema, err := database.DescribeCollection(r.Context(), db, mux.Vars(r)["collection"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schema)
+}
+
+// ListDocumentsHandler lists the documents of a collection
+// @Summary List Documents
+// @Description Documents are returned in pages of `limit` items; pass the returned `next_cursor` as `cursor` to fetch the next page.
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param select query string false "Comma-separated list of columns to return"
+// @Param sort query string false "Comma-separated list of sort columns, prefixed with - for descending order"
+// @Param limit query int false "Maximum number of documents (default 100, max 1000)"
+// @Param offset query int false "Number of documents to skip"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param count query string false "Include the total number of documents" Enums(exact, estimated)
+// @Param expand query string false "Related documents to embed: comma-separated relations nested with dots (author,comments.author), or a JSON array of relations with select, filter, sort and limit"
+// @Param trashed query string false "Documents of the trash to read, for a collection with soft delete" Enums(exclude, include, only)
+// @Param If-None-Match header string false "ETag of a previous response: 304 without body if the page is unchanged"
+// @Success 200 {object} database.Page
+// @Success 304 "Page unchanged"
+// @Header 200 {string} ETag "Hash of the page"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/documents [get]
+func ListDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := collectionQueryParams(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	page, err := database.QueryDocuments(r.Context(), db, table, query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeCacheableJSON(w, r, page)
 }
 
 // InsertDataHandler inserts data into the database
@@ -113,14 +342,45 @@ func GetCollectionHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Param request body models.InsertDataRequest true "Data to insert"
-// @Success 200
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.InsertDataRequest true "Data to insert (a document or an array of documents)"
+// @Success 201
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/insert [post]
 func InsertDataHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.InsertDataRequest
-	_ = req
-	// TODO: Implement insert data logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	docs, err := documentsFromData(req.Data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	inserted, err := database.InsertDocuments(r.Context(), db, table, docs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": inserted})
 }
 
 // DeleteDocumentHandler deletes a specific document
@@ -130,12 +390,32 @@ func InsertDataHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param doc_id path string true "Document ID"
-// @Success 200
+// @Param If-Match header string false "Only delete the document if its ETag is one of these"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/{doc_id} [delete]
 func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement delete document logic
-	w.WriteHeader(http.StatusOK)
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DeleteDocument(r.Context(), db, table, mux.Vars(r)["doc_id"], ifMatchVersions(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // BatchDeleteHandler deletes documents with specific filters
@@ -147,27 +427,217 @@ func DeleteDocumentHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Param request body models.BatchDeleteRequest true "IDs to delete"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.BatchDeleteRequest true "IDs and/or filter of the documents to delete"
 // @Success 200
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/delete [post]
 func BatchDeleteHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.BatchDeleteRequest
-	_ = req
-	// TODO: Implement batch delete logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	deleted, err := database.BatchDelete(r.Context(), db, table, req.IDs, req.Filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": deleted})
+}
+
+// RestoreDocumentsHandler moves documents out of the trash of a collection
+// @Summary Restore trashed documents
+// @Description Clears deleted_at on the trashed documents listed by ids and/or matching the filter. An empty filter ({}) restores the whole trash.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.TrashRequest true "IDs and/or filter of the documents to restore"
+// @Success 200 {object} map[string]int64
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/{collection}/restore [post]
+func RestoreDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.TrashRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	restored, err := database.RestoreDocuments(r.Context(), db, table, req.IDs, req.Filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"restored": restored})
+}
+
+// PurgeDocumentsHandler permanently deletes documents of the trash of a collection
+// @Summary Purge trashed documents
+// @Description Permanently deletes the trashed documents listed by ids and/or matching the filter. An empty filter ({}) empties the trash.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.TrashRequest true "IDs and/or filter of the documents to purge"
+// @Success 200 {object} map[string]int64
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/{collection}/purge [post]
+func PurgeDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.TrashRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	purged, err := database.PurgeDocuments(r.Context(), db, table, req.IDs, req.Filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"purged": purged})
+}
+
+// BatchHandler runs an ordered list of write operations in a single transaction
+// @Summary Run several write operations atomically
+// @Description Operations (insert, update, upsert, delete) run in order, possibly across collections. If one fails, every operation is rolled back and the response gives the index of the failing operation.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.BatchRequest true "Operations to run"
+// @Success 200 {object} map[string][]database.OperationResult
+// @Failure 404 {object} models.BatchErrorResponse
+// @Failure 409 {object} models.BatchErrorResponse
+// @Failure 422 {object} models.BatchErrorResponse
+// @Router /project/{id}/data/{db_id}/batch [post]
+func BatchHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	ops := make([]database.Operation, len(req.Operations))
+	for i, op := range req.Operations {
+		converted, err := batchOperation(op)
+		if err != nil {
+			writeBatchError(w, &database.OperationError{Index: i, Err: err})
+			return
+		}
+		ops[i] = converted
+	}
+
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	results, err := database.RunBatch(r.Context(), db, ops)
+	if err != nil {
+		writeBatchError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
 }
 
 // BeginTransactionHandler starts a new transaction
 // @Summary Start a new transaction (Usefull for postgres Database only)
+// @Description The transaction holds a dedicated connection until it is committed or rolled back. Send its ID in the X-Transaction-ID header of data operations to run them inside it. An idle transaction is rolled back after idle_timeout seconds.
 // @Tags Database
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
-// @Success 200
+// @Success 201 {object} models.TransactionResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/transactions/begin [post]
 func BeginTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement begin transaction logic
-	w.WriteHeader(http.StatusOK)
+	if databaseManager == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	claims, err := requestClaims(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tx, err := databaseManager.BeginTransaction(r.Context(), vars["id"], vars["db_id"], claims)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.TransactionResponse{
+		TransactionID: tx.ID,
+		IdleTimeout:   int(databaseManager.TransactionTimeout().Seconds()),
+	})
 }
 
 // CommitTransactionHandler commits a transaction
@@ -177,11 +647,29 @@ func BeginTransactionHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param tx_id path string true "Transaction ID"
-// @Success 200
+// @Success 204 "No Body content, commit successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/transactions/{tx_id}/commit [post]
 func CommitTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement commit transaction logic
-	w.WriteHeader(http.StatusOK)
+	if databaseManager == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	claims, err := requestClaims(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := databaseManager.CommitTransaction(r.Context(), vars["tx_id"], vars["id"], vars["db_id"], claims); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // RollbackTransactionHandler rolls back a transaction
@@ -191,15 +679,33 @@ func CommitTransactionHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param tx_id path string true "Transaction ID"
-// @Success 200
+// @Success 204 "No Body content, rollback successful."
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/transactions/{tx_id}/rollback [post]
 func RollbackTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement rollback transaction logic
-	w.WriteHeader(http.StatusOK)
+	if databaseManager == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	claims, err := requestClaims(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := databaseManager.RollbackTransaction(r.Context(), vars["tx_id"], vars["id"], vars["db_id"], claims); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // QueryCollectionHandler queries a collection
 // @Summary Query the database on a collection aka table (Select QUERY only)
+// @Description The filter grammar ($eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $like, $ilike, $null, $and, $or and "column->json->path" keys) is described in docs/filters.md. With nearest, documents are the k nearest neighbours of a vector on a pgvector column, ordered by distance (docs/vectors.md). With expand, related documents are embedded by following foreign keys (docs/relations.md).
 // @Tags Database
 // @Security Bearer
 // @Accept json
@@ -207,14 +713,184 @@ func RollbackTransactionHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param request body models.QueryCollectionRequest true "Query parameters"
-// @Success 200
+// @Success 200 {object} database.Page
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/query [post]
 func QueryCollectionHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.QueryCollectionRequest
-	_ = req
-	// TODO: Implement query logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	query := database.Query{
+		Filter: req.Filter,
+		Select: req.Select,
+		Sort:   req.Sort,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+		Cursor: req.Cursor,
+		Count:  req.Count,
+		Expand: expandsOf(req.Expand),
+
+		Trashed: req.Trashed,
+	}
+	if n := req.Nearest; n != nil {
+		query.Nearest = &database.Nearest{
+			Column:      n.Column,
+			Vector:      n.Vector,
+			Distance:    n.Distance,
+			MaxDistance: n.MaxDistance,
+		}
+	}
+
+	page, err := database.QueryDocuments(r.Context(), db, table, query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// AggregateCollectionHandler computes aggregates over a collection
+// @Summary Aggregate a collection
+// @Description Computes count, sum, avg, min and max, optionally grouped by columns. Filter selects the aggregated documents and having the returned groups, both in the filter grammar of docs/filters.md; having and sort apply to group columns and aggregate aliases. See docs/aggregations.md.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.AggregateRequest true "Aggregation"
+// @Success 200 {object} map[string][]map[string]interface{}
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/{collection}/aggregate [post]
+func AggregateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.AggregateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	agg := database.Aggregation{
+		GroupBy:    req.GroupBy,
+		Aggregates: make([]database.Aggregate, len(req.Aggregates)),
+		Filter:     req.Filter,
+		Having:     req.Having,
+		Sort:       req.Sort,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}
+	for i, a := range req.Aggregates {
+		agg.Aggregates[i] = database.Aggregate{Function: a.Function, Column: a.Column, Alias: a.Alias, Distinct: a.Distinct}
+	}
+
+	groups, err := database.AggregateDocuments(r.Context(), db, table, agg)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": groups})
+}
+
+// SearchCollectionHandler runs a full-text search on a collection
+// @Summary Full-text search in a collection
+// @Description Matches documents with Postgres tsvector/tsquery and returns them by decreasing rank, with optional highlighted snippets. Without columns, the managed search column of the collection is used. The search grammar is described in docs/search.md.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.SearchRequest true "Search parameters"
+// @Success 200 {object} database.SearchResult
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/{collection}/search [post]
+func SearchCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	search := database.Search{
+		Query:         req.Query,
+		Mode:          req.Mode,
+		Columns:       req.Columns,
+		Weights:       req.Weights,
+		Language:      req.Language,
+		Ranking:       req.Ranking,
+		Normalization: req.Normalization,
+		MinRank:       req.MinRank,
+		Filter:        req.Filter,
+		Select:        req.Select,
+		Limit:         req.Limit,
+		Offset:        req.Offset,
+	}
+	if h := req.Highlight; h != nil {
+		search.Highlight = &database.Highlight{
+			StartSel:     h.StartSel,
+			StopSel:      h.StopSel,
+			MaxWords:     h.MaxWords,
+			MinWords:     h.MinWords,
+			MaxFragments: h.MaxFragments,
+		}
+	}
+
+	result, err := database.SearchDocuments(r.Context(), db, table, search)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
 }
 
 // UpsertDataHandler inserts or updates data
@@ -226,66 +902,251 @@ func QueryCollectionHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Param request body models.UpsertDataRequest true "Data to upsert"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.UpsertDataRequest true "Data to upsert (a document or an array of documents)"
 // @Success 200
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/upsert [post]
 func UpsertDataHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.UpsertDataRequest
-	_ = req
-	// TODO: Implement upsert logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	docs, err := documentsFromData(req.Data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	upserted, err := database.UpsertDocuments(r.Context(), db, table, docs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": upserted})
 }
 
 // GetDocumentHandler gets a specific document
 // @Summary GET un document spécifique
 // @Tags Database
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param doc_id path string true "Document ID"
+// @Param expand query string false "Related documents to embed: comma-separated relations nested with dots (author,comments.author), or a JSON array of relations with select, filter, sort and limit"
 // @Success 200
+// @Header 200 {string} ETag "Version of the document, for If-Match"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/{doc_id} [get]
 func GetDocumentHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get document logic
-	w.WriteHeader(http.StatusOK)
+	expand, err := expandParam(r.URL.Query().Get("expand"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	doc, version, err := database.GetDocument(r.Context(), db, table, mux.Vars(r)["doc_id"], expand)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", documentETag(version))
+	writeJSON(w, http.StatusOK, doc)
 }
 
-// UpdateDocumentHandler updates a specific document
+// UpdateDocumentHandler updates a specific document. Besides {"data": {...}}, the body may be a
+// JSON Patch (application/json-patch+json) or a merge patch (application/merge-patch+json),
+// applied atomically to the row.
 // @Summary UPDATE un document spécifique
+// @Description The body is {"data": {...}} with application/json, an array of operations with application/json-patch+json (RFC 6902) or the changes themselves with application/merge-patch+json (RFC 7396). A patch that cannot be applied returns 409.
 // @Tags Database
 // @Security Bearer
 // @Accept json
+// @Accept application/json-patch+json
+// @Accept application/merge-patch+json
 // @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param doc_id path string true "Document ID"
+// @Param If-Match header string false "Only update the document if its ETag is one of these"
 // @Param request body models.UpdateDocumentRequest true "Document update data"
 // @Success 200
+// @Header 200 {string} ETag "Version of the updated document"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/{collection}/{doc_id} [patch]
 func UpdateDocumentHandler(w http.ResponseWriter, r *http.Request) {
-	var req models.UpdateDocumentRequest
-	_ = req
-	// TODO: Implement update document logic
-	w.WriteHeader(http.StatusOK)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var body interface{}
+	switch mediaType {
+	case jsonPatchMediaType:
+		body = &[]models.PatchOperation{}
+	case mergePatchMediaType:
+		body = &map[string]interface{}{}
+	default:
+		body = &models.UpdateDocumentRequest{}
+	}
+	if err := decodeJSON(r, body); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	id, versions := mux.Vars(r)["doc_id"], ifMatchVersions(r)
+	var doc json.RawMessage
+	var version string
+	switch body := body.(type) {
+	case *[]models.PatchOperation:
+		ops := make([]database.PatchOperation, len(*body))
+		for i, op := range *body {
+			ops[i] = database.PatchOperation{Op: op.Op, Path: op.Path, From: op.From, Value: op.Value}
+		}
+		doc, version, err = database.PatchDocument(r.Context(), db, table, id, ops, versions)
+	case *map[string]interface{}:
+		doc, version, err = database.MergePatchDocument(r.Context(), db, table, id, *body, versions)
+	case *models.UpdateDocumentRequest:
+		doc, version, err = database.UpdateDocument(r.Context(), db, table, id, body.Data, versions)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", documentETag(version))
+	writeJSON(w, http.StatusOK, doc)
 }
 
 // ListCollectionsHandler lists all collections
 // @Summary List Collections
 // @Tags Database
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Success 200
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/collections [get]
 func ListCollectionsHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement list collections logic
-	w.WriteHeader(http.StatusOK)
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	collections, err := database.ListCollections(r.Context(), db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": collections})
+}
+
+// GetDatabaseOpenAPIHandler describes the data API of a database
+// @Summary Get the OpenAPI document of a database
+// @Description Generates an OpenAPI 3.1 document describing the data API routes of every collection of the database, with JSON Schemas of their documents, to generate typed clients.
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/openapi.json [get]
+func GetDatabaseOpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	summaries, err := database.ListCollections(r.Context(), db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	collections := make([]*database.CollectionSchema, 0, len(summaries))
+	for _, summary := range summaries {
+		schema, err := database.DescribeCollection(r.Context(), db, summary.Name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		collections = append(collections, schema)
+	}
+
+	vars := mux.Vars(r)
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, openAPIDocument(vars["id"], vars["db_id"], collections))
 }
 
 // UpdateCollectionHandler updates a collection
 // @Summary Update Collection
+// @Description Renames the collection. The change is recorded in the migration history of the database.
 // @Tags Database
 // @Security Bearer
 // @Accept json
@@ -293,46 +1154,113 @@ func ListCollectionsHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param request body models.UpdateCollectionRequest true "Collection update data"
-// @Success 200
+// @Success 200 {object} database.Table
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/collections/{collection} [patch]
 func UpdateCollectionHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.UpdateCollectionRequest
-	_ = req
-	// TODO: Implement update collection logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	updated, err := database.RenameCollection(r.Context(), db, table, req.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
 }
 
 // DeleteCollectionHandler deletes a collection
 // @Summary Delete Collection
+// @Description The change is recorded in the migration history of the database.
 // @Tags Database
 // @Security Bearer
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Success 200
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /project/{id}/data/{db_id}/collections/{collection} [delete]
 func DeleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement delete collection logic
-	w.WriteHeader(http.StatusOK)
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DropCollection(r.Context(), db, table); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // ListIndexesHandler lists all indexes for a collection
 // @Summary Get a List of index
+// @Description Reads the indexes of the collection from pg_indexes, with their size and usage statistics.
 // @Tags Database
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Success 200
-// @Router /project/{id}/data/{db_id}/{collection}/indexes [get]
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 200 {object} map[string][]database.Index
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/indexes [get]
 func ListIndexesHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement list indexes logic
-	w.WriteHeader(http.StatusOK)
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	indexes, err := database.ListIndexes(r.Context(), db, table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": indexes})
 }
 
 // CreateIndexHandler creates a new index
 // @Summary Post a new index !
+// @Description Creates a Postgres index with column order, partial predicate (filter grammar of docs/filters.md), operator classes, full-text expressions and pgvector HNSW or IVFFlat indexes. Outside a transaction the index is built with CREATE INDEX CONCURRENTLY so writes are not blocked. The change is recorded in the migration history of the database.
 // @Tags Database
 // @Security Bearer
 // @Accept json
@@ -340,29 +1268,94 @@ func ListIndexesHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
 // @Param request body models.CreateIndexRequest true "Index creation data"
-// @Success 200
-// @Router /project/{id}/data/{db_id}/{collection}/indexes [post]
+// @Success 201 {object} database.Index
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/indexes [post]
 func CreateIndexHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateIndexRequest
-	_ = req
-	// TODO: Implement create index logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	def := database.IndexDefinition{
+		Name:   req.Name,
+		Fields: make([]database.IndexField, len(req.Fields)),
+		Unique: req.Unique,
+		Method: req.Method,
+		Where:  req.Where,
+		With:   req.With,
+		// CONCURRENTLY cannot run inside a transaction block
+		Concurrently: isPool(db),
+	}
+	for i, field := range req.Fields {
+		def.Fields[i] = database.IndexField{
+			Column:   field.Column,
+			Order:    field.Order,
+			Nulls:    field.Nulls,
+			OpClass:  field.OpClass,
+			Language: field.Language,
+		}
+	}
+
+	index, err := database.CreateIndex(r.Context(), db, table, def)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, index)
 }
 
 // DeleteIndexHandler deletes an index
 // @Summary Remove an Index !
+// @Description Outside a transaction the index is dropped with DROP INDEX CONCURRENTLY. The change is recorded in the migration history of the database.
 // @Tags Database
 // @Security Bearer
 // @Param id path string true "Project ID"
 // @Param db_id path string true "Database ID"
 // @Param collection path string true "Collection Name"
-// @Param index_id path string true "Index ID"
-// @Success 200
-// @Router /project/{id}/data/{db_id}/{collection}/indexes/{index_id} [delete]
+// @Param index_id path string true "Index name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/indexes/{index_id} [delete]
 func DeleteIndexHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement delete index logic
-	w.WriteHeader(http.StatusOK)
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DropIndex(r.Context(), db, table, mux.Vars(r)["index_id"], isPool(db)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // ListDatabasesHandler lists all databases
@@ -402,3 +1395,237 @@ func DeleteDatabaseHandler(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement delete database logic
 	w.WriteHeader(http.StatusOK)
 }
+
+// openDatabase returns the database targeted by the request, or the transaction
+// named by the X-Transaction-ID header. Requests carrying an end-user token run as that
+// user, under the row-level security policies of the database; only a verified API key
+// reaches the pool directly. release must be called once the request is done.
+func openDatabase(r *http.Request) (database.Querier, func(), error) {
+	if databaseManager == nil {
+		return nil, nil, errDataAPIUnavailable
+	}
+
+	claims, err := requestClaims(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkDatabase(r); err != nil {
+		return nil, nil, err
+	}
+
+	vars := mux.Vars(r)
+	if txID := r.Header.Get(transactionHeader); txID != "" {
+		return databaseManager.AcquireTransaction(txID, vars["id"], vars["db_id"], claims)
+	}
+	if claims != nil {
+		return databaseManager.Session(r.Context(), vars["db_id"], claims)
+	}
+
+	pool, err := databaseManager.Pool(r.Context(), vars["db_id"])
+	if err != nil {
+		return nil, nil, err
+	}
+	return pool, func() {}, nil
+}
+
+// checkDatabase verifies that the database of the request belongs to its project. The token
+// is only checked against the project: a database of another project is reported as missing.
+func checkDatabase(r *http.Request) error {
+	vars := mux.Vars(r)
+	return databaseManager.CheckOwner(r.Context(), vars["id"], vars["db_id"])
+}
+
+// requestClaims returns the end user identified by the bearer token of the request, or nil
+// when the token is a verified API key. Tokens are HS256 JWTs issued for the project; API
+// keys are project tokens too, but grant service access rather than acting as a user. A
+// request without a token, or with a malformed one, is refused.
+func requestClaims(r *http.Request) (*database.Claims, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !auth.IsToken(token) {
+		return nil, fmt.Errorf("%w: an API key or an end-user token is required", auth.ErrInvalidToken)
+	}
+
+	claims, err := auth.Verify(token, jwtSecret, mux.Vars(r)["id"], time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if claims.IsAPIKey() {
+		return nil, nil
+	}
+	return &database.Claims{Subject: claims.Subject(), Role: claims.Role(), Raw: claims}, nil
+}
+
+// isPool reports whether statements run directly on the pool rather than in a transaction
+func isPool(db database.Querier) bool {
+	_, ok := db.(*pgxpool.Pool)
+	return ok
+}
+
+// openCollection returns the database and the collection targeted by the request
+func openCollection(r *http.Request) (database.Querier, *database.Table, func(), error) {
+	db, release, err := openDatabase(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	table, err := database.LoadTable(r.Context(), db, mux.Vars(r)["collection"])
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return db, table, release, nil
+}
+
+// documentsFromData accepts either a single document or an array of documents
+func documentsFromData(data interface{}) ([]map[string]interface{}, error) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{value}, nil
+	case []interface{}:
+		docs := make([]map[string]interface{}, len(value))
+		for i, item := range value {
+			doc, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: data[%d] must be an object", database.ErrInvalid, i)
+			}
+			docs[i] = doc
+		}
+		return docs, nil
+	default:
+		return nil, fmt.Errorf("%w: data must be an object or an array of objects", database.ErrInvalid)
+	}
+}
+
+// batchOperation converts a batch operation of the request for the database layer
+func batchOperation(op models.BatchOperation) (database.Operation, error) {
+	converted := database.Operation{
+		Op:         op.Op,
+		Collection: op.Collection,
+		ID:         op.ID,
+		IDs:        op.IDs,
+		Filter:     op.Filter,
+	}
+
+	switch op.Op {
+	case database.OpInsert, database.OpUpsert:
+		docs, err := documentsFromData(op.Data)
+		if err != nil {
+			return converted, err
+		}
+		converted.Documents = docs
+	case database.OpUpdate:
+		doc, ok := op.Data.(map[string]interface{})
+		if !ok {
+			return converted, fmt.Errorf("%w: data must be an object", database.ErrInvalid)
+		}
+		converted.Documents = []map[string]interface{}{doc}
+	}
+	return converted, nil
+}
+
+// writeBatchError writes a batch failure, with the index of the failing operation when known
+func writeBatchError(w http.ResponseWriter, err error) {
+	var opErr *database.OperationError
+	if !errors.As(err, &opErr) {
+		writeError(w, err)
+		return
+	}
+
+	status, message := errorStatus(opErr.Err)
+	writeJSON(w, status, models.BatchErrorResponse{Error: message, Index: opErr.Index})
+}
+
+// collectionQueryParams reads the projection, sort and pagination query parameters
+func collectionQueryParams(r *http.Request) (database.Query, error) {
+	values := r.URL.Query()
+	query := database.Query{
+		Select: splitList(values.Get("select")),
+		Sort:   splitList(values.Get("sort")),
+		Cursor: values.Get("cursor"),
+		Count:  values.Get("count"),
+
+		Trashed: values.Get("trashed"),
+	}
+
+	var err error
+	if query.Expand, err = expandParam(values.Get("expand")); err != nil {
+		return query, err
+	}
+	if value := values.Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil {
+			return query, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+	if value := values.Get("offset"); value != "" {
+		if query.Offset, err = strconv.Atoi(value); err != nil {
+			return query, fmt.Errorf("invalid offset: %s", value)
+		}
+	}
+	return query, nil
+}
+
+// splitList splits a comma-separated query parameter
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// expandParam reads the expand query parameter: either comma-separated relations, nested
+// with dots (author,comments.author), or a JSON array of relations with their options
+func expandParam(value string) ([]database.Expand, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var req []models.ExpandRequest
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid expand: %v", err)
+		}
+		return expandsOf(req), nil
+	}
+
+	var expands []database.Expand
+	for _, path := range splitList(value) {
+		level := &expands
+		for _, relation := range strings.Split(path, ".") {
+			if relation == "" {
+				return nil, fmt.Errorf("invalid expand: %s", path)
+			}
+			i := slices.IndexFunc(*level, func(e database.Expand) bool { return e.Relation == relation })
+			if i < 0 {
+				*level = append(*level, database.Expand{Relation: relation})
+				i = len(*level) - 1
+			}
+			level = &(*level)[i].Expand
+		}
+	}
+	return expands, nil
+}
+
+// expandsOf converts the expanded relations of a request for the database layer
+func expandsOf(req []models.ExpandRequest) []database.Expand {
+	if len(req) == 0 {
+		return nil
+	}
+	expands := make([]database.Expand, len(req))
+	for i, e := range req {
+		expands[i] = database.Expand{
+			Relation: e.Relation,
+			As:       e.As,
+			Select:   e.Select,
+			Filter:   e.Filter,
+			Sort:     e.Sort,
+			Limit:    e.Limit,
+			Expand:   expandsOf(e.Expand),
+		}
+	}
+	return expands
+}
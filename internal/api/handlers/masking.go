@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/auth"
+	"github.com/ketsuna-org/sovrabase/internal/database"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+)
+
+var maskingProfiles *database.MaskingProfiles
+
+// SetMaskingProfiles registers the masking profiles used by branches and exports
+func SetMaskingProfiles(profiles *database.MaskingProfiles) {
+	maskingProfiles = profiles
+}
+
+// GetMaskingProfileHandler returns the masking profile of a database
+// @Summary Get Masking Profile
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Success 200 {object} database.MaskingProfile
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/masking [get]
+func GetMaskingProfileHandler(w http.ResponseWriter, r *http.Request) {
+	_, owner, err := maskingDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	profile, err := maskingProfiles.Get(r.Context(), owner, mux.Vars(r)["db_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// SetMaskingProfileHandler creates or replaces the masking profile of a database
+// @Summary Set Masking Profile
+// @Description Masking rules are applied per column to the data leaving the database: database branches and exports. The profile is enforced when the organization of the project requires it (RGPD compliance): it cannot be bypassed with unmasked, and a replacement must keep masking every existing column already masked.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param request body models.MaskingProfileRequest true "Masking rules"
+// @Success 200 {object} database.MaskingProfile
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/masking [put]
+func SetMaskingProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.MaskingProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	pool, owner, err := maskingDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rules := make([]database.MaskingRule, len(req.Rules))
+	for i, rule := range req.Rules {
+		rules[i] = database.MaskingRule{
+			Collection: rule.Collection,
+			Column:     rule.Column,
+			Strategy:   rule.Strategy,
+			Value:      rule.Value,
+		}
+	}
+
+	saved, err := maskingProfiles.Set(r.Context(), pool, owner, mux.Vars(r)["db_id"], rules)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteMaskingProfileHandler removes the masking profile of a database
+// @Summary Delete Masking Profile
+// @Description An enforced profile cannot be deleted.
+// @Tags Database
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/masking [delete]
+func DeleteMaskingProfileHandler(w http.ResponseWriter, r *http.Request) {
+	_, owner, err := maskingDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := maskingProfiles.Delete(r.Context(), owner, mux.Vars(r)["db_id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maskingDatabase returns the service pool of the database and its owning project, for an
+// API key with the masking permission
+func maskingDatabase(r *http.Request) (database.Querier, string, error) {
+	if databaseManager == nil || maskingProfiles == nil {
+		return nil, "", errDataAPIUnavailable
+	}
+	if _, err := requestAPIKey(r, auth.PermissionMasking); err != nil {
+		return nil, "", err
+	}
+	if err := checkDatabase(r); err != nil {
+		return nil, "", err
+	}
+
+	dbID := mux.Vars(r)["db_id"]
+	owner, err := databaseManager.Owner(r.Context(), dbID)
+	if err != nil {
+		return nil, "", err
+	}
+	pool, err := databaseManager.Pool(r.Context(), dbID)
+	if err != nil {
+		return nil, "", err
+	}
+	return pool, owner, nil
+}
+
+// requestMasking returns the masking to apply to a copy of the database, nil without a
+// profile. The profile and its enforcement follow the project owning the database, whatever
+// the project of the request. Skipping the profile requires the masking permission and is
+// refused when enforced; a database whose masking is enforced cannot be copied without a profile.
+func requestMasking(r *http.Request, dbID string, unmasked bool) (*database.Masking, error) {
+	if databaseManager == nil || maskingProfiles == nil {
+		return nil, errDataAPIUnavailable
+	}
+	ctx := r.Context()
+	owner, err := databaseManager.Owner(ctx, dbID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := maskingProfiles.Get(ctx, owner, dbID)
+	if errors.Is(err, database.ErrNotFound) {
+		enforced, err := maskingProfiles.Enforced(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		if enforced {
+			return nil, fmt.Errorf("%w: the organization of the project requires a masking profile on its databases", database.ErrForbidden)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if unmasked {
+		if profile.Enforced {
+			return nil, fmt.Errorf("%w: the masking profile of the database is enforced", database.ErrForbidden)
+		}
+		if _, err := requestAPIKey(r, auth.PermissionMasking); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return database.NewMasking(profile)
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/ketsuna-org/sovrabase/internal/database"
+)
+
+// componentNameInvalid matches the characters OpenAPI does not allow in component names
+var componentNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// openAPIDocument describes the data API of a database, one set of routes per collection.
+// Document schemas are JSON Schema 2020-12, as used by OpenAPI 3.1.
+func openAPIDocument(projectID, dbID string, collections []*database.CollectionSchema) map[string]interface{} {
+	base := "/project/" + url.PathEscape(projectID) + "/data/" + url.PathEscape(dbID)
+	paths := map[string]interface{}{}
+	schemas := map[string]interface{}{
+		"ErrorResponse": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+			"required":   []string{"error"},
+		},
+		"Filter": map[string]interface{}{
+			"type":        "object",
+			"description": "Filter grammar described in docs/filters.md",
+		},
+		"Expand": map[string]interface{}{
+			"type":        "object",
+			"description": "Related documents to embed, following a foreign key (docs/relations.md)",
+			"properties": map[string]interface{}{
+				"relation": map[string]interface{}{"type": "string"},
+				"as":       map[string]interface{}{"type": "string"},
+				"select":   stringArray(),
+				"filter":   schemaRef("Filter"),
+				"sort":     stringArray(),
+				"limit":    map[string]interface{}{"type": "integer"},
+				"expand":   map[string]interface{}{"type": "array", "items": schemaRef("Expand")},
+			},
+			"required": []string{"relation"},
+		},
+		"JSONPatch": map[string]interface{}{
+			"type":        "array",
+			"description": "JSON Patch (RFC 6902) whose paths start with a column (docs/patch.md)",
+			"items": objectBody(map[string]interface{}{
+				"op": map[string]interface{}{"type": "string", "enum": []string{
+					database.PatchAdd, database.PatchRemove, database.PatchReplace, database.PatchMove, database.PatchCopy, database.PatchTest,
+				}},
+				"path":  map[string]interface{}{"type": "string"},
+				"from":  map[string]interface{}{"type": "string"},
+				"value": map[string]interface{}{},
+			}, []string{"op", "path"}),
+		},
+	}
+
+	for _, collection := range collections {
+		name := componentNameInvalid.ReplaceAllString(collection.Name, "_")
+		document := schemaRef(name)
+		input := schemaRef(name + "Input")
+		patch := database.DocumentSchema(collection, true)
+		patch["required"] = []string{}
+
+		schemas[name] = database.DocumentSchema(collection, false)
+		schemas[name+"Input"] = database.DocumentSchema(collection, true)
+		schemas[name+"Patch"] = patch
+
+		tag := []string{collection.Name}
+		escaped := url.PathEscape(collection.Name)
+		oneOrMany := map[string]interface{}{
+			"oneOf": []interface{}{input, map[string]interface{}{"type": "array", "items": input}},
+		}
+
+		listParameters := collectionQueryParameters()
+		queryProperties := map[string]interface{}{
+			"filter": schemaRef("Filter"),
+			"select": stringArray(),
+			"sort":   stringArray(),
+			"limit":  map[string]interface{}{"type": "integer"},
+			"offset": map[string]interface{}{"type": "integer"},
+			"cursor": map[string]interface{}{"type": "string"},
+			"count":  map[string]interface{}{"type": "string", "enum": []string{database.CountExact, database.CountEstimated}},
+			"nearest": objectBody(map[string]interface{}{
+				"column":       map[string]interface{}{"type": "string"},
+				"vector":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}},
+				"distance":     map[string]interface{}{"type": "string", "enum": []string{database.DistanceCosine, database.DistanceL2, database.DistanceInnerProduct}},
+				"max_distance": map[string]interface{}{"type": "number"},
+			}, []string{"column", "vector"}),
+			"expand": map[string]interface{}{"type": "array", "items": schemaRef("Expand")},
+		}
+		if collection.SoftDelete != nil {
+			trashed := map[string]interface{}{"type": "string", "enum": []string{database.TrashedExclude, database.TrashedInclude, database.TrashedOnly}}
+			listParameters = append(listParameters, map[string]interface{}{
+				"name": "trashed", "in": "query", "description": "Documents of the trash to read", "schema": trashed,
+			})
+			queryProperties["trashed"] = trashed
+		}
+
+		paths[base+"/collections/"+escaped+"/documents"] = map[string]interface{}{
+			"get": operation(tag, "List "+collection.Name, nil, pageOf(document), "200", listParameters),
+		}
+		paths[base+"/"+escaped+"/query"] = map[string]interface{}{
+			"post": operation(tag, "Query "+collection.Name, objectBody(queryProperties, nil), pageOf(document), "200", nil),
+		}
+		paths[base+"/"+escaped+"/aggregate"] = map[string]interface{}{
+			"post": operation(tag, "Aggregate "+collection.Name, objectBody(map[string]interface{}{
+				"group_by": stringArray(),
+				"aggregates": map[string]interface{}{"type": "array", "items": objectBody(map[string]interface{}{
+					"function": map[string]interface{}{"type": "string", "enum": []string{
+						database.AggregateCount, database.AggregateSum, database.AggregateAvg, database.AggregateMin, database.AggregateMax,
+					}},
+					"column":   map[string]interface{}{"type": "string"},
+					"alias":    map[string]interface{}{"type": "string"},
+					"distinct": map[string]interface{}{"type": "boolean"},
+				}, []string{"function"})},
+				"filter": schemaRef("Filter"),
+				"having": schemaRef("Filter"),
+				"sort":   stringArray(),
+				"limit":  map[string]interface{}{"type": "integer"},
+				"offset": map[string]interface{}{"type": "integer"},
+			}, nil), dataOf(map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}}), "200", nil),
+		}
+		paths[base+"/"+escaped+"/insert"] = map[string]interface{}{
+			"post": operation(tag, "Insert "+collection.Name, objectBody(map[string]interface{}{"data": oneOrMany}, []string{"data"}),
+				dataOf(map[string]interface{}{"type": "array", "items": document}), "201", nil),
+		}
+		paths[base+"/"+escaped+"/upsert"] = map[string]interface{}{
+			"post": operation(tag, "Upsert "+collection.Name, objectBody(map[string]interface{}{"data": oneOrMany}, []string{"data"}),
+				dataOf(map[string]interface{}{"type": "array", "items": document}), "200", nil),
+		}
+		paths[base+"/"+escaped+"/delete"] = map[string]interface{}{
+			"post": operation(tag, "Delete "+collection.Name, objectBody(map[string]interface{}{
+				"ids":    stringArray(),
+				"filter": schemaRef("Filter"),
+			}, nil), map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"deleted": map[string]interface{}{"type": "integer"}},
+			}, "200", nil),
+		}
+		if collection.SoftDelete != nil {
+			for _, action := range []struct{ path, summary, count string }{
+				{"restore", "Restore trashed " + collection.Name, "restored"},
+				{"purge", "Purge trashed " + collection.Name, "purged"},
+			} {
+				paths[base+"/"+escaped+"/"+action.path] = map[string]interface{}{
+					"post": operation(tag, action.summary, objectBody(map[string]interface{}{
+						"ids":    stringArray(),
+						"filter": schemaRef("Filter"),
+					}, nil), map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{action.count: map[string]interface{}{"type": "integer"}},
+					}, "200", nil),
+				}
+			}
+		}
+
+		// Document routes need a single-column primary key
+		if len(collection.PrimaryKey) == 1 {
+			docID := []interface{}{map[string]interface{}{
+				"name": "doc_id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			}}
+			update := operation(tag, "Update a "+collection.Name+" document",
+				objectBody(map[string]interface{}{"data": schemaRef(name + "Patch")}, []string{"data"}), document, "200", docID)
+			content := update["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+			content[jsonPatchMediaType] = map[string]interface{}{"schema": schemaRef("JSONPatch")}
+			content[mergePatchMediaType] = map[string]interface{}{"schema": schemaRef(name + "Patch")}
+			update["responses"].(map[string]interface{})["409"] = map[string]interface{}{
+				"description": "The patch cannot be applied to the document", "content": jsonContent(schemaRef("ErrorResponse")),
+			}
+			paths[base+"/"+escaped+"/{doc_id}"] = map[string]interface{}{
+				"get":    operation(tag, "Get a "+collection.Name+" document", nil, document, "200", append(docID, expandParameter())),
+				"patch":  update,
+				"delete": operation(tag, "Delete a "+collection.Name+" document", nil, nil, "204", docID),
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "Sovrabase data API: " + dbID,
+			"description": "Data API of database " + dbID + " of project " + projectID + ", generated from its schema.",
+			"version":     "1.0",
+		},
+		"servers":  []interface{}{map[string]interface{}{"url": "/"}},
+		"security": []interface{}{map[string]interface{}{"Bearer": []string{}}},
+		"paths":    paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"Bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operation builds an OpenAPI operation with the usual error responses
+func operation(tags []string, summary string, body, response map[string]interface{}, status string, parameters []interface{}) map[string]interface{} {
+	success := map[string]interface{}{"description": summary}
+	if response != nil {
+		success["content"] = jsonContent(response)
+	}
+
+	errorResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{"description": description, "content": jsonContent(schemaRef("ErrorResponse"))}
+	}
+
+	op := map[string]interface{}{
+		"tags":    tags,
+		"summary": summary,
+		"parameters": append([]interface{}{map[string]interface{}{
+			"name": "X-Transaction-ID", "in": "header", "schema": map[string]interface{}{"type": "string"},
+			"description": "Run the operation inside this transaction",
+		}}, parameters...),
+		"responses": map[string]interface{}{
+			status: success,
+			"401":  errorResponse("Invalid end-user token"),
+			"403":  errorResponse("Denied by a row-level security policy"),
+			"404":  errorResponse("Collection or document not found"),
+			"422":  errorResponse("Invalid data or query"),
+		},
+	}
+	if body != nil {
+		op["requestBody"] = map[string]interface{}{"required": true, "content": jsonContent(body)}
+	}
+	return op
+}
+
+// collectionQueryParameters describes the query parameters of the document listing
+func collectionQueryParameters() []interface{} {
+	param := func(name, kind, description string) interface{} {
+		return map[string]interface{}{
+			"name": name, "in": "query", "description": description, "schema": map[string]interface{}{"type": kind},
+		}
+	}
+	return []interface{}{
+		param("select", "string", "Comma-separated list of columns to return"),
+		param("sort", "string", "Comma-separated list of sort columns, prefixed with - for descending order"),
+		param("limit", "integer", "Maximum number of documents"),
+		param("offset", "integer", "Number of documents to skip"),
+		param("cursor", "string", "Cursor returned as next_cursor by the previous page"),
+		param("count", "string", "Include the total number of documents (exact or estimated)"),
+		expandParameter(),
+	}
+}
+
+// expandParameter describes the expand query parameter of document reads
+func expandParameter() interface{} {
+	return map[string]interface{}{
+		"name": "expand", "in": "query", "schema": map[string]interface{}{"type": "string"},
+		"description": "Related documents to embed: comma-separated relations nested with dots, or a JSON array of Expand objects",
+	}
+}
+
+// pageOf describes the page envelope returned by document listings
+func pageOf(document map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data":            map[string]interface{}{"type": "array", "items": document},
+			"next_cursor":     map[string]interface{}{"type": "string"},
+			"total":           map[string]interface{}{"type": "integer"},
+			"total_estimated": map[string]interface{}{"type": "boolean"},
+			"distances":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}},
+		},
+		"required": []string{"data"},
+	}
+}
+
+// dataOf describes a {"data": ...} envelope
+func dataOf(schema map[string]interface{}) map[string]interface{} {
+	return objectBody(map[string]interface{}{"data": schema}, []string{"data"})
+}
+
+func objectBody(properties map[string]interface{}, required []string) map[string]interface{} {
+	body := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		body["required"] = required
+	}
+	return body
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func stringArray() map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+}
@@ -1,14 +1,32 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/auth"
+	"github.com/ketsuna-org/sovrabase/internal/database"
 	"github.com/ketsuna-org/sovrabase/internal/models"
 	"github.com/ketsuna-org/sovrabase/internal/models/project"
 )
 
+// apiKeyLifetime is the validity of API keys created without an expiration date
+const apiKeyLifetime = 365 * 24 * time.Hour
+
+// superUser holds the credentials of the server administrator, empty when none is configured
+var superUser struct{ username, password string }
+
+// SetSuperUser registers the credentials of the server administrator
+func SetSuperUser(username, password string) {
+	superUser.username, superUser.password = username, password
+}
+
 // CreateProjectHandler creates a new project
 // @Summary Create a New Project
 // @Tags Projects
@@ -157,6 +175,7 @@ func GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateAPIKeyHandler creates a new API key
 // @Summary Create a new Public API Key (Can be used on any front end)
+// @Description Requires a project administrator: the super user of the server (HTTP Basic authentication) or an API key of the project carrying the admin permission. The key is a token signed for the project and is only returned once. It only carries the permissions listed in the request (sql, branches, masking, admin), which grant access beyond the data API (see docs/sql.md); only the super user can grant admin. Without expires_at, the key is valid for one year.
 // @Tags Projects
 // @Security Bearer
 // @Accept json
@@ -164,17 +183,94 @@ func GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param request body models.CreateAPIKeyRequest true "API Key creation data"
 // @Success 200 {object} project.APIKey
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/api-keys [post]
 func CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateAPIKeyRequest
-	_ = req
-	// TODO: Implement API key creation logic
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"id":   "new_key_id",
-		"key":  "new_api_key_value",
-		"name": "New API Key",
-	})
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if len(jwtSecret) == 0 {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	isSuperUser, err := requestProjectAdmin(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, permission := range req.Permissions {
+		if !auth.IsPermission(permission) {
+			writeError(w, fmt.Errorf("%w: unknown permission %q", database.ErrInvalid, permission))
+			return
+		}
+		if permission == auth.PermissionAdmin && !isSuperUser {
+			writeError(w, fmt.Errorf("%w: only the super user can grant the %q permission", database.ErrForbidden, permission))
+			return
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(apiKeyLifetime)
+	if req.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !parsed.After(now) {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "expires_at must be a future RFC 3339 date"})
+			return
+		}
+		expiresAt = parsed.UTC()
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		writeError(w, err)
+		return
+	}
+	key := project.APIKey{
+		ID:          hex.EncodeToString(buf),
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   now,
+		ExpiresAt:   &expiresAt,
+		Active:      true,
+		Permissions: req.Permissions,
+	}
+	if key.Permissions == nil {
+		key.Permissions = []string{}
+	}
+
+	signed, err := auth.Sign(auth.NewAPIKey(key.ID, mux.Vars(r)["id"], key.Permissions, now, expiresAt), jwtSecret)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	key.Key = signed
+
+	writeJSON(w, http.StatusOK, key)
+}
+
+// requestProjectAdmin authenticates an administrator of the project: the super user, with
+// HTTP Basic authentication, or an API key of the project carrying the admin permission.
+// It reports whether the request comes from the super user.
+func requestProjectAdmin(r *http.Request) (bool, error) {
+	if username, password, ok := r.BasicAuth(); ok {
+		if superUser.password == "" ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(superUser.username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(superUser.password)) != 1 {
+			return false, fmt.Errorf("%w: invalid super user credentials", auth.ErrInvalidToken)
+		}
+		return true, nil
+	}
+
+	if _, err := requestAPIKey(r, auth.PermissionAdmin); err != nil {
+		return false, err
+	}
+	return false, nil
 }
 
 // UpdateAPIKeyHandler updates an API key
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ketsuna-org/sovrabase/internal/auth"
+	"github.com/ketsuna-org/sovrabase/internal/database"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+	"github.com/ketsuna-org/sovrabase/internal/storage"
+)
+
+// errDataAPIUnavailable is returned when no database manager has been registered
+var errDataAPIUnavailable = errors.New("data API is not available")
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeCacheableJSON writes v as a JSON response with an ETag computed from its content,
+// or a 304 without body when the If-None-Match header of the request matches it
+func writeCacheableJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		writeError(w, err)
+		return
+	}
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Values("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header lists the entity tag, with the weak
+// comparison of RFC 9110
+func etagMatches(header []string, etag string) bool {
+	for _, tag := range splitList(strings.Join(header, ",")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// documentETag returns the entity tag of a document version
+func documentETag(version string) string {
+	return `"` + version + `"`
+}
+
+// ifMatchVersions returns the document versions accepted by the If-Match header of the
+// request: nil when the header is absent or *, otherwise the versions of its strong entity
+// tags, weak tags never matching with the strong comparison If-Match requires
+func ifMatchVersions(r *http.Request) []string {
+	header := r.Header.Values("If-Match")
+	if len(header) == 0 {
+		return nil
+	}
+	versions := []string{}
+	for _, tag := range splitList(strings.Join(header, ",")) {
+		if tag == "*" {
+			return nil
+		}
+		if strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) && len(tag) >= 2 {
+			versions = append(versions, tag[1:len(tag)-1])
+		}
+	}
+	return versions
+}
+
+// writeError maps an error to its HTTP status code and writes it as JSON
+func writeError(w http.ResponseWriter, err error) {
+	status, message := errorStatus(err)
+	writeJSON(w, status, models.ErrorResponse{Error: message})
+}
+
+// errorStatus returns the HTTP status code and the client message of an error
+func errorStatus(err error) (int, string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errDataAPIUnavailable), errors.Is(err, errStorageUnavailable):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, auth.ErrInvalidToken):
+		status = http.StatusUnauthorized
+	case errors.Is(err, database.ErrForbidden), errors.Is(err, storage.ErrInvalidSignature):
+		status = http.StatusForbidden
+	case errors.Is(err, database.ErrNotFound), errors.Is(err, orchestrator.ErrDatabaseNotFound), errors.Is(err, storage.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, database.ErrConflict), errors.Is(err, orchestrator.ErrDatabaseExists), errors.Is(err, storage.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, database.ErrInvalid), errors.Is(err, storage.ErrInvalid):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, database.ErrTooManyTransactions):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, database.ErrTimeout):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, database.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	if status == http.StatusInternalServerError {
+		log.Printf("❌ Internal error: %v", err)
+		return status, "Internal server error"
+	}
+	return status, err.Error()
+}
+
+// decodeJSON decodes the request body into v, keeping numbers exact
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
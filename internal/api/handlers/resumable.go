@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+	"github.com/ketsuna-org/sovrabase/internal/storage"
+)
+
+// Resumable uploads follow the core tus protocol (https://tus.io/protocols/resumable-upload),
+// with its creation, termination and expiration extensions, so that tus clients work unchanged.
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+)
+
+// tusResponse sets the headers common to every tus response
+func tusResponse(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// checkTusVersion rejects the requests of a client speaking another tus version
+func checkTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	if version := r.Header.Get("Tus-Resumable"); version != "" && version != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		writeJSON(w, http.StatusPreconditionFailed, models.ErrorResponse{Error: "unsupported tus version: " + version})
+		return false
+	}
+	return true
+}
+
+// writeUploadProgress sets the headers describing the progress of a resumable upload
+func writeUploadProgress(w http.ResponseWriter, upload *storage.ResumableUpload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// ResumableUploadOptionsHandler describes the resumable upload capabilities of a bucket
+// @Summary Resumable Upload Capabilities
+// @Description tus discovery: supported version, extensions and, when the bucket has one, maximum file size.
+// @Tags Storage
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/uploads [options]
+func ResumableUploadOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, err := service.Bucket(r.Context(), vars["id"], vars["bucket_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	tusResponse(w)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	if bucket.MaxFileSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(bucket.MaxFileSize, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateResumableUploadHandler starts a resumable upload
+// @Summary Create Resumable Upload
+// @Description tus creation: Upload-Length gives the file size, Upload-Metadata its name (name or filename), content type (content_type or filetype) and metadata (a JSON object), as comma-separated "key base64(value)" pairs. The response Location is the upload URL, to send the content to with PATCH. The file name is checked now: an existing file answers 409 unless upsert.
+// @Tags Storage
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param Upload-Length header int true "File size in bytes"
+// @Param Upload-Metadata header string true "tus metadata, with at least the file name"
+// @Param Tus-Resumable header string false "tus version (1.0.0)"
+// @Param upsert query bool false "Replace an existing file with the same name"
+// @Success 201
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/uploads [post]
+func CreateResumableUploadHandler(w http.ResponseWriter, r *http.Request) {
+	tusResponse(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	var upload storage.Upload
+	var err error
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Upload-Defer-Length is not supported"})
+		return
+	}
+	value := r.Header.Get("Upload-Length")
+	if upload.Size, err = strconv.ParseInt(value, 10, 64); err != nil || upload.Size < 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid Upload-Length: " + value})
+		return
+	}
+	if err := parseUploadMetadata(r.Header.Get("Upload-Metadata"), &upload); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid Upload-Metadata: " + err.Error()})
+		return
+	}
+	if value := r.URL.Query().Get("upsert"); value != "" {
+		if upload.Upsert, err = strconv.ParseBool(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid upsert: " + value})
+			return
+		}
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	resumable, err := service.CreateResumableUpload(r.Context(), vars["id"], vars["bucket_id"], upload)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+resumable.ID)
+	writeUploadProgress(w, resumable)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// parseUploadMetadata reads the file description of the tus Upload-Metadata header
+func parseUploadMetadata(header string, upload *storage.Upload) error {
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("value of %s is not base64", key)
+		}
+		value := string(decoded)
+		switch key {
+		case "name", "filename":
+			if upload.Name == "" || key == "name" {
+				upload.Name = value
+			}
+		case "content_type", "filetype":
+			if upload.ContentType == "" || key == "content_type" {
+				upload.ContentType = value
+			}
+		case "metadata":
+			if err := json.Unmarshal(decoded, &upload.Metadata); err != nil {
+				return fmt.Errorf("metadata is not a JSON object: %v", err)
+			}
+		}
+	}
+	if upload.Name == "" {
+		return fmt.Errorf("missing file name")
+	}
+	upload.ContentType = detectContentType(upload.ContentType, upload.Name)
+	return nil
+}
+
+// GetResumableUploadHandler returns the progress of a resumable upload
+// @Summary Get Resumable Upload Offset
+// @Description tus offset retrieval: Upload-Offset gives the bytes received, to resume the upload from.
+// @Tags Storage
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param upload_id path string true "Upload ID"
+// @Success 200
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id} [head]
+func GetResumableUploadHandler(w http.ResponseWriter, r *http.Request) {
+	tusResponse(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	upload, err := service.ResumableUpload(r.Context(), vars["id"], vars["bucket_id"], vars["upload_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeUploadProgress(w, upload)
+	w.WriteHeader(http.StatusOK)
+}
+
+// PatchResumableUploadHandler appends content to a resumable upload
+// @Summary Resume Upload
+// @Description tus core: the body, sent as application/offset+octet-stream, is appended at Upload-Offset, which must be the current offset. Bytes received before a connection loss are kept. Once the whole content is received, the file is created and its ID returned in the File-Id header.
+// @Tags Storage
+// @Security Bearer
+// @Accept application/offset+octet-stream
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param upload_id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of the content"
+// @Param Tus-Resumable header string false "tus version (1.0.0)"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id} [patch]
+func PatchResumableUploadHandler(w http.ResponseWriter, r *http.Request) {
+	tusResponse(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/offset+octet-stream" {
+		writeJSON(w, http.StatusUnsupportedMediaType, models.ErrorResponse{Error: "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	value := r.Header.Get("Upload-Offset")
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || offset < 0 {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid Upload-Offset: " + value})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	upload, object, err := service.WriteChunk(r.Context(), vars["id"], vars["bucket_id"], vars["upload_id"], offset, r.Body)
+	if upload != nil {
+		writeUploadProgress(w, upload)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if object != nil {
+		w.Header().Set("File-Id", object.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteResumableUploadHandler cancels a resumable upload
+// @Summary Cancel Resumable Upload
+// @Description tus termination: the received content is deleted. Unfinished uploads are also deleted once expired.
+// @Tags Storage
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param upload_id path string true "Upload ID"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id} [delete]
+func DeleteResumableUploadHandler(w http.ResponseWriter, r *http.Request) {
+	tusResponse(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := service.CancelResumableUpload(r.Context(), vars["id"], vars["bucket_id"], vars["upload_id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
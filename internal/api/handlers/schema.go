@@ -0,0 +1,605 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/database"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+)
+
+// CreateCollectionHandler creates a collection
+// @Summary Create Collection
+// @Description Creates a table with typed columns, primary key, defaults, foreign keys and check constraints (expressed with the filter grammar of docs/filters.md). The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.CreateCollectionRequest true "Collection definition"
+// @Success 201 {object} database.Table
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections [post]
+func CreateCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCollectionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	def := database.CollectionDefinition{
+		Name:       req.Name,
+		Columns:    make([]database.ColumnDefinition, len(req.Columns)),
+		PrimaryKey: req.PrimaryKey,
+		Checks:     make([]database.CheckDefinition, len(req.Checks)),
+	}
+	for i, col := range req.Columns {
+		def.Columns[i] = columnDefinition(col)
+	}
+	for i, check := range req.Checks {
+		def.Checks[i] = database.CheckDefinition{Name: check.Name, Filter: check.Filter}
+	}
+
+	table, err := database.CreateCollection(r.Context(), db, def)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, table)
+}
+
+// AddColumnHandler adds a column to a collection
+// @Summary Add Column
+// @Description The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.ColumnDefinition true "Column definition"
+// @Success 201 {object} database.Table
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/columns [post]
+func AddColumnHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.ColumnDefinition
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	updated, err := database.AddColumn(r.Context(), db, table, columnDefinition(req))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, updated)
+}
+
+// AlterColumnHandler changes the name, type, nullability or default of a column
+// @Summary Alter Column
+// @Description Omitted fields are left unchanged. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param column path string true "Column Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.AlterColumnRequest true "Column changes"
+// @Success 200 {object} database.Table
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/columns/{column} [patch]
+func AlterColumnHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.AlterColumnRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	updated, err := database.AlterColumn(r.Context(), db, table, mux.Vars(r)["column"], database.ColumnChange{
+		Name:            req.Name,
+		Type:            req.Type,
+		NotNull:         req.NotNull,
+		Default:         req.Default,
+		DefaultFunction: req.DefaultFunction,
+		DropDefault:     req.DropDefault,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DropColumnHandler removes a column from a collection
+// @Summary Drop Column
+// @Description The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param column path string true "Column Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/columns/{column} [delete]
+func DropColumnHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DropColumn(r.Context(), db, table, mux.Vars(r)["column"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMigrationsHandler lists the schema changes applied to a database
+// @Summary List Migrations
+// @Description Every schema change made through the API is recorded. With format=sql, the history is returned as a SQL script that can be replayed on another database.
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Produce plain
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param format query string false "Response format" Enums(json, sql)
+// @Success 200 {array} database.Migration
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/migrations [get]
+func ListMigrationsHandler(w http.ResponseWriter, r *http.Request) {
+	db, release, err := openDatabase(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	migrations, err := database.ListMigrations(r.Context(), db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "sql" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(database.MigrationScript(migrations)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, migrations)
+}
+
+// ListPoliciesHandler lists the row-level security policies of a collection
+// @Summary List Policies
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 200 {object} map[string][]database.Policy
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/policies [get]
+func ListPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	policies, err := database.ListPolicies(r.Context(), db, table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": policies})
+}
+
+// CreatePolicyHandler creates a row-level security policy
+// @Summary Create Policy
+// @Description Compiles an access rule to a Postgres row-level security policy. Rules use the filter grammar of docs/filters.md, where {"$auth": "uid"}, {"$auth": "role"} or {"$auth": "<claim>"} stand for the end user's token claims. Requests with an end-user token are then filtered by the database itself. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.CreatePolicyRequest true "Policy definition"
+// @Success 201 {object} database.Policy
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/policies [post]
+func CreatePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	policy, err := database.CreatePolicy(r.Context(), db, table, database.PolicyDefinition{
+		Name:    req.Name,
+		Command: req.Command,
+		Role:    req.Role,
+		Using:   req.Using,
+		Check:   req.Check,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// DeletePolicyHandler deletes a row-level security policy
+// @Summary Delete Policy
+// @Description Row-level security stays enabled on the collection: without any policy, end users can no longer access it. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param policy path string true "Policy name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/policies/{policy} [delete]
+func DeletePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DropPolicy(r.Context(), db, table, mux.Vars(r)["policy"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSearchIndexHandler returns the managed search column of a collection
+// @Summary Get Search Column
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 200 {object} database.SearchIndex
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/search [get]
+func GetSearchIndexHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	index, err := database.GetSearchIndex(r.Context(), db, table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, index)
+}
+
+// EnableSearchHandler creates or replaces the managed search column of a collection
+// @Summary Enable Full-Text Search
+// @Description Adds a generated tsvector column (search_vector) computed from text and json columns, with a GIN index. Searches without explicit columns use it. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.SearchIndexRequest true "Search column definition"
+// @Success 200 {object} database.SearchIndex
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/search [put]
+func EnableSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchIndexRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	index, err := database.EnableSearch(r.Context(), db, table, database.SearchIndex{
+		Columns:  req.Columns,
+		Weights:  req.Weights,
+		Language: req.Language,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, index)
+}
+
+// DisableSearchHandler removes the managed search column of a collection
+// @Summary Disable Full-Text Search
+// @Description Drops the search_vector column and its index. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/search [delete]
+func DisableSearchHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DisableSearch(r.Context(), db, table); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSoftDeleteHandler returns the trash settings of a collection
+// @Summary Get Soft Delete Settings
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 200 {object} database.SoftDelete
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/soft-delete [get]
+func GetSoftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	_, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	config, err := database.GetSoftDelete(table)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, config)
+}
+
+// EnableSoftDeleteHandler enables or reconfigures the trash of a collection
+// @Summary Enable Soft Delete
+// @Description Deletes then set deleted_at instead of removing rows, reads hide trashed documents, and trashed documents older than retention_days are purged. The deleted_at column is added if missing. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Param request body models.SoftDeleteRequest true "Trash settings"
+// @Success 200 {object} database.SoftDelete
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/soft-delete [put]
+func EnableSoftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SoftDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	config, err := database.EnableSoftDelete(r.Context(), db, table, database.SoftDelete{RetentionDays: req.RetentionDays})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, config)
+}
+
+// DisableSoftDeleteHandler disables the trash of a collection
+// @Summary Disable Soft Delete
+// @Description Deletes remove rows again. The trash must be empty; the deleted_at column is kept. The change is recorded in the migration history of the database.
+// @Tags Database
+// @Security Bearer
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param collection path string true "Collection Name"
+// @Param X-Transaction-ID header string false "Run the operation inside this transaction"
+// @Success 204 "No Body content, delete successful."
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/collections/{collection}/soft-delete [delete]
+func DisableSoftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	db, table, release, err := openCollection(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer release()
+
+	if err := database.DisableSoftDelete(r.Context(), db, table); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := database.CommitSession(r.Context(), db); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// columnDefinition converts a column definition of a request for the database layer
+func columnDefinition(col models.ColumnDefinition) database.ColumnDefinition {
+	def := database.ColumnDefinition{
+		Name:            col.Name,
+		Type:            col.Type,
+		NotNull:         col.NotNull,
+		Unique:          col.Unique,
+		Identity:        col.Identity,
+		Default:         col.Default,
+		DefaultFunction: col.DefaultFunction,
+		Check:           col.Check,
+	}
+	if ref := col.References; ref != nil {
+		def.References = &database.ForeignKey{Collection: ref.Collection, Column: ref.Column, OnDelete: ref.OnDelete}
+	}
+	return def
+}
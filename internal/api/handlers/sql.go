@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ketsuna-org/sovrabase/internal/auth"
+	"github.com/ketsuna-org/sovrabase/internal/database"
+	"github.com/ketsuna-org/sovrabase/internal/models"
+)
+
+var auditLog database.AuditLog
+
+// SetAuditLog registers the audit log of the databases, kept in the control plane
+func SetAuditLog(log database.AuditLog) {
+	auditLog = log
+}
+
+// Trailers sent with CSV results, whose summary cannot be part of the body
+const (
+	sqlRowCountTrailer  = "X-SQL-Row-Count"
+	sqlTruncatedTrailer = "X-SQL-Truncated"
+	sqlErrorTrailer     = "X-SQL-Error"
+)
+
+// ExecuteSQLHandler runs a raw SQL statement on a database
+// @Summary Execute SQL
+// @Description Runs a parameterised SQL statement in its own transaction, with a statement timeout and a row limit. Only API keys carrying the sql permission are accepted. Results are streamed as JSON or CSV; an error raised while streaming is reported in the error field (JSON) or the X-SQL-Error trailer (CSV). Every statement is written to the audit log of the database. See docs/sql.md.
+// @Tags Database
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param request body models.SQLRequest true "Statement and parameters"
+// @Success 200 {object} database.SQLOutcome
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/sql [post]
+func ExecuteSQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SQLRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if databaseManager == nil || auditLog == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+
+	key, err := requestAPIKey(r, auth.PermissionSQL)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkDatabase(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	format, err := sqlFormat(r, req.Format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The statement may leave session state behind: it never runs on a pooled connection
+	conn, err := databaseManager.Connect(r.Context(), mux.Vars(r)["db_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer conn.Close(context.WithoutCancel(r.Context()))
+
+	raw := database.RawSQL{
+		Statement: req.Query,
+		Params:    req.Params,
+		ReadOnly:  req.ReadOnly,
+		Timeout:   time.Duration(req.TimeoutMs) * time.Millisecond,
+		MaxRows:   req.MaxRows,
+	}
+	var result sqlResultWriter = &sqlJSONWriter{w: w}
+	if format == "csv" {
+		result = &sqlCSVWriter{w: w}
+	}
+
+	started := time.Now()
+	outcome, err := database.ExecSQL(r.Context(), conn, raw, result)
+
+	entry := &database.AuditEntry{
+		Actor:      key.Subject(),
+		Action:     database.AuditActionSQL,
+		Statement:  req.Query,
+		ReadOnly:   req.ReadOnly,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if len(req.Params) > 0 {
+		entry.Params, _ = json.Marshal(req.Params)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Rows = outcome.Rows
+	}
+	// The statement is audited even when the client has gone away
+	vars := mux.Vars(r)
+	if auditErr := auditLog.RecordAudit(context.WithoutCancel(r.Context()), vars["id"], vars["db_id"], entry); auditErr != nil {
+		log.Printf("❌ SQL audit failed on database %s: %v", vars["db_id"], auditErr)
+	}
+
+	if err != nil {
+		if !result.started() {
+			writeError(w, err)
+			return
+		}
+		_, message := errorStatus(err)
+		result.fail(message)
+		return
+	}
+	result.finish(outcome)
+}
+
+// ListAuditHandler lists the audit log of a database
+// @Summary List Audit Log
+// @Description Returns the audited operations of the database, such as raw SQL statements, most recent first. Pass the id of the last entry as before to get the next page. The log is kept in the control plane and only API keys can read it.
+// @Tags Database
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param db_id path string true "Database ID"
+// @Param limit query int false "Maximum number of entries (default 100, max 1000)"
+// @Param before query int false "Only return entries older than this id"
+// @Success 200 {object} map[string][]database.AuditEntry
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /project/{id}/data/{db_id}/audit [get]
+func ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	var before int64
+	var err error
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid limit: " + value})
+			return
+		}
+	}
+	if value := r.URL.Query().Get("before"); value != "" {
+		if before, err = strconv.ParseInt(value, 10, 64); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid before: " + value})
+			return
+		}
+	}
+
+	if databaseManager == nil || auditLog == nil {
+		writeError(w, errDataAPIUnavailable)
+		return
+	}
+	if _, err := requestServiceKey(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkDatabase(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	entries, err := auditLog.ListAudit(r.Context(), vars["id"], vars["db_id"], limit, before)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": entries})
+}
+
+// requestServiceKey returns the API key of the request, refusing end-user tokens
+func requestServiceKey(r *http.Request) (auth.Claims, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !auth.IsToken(token) {
+		return nil, fmt.Errorf("%w: an API key is required", auth.ErrInvalidToken)
+	}
+
+	claims, err := auth.Verify(token, jwtSecret, mux.Vars(r)["id"], time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsAPIKey() {
+		return nil, fmt.Errorf("%w: an API key is required", database.ErrForbidden)
+	}
+	return claims, nil
+}
+
+// requestAPIKey returns the API key of the request, which must carry the permission
+func requestAPIKey(r *http.Request, permission string) (auth.Claims, error) {
+	claims, err := requestServiceKey(r)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.HasPermission(permission) {
+		return nil, fmt.Errorf("%w: an API key with the %q permission is required", database.ErrForbidden, permission)
+	}
+	return claims, nil
+}
+
+// sqlFormat returns the result format of a SQL request: the format field, else the Accept header
+func sqlFormat(r *http.Request, format string) (string, error) {
+	if format == "" {
+		for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+			if mediaType, _, err := mime.ParseMediaType(accepted); err == nil && mediaType == "text/csv" {
+				return "csv", nil
+			}
+		}
+		return "json", nil
+	}
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("%w: unsupported format %q (json or csv)", database.ErrInvalid, format)
+	}
+	return format, nil
+}
+
+// sqlResultWriter streams the result of a statement in a response format
+type sqlResultWriter interface {
+	database.SQLResultWriter
+	started() bool               // Whether the response has been sent, errors then go in the body
+	fail(message string)         // Ends the response with an error raised while streaming
+	finish(*database.SQLOutcome) // Ends the response after the last row
+}
+
+// sqlJSONWriter streams a result as {"columns": [...], "rows": [[...]], "command": ..., "row_count": ..., "truncated": ...}
+type sqlJSONWriter struct {
+	w       http.ResponseWriter
+	columns []database.SQLColumn
+	rows    int
+	line    []byte
+}
+
+func (s *sqlJSONWriter) Columns(columns []database.SQLColumn) error {
+	s.columns = columns
+	header, err := json.Marshal(columns)
+	if err != nil {
+		return err
+	}
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusOK)
+	_, err = fmt.Fprintf(s.w, `{"columns":%s,"rows":[`, header)
+	return err
+}
+
+func (s *sqlJSONWriter) Row(values [][]byte) error {
+	s.line = s.line[:0]
+	if s.rows > 0 {
+		s.line = append(s.line, ',')
+	}
+	s.line = append(s.line, '[')
+	for i, value := range values {
+		if i > 0 {
+			s.line = append(s.line, ',')
+		}
+		s.line = append(s.line, s.columns[i].JSONValue(value)...)
+	}
+	s.line = append(s.line, ']')
+	s.rows++
+	_, err := s.w.Write(s.line)
+	return err
+}
+
+func (s *sqlJSONWriter) started() bool {
+	return s.columns != nil
+}
+
+func (s *sqlJSONWriter) fail(message string) {
+	encoded, _ := json.Marshal(message)
+	fmt.Fprintf(s.w, `],"error":%s}`+"\n", encoded)
+}
+
+func (s *sqlJSONWriter) finish(outcome *database.SQLOutcome) {
+	if !s.started() {
+		s.Columns([]database.SQLColumn{})
+	}
+	command, _ := json.Marshal(outcome.Command)
+	fmt.Fprintf(s.w, `],"command":%s,"row_count":%d,"truncated":%t}`+"\n", command, outcome.Rows, outcome.Truncated)
+}
+
+// sqlCSVWriter streams a result as CSV with a header line. Values keep the Postgres
+// text format and NULL is an empty field; the summary is sent in trailers.
+type sqlCSVWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	columns int
+	record  []string
+}
+
+func (s *sqlCSVWriter) Columns(columns []database.SQLColumn) error {
+	header := s.w.Header()
+	header.Set("Content-Type", "text/csv; charset=utf-8")
+	header.Set("Trailer", strings.Join([]string{sqlRowCountTrailer, sqlTruncatedTrailer, sqlErrorTrailer}, ", "))
+	s.w.WriteHeader(http.StatusOK)
+
+	s.csv = csv.NewWriter(s.w)
+	s.columns = len(columns)
+	s.record = make([]string, len(columns))
+	if len(columns) == 0 {
+		return nil
+	}
+	for i, column := range columns {
+		s.record[i] = column.Name
+	}
+	return s.csv.Write(s.record)
+}
+
+func (s *sqlCSVWriter) Row(values [][]byte) error {
+	for i, value := range values {
+		s.record[i] = string(value)
+	}
+	return s.csv.Write(s.record)
+}
+
+func (s *sqlCSVWriter) started() bool {
+	return s.csv != nil
+}
+
+func (s *sqlCSVWriter) fail(message string) {
+	s.csv.Flush()
+	s.w.Header().Set(sqlErrorTrailer, message)
+}
+
+func (s *sqlCSVWriter) finish(outcome *database.SQLOutcome) {
+	if !s.started() {
+		s.Columns(nil)
+	}
+	s.csv.Flush()
+	s.w.Header().Set(sqlRowCountTrailer, strconv.FormatInt(outcome.Rows, 10))
+	s.w.Header().Set(sqlTruncatedTrailer, strconv.FormatBool(outcome.Truncated))
+}
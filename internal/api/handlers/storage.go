@@ -1,105 +1,615 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/ketsuna-org/sovrabase/internal/models"
+	"github.com/ketsuna-org/sovrabase/internal/storage"
 )
 
+// storageService stores the buckets and files of the projects
+var storageService *storage.Service
+
+// errStorageUnavailable is returned when no storage service has been registered
+var errStorageUnavailable = errors.New("storage is not available")
+
+// SetStorage registers the storage service used by the storage handlers
+func SetStorage(service *storage.Service) {
+	storageService = service
+}
+
+// openStorage returns the storage service for a request with service access: files having
+// no access policies, only a verified API key is accepted
+func openStorage(r *http.Request) (*storage.Service, error) {
+	if storageService == nil {
+		return nil, errStorageUnavailable
+	}
+	if _, err := requestServiceKey(r); err != nil {
+		return nil, err
+	}
+	return storageService, nil
+}
+
 // GetStorageBucketsHandler gets all storage buckets
 // @Summary Get Storage Buckets
 // @Tags Storage
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
-// @Success 200
+// @Success 200 {object} map[string][]storage.Bucket
 // @Router /project/{id}/storage/buckets [get]
 func GetStorageBucketsHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get buckets logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	buckets, err := service.ListBuckets(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": buckets})
 }
 
 // CreateStorageBucketHandler creates a new storage bucket
 // @Summary Create Storage Bucket
+// @Description The bucket name identifies it in the storage routes: 2 to 63 lowercase letters, digits, dots, dashes or underscores. max_file_size limits the size of each file, in bytes.
 // @Tags Storage
 // @Security Bearer
 // @Accept json
 // @Produce json
 // @Param id path string true "Project ID"
 // @Param request body models.CreateStorageBucketRequest true "Bucket creation data"
-// @Success 200
+// @Success 201 {object} storage.Bucket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets [post]
 func CreateStorageBucketHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateStorageBucketRequest
-	_ = req
-	// TODO: Implement create bucket logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	settings := storage.BucketSettings{Public: req.Public, MaxFileSize: req.MaxFileSize, CacheControl: req.CacheControl}
+	bucket, err := service.CreateBucket(r.Context(), mux.Vars(r)["id"], req.Name, settings)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, bucket)
 }
 
 // GetStorageBucketHandler gets a specific storage bucket
 // @Summary Get Storage Bucket
 // @Tags Storage
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
-// @Success 200
+// @Success 200 {object} storage.Bucket
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id} [get]
 func GetStorageBucketHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get bucket logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, err := service.Bucket(r.Context(), vars["id"], vars["bucket_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bucket)
+}
+
+// UpdateStorageBucketHandler updates the settings of a storage bucket
+// @Summary Update Storage Bucket
+// @Description Omitted settings are unchanged. A lower max_file_size only applies to the next uploads.
+// @Tags Storage
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param request body models.UpdateStorageBucketRequest true "Bucket settings"
+// @Success 200 {object} storage.Bucket
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id} [patch]
+func UpdateStorageBucketHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateStorageBucketRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, err := service.Bucket(r.Context(), vars["id"], vars["bucket_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	settings := bucket.BucketSettings
+	if req.Public != nil {
+		settings.Public = *req.Public
+	}
+	if req.MaxFileSize != nil {
+		settings.MaxFileSize = *req.MaxFileSize
+	}
+	if req.CacheControl != nil {
+		settings.CacheControl = *req.CacheControl
+	}
+
+	bucket, err = service.UpdateBucket(r.Context(), vars["id"], vars["bucket_id"], settings)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bucket)
 }
 
 // DeleteStorageBucketHandler deletes a storage bucket
 // @Summary Delete Storage Bucket
+// @Description Only buckets without files nor resumable uploads can be deleted.
 // @Tags Storage
 // @Security Bearer
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
-// @Success 200
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id} [delete]
 func DeleteStorageBucketHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement delete bucket logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := service.DeleteBucket(r.Context(), vars["id"], vars["bucket_id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // GetBucketFilesHandler gets files in a bucket
 // @Summary Get Bucket Files
 // @Tags Storage
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
-// @Success 200
+// @Param prefix query string false "Only list files whose name starts with this prefix"
+// @Param limit query int false "Maximum number of files (default 100, max 1000)"
+// @Param offset query int false "Number of files to skip"
+// @Success 200 {object} map[string][]storage.Object
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files [get]
 func GetBucketFilesHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get files logic
-	w.WriteHeader(http.StatusOK)
+	values := r.URL.Query()
+	options := storage.ListOptions{Prefix: values.Get("prefix")}
+	var err error
+	if value := values.Get("limit"); value != "" {
+		if options.Limit, err = strconv.Atoi(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid limit: " + value})
+			return
+		}
+	}
+	if value := values.Get("offset"); value != "" {
+		if options.Offset, err = strconv.Atoi(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid offset: " + value})
+			return
+		}
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	objects, err := service.ListObjects(r.Context(), vars["id"], vars["bucket_id"], options)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": objects})
 }
 
 // UploadFileHandler uploads a file to a bucket
 // @Summary Upload File
+// @Description Streams the file part of a multipart form to the storage backend. The name and metadata fields must precede the file part; fields after it are ignored. The file is named after the name field, else after its file name; a name may contain folders (users/42.png). An existing file with the same name is replaced only with upsert. Large files over unreliable connections should use resumable uploads.
 // @Tags Storage
 // @Security Bearer
 // @Accept multipart/form-data
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
-// @Success 200
+// @Param name formData string false "File name in the bucket"
+// @Param metadata formData string false "Metadata, as a JSON object"
+// @Param file formData file true "File content"
+// @Param upsert query bool false "Replace an existing file with the same name"
+// @Success 201 {object} storage.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files [post]
 func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement file upload logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	upload := storage.Upload{Size: -1}
+	if value := r.URL.Query().Get("upsert"); value != "" {
+		if upload.Upsert, err = strconv.ParseBool(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid upsert: " + value})
+			return
+		}
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid multipart form: " + err.Error()})
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Missing file part"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid multipart form: " + err.Error()})
+			return
+		}
+
+		switch part.FormName() {
+		case "name":
+			value, err := readFormField(part)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid name: " + err.Error()})
+				return
+			}
+			upload.Name = value
+		case "metadata":
+			value, err := readFormField(part)
+			if err == nil {
+				err = json.Unmarshal([]byte(value), &upload.Metadata)
+			}
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid metadata: " + err.Error()})
+				return
+			}
+		case "file":
+			// The content is read straight from the request, without a memory or disk copy
+			if upload.Name == "" {
+				upload.Name = part.FileName()
+			}
+			upload.ContentType = detectContentType(part.Header.Get("Content-Type"), upload.Name)
+			upload.Body = part
+
+			vars := mux.Vars(r)
+			object, err := service.Upload(r.Context(), vars["id"], vars["bucket_id"], upload)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, object)
+			return
+		}
+	}
+}
+
+// maxFormField is the maximum size of a multipart form field other than the file
+const maxFormField = 64 << 10
+
+// readFormField reads a multipart form field, up to maxFormField bytes
+func readFormField(part io.Reader) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, maxFormField+1))
+	if err != nil {
+		return "", err
+	}
+	if len(value) > maxFormField {
+		return "", fmt.Errorf("field larger than %d bytes", maxFormField)
+	}
+	return string(value), nil
+}
+
+// detectContentType returns the declared content type of a file, else the one of its
+// name extension
+func detectContentType(declared, name string) string {
+	if declared == "" || declared == "application/octet-stream" {
+		if byExtension := mime.TypeByExtension(path.Ext(name)); byExtension != "" {
+			return byExtension
+		}
+	}
+	return declared
 }
 
 // GetFileHandler gets a specific file
 // @Summary Get File
+// @Description Downloads the content of the file, with its content type. Range requests get 206 Partial Content, and conditional requests (If-None-Match on the ETag, If-Modified-Since) 304 Not Modified. Responses are private to the client, cached as set by the bucket cache_control. JPEG, PNG and GIF images can be transformed with the width, height, resize, crop, format and quality parameters; each variant is computed once, then kept until the file is replaced.
 // @Tags Storage
 // @Security Bearer
+// @Produce octet-stream
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
 // @Param file_id path string true "File ID"
-// @Success 200
+// @Param width query int false "Image width in pixels, at most 2500"
+// @Param height query int false "Image height in pixels, at most 2500"
+// @Param resize query string false "With both width and height: cover (default, cropped), contain or fill" Enums(cover, contain, fill)
+// @Param crop query string false "Area of the original image kept before resizing: x,y,width,height in pixels"
+// @Param format query string false "Image format, the original one by default (png for gif)" Enums(jpeg, png, webp)
+// @Param quality query int false "JPEG quality from 1 to 100 (default 80), rejected for png and webp, which are lossless"
+// @Param Range header string false "Byte ranges, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 416
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/{file_id} [get]
 func GetFileHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get file logic
-	w.WriteHeader(http.StatusOK)
+	transform, err := parseTransform(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, err := service.Bucket(r.Context(), vars["id"], vars["bucket_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var object *storage.Object
+	var content io.ReadSeekCloser
+	if transform != nil {
+		object, err = service.Object(r.Context(), vars["id"], vars["bucket_id"], vars["file_id"])
+		if err == nil {
+			object, content, err = service.OpenVariant(r.Context(), object, *transform)
+		}
+	} else {
+		object, content, err = service.Open(r.Context(), vars["id"], vars["bucket_id"], vars["file_id"])
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	serveFile(w, r, object, content, fileCacheControl(bucket, false), "")
+}
+
+// parseTransform reads the image transformation of the query, nil when there is none
+func parseTransform(r *http.Request) (*storage.Transform, error) {
+	query := r.URL.Query()
+	var transform storage.Transform
+	for name, value := range map[string]*int{"width": &transform.Width, "height": &transform.Height, "quality": &transform.Quality} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("invalid %s: %s", name, raw)
+			}
+			*value = parsed
+		}
+	}
+	transform.Resize = query.Get("resize")
+	transform.Format = query.Get("format")
+	if raw := query.Get("crop"); raw != "" {
+		var crop storage.Crop
+		fields := strings.Split(raw, ",")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid crop: %s", raw)
+		}
+		for i, value := range []*int{&crop.X, &crop.Y, &crop.Width, &crop.Height} {
+			parsed, err := strconv.Atoi(strings.TrimSpace(fields[i]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid crop: %s", raw)
+			}
+			*value = parsed
+		}
+		transform.Crop = &crop
+	}
+	if transform == (storage.Transform{}) {
+		return nil, nil
+	}
+	return &transform, nil
+}
+
+// PublicFileHandler gets a file of a public bucket
+// @Summary Get Public File
+// @Description Unauthenticated, for the files of public buckets only. Files are addressed by name and responses are public, so that reverse proxies and CDNs can cache them as set by the bucket cache_control (default max-age=3600). Range and conditional requests, and image transformations, are supported as for Get File. Files whose type could run script, such as text/html or image/svg+xml, are served as attachments.
+// @Tags Storage
+// @Produce octet-stream
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param name path string true "File name, with its folders"
+// @Param width query int false "Image width in pixels, at most 2500"
+// @Param height query int false "Image height in pixels, at most 2500"
+// @Param resize query string false "With both width and height: cover (default, cropped), contain or fill" Enums(cover, contain, fill)
+// @Param crop query string false "Area of the original image kept before resizing: x,y,width,height in pixels"
+// @Param format query string false "Image format, the original one by default (png for gif)" Enums(jpeg, png, webp)
+// @Param quality query int false "JPEG quality from 1 to 100 (default 80), rejected for png and webp, which are lossless"
+// @Param Range header string false "Byte ranges, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 416
+// @Failure 422 {object} models.ErrorResponse
+// @Router /storage/public/{id}/{bucket_id}/{name} [get]
+func PublicFileHandler(w http.ResponseWriter, r *http.Request) {
+	transform, err := parseTransform(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if storageService == nil {
+		writeError(w, errStorageUnavailable)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket, err := storageService.Bucket(r.Context(), vars["id"], vars["bucket_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// A private bucket is reported as missing, so that its existence is not disclosed
+	if !bucket.Public {
+		writeError(w, fmt.Errorf("%w: bucket %q", storage.ErrNotFound, bucket.Name))
+		return
+	}
+	var object *storage.Object
+	var content io.ReadSeekCloser
+	if transform != nil {
+		object, err = storageService.ObjectByName(r.Context(), bucket.ProjectID, bucket.Name, vars["name"])
+		if err == nil {
+			object, content, err = storageService.OpenVariant(r.Context(), object, *transform)
+		}
+	} else {
+		object, content, err = storageService.OpenByName(r.Context(), bucket.ProjectID, bucket.Name, vars["name"])
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	serveFile(w, r, object, content, fileCacheControl(bucket, true), "")
+}
+
+// Cache-Control directives of the served files when their bucket sets none: public files
+// are cached for an hour, private ones revalidated with their ETag
+const (
+	defaultPublicCacheControl  = "max-age=3600"
+	defaultPrivateCacheControl = "no-cache"
+)
+
+// fileCacheControl returns the Cache-Control header of the files of the bucket. Only the
+// public route lets shared caches store them: other responses depend on a token or a
+// signature, and are private.
+func fileCacheControl(bucket *storage.Bucket, public bool) string {
+	directives := bucket.CacheControl
+	if public {
+		if directives == "" {
+			directives = defaultPublicCacheControl
+		}
+		return "public, " + directives
+	}
+	if directives == "" {
+		directives = defaultPrivateCacheControl
+	}
+	return "private, " + directives
+}
+
+// serveFile writes the content of a file, with the Content-Disposition header when given.
+// Files whose type could run script on the API origin are always sent as attachments.
+// Range and conditional requests are answered from the ETag and the modification date.
+func serveFile(w http.ResponseWriter, r *http.Request, object *storage.Object, content io.ReadSeeker, cacheControl, disposition string) {
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("ETag", `"`+object.ETag+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if disposition = fileDisposition(object.ContentType, disposition); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	http.ServeContent(w, r, "", object.UpdatedAt, content)
+}
+
+// inlineContentTypes are the file types served inline: the browser renders them without
+// running script. Any other type, such as text/html or image/svg+xml, is downloaded.
+var inlineContentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/avif":      true,
+	"audio/mpeg":      true,
+	"audio/ogg":       true,
+	"audio/wav":       true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"text/plain":      true,
+	"text/csv":        true,
+	"application/pdf": true,
+}
+
+// fileDisposition returns the Content-Disposition of a file of the content type, forcing an
+// attachment, with the requested file name if any, for the types that are not served inline
+func fileDisposition(contentType, disposition string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && inlineContentTypes[mediaType] {
+		return disposition
+	}
+	kind, params, err := mime.ParseMediaType(disposition)
+	if err != nil {
+		return "attachment"
+	}
+	if kind == "attachment" {
+		return disposition
+	}
+	if attachment := mime.FormatMediaType("attachment", params); attachment != "" {
+		return attachment
+	}
+	return "attachment"
 }
 
 // DeleteFileHandler deletes a file
@@ -109,43 +619,346 @@ func GetFileHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
 // @Param file_id path string true "File ID"
-// @Success 200
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/{file_id} [delete]
 func DeleteFileHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement delete file logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	deleted, err := service.Delete(r.Context(), vars["id"], vars["bucket_id"], []string{vars["file_id"]})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if deleted == 0 {
+		writeError(w, fmt.Errorf("%w: file %q", storage.ErrNotFound, vars["file_id"]))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // GetFileInfoHandler gets file information
 // @Summary Get File Info
 // @Tags Storage
 // @Security Bearer
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
 // @Param file_id path string true "File ID"
-// @Success 200
+// @Success 200 {object} storage.Object
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/{file_id}/info [get]
 func GetFileInfoHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement get file info logic
-	w.WriteHeader(http.StatusOK)
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	object, err := service.Object(r.Context(), vars["id"], vars["bucket_id"], vars["file_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, object)
 }
 
-// CreatePublicURLHandler creates a public URL for a file
+// CreatePublicURLHandler creates a signed URL to download a file
 // @Summary Create Public URL
+// @Description Returns an HMAC-signed URL giving access to the file without an API key until it expires, on the unauthenticated /storage/signed route. download serves the file as an attachment, named after filename. Anyone holding the URL can download the file: share it like a secret.
 // @Tags Storage
 // @Security Bearer
+// @Accept json
+// @Produce json
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
 // @Param file_id path string true "File ID"
-// @Success 200
+// @Param request body models.CreateSignedURLRequest false "Expiry and content disposition"
+// @Success 200 {object} models.SignedURLResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/{file_id}/public-url [post]
 func CreatePublicURLHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement create public URL logic
-	w.WriteHeader(http.StatusOK)
+	var req models.CreateSignedURLRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	signer, err := urlSigner()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	object, err := service.Object(r.Context(), vars["id"], vars["bucket_id"], vars["file_id"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	expires, err := signedURLExpiry(req.ExpiresIn)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	signed := storage.SignedURL{
+		Method:    storage.SignedDownload,
+		ProjectID: object.ProjectID,
+		Bucket:    object.Bucket,
+		Object:    object.ID,
+		Expires:   expires,
+	}
+	if req.Download || req.Filename != "" {
+		filename := req.Filename
+		if filename == "" {
+			filename = path.Base(object.Name)
+		}
+		kind := "inline"
+		if req.Download {
+			kind = "attachment"
+		}
+		signed.Disposition = mime.FormatMediaType(kind, map[string]string{"filename": filename})
+		if signed.Disposition == "" {
+			writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "invalid filename: " + filename})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, signedURLResponse(signer, signed))
+}
+
+// CreateSignedUploadURLHandler creates a signed URL to upload a file
+// @Summary Create Signed Upload URL
+// @Description Returns an HMAC-signed URL letting a client without an API key, such as a browser, upload the named file with PUT until it expires. The bucket size limit applies; an existing file is replaced only with upsert.
+// @Tags Storage
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param request body models.CreateSignedUploadURLRequest true "File name and expiry"
+// @Success 200 {object} models.SignedURLResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /project/{id}/storage/buckets/{bucket_id}/upload-url [post]
+func CreateSignedUploadURLHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSignedUploadURLRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	signer, err := urlSigner()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if _, err := service.Bucket(r.Context(), vars["id"], vars["bucket_id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := storage.CheckObjectName(req.Name); err != nil {
+		writeError(w, err)
+		return
+	}
+	expires, err := signedURLExpiry(req.ExpiresIn)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, signedURLResponse(signer, storage.SignedURL{
+		Method:    storage.SignedUpload,
+		ProjectID: vars["id"],
+		Bucket:    vars["bucket_id"],
+		Object:    req.Name,
+		Expires:   expires,
+		Upsert:    req.Upsert,
+	}))
+}
+
+// urlSigner returns the signer of the storage URLs, keyed by the server secret
+func urlSigner() (*storage.URLSigner, error) {
+	signer, err := storage.NewURLSigner(jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signed URLs require data_api.jwt_secret", errStorageUnavailable)
+	}
+	return signer, nil
+}
+
+// signedURLExpiry returns the expiry of a signed URL valid for expiresIn seconds
+func signedURLExpiry(expiresIn int) (time.Time, error) {
+	validity := storage.DefaultSignedURLExpiry
+	if expiresIn != 0 {
+		validity = time.Duration(expiresIn) * time.Second
+	}
+	if err := storage.CheckSignedURLExpiry(validity); err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(validity).Truncate(time.Second), nil
+}
+
+// signedURLResponse signs the access and returns its URL
+func signedURLResponse(signer *storage.URLSigner, signed storage.SignedURL) models.SignedURLResponse {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(signed.Expires.Unix(), 10))
+	target := "/storage/signed/" + url.PathEscape(signed.ProjectID) + "/" + url.PathEscape(signed.Bucket)
+	switch signed.Method {
+	case storage.SignedDownload:
+		target += "/" + url.PathEscape(signed.Object)
+		if signed.Disposition != "" {
+			query.Set("disposition", signed.Disposition)
+		}
+	case storage.SignedUpload:
+		query.Set("name", signed.Object)
+		if signed.Upsert {
+			query.Set("upsert", "true")
+		}
+	}
+	query.Set("signature", signer.Sign(signed))
+	return models.SignedURLResponse{URL: target + "?" + query.Encode(), Method: signed.Method, ExpiresAt: signed.Expires.UTC()}
+}
+
+// verifySignedURL checks the signature of a signed URL request for the method and returns
+// the storage service
+func verifySignedURL(r *http.Request, signed *storage.SignedURL) (*storage.Service, error) {
+	if storageService == nil {
+		return nil, errStorageUnavailable
+	}
+	signer, err := urlSigner()
+	if err != nil {
+		return nil, err
+	}
+
+	values := r.URL.Query()
+	vars := mux.Vars(r)
+	signed.ProjectID = vars["id"]
+	signed.Bucket = vars["bucket_id"]
+	expires, err := strconv.ParseInt(values.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid expires", storage.ErrInvalidSignature)
+	}
+	signed.Expires = time.Unix(expires, 0)
+	signed.Disposition = values.Get("disposition")
+	if signed.Method == storage.SignedUpload {
+		signed.Object = values.Get("name")
+		signed.Upsert = values.Get("upsert") == "true"
+	}
+	if err := signer.Verify(*signed, values.Get("signature"), time.Now()); err != nil {
+		return nil, err
+	}
+	return storageService, nil
+}
+
+// SignedDownloadHandler downloads a file with a signed URL
+// @Summary Download File With Signed URL
+// @Description Unauthenticated: the signature grants access to the file until it expires. The URL is created with the public-url route; changing any of its parameters invalidates it. Range and conditional requests are supported as for Get File.
+// @Tags Storage
+// @Produce octet-stream
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param file_id path string true "File ID"
+// @Param expires query int true "Expiry, as a Unix timestamp"
+// @Param disposition query string false "Content-Disposition of the response"
+// @Param signature query string true "HMAC-SHA256 signature"
+// @Success 200 {file} file
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /storage/signed/{id}/{bucket_id}/{file_id} [get]
+func SignedDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	signed := storage.SignedURL{Method: storage.SignedDownload, Object: mux.Vars(r)["file_id"]}
+	service, err := verifySignedURL(r, &signed)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	bucket, err := service.Bucket(r.Context(), signed.ProjectID, signed.Bucket)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	object, content, err := service.Open(r.Context(), signed.ProjectID, signed.Bucket, signed.Object)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	serveFile(w, r, object, content, fileCacheControl(bucket, false), signed.Disposition)
+}
+
+// SignedUploadHandler uploads a file with a signed URL
+// @Summary Upload File With Signed URL
+// @Description Unauthenticated: the signature grants the upload of the named file until it expires. The body is the raw file content, typed by Content-Type. The URL is created with the upload-url route; changing any of its parameters invalidates it.
+// @Tags Storage
+// @Accept octet-stream
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param bucket_id path string true "Bucket ID"
+// @Param name query string true "File name in the bucket"
+// @Param upsert query bool false "Replace an existing file with the same name"
+// @Param expires query int true "Expiry, as a Unix timestamp"
+// @Param signature query string true "HMAC-SHA256 signature"
+// @Success 201 {object} storage.Object
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Router /storage/signed/{id}/{bucket_id} [put]
+func SignedUploadHandler(w http.ResponseWriter, r *http.Request) {
+	signed := storage.SignedURL{Method: storage.SignedUpload}
+	service, err := verifySignedURL(r, &signed)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	object, err := service.Upload(r.Context(), signed.ProjectID, signed.Bucket, storage.Upload{
+		Name:        signed.Object,
+		ContentType: detectContentType(r.Header.Get("Content-Type"), signed.Object),
+		Size:        r.ContentLength,
+		Upsert:      signed.Upsert,
+		Body:        r.Body,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, object)
 }
 
 // BatchDeleteFilesHandler deletes files in a batch operation
 // @Summary Delete files in a batch operations !
+// @Description Missing files are ignored; the response counts the deleted files.
 // @Tags Storage
 // @Security Bearer
 // @Accept json
@@ -153,17 +966,35 @@ func CreatePublicURLHandler(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Project ID"
 // @Param bucket_id path string true "Bucket ID"
 // @Param request body models.BatchDeleteFilesRequest true "File IDs to delete"
-// @Success 200
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/delete-batch [post]
 func BatchDeleteFilesHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.BatchDeleteFilesRequest
-	_ = req
-	// TODO: Implement batch delete files logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	deleted, err := service.Delete(r.Context(), vars["id"], vars["bucket_id"], req.FileIDs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
 }
 
 // UpdateFileMetadataHandler updates file metadata
 // @Summary Update metadata informations only !
+// @Description Replaces the metadata of the file; its content is unchanged.
 // @Tags Storage
 // @Security Bearer
 // @Accept json
@@ -172,11 +1003,29 @@ func BatchDeleteFilesHandler(w http.ResponseWriter, r *http.Request) {
 // @Param bucket_id path string true "Bucket ID"
 // @Param file_id path string true "File ID"
 // @Param request body models.UpdateFileMetadataRequest true "Metadata update data"
-// @Success 200
+// @Success 200 {object} storage.Object
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /project/{id}/storage/buckets/{bucket_id}/files/{file_id} [patch]
 func UpdateFileMetadataHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.UpdateFileMetadataRequest
-	_ = req
-	// TODO: Implement update file metadata logic
-	w.WriteHeader(http.StatusOK)
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	service, err := openStorage(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	object, err := service.UpdateMetadata(r.Context(), vars["id"], vars["bucket_id"], vars["file_id"], req.Metadata)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, object)
 }
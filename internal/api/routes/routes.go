@@ -69,26 +69,55 @@ func SetupRoutes(router *mux.Router) {
 	router.HandleFunc("/project/{id}/databases/{db_id}/backup", handlers.GetDatabaseBackupsHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/databases/{db_id}/backup", handlers.CreateDatabaseBackupHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/databases/{db_id}/restore", handlers.RestoreDatabaseHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/databases/{db_id}/branches", handlers.CreateDatabaseBranchHandler).Methods("POST")
 
 	// Collections
 	router.HandleFunc("/project/{id}/data/{db_id}/collections", handlers.ListCollectionsHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections", handlers.CreateCollectionHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}", handlers.GetCollectionHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/documents", handlers.ListDocumentsHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}", handlers.UpdateCollectionHandler).Methods("PATCH")
 	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}", handlers.DeleteCollectionHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/columns", handlers.AddColumnHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/columns/{column}", handlers.AlterColumnHandler).Methods("PATCH")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/columns/{column}", handlers.DropColumnHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/indexes", handlers.ListIndexesHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/indexes", handlers.CreateIndexHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/indexes/{index_id}", handlers.DeleteIndexHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/policies", handlers.ListPoliciesHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/policies", handlers.CreatePolicyHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/policies/{policy}", handlers.DeletePolicyHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/search", handlers.GetSearchIndexHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/search", handlers.EnableSearchHandler).Methods("PUT")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/search", handlers.DisableSearchHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/soft-delete", handlers.GetSoftDeleteHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/soft-delete", handlers.EnableSoftDeleteHandler).Methods("PUT")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/soft-delete", handlers.DisableSoftDeleteHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/export", handlers.ExportCollectionHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/collections/{collection}/export", handlers.QueryExportHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/migrations", handlers.ListMigrationsHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/audit", handlers.ListAuditHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/masking", handlers.GetMaskingProfileHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/data/{db_id}/masking", handlers.SetMaskingProfileHandler).Methods("PUT")
+	router.HandleFunc("/project/{id}/data/{db_id}/masking", handlers.DeleteMaskingProfileHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/sql", handlers.ExecuteSQLHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/openapi.json", handlers.GetDatabaseOpenAPIHandler).Methods("GET")
 
 	// Documents/Data Operations
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/query", handlers.QueryCollectionHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/aggregate", handlers.AggregateCollectionHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/search", handlers.SearchCollectionHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/import", handlers.ImportCollectionHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/insert", handlers.InsertDataHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/upsert", handlers.UpsertDataHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/delete", handlers.BatchDeleteHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/restore", handlers.RestoreDocumentsHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/purge", handlers.PurgeDocumentsHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/{doc_id}", handlers.GetDocumentHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/{doc_id}", handlers.UpdateDocumentHandler).Methods("PATCH")
 	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/{doc_id}", handlers.DeleteDocumentHandler).Methods("DELETE")
 
-	// Indexes
-	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/indexes", handlers.ListIndexesHandler).Methods("GET")
-	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/indexes", handlers.CreateIndexHandler).Methods("POST")
-	router.HandleFunc("/project/{id}/data/{db_id}/{collection}/indexes/{index_id}", handlers.DeleteIndexHandler).Methods("DELETE")
+	router.HandleFunc("/project/{id}/data/{db_id}/batch", handlers.BatchHandler).Methods("POST")
 
 	// Transactions
 	router.HandleFunc("/project/{id}/data/{db_id}/transactions/begin", handlers.BeginTransactionHandler).Methods("POST")
@@ -99,15 +128,31 @@ func SetupRoutes(router *mux.Router) {
 	router.HandleFunc("/project/{id}/storage/buckets", handlers.GetStorageBucketsHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/storage/buckets", handlers.CreateStorageBucketHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}", handlers.GetStorageBucketHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}", handlers.UpdateStorageBucketHandler).Methods("PATCH")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}", handlers.DeleteStorageBucketHandler).Methods("DELETE")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files", handlers.GetBucketFilesHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files", handlers.UploadFileHandler).Methods("POST")
-	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}", handlers.GetFileHandler).Methods("GET")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}", handlers.GetFileHandler).Methods("GET", "HEAD")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}", handlers.UpdateFileMetadataHandler).Methods("PATCH")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}", handlers.DeleteFileHandler).Methods("DELETE")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/info", handlers.GetFileInfoHandler).Methods("GET")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/{file_id}/public-url", handlers.CreatePublicURLHandler).Methods("POST")
 	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/files/delete-batch", handlers.BatchDeleteFilesHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/upload-url", handlers.CreateSignedUploadURLHandler).Methods("POST")
+
+	// Resumable uploads (tus protocol)
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/uploads", handlers.ResumableUploadOptionsHandler).Methods("OPTIONS")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/uploads", handlers.CreateResumableUploadHandler).Methods("POST")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id}", handlers.GetResumableUploadHandler).Methods("HEAD")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id}", handlers.PatchResumableUploadHandler).Methods("PATCH")
+	router.HandleFunc("/project/{id}/storage/buckets/{bucket_id}/uploads/{upload_id}", handlers.DeleteResumableUploadHandler).Methods("DELETE")
+
+	// Signed URLs: unauthenticated, the signature grants the access
+	router.HandleFunc("/storage/signed/{id}/{bucket_id}/{file_id}", handlers.SignedDownloadHandler).Methods("GET", "HEAD")
+	router.HandleFunc("/storage/signed/{id}/{bucket_id}", handlers.SignedUploadHandler).Methods("PUT")
+
+	// Public buckets: unauthenticated, files addressed by name so that reverse proxies can cache them
+	router.HandleFunc("/storage/public/{id}/{bucket_id}/{name:.+}", handlers.PublicFileHandler).Methods("GET", "HEAD")
 
 	// ============ Webhook routes ============
 	router.HandleFunc("/project/{id}/webhooks", handlers.GetWebhooksHandler).Methods("GET")
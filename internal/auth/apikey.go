@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// APIKeyType est la valeur de la revendication type des clés d'API. Une clé d'API est un
+// jeton du projet donnant un accès de service, sans les politiques des utilisateurs finaux.
+const APIKeyType = "api_key"
+
+// Permissions accordées aux clés d'API au-delà de l'accès de service
+const (
+	PermissionSQL      = "sql"      // Exécution de SQL arbitraire
+	PermissionBranches = "branches" // Création de branches des bases de données
+	PermissionMasking  = "masking"  // Gestion du profil de masquage, copies et exports non masqués
+	PermissionAdmin    = "admin"    // Création des clés d'API du projet
+)
+
+// IsPermission indique si la permission est connue : une clé d'API ne peut porter que celles-ci
+func IsPermission(permission string) bool {
+	switch permission {
+	case PermissionSQL, PermissionBranches, PermissionMasking, PermissionAdmin:
+		return true
+	}
+	return false
+}
+
+// IsAPIKey indique si le jeton est une clé d'API plutôt que celui d'un utilisateur final
+func (c Claims) IsAPIKey() bool {
+	kind, _ := c["type"].(string)
+	return kind == APIKeyType
+}
+
+// Permissions retourne les permissions de la clé d'API (revendication permissions)
+func (c Claims) Permissions() []string {
+	list, _ := c["permissions"].([]any)
+	permissions := make([]string, 0, len(list))
+	for _, item := range list {
+		if permission, ok := item.(string); ok {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// HasPermission indique si le jeton est une clé d'API portant explicitement la permission
+func (c Claims) HasPermission(permission string) bool {
+	return c.IsAPIKey() && slices.Contains(c.Permissions(), permission)
+}
+
+// NewAPIKey retourne les revendications d'une clé d'API du projet
+func NewAPIKey(id, projectID string, permissions []string, issuedAt, expiresAt time.Time) Claims {
+	list := make([]any, len(permissions))
+	for i, permission := range permissions {
+		list[i] = permission
+	}
+	return Claims{
+		"type":        APIKeyType,
+		"sub":         id,
+		"aud":         projectID,
+		"permissions": list,
+		"iat":         float64(issuedAt.Unix()),
+		"exp":         float64(expiresAt.Unix()),
+	}
+}
+
+// Sign signe les revendications en HS256 avec secret et retourne le jeton
+func Sign(claims Claims, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", fmt.Errorf("%w: aucun secret de signature configuré", ErrInvalidToken)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("%w: revendications non sérialisables", ErrInvalidToken)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
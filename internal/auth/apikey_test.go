@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign_APIKey(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	token, err := Sign(NewAPIKey("key-1", "project-1", []string{PermissionSQL}, now, now.Add(time.Hour)), secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := Verify(token, secret, "project-1", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !claims.IsAPIKey() || claims.Subject() != "key-1" || !claims.HasPermission(PermissionSQL) {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+
+	if _, err := Verify(token, []byte("other-secret"), "project-1", now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken with another secret, got %v", err)
+	}
+	if _, err := Sign(claims, nil); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken without secret, got %v", err)
+	}
+}
+
+func TestIsPermission(t *testing.T) {
+	for _, permission := range []string{PermissionSQL, PermissionBranches, PermissionMasking, PermissionAdmin} {
+		if !IsPermission(permission) {
+			t.Errorf("%s should be a known permission", permission)
+		}
+	}
+	for _, permission := range []string{"", "read", "SQL", "*"} {
+		if IsPermission(permission) {
+			t.Errorf("%q should not be a known permission", permission)
+		}
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cases := map[string]struct {
+		claims Claims
+		want   bool
+	}{
+		"granted":        {NewAPIKey("key-1", "p", []string{"read", PermissionSQL}, now, now), true},
+		"not granted":    {NewAPIKey("key-1", "p", []string{"read"}, now, now), false},
+		"end-user token": {Claims{"sub": "user-1", "permissions": []any{PermissionSQL}}, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Les revendications passent par JSON comme celles d'un jeton vérifié
+			token, err := Sign(tc.claims, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Claims
+			if err := decodeSegment(strings.Split(token, ".")[1], &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if got := decoded.HasPermission(PermissionSQL); got != tc.want {
+				t.Errorf("HasPermission = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
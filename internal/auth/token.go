@@ -0,0 +1,131 @@
+// Package auth vérifie les jetons des utilisateurs finaux des projets
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken est renvoyée pour un jeton mal formé, mal signé, expiré ou émis pour un autre projet
+var ErrInvalidToken = errors.New("jeton invalide")
+
+// Claims regroupe les revendications d'un jeton vérifié
+type Claims map[string]any
+
+// Subject retourne l'identifiant de l'utilisateur (revendication sub)
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// Role retourne le rôle de l'utilisateur dans le projet (revendication role)
+func (c Claims) Role() string {
+	role, _ := c["role"].(string)
+	return role
+}
+
+// IsToken indique si la valeur a la forme d'un JWT (trois segments séparés par des points)
+func IsToken(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify vérifie un JWT signé en HS256 avec secret et retourne ses revendications.
+// Le jeton doit être émis pour le projet (revendication aud) et être valide à l'instant now.
+func Verify(token string, secret []byte, projectID string, now time.Time) (Claims, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%w: aucun secret de signature configuré", ErrInvalidToken)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: format attendu header.payload.signature", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	// L'algorithme est imposé : accepter celui du jeton permettrait "none"
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: algorithme %q non supporté", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature mal encodée", ErrInvalidToken)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: signature incorrecte", ErrInvalidToken)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := claims.validate(projectID, now); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validate contrôle la période de validité, l'audience et le sujet du jeton
+func (c Claims) validate(projectID string, now time.Time) error {
+	if exp, ok := c.time("exp"); !ok || !now.Before(exp) {
+		return fmt.Errorf("%w: jeton expiré ou sans expiration", ErrInvalidToken)
+	}
+	if nbf, ok := c.time("nbf"); ok && now.Before(nbf) {
+		return fmt.Errorf("%w: jeton pas encore valide", ErrInvalidToken)
+	}
+	if !c.hasAudience(projectID) {
+		return fmt.Errorf("%w: jeton émis pour un autre projet", ErrInvalidToken)
+	}
+	if c.Subject() == "" {
+		return fmt.Errorf("%w: revendication sub manquante", ErrInvalidToken)
+	}
+	return nil
+}
+
+// time lit une date exprimée en secondes depuis l'epoch
+func (c Claims) time(name string) (time.Time, bool) {
+	seconds, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// hasAudience indique si le projet figure dans la revendication aud (chaîne ou tableau)
+func (c Claims) hasAudience(projectID string) bool {
+	switch aud := c["aud"].(type) {
+	case string:
+		return aud == projectID
+	case []any:
+		for _, item := range aud {
+			if item == projectID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: segment mal encodé", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: segment JSON invalide", ErrInvalidToken)
+	}
+	return nil
+}
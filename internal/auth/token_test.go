@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var secret = []byte("test-secret")
+
+// sign construit un JWT HS256 comme le ferait l'émetteur des jetons
+func sign(t *testing.T, alg string, claims map[string]any, key []byte) string {
+	t.Helper()
+	encode := func(v any) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	unsigned := encode(map[string]string{"alg": alg, "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	valid := map[string]any{"sub": "user-1", "role": "owner", "aud": "project-1", "exp": now.Add(time.Hour).Unix()}
+
+	claims, err := Verify(sign(t, "HS256", valid, secret), secret, "project-1", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims.Subject() != "user-1" || claims.Role() != "owner" {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	exp := now.Add(time.Hour).Unix()
+	with := func(key string, value any) map[string]any {
+		claims := map[string]any{"sub": "user-1", "aud": "project-1", "exp": exp}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	cases := map[string]string{
+		"expired":       sign(t, "HS256", with("exp", now.Add(-time.Second).Unix()), secret),
+		"no expiration": sign(t, "HS256", with("exp", nil), secret),
+		"not before":    sign(t, "HS256", with("nbf", now.Add(time.Minute).Unix()), secret),
+		"other project": sign(t, "HS256", with("aud", "project-2"), secret),
+		"no subject":    sign(t, "HS256", with("sub", nil), secret),
+		"wrong secret":  sign(t, "HS256", with("role", "owner"), []byte("other")),
+		"algorithm":     sign(t, "none", with("role", "owner"), secret),
+		"malformed":     "not-a-token",
+	}
+
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Verify(token, secret, "project-1", now); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
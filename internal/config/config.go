@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/ketsuna-org/sovrabase/internal/models/organization"
 	"gopkg.in/yaml.v3"
 
 	// Import des packages Docker et Kubernetes pour la gestion des conteneurs
@@ -29,11 +31,48 @@ type InternalDB struct {
 
 // Orchestrator holds container orchestration configuration
 type Orchestrator struct {
-	Type       string `yaml:"type"`        // "docker" or "kubernetes"
-	DockerHost string `yaml:"docker_host"` // Docker/Podman socket or remote host
-	KubeAPI    string `yaml:"kube_api"`    // Kubernetes API endpoint
-	KubeToken  string `yaml:"kube_token"`  // Kubernetes API token
-	Namespace  string `yaml:"namespace"`   // Kubernetes namespace for database deployments
+	Type              string        `yaml:"type"`               // "docker" or "kubernetes"
+	DockerHost        string        `yaml:"docker_host"`        // Docker/Podman socket or remote host
+	KubeAPI           string        `yaml:"kube_api"`           // Kubernetes API endpoint
+	KubeToken         string        `yaml:"kube_token"`         // Kubernetes API token
+	Namespace         string        `yaml:"namespace"`          // Kubernetes namespace for database deployments
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"` // Interval between deletions of expired database branches
+}
+
+// DataAPI holds data API configuration
+type DataAPI struct {
+	MaxConns           int32         `yaml:"max_conns"`            // Maximum connections per database
+	MaxConnIdleTime    time.Duration `yaml:"max_conn_idle_time"`   // Idle time before a connection is closed
+	TransactionTimeout time.Duration `yaml:"transaction_timeout"`  // Idle time before a transaction is rolled back
+	MaxTransactions    int           `yaml:"max_transactions"`     // Maximum open transactions per project
+	JWTSecret          string        `yaml:"jwt_secret"`           // HS256 secret of end-user tokens
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval"` // Interval between purges of expired trashed documents
+}
+
+// Storage holds file storage configuration
+type Storage struct {
+	Backend             string        `yaml:"backend"`    // "local" or "s3"
+	LocalPath           string        `yaml:"local_path"` // Root directory of the local backend
+	S3                  S3Storage     `yaml:"s3"`
+	UploadPurgeInterval time.Duration `yaml:"upload_purge_interval"` // Interval between purges of expired resumable uploads
+}
+
+// S3Storage holds the connection to an S3-compatible service
+type S3Storage struct {
+	Endpoint  string `yaml:"endpoint"`   // Service URL, e.g. "https://s3.eu-west-1.amazonaws.com"
+	Region    string `yaml:"region"`     // Signing region
+	Bucket    string `yaml:"bucket"`     // S3 bucket holding the files of every project
+	AccessKey string `yaml:"access_key"` // Access key ID
+	SecretKey string `yaml:"secret_key"` // Secret access key
+	PathStyle bool   `yaml:"path_style"` // Address the bucket in the path (MinIO, Garage)
+}
+
+// Organization declares an organization, its projects and their compliance requirements,
+// until organizations are persisted by the control plane
+type Organization struct {
+	ID         string                              `yaml:"id"`
+	Projects   []string                            `yaml:"projects"`   // IDs of the projects owned by the organization
+	Compliance organization.OrganisationCompliance `yaml:"compliance"` // RGPD enforces the masking of every database
 }
 
 // SuperUser holds super user configuration
@@ -45,11 +84,14 @@ type SuperUser struct {
 
 // Config holds the application configuration
 type Config struct {
-	Region       string
-	API          API
-	InternalDB   InternalDB
-	Orchestrator Orchestrator
-	SuperUser    SuperUser
+	Region        string
+	API           API
+	InternalDB    InternalDB
+	Orchestrator  Orchestrator
+	DataAPI       DataAPI `yaml:"data_api"`
+	Storage       Storage
+	Organizations []Organization `yaml:"organizations"`
+	SuperUser     SuperUser      `yaml:"super_user"`
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -78,6 +120,33 @@ func LoadConfig(filePath string) (*Config, error) {
 	if config.Orchestrator.Namespace == "" && config.Orchestrator.Type == "kubernetes" {
 		config.Orchestrator.Namespace = "sovrabase-databases"
 	}
+	if config.Orchestrator.ReconcileInterval == 0 {
+		config.Orchestrator.ReconcileInterval = time.Minute
+	}
+	if config.DataAPI.MaxConns == 0 {
+		config.DataAPI.MaxConns = 10
+	}
+	if config.DataAPI.MaxConnIdleTime == 0 {
+		config.DataAPI.MaxConnIdleTime = 5 * time.Minute
+	}
+	if config.DataAPI.TransactionTimeout == 0 {
+		config.DataAPI.TransactionTimeout = 30 * time.Second
+	}
+	if config.DataAPI.MaxTransactions == 0 {
+		config.DataAPI.MaxTransactions = 5
+	}
+	if config.DataAPI.TrashPurgeInterval == 0 {
+		config.DataAPI.TrashPurgeInterval = time.Hour
+	}
+	if config.Storage.Backend == "" {
+		config.Storage.Backend = "local"
+	}
+	if config.Storage.LocalPath == "" && config.Storage.Backend == "local" {
+		config.Storage.LocalPath = "./data/storage"
+	}
+	if config.Storage.UploadPurgeInterval == 0 {
+		config.Storage.UploadPurgeInterval = time.Hour
+	}
 
 	return &config, nil
 }
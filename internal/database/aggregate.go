@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fonctions d'agrégation acceptées
+const (
+	AggregateCount = "count"
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+)
+
+// aggregateFunctions liste les fonctions d'agrégation acceptées
+var aggregateFunctions = map[string]bool{
+	AggregateCount: true,
+	AggregateSum:   true,
+	AggregateAvg:   true,
+	AggregateMin:   true,
+	AggregateMax:   true,
+}
+
+// numericTypes liste les types sur lesquels sum et avg sont définis
+var numericTypes = map[string]bool{
+	"smallint":         true,
+	"integer":          true,
+	"bigint":           true,
+	"numeric":          true,
+	"real":             true,
+	"double precision": true,
+}
+
+// aliasPattern valide le nom d'un résultat d'agrégation
+var aliasPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Aggregation décrit une agrégation des documents d'une collection, éventuellement par groupe
+type Aggregation struct {
+	GroupBy    []string
+	Aggregates []Aggregate
+	Filter     map[string]any // Documents agrégés
+	Having     map[string]any // Groupes retournés, filtrés sur les colonnes de groupe et les alias
+	Sort       []string       // Colonnes de groupe ou alias, préfixés par "-" pour un tri décroissant
+	Limit      int
+	Offset     int
+}
+
+// Aggregate est une fonction d'agrégation appliquée à une colonne
+type Aggregate struct {
+	Function string
+	Column   string // Vide pour count(*)
+	Alias    string // Nom du résultat, "<fonction>_<colonne>" par défaut
+	Distinct bool   // N'agrège que les valeurs distinctes
+}
+
+// AggregateDocuments agrège les documents de la collection. Chaque groupe est retourné
+// sous forme d'objet portant les colonnes de groupe et les résultats des agrégats.
+func AggregateDocuments(ctx context.Context, q Querier, t *Table, agg Aggregation) ([]json.RawMessage, error) {
+	statement, args, err := aggregateStatement(t, agg)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectDocuments(q.Query(ctx, statement, args...))
+}
+
+// aggregateStatement génère la requête d'agrégation. L'agrégation est calculée dans une
+// sous-requête a, sur laquelle having et le tri portent comme sur une collection.
+func aggregateStatement(t *Table, agg Aggregation) (string, []any, error) {
+	if len(agg.GroupBy) == 0 && len(agg.Aggregates) == 0 {
+		return "", nil, fmt.Errorf("%w: group_by ou aggregates est requis", ErrInvalid)
+	}
+
+	// Le résultat est décrit comme une collection pour valider having et le tri
+	result := &Table{Schema: t.Schema, Name: t.Name}
+	selects := make([]string, 0, len(agg.GroupBy)+len(agg.Aggregates))
+	groups := make([]string, len(agg.GroupBy))
+	for i, name := range agg.GroupBy {
+		col, ok := t.Column(name)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: regroupement sur une colonne inconnue %q", ErrInvalid, name)
+		}
+		if _, ok := result.Column(name); ok {
+			return "", nil, fmt.Errorf("%w: colonne %q regroupée deux fois", ErrInvalid, name)
+		}
+		groups[i] = "r." + quoteIdent(name)
+		selects = append(selects, groups[i])
+		result.Columns = append(result.Columns, col)
+	}
+
+	for _, a := range agg.Aggregates {
+		expr, col, err := aggregateExpr(t, a)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, ok := result.Column(col.Name); ok {
+			return "", nil, fmt.Errorf("%w: résultat %q défini deux fois, précisez un alias", ErrInvalid, col.Name)
+		}
+		selects = append(selects, expr+" AS "+quoteIdent(col.Name))
+		result.Columns = append(result.Columns, col)
+	}
+
+	cond, args, err := CompileFilter(t, agg.Filter, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	cond = t.liveCond("r", cond)
+	having := &filterCompiler{table: result, args: args, alias: "a"}
+	havingCond, err := having.object(agg.Having, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	args = having.args
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT to_jsonb(a.*) FROM (SELECT %s FROM %s AS r WHERE %s", strings.Join(selects, ", "), t.Identifier(), cond)
+	if len(groups) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(groups, ", "))
+	}
+	b.WriteString(") AS a WHERE " + havingCond)
+
+	order, err := aggregateOrder(result, agg.Sort, agg.GroupBy)
+	if err != nil {
+		return "", nil, err
+	}
+	b.WriteString(order)
+
+	args = append(args, clampLimit(agg.Limit), max(agg.Offset, 0))
+	fmt.Fprintf(&b, " LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return b.String(), args, nil
+}
+
+// aggregateExpr génère l'expression d'un agrégat et décrit la colonne de résultat
+func aggregateExpr(t *Table, a Aggregate) (string, Column, error) {
+	function := strings.ToLower(a.Function)
+	if !aggregateFunctions[function] {
+		return "", Column{}, fmt.Errorf("%w: fonction d'agrégation inconnue %q", ErrInvalid, a.Function)
+	}
+
+	alias := a.Alias
+	if alias == "" {
+		alias = function
+		if a.Column != "" {
+			alias += "_" + a.Column
+		}
+	}
+	if !aliasPattern.MatchString(alias) || len(alias) > maxIdentifierLength {
+		return "", Column{}, fmt.Errorf("%w: alias invalide %q (lettres minuscules, chiffres et _)", ErrInvalid, alias)
+	}
+
+	if a.Column == "" {
+		if function != AggregateCount || a.Distinct {
+			return "", Column{}, fmt.Errorf("%w: %s nécessite une colonne", ErrInvalid, function)
+		}
+		return "count(*)", Column{Name: alias, Type: "bigint"}, nil
+	}
+
+	col, ok := t.Column(a.Column)
+	if !ok {
+		return "", Column{}, fmt.Errorf("%w: agrégation d'une colonne inconnue %q", ErrInvalid, a.Column)
+	}
+	base, _, _ := strings.Cut(col.Type, "(")
+	resultType := col.Type
+	switch function {
+	case AggregateCount:
+		resultType = "bigint"
+	case AggregateSum, AggregateAvg:
+		if !numericTypes[base] {
+			return "", Column{}, fmt.Errorf("%w: %s attend une colonne numérique, %q est de type %s", ErrInvalid, function, a.Column, col.Type)
+		}
+		resultType = "numeric"
+	case AggregateMin, AggregateMax:
+		if isJSONType(col.Type) {
+			return "", Column{}, fmt.Errorf("%w: %s n'est pas défini sur la colonne json %q", ErrInvalid, function, a.Column)
+		}
+	}
+
+	arg := "r." + quoteIdent(a.Column)
+	if a.Distinct {
+		arg = "DISTINCT " + arg
+	}
+	return fmt.Sprintf("%s(%s)", function, arg), Column{Name: alias, Type: resultType, Nullable: true}, nil
+}
+
+// aggregateOrder construit le tri des groupes ; par défaut, ils sont triés par colonnes de groupe
+func aggregateOrder(result *Table, sort, groupBy []string) (string, error) {
+	if len(sort) == 0 {
+		sort = groupBy
+	}
+	if len(sort) == 0 {
+		return "", nil
+	}
+	parts := make([]string, len(sort))
+	for i, entry := range sort {
+		name := strings.TrimPrefix(entry, "-")
+		if _, ok := result.Column(name); !ok {
+			return "", fmt.Errorf("%w: tri sur un résultat inconnu %q", ErrInvalid, name)
+		}
+		direction := " ASC"
+		if strings.HasPrefix(entry, "-") {
+			direction = " DESC"
+		}
+		parts[i] = "a." + quoteIdent(name) + direction
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
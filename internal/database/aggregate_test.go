@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateStatement(t *testing.T) {
+	sql, args, err := aggregateStatement(postsTable(), Aggregation{
+		GroupBy: []string{"title"},
+		Aggregates: []Aggregate{
+			{Function: "count"},
+			{Function: "SUM", Column: "views"},
+			{Function: "count", Column: "tags", Distinct: true, Alias: "tag_sets"},
+		},
+		Filter: map[string]any{"deleted_at": nil},
+		Having: map[string]any{"count": map[string]any{"$gte": "2"}},
+		Sort:   []string{"-sum_views"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT to_jsonb(a.*) FROM (SELECT r."title", count(*) AS "count", sum(r."views") AS "sum_views", count(DISTINCT r."tags") AS "tag_sets"` +
+		` FROM "public"."posts" AS r WHERE r."deleted_at" IS NULL GROUP BY r."title") AS a` +
+		` WHERE a."count" >= $1::text::bigint ORDER BY a."sum_views" DESC LIMIT $2 OFFSET $3`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+	if want := []any{"2", DefaultLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
+
+func TestAggregateStatement_WithoutGroups(t *testing.T) {
+	sql, _, err := aggregateStatement(postsTable(), Aggregation{
+		Aggregates: []Aggregate{{Function: "avg", Column: "views"}, {Function: "max", Column: "deleted_at", Alias: "last_deleted"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT to_jsonb(a.*) FROM (SELECT avg(r."views") AS "avg_views", max(r."deleted_at") AS "last_deleted"` +
+		` FROM "public"."posts" AS r WHERE TRUE) AS a WHERE TRUE LIMIT $1 OFFSET $2`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestAggregateStatement_Errors(t *testing.T) {
+	cases := map[string]Aggregation{
+		"empty":             {},
+		"unknown group":     {GroupBy: []string{"nope"}},
+		"duplicate group":   {GroupBy: []string{"title", "title"}},
+		"unknown function":  {Aggregates: []Aggregate{{Function: "pg_sleep", Column: "views"}}},
+		"unknown column":    {Aggregates: []Aggregate{{Function: "min", Column: "nope"}}},
+		"sum of text":       {Aggregates: []Aggregate{{Function: "sum", Column: "title"}}},
+		"max of json":       {Aggregates: []Aggregate{{Function: "max", Column: "meta"}}},
+		"sum without col":   {Aggregates: []Aggregate{{Function: "sum"}}},
+		"injected alias":    {Aggregates: []Aggregate{{Function: "count", Alias: `n" FROM pg_user; --`}}},
+		"duplicate alias":   {GroupBy: []string{"title"}, Aggregates: []Aggregate{{Function: "count", Alias: "title"}}},
+		"having on column":  {GroupBy: []string{"title"}, Having: map[string]any{"views": "1"}},
+		"sort not a result": {GroupBy: []string{"title"}, Sort: []string{"views"}},
+	}
+
+	for name, agg := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := aggregateStatement(postsTable(), agg); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
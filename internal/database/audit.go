@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ketsuna-org/sovrabase/internal/config"
+)
+
+// AuditEntry est une opération sensible consignée dans le journal d'audit de la base
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`  // Sujet du jeton à l'origine de l'opération
+	Action     string          `json:"action"` // Nature de l'opération, ex: AuditActionSQL
+	Statement  string          `json:"statement,omitempty"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	ReadOnly   bool            `json:"read_only"`
+	Rows       int64           `json:"row_count"`
+	DurationMs int64           `json:"duration_ms"`
+	Error      string          `json:"error,omitempty"` // Erreur renvoyée, vide si l'opération a réussi
+}
+
+// AuditActionSQL consigne l'exécution d'une instruction SQL brute
+const AuditActionSQL = "sql"
+
+// AuditLog conserve le journal d'audit des bases dans le plan de contrôle : les clés
+// autorisées à exécuter du SQL sur une base, dont il consigne les opérations, ne peuvent ni
+// modifier ni effacer ses entrées
+type AuditLog interface {
+	// RecordAudit ajoute une entrée au journal de la base et renseigne son identifiant et sa date
+	RecordAudit(ctx context.Context, projectID, dbID string, entry *AuditEntry) error
+	// ListAudit retourne les entrées du journal de la base, des plus récentes aux plus anciennes.
+	// before pagine le journal : seules les entrées d'identifiant inférieur sont retournées s'il est positif.
+	ListAudit(ctx context.Context, projectID, dbID string, limit int, before int64) ([]AuditEntry, error)
+}
+
+// NewAuditLog ouvre le journal d'audit dans la base interne du plan de contrôle
+func NewAuditLog(ctx context.Context, cfg *config.InternalDB) (AuditLog, error) {
+	switch cfg.Manager {
+	case "postgres":
+		return NewPostgresAuditLog(ctx, cfg.URI)
+	case "memory":
+		return NewMemoryAuditLog(), nil
+	default:
+		return nil, fmt.Errorf("base interne %q non supportée par le journal d'audit (postgres ou memory)", cfg.Manager)
+	}
+}
+
+// MemoryAuditLog conserve le journal en mémoire, perdu à l'arrêt du serveur : il est destiné
+// aux tests et au développement
+type MemoryAuditLog struct {
+	mu      sync.RWMutex
+	entries []auditRecord // Par identifiant croissant
+}
+
+type auditRecord struct {
+	projectID, dbID string
+	entry           AuditEntry
+}
+
+// NewMemoryAuditLog crée un journal en mémoire vide
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+func (l *MemoryAuditLog) RecordAudit(ctx context.Context, projectID, dbID string, entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.ID = int64(len(l.entries)) + 1
+	entry.OccurredAt = time.Now().UTC()
+	l.entries = append(l.entries, auditRecord{projectID: projectID, dbID: dbID, entry: *entry})
+	return nil
+}
+
+func (l *MemoryAuditLog) ListAudit(ctx context.Context, projectID, dbID string, limit int, before int64) ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	limit = clampLimit(limit)
+	entries := []AuditEntry{}
+	for i := len(l.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		record := l.entries[i]
+		if record.projectID == projectID && record.dbID == dbID && (before <= 0 || record.entry.ID < before) {
+			entries = append(entries, record.entry)
+		}
+	}
+	return entries, nil
+}
+
+// auditSchema crée la table du journal dans la base interne
+const auditSchema = `
+CREATE SCHEMA IF NOT EXISTS sovrabase;
+
+CREATE TABLE IF NOT EXISTS sovrabase.audit_log (
+	id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	project_id text NOT NULL,
+	db_id text NOT NULL,
+	occurred_at timestamptz NOT NULL DEFAULT now(),
+	actor text NOT NULL,
+	action text NOT NULL,
+	statement text,
+	params jsonb,
+	read_only boolean NOT NULL DEFAULT false,
+	row_count bigint NOT NULL DEFAULT 0,
+	duration_ms bigint NOT NULL DEFAULT 0,
+	error text
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_database ON sovrabase.audit_log (project_id, db_id, id);`
+
+// PostgresAuditLog conserve le journal dans la base interne PostgreSQL
+type PostgresAuditLog struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresAuditLog se connecte à la base interne et crée la table du journal
+func NewPostgresAuditLog(ctx context.Context, uri string) (*PostgresAuditLog, error) {
+	pool, err := pgxpool.New(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("connexion à la base interne: %w", err)
+	}
+	if _, err := pool.Exec(ctx, auditSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("création de la table du journal d'audit: %w", err)
+	}
+	return &PostgresAuditLog{pool: pool}, nil
+}
+
+// Close ferme les connexions à la base interne
+func (l *PostgresAuditLog) Close() {
+	l.pool.Close()
+}
+
+func (l *PostgresAuditLog) RecordAudit(ctx context.Context, projectID, dbID string, entry *AuditEntry) error {
+	var params any
+	if len(entry.Params) > 0 {
+		params = string(entry.Params)
+	}
+	var errorMessage any
+	if entry.Error != "" {
+		errorMessage = entry.Error
+	}
+	return l.pool.QueryRow(ctx, `INSERT INTO sovrabase.audit_log (project_id, db_id, actor, action, statement, params, read_only, row_count, duration_ms, error)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8, $9, $10) RETURNING id, occurred_at`,
+		projectID, dbID, entry.Actor, entry.Action, entry.Statement, params, entry.ReadOnly, entry.Rows, entry.DurationMs, errorMessage,
+	).Scan(&entry.ID, &entry.OccurredAt)
+}
+
+func (l *PostgresAuditLog) ListAudit(ctx context.Context, projectID, dbID string, limit int, before int64) ([]AuditEntry, error) {
+	rows, err := l.pool.Query(ctx, `SELECT id, occurred_at, actor, action, coalesce(statement, ''), params, read_only, row_count, duration_ms, coalesce(error, '')
+		FROM sovrabase.audit_log WHERE project_id = $1 AND db_id = $2 AND ($3 <= 0 OR id < $3)
+		ORDER BY id DESC LIMIT $4`, projectID, dbID, before, clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var params []byte
+		if err := rows.Scan(&entry.ID, &entry.OccurredAt, &entry.Actor, &entry.Action, &entry.Statement,
+			&params, &entry.ReadOnly, &entry.Rows, &entry.DurationMs, &entry.Error); err != nil {
+			return nil, err
+		}
+		entry.Params = params
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
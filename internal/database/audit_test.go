@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryAuditLog(t *testing.T) {
+	ctx := context.Background()
+	log := NewMemoryAuditLog()
+	for _, db := range []string{"db", "other", "db", "db"} {
+		if err := log.RecordAudit(ctx, "shop", db, &AuditEntry{Actor: "key", Action: AuditActionSQL}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries, err := log.ListAudit(ctx, "shop", "db", 2, 0)
+	if err != nil || len(entries) != 2 || entries[0].ID != 4 || entries[1].ID != 3 {
+		t.Fatalf("unexpected entries %+v: %v", entries, err)
+	}
+	if entries[0].OccurredAt.IsZero() {
+		t.Error("the entry should be dated")
+	}
+
+	// before pagine à partir de la dernière entrée reçue
+	entries, _ = log.ListAudit(ctx, "shop", "db", 2, entries[1].ID)
+	if len(entries) != 1 || entries[0].ID != 1 {
+		t.Errorf("unexpected page %+v", entries)
+	}
+
+	// Le journal d'une base n'est visible que depuis son projet
+	if entries, _ := log.ListAudit(ctx, "lab", "db", 0, 0); len(entries) != 0 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxBatchOperations limite le nombre d'opérations d'un lot
+const MaxBatchOperations = 1000
+
+// Types d'opérations acceptés dans un lot
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+)
+
+// Operation est une écriture d'un lot, appliquée à une collection
+type Operation struct {
+	Op         string
+	Collection string
+	ID         string           // Document ciblé par update, ou par delete
+	Documents  []map[string]any // Documents de insert et upsert, modifications de update
+	IDs        []string         // Documents ciblés par delete
+	Filter     map[string]any   // Filtre de delete
+}
+
+// OperationResult est le résultat d'une opération d'un lot
+type OperationResult struct {
+	Data    []json.RawMessage `json:"data,omitempty"`
+	Deleted *int64            `json:"deleted,omitempty"`
+}
+
+// OperationError indique l'opération d'un lot qui a échoué
+type OperationError struct {
+	Index int
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("opération %d: %v", e.Index, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// RunBatch exécute les opérations dans l'ordre au sein d'une même transaction.
+// À la première erreur, tout est annulé et une *OperationError indique l'opération fautive.
+func RunBatch(ctx context.Context, q Querier, ops []Operation) ([]OperationResult, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: le lot ne contient aucune opération", ErrInvalid)
+	}
+	if len(ops) > MaxBatchOperations {
+		return nil, fmt.Errorf("%w: %d opérations au maximum par lot", ErrInvalid, MaxBatchOperations)
+	}
+	for i, op := range ops {
+		if err := op.validate(); err != nil {
+			return nil, &OperationError{Index: i, Err: err}
+		}
+	}
+
+	results := make([]OperationResult, len(ops))
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		tables := make(map[string]*Table)
+		for i, op := range ops {
+			table, ok := tables[op.Collection]
+			if !ok {
+				var err error
+				if table, err = LoadTable(ctx, tx, op.Collection); err != nil {
+					return &OperationError{Index: i, Err: err}
+				}
+				tables[op.Collection] = table
+			}
+
+			result, err := op.run(ctx, tx, table)
+			if err != nil {
+				return &OperationError{Index: i, Err: err}
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// validate vérifie la forme de l'opération avant d'ouvrir la transaction
+func (op Operation) validate() error {
+	if op.Collection == "" {
+		return fmt.Errorf("%w: collection manquante", ErrInvalid)
+	}
+
+	switch op.Op {
+	case OpInsert, OpUpsert:
+		if len(op.Documents) == 0 {
+			return fmt.Errorf("%w: %s attend au moins un document", ErrInvalid, op.Op)
+		}
+	case OpUpdate:
+		if op.ID == "" || len(op.Documents) != 1 {
+			return fmt.Errorf("%w: update attend un id et un document", ErrInvalid)
+		}
+	case OpDelete:
+		if op.ID == "" && len(op.IDs) == 0 && len(op.Filter) == 0 {
+			return fmt.Errorf("%w: delete attend un id, des ids ou un filtre", ErrInvalid)
+		}
+		if op.ID != "" && (len(op.IDs) > 0 || len(op.Filter) > 0) {
+			return fmt.Errorf("%w: delete accepte un id ou bien des ids et un filtre", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: opération inconnue %q", ErrInvalid, op.Op)
+	}
+	return nil
+}
+
+func (op Operation) run(ctx context.Context, q Querier, t *Table) (OperationResult, error) {
+	switch op.Op {
+	case OpInsert:
+		docs, err := InsertDocuments(ctx, q, t, op.Documents)
+		return OperationResult{Data: docs}, err
+	case OpUpsert:
+		docs, err := UpsertDocuments(ctx, q, t, op.Documents)
+		return OperationResult{Data: docs}, err
+	case OpUpdate:
+		doc, _, err := UpdateDocument(ctx, q, t, op.ID, op.Documents[0], nil)
+		return OperationResult{Data: []json.RawMessage{doc}}, err
+	default:
+		var deleted int64 = 1
+		var err error
+		if op.ID != "" {
+			err = DeleteDocument(ctx, q, t, op.ID, nil)
+		} else {
+			deleted, err = BatchDelete(ctx, q, t, op.IDs, op.Filter)
+		}
+		return OperationResult{Deleted: &deleted}, err
+	}
+}
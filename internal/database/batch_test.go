@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOperationValidate(t *testing.T) {
+	doc := []map[string]any{{"title": "a"}}
+	cases := map[string]struct {
+		op    Operation
+		valid bool
+	}{
+		"insert":                {Operation{Op: OpInsert, Collection: "posts", Documents: doc}, true},
+		"insert without docs":   {Operation{Op: OpInsert, Collection: "posts"}, false},
+		"update":                {Operation{Op: OpUpdate, Collection: "posts", ID: "1", Documents: doc}, true},
+		"update without id":     {Operation{Op: OpUpdate, Collection: "posts", Documents: doc}, false},
+		"delete by id":          {Operation{Op: OpDelete, Collection: "posts", ID: "1"}, true},
+		"delete by filter":      {Operation{Op: OpDelete, Collection: "posts", Filter: map[string]any{"views": 0}}, true},
+		"delete without target": {Operation{Op: OpDelete, Collection: "posts"}, false},
+		"delete id and filter":  {Operation{Op: OpDelete, Collection: "posts", ID: "1", Filter: map[string]any{"views": 0}}, false},
+		"unknown operation":     {Operation{Op: "truncate", Collection: "posts"}, false},
+		"missing collection":    {Operation{Op: OpInsert, Documents: doc}, false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.op.validate()
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunBatch_ReportsInvalidOperationIndex(t *testing.T) {
+	ops := []Operation{
+		{Op: OpInsert, Collection: "posts", Documents: []map[string]any{{"title": "a"}}},
+		{Op: OpDelete, Collection: "posts"},
+	}
+
+	// La validation échoue avant toute requête : aucune base n'est nécessaire
+	_, err := RunBatch(context.Background(), nil, ops)
+
+	var opErr *OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expected *OperationError, got %v", err)
+	}
+	if opErr.Index != 1 || !errors.Is(err, ErrInvalid) {
+		t.Errorf("unexpected error: index %d, %v", opErr.Index, err)
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// cursor est le contenu d'un curseur de pagination. Il est encodé en base64 pour
+// rester opaque aux clients, qui ne doivent pas en dépendre.
+type cursor struct {
+	Sort []string        `json:"s"` // tri pour lequel le curseur a été émis
+	Key  json.RawMessage `json:"k"` // valeurs des colonnes de tri de la dernière ligne
+}
+
+// encodeCursor construit le curseur désignant la position après la ligne de clé key
+func encodeCursor(terms []sortTerm, key json.RawMessage) string {
+	sort := make([]string, len(terms))
+	for i, term := range terms {
+		sort[i] = term.String()
+	}
+	data, _ := json.Marshal(cursor{Sort: sort, Key: key})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor retourne l'objet JSON des valeurs de clé d'un curseur, après avoir
+// vérifié qu'il a été émis pour le même tri
+func decodeCursor(token string, terms []sortTerm) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: curseur invalide", ErrInvalid)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", fmt.Errorf("%w: curseur invalide", ErrInvalid)
+	}
+
+	sort := make([]string, len(terms))
+	for i, term := range terms {
+		sort[i] = term.String()
+	}
+	if !slices.Equal(c.Sort, sort) {
+		return "", fmt.Errorf("%w: le curseur a été émis pour un autre tri", ErrInvalid)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(c.Key, &values); err != nil {
+		return "", fmt.Errorf("%w: curseur invalide", ErrInvalid)
+	}
+	for _, term := range terms {
+		if _, ok := values[term.column]; !ok {
+			return "", fmt.Errorf("%w: curseur invalide", ErrInvalid)
+		}
+	}
+	return string(c.Key), nil
+}
+
+// keysetCondition sélectionne les lignes situées après la position c dans l'ordre
+// des termes de tri. PostgreSQL place les NULL en dernier en ordre croissant et en
+// premier en ordre décroissant ; la condition en tient compte pour ne sauter
+// aucune ligne.
+func keysetCondition(terms []sortTerm) string {
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		parts := make([]string, 0, i+1)
+		for _, previous := range terms[:i] {
+			col := quoteIdent(previous.column)
+			parts = append(parts, fmt.Sprintf("r.%[1]s IS NOT DISTINCT FROM c.%[1]s", col))
+		}
+
+		col := quoteIdent(term.column)
+		if term.desc {
+			parts = append(parts, fmt.Sprintf("(r.%[1]s < c.%[1]s OR (c.%[1]s IS NULL AND r.%[1]s IS NOT NULL))", col))
+		} else {
+			parts = append(parts, fmt.Sprintf("(r.%[1]s > c.%[1]s OR (c.%[1]s IS NOT NULL AND r.%[1]s IS NULL))", col))
+		}
+		alternatives[i] = joinConditions(parts, " AND ", "TRUE")
+	}
+	return joinConditions(alternatives, " OR ", "FALSE")
+}
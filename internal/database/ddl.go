@@ -0,0 +1,363 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Le DDL ne peut pas être paramétré : les identifiants sont échappés, les types
+// validés par une expression stricte, et les valeurs écrites en littéraux échappés.
+// Les contraintes CHECK s'expriment avec le langage de filtre (docs/filters.md).
+
+// maxIdentifierLength est la longueur maximale d'un identifiant PostgreSQL (NAMEDATALEN - 1)
+const maxIdentifierLength = 63
+
+// typePattern accepte les noms de types PostgreSQL, avec modificateurs et dimension de tableau
+// (ex: "text", "timestamp with time zone", "numeric(10, 2)", "varchar(255)[]")
+var typePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*( [a-z][a-z0-9_]*)*( ?\([0-9]+( ?, ?[0-9]+)?\))?(\[\])*$`)
+
+// defaultFunctions liste les fonctions acceptées comme valeur par défaut
+var defaultFunctions = map[string]string{
+	"now":               "now()",
+	"current_date":      "CURRENT_DATE",
+	"current_timestamp": "CURRENT_TIMESTAMP",
+	"gen_random_uuid":   "gen_random_uuid()",
+}
+
+// foreignKeyActions liste les actions ON DELETE acceptées
+var foreignKeyActions = map[string]string{
+	"":            "",
+	"no action":   " ON DELETE NO ACTION",
+	"restrict":    " ON DELETE RESTRICT",
+	"cascade":     " ON DELETE CASCADE",
+	"set null":    " ON DELETE SET NULL",
+	"set default": " ON DELETE SET DEFAULT",
+}
+
+// CollectionDefinition décrit une collection à créer
+type CollectionDefinition struct {
+	Name       string
+	Columns    []ColumnDefinition
+	PrimaryKey []string
+	Checks     []CheckDefinition
+}
+
+// ColumnDefinition décrit une colonne à créer
+type ColumnDefinition struct {
+	Name            string
+	Type            string
+	NotNull         bool
+	Unique          bool
+	Identity        bool           // GENERATED BY DEFAULT AS IDENTITY
+	Default         any            // Valeur par défaut littérale
+	DefaultFunction string         // Ou fonction par défaut (voir defaultFunctions)
+	References      *ForeignKey    // Clé étrangère
+	Check           map[string]any // Contrainte CHECK sur la colonne, en langage de filtre
+}
+
+// ForeignKey décrit la collection et la colonne référencées par une clé étrangère
+type ForeignKey struct {
+	Collection string
+	Column     string
+	OnDelete   string
+}
+
+// CheckDefinition est une contrainte CHECK de table, exprimée en langage de filtre
+type CheckDefinition struct {
+	Name   string
+	Filter map[string]any
+}
+
+// ColumnChange décrit les modifications d'une colonne existante ; les champs vides sont ignorés
+type ColumnChange struct {
+	Name            string // Nouveau nom
+	Type            string
+	NotNull         *bool
+	Default         any
+	DefaultFunction string
+	DropDefault     bool
+}
+
+// createTableStatement génère le CREATE TABLE d'une collection
+func createTableStatement(def CollectionDefinition) (string, error) {
+	if err := checkIdentifier("collection", def.Name); err != nil {
+		return "", err
+	}
+	if len(def.Columns) == 0 {
+		return "", fmt.Errorf("%w: la collection %q doit avoir au moins une colonne", ErrInvalid, def.Name)
+	}
+
+	// La table décrite sert à valider les contraintes CHECK avant sa création
+	// (les types y sont normalisés car ils apparaissent dans les conversions générées)
+	table := &Table{Schema: DefaultSchema, Name: def.Name}
+	for _, col := range def.Columns {
+		if _, ok := table.Column(col.Name); ok {
+			return "", fmt.Errorf("%w: colonne %q définie deux fois", ErrInvalid, col.Name)
+		}
+		columnType, err := normalizeType(col.Type)
+		if err != nil {
+			return "", err
+		}
+		table.Columns = append(table.Columns, Column{Name: col.Name, Type: columnType, Nullable: !col.NotNull})
+	}
+
+	clauses := make([]string, 0, len(def.Columns)+len(def.Checks)+1)
+	for _, col := range def.Columns {
+		clause, err := columnClause(table, col)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+
+	if len(def.PrimaryKey) > 0 {
+		keys := make([]string, len(def.PrimaryKey))
+		for i, key := range def.PrimaryKey {
+			if _, ok := table.Column(key); !ok {
+				return "", fmt.Errorf("%w: clé primaire sur une colonne inconnue %q", ErrInvalid, key)
+			}
+			keys[i] = quoteIdent(key)
+		}
+		clauses = append(clauses, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+
+	for _, check := range def.Checks {
+		clause, err := checkClause(table, check)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", table.Identifier(), strings.Join(clauses, ",\n  ")), nil
+}
+
+// addColumnStatement génère l'ajout d'une colonne à une collection existante
+func addColumnStatement(t *Table, col ColumnDefinition) (string, error) {
+	if _, ok := t.Column(col.Name); ok {
+		return "", fmt.Errorf("%w: la colonne %q existe déjà", ErrConflict, col.Name)
+	}
+
+	columnType, err := normalizeType(col.Type)
+	if err != nil {
+		return "", err
+	}
+
+	// La contrainte CHECK peut porter sur la nouvelle colonne comme sur les existantes
+	table := *t
+	table.Columns = append(append([]Column(nil), t.Columns...), Column{Name: col.Name, Type: columnType, Nullable: !col.NotNull})
+
+	clause, err := columnClause(&table, col)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", t.Identifier(), clause), nil
+}
+
+// alterColumnStatements génère les modifications d'une colonne, le renommage en dernier
+func alterColumnStatements(t *Table, name string, change ColumnChange) ([]string, error) {
+	col, ok := t.Column(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: colonne %q introuvable dans la collection %q", ErrNotFound, name, t.Name)
+	}
+
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", t.Identifier(), quoteIdent(name))
+	var statements []string
+
+	if change.Type != "" {
+		columnType, err := normalizeType(change.Type)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, fmt.Sprintf("%sTYPE %s USING %s::%s", prefix, columnType, quoteIdent(name), columnType))
+		col.Type = columnType
+	}
+
+	if change.NotNull != nil {
+		if *change.NotNull {
+			statements = append(statements, prefix+"SET NOT NULL")
+		} else {
+			statements = append(statements, prefix+"DROP NOT NULL")
+		}
+	}
+
+	if change.DropDefault {
+		if change.Default != nil || change.DefaultFunction != "" {
+			return nil, fmt.Errorf("%w: drop_default est incompatible avec une nouvelle valeur par défaut", ErrInvalid)
+		}
+		statements = append(statements, prefix+"DROP DEFAULT")
+	} else {
+		expr, err := defaultExpr(col.Type, change.Default, change.DefaultFunction)
+		if err != nil {
+			return nil, err
+		}
+		if expr != "" {
+			statements = append(statements, prefix+"SET DEFAULT "+expr)
+		}
+	}
+
+	if change.Name != "" && change.Name != name {
+		if err := checkIdentifier("colonne", change.Name); err != nil {
+			return nil, err
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", t.Identifier(), quoteIdent(name), quoteIdent(change.Name)))
+	}
+
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("%w: aucune modification demandée pour la colonne %q", ErrInvalid, name)
+	}
+	return statements, nil
+}
+
+// dropColumnStatement génère la suppression d'une colonne
+func dropColumnStatement(t *Table, name string) (string, error) {
+	if _, ok := t.Column(name); !ok {
+		return "", fmt.Errorf("%w: colonne %q introuvable dans la collection %q", ErrNotFound, name, t.Name)
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Identifier(), quoteIdent(name)), nil
+}
+
+// renameTableStatement génère le renommage d'une collection
+func renameTableStatement(t *Table, name string) (string, error) {
+	if err := checkIdentifier("collection", name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", t.Identifier(), quoteIdent(name)), nil
+}
+
+// dropTableStatement génère la suppression d'une collection
+func dropTableStatement(t *Table) string {
+	return "DROP TABLE " + t.Identifier()
+}
+
+// columnClause génère la définition d'une colonne, contraintes comprises
+func columnClause(t *Table, col ColumnDefinition) (string, error) {
+	if err := checkIdentifier("colonne", col.Name); err != nil {
+		return "", err
+	}
+	columnType, err := normalizeType(col.Type)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(quoteIdent(col.Name) + " " + columnType)
+
+	if col.Identity {
+		if col.Default != nil || col.DefaultFunction != "" {
+			return "", fmt.Errorf("%w: la colonne %q ne peut pas être à la fois identity et avoir une valeur par défaut", ErrInvalid, col.Name)
+		}
+		b.WriteString(" GENERATED BY DEFAULT AS IDENTITY")
+	}
+	if col.NotNull {
+		b.WriteString(" NOT NULL")
+	}
+
+	expr, err := defaultExpr(columnType, col.Default, col.DefaultFunction)
+	if err != nil {
+		return "", err
+	}
+	if expr != "" {
+		b.WriteString(" DEFAULT " + expr)
+	}
+
+	if col.Unique {
+		b.WriteString(" UNIQUE")
+	}
+
+	if ref := col.References; ref != nil {
+		action, ok := foreignKeyActions[strings.ToLower(ref.OnDelete)]
+		if !ok {
+			return "", fmt.Errorf("%w: action on_delete inconnue %q", ErrInvalid, ref.OnDelete)
+		}
+		if ref.Collection == "" || ref.Column == "" {
+			return "", fmt.Errorf("%w: la clé étrangère de %q doit indiquer une collection et une colonne", ErrInvalid, col.Name)
+		}
+		target := pgx.Identifier{DefaultSchema, ref.Collection}.Sanitize()
+		b.WriteString(fmt.Sprintf(" REFERENCES %s (%s)%s", target, quoteIdent(ref.Column), action))
+	}
+
+	if len(col.Check) > 0 {
+		cond, err := compileCheck(t, col.Check)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" CHECK (" + cond + ")")
+	}
+
+	return b.String(), nil
+}
+
+// checkClause génère une contrainte CHECK de table, nommée si un nom est fourni
+func checkClause(t *Table, check CheckDefinition) (string, error) {
+	cond, err := compileCheck(t, check.Filter)
+	if err != nil {
+		return "", err
+	}
+	if check.Name == "" {
+		return "CHECK (" + cond + ")", nil
+	}
+	if err := checkIdentifier("contrainte", check.Name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", quoteIdent(check.Name), cond), nil
+}
+
+// defaultExpr génère la valeur par défaut d'une colonne, littérale ou fonction autorisée
+func defaultExpr(columnType string, value any, function string) (string, error) {
+	if function != "" {
+		if value != nil {
+			return "", fmt.Errorf("%w: default et default_function sont incompatibles", ErrInvalid)
+		}
+		expr, ok := defaultFunctions[function]
+		if !ok {
+			return "", fmt.Errorf("%w: fonction par défaut non autorisée %q", ErrInvalid, function)
+		}
+		return expr, nil
+	}
+	if value == nil {
+		return "", nil
+	}
+
+	if isJSONType(columnType) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: valeur par défaut invalide", ErrInvalid)
+		}
+		return quoteLiteral(string(encoded)) + "::" + columnType, nil
+	}
+
+	text, err := scalarText(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: valeur par défaut: %v", ErrInvalid, err)
+	}
+	return quoteLiteral(text) + "::" + columnType, nil
+}
+
+// checkIdentifier vérifie qu'un nom peut être utilisé comme identifiant PostgreSQL
+func checkIdentifier(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: nom de %s manquant", ErrInvalid, kind)
+	}
+	if len(name) > maxIdentifierLength {
+		return fmt.Errorf("%w: le nom de %s %q dépasse %d caractères", ErrInvalid, kind, name, maxIdentifierLength)
+	}
+	if strings.ContainsRune(name, 0) {
+		return fmt.Errorf("%w: nom de %s invalide", ErrInvalid, kind)
+	}
+	return nil
+}
+
+// normalizeType vérifie qu'un type de colonne ne contient rien d'autre qu'un nom de type
+// et le retourne en minuscules
+func normalizeType(columnType string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(columnType))
+	if !typePattern.MatchString(normalized) {
+		return "", fmt.Errorf("%w: type de colonne invalide %q", ErrInvalid, columnType)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateTableStatement(t *testing.T) {
+	def := CollectionDefinition{
+		Name: "products",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "bigint", Identity: true},
+			{Name: "name", Type: "TEXT", NotNull: true, Unique: true},
+			{Name: "price", Type: "numeric(10, 2)", Default: "0", Check: map[string]any{"price": map[string]any{"$gte": "0"}}},
+			{Name: "owner_id", Type: "uuid", References: &ForeignKey{Collection: "users", Column: "id", OnDelete: "cascade"}},
+			{Name: "created_at", Type: "timestamp with time zone", DefaultFunction: "now"},
+		},
+		PrimaryKey: []string{"id"},
+		Checks:     []CheckDefinition{{Name: "named", Filter: map[string]any{"name": map[string]any{"$ne": "x"}}}},
+	}
+
+	sql, err := createTableStatement(def)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `CREATE TABLE "public"."products" (
+  "id" bigint GENERATED BY DEFAULT AS IDENTITY,
+  "name" text NOT NULL UNIQUE,
+  "price" numeric(10, 2) DEFAULT '0'::numeric(10, 2) CHECK ("price" >= '0'::text::numeric(10, 2)),
+  "owner_id" uuid REFERENCES "public"."users" ("id") ON DELETE CASCADE,
+  "created_at" timestamp with time zone DEFAULT now(),
+  PRIMARY KEY ("id"),
+  CONSTRAINT "named" CHECK ("name" IS DISTINCT FROM 'x'::text::text)
+)`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestCreateTableStatement_Errors(t *testing.T) {
+	cases := map[string]CollectionDefinition{
+		"no columns":         {Name: "t"},
+		"no name":            {Columns: []ColumnDefinition{{Name: "a", Type: "text"}}},
+		"injected type":      {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "text); DROP TABLE users; --"}}},
+		"duplicate column":   {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "text"}, {Name: "a", Type: "int"}}},
+		"unknown key":        {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "text"}}, PrimaryKey: []string{"b"}},
+		"unknown function":   {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "text", DefaultFunction: "pg_sleep"}}},
+		"unknown on delete":  {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "int", References: &ForeignKey{Collection: "u", Column: "id", OnDelete: "drop"}}}},
+		"check unknown col":  {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "int", Check: map[string]any{"b": "1"}}}},
+		"identity default":   {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "int", Identity: true, Default: "1"}}},
+		"long name":          {Name: strings.Repeat("a", 64), Columns: []ColumnDefinition{{Name: "a", Type: "text"}}},
+		"injected check col": {Name: "t", Columns: []ColumnDefinition{{Name: "a", Type: "int", Check: map[string]any{"b": "1"}}, {Name: "b", Type: "int) OR (1=1"}}},
+	}
+
+	for name, def := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := createTableStatement(def); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckConstraint_EscapesLiterals(t *testing.T) {
+	cond, err := compileCheck(postsTable(), map[string]any{
+		"title":      map[string]any{"$nin": []any{"it's", `back\slash`}},
+		"meta->lang": "fr",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `(("meta"::jsonb #> ARRAY['lang']::text[]) = '"fr"'::jsonb AND ` +
+		`NOT COALESCE("title" = ANY(ARRAY['it''s', E'back\\slash']::text[]::text[]), FALSE))`
+	if cond != expected {
+		t.Errorf("unexpected condition:\ngot  %s\nwant %s", cond, expected)
+	}
+}
+
+func TestAlterColumnStatements(t *testing.T) {
+	notNull := true
+	statements, err := alterColumnStatements(postsTable(), "views", ColumnChange{
+		Name:    "view_count",
+		Type:    "integer",
+		NotNull: &notNull,
+		Default: "0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`ALTER TABLE "public"."posts" ALTER COLUMN "views" TYPE integer USING "views"::integer`,
+		`ALTER TABLE "public"."posts" ALTER COLUMN "views" SET NOT NULL`,
+		`ALTER TABLE "public"."posts" ALTER COLUMN "views" SET DEFAULT '0'::integer`,
+		`ALTER TABLE "public"."posts" RENAME COLUMN "views" TO "view_count"`,
+	}
+	if strings.Join(statements, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected statements:\ngot  %s\nwant %s", strings.Join(statements, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if _, err := alterColumnStatements(postsTable(), "nope", ColumnChange{Type: "text"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown column, got %v", err)
+	}
+	if _, err := alterColumnStatements(postsTable(), "views", ColumnChange{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for empty change, got %v", err)
+	}
+}
+
+func TestAddColumnStatement_RejectsExistingColumn(t *testing.T) {
+	if _, err := addColumnStatement(postsTable(), ColumnDefinition{Name: "title", Type: "text"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+}
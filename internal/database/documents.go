@@ -0,0 +1,272 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Limites de pagination des listings de documents
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Les valeurs des documents ne sont jamais interpolées dans le SQL : elles sont
+// transmises en un unique paramètre jsonb et converties vers les types des
+// colonnes par jsonb_populate_record. Seuls les identifiants validés contre le
+// catalogue sont échappés puis insérés dans la requête.
+
+// GetDocument retourne le document identifié par sa clé primaire, avec les documents liés
+// demandés, et la version de sa ligne
+func GetDocument(ctx context.Context, q Querier, t *Table, id string, expand []Expand) (json.RawMessage, string, error) {
+	key, err := t.documentKey()
+	if err != nil {
+		return nil, "", err
+	}
+	expansions, err := resolveExpand(ctx, q, t, expand)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var aliases int
+	projection, args, err := expandExpr(t.documentExpr(), "r", expansions, []any{map[string]any{key: id}}, &aliases)
+	if err != nil {
+		return nil, "", err
+	}
+	where := t.liveCond("r", fmt.Sprintf("r.%[1]s = k.%[1]s", quoteIdent(key)))
+	sql := fmt.Sprintf(`SELECT %[3]s, %[4]s FROM %[1]s AS r, jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS k WHERE %[2]s`,
+		t.Identifier(), where, projection, t.versionExpr("r"))
+
+	var doc json.RawMessage
+	var version string
+	if err := q.QueryRow(ctx, sql, args...).Scan(&doc, &version); err != nil {
+		return nil, "", translateError(err)
+	}
+	return doc, version, nil
+}
+
+// InsertDocuments insère les documents dans une même transaction et retourne les lignes créées
+func InsertDocuments(ctx context.Context, q Querier, t *Table, docs []map[string]any) ([]json.RawMessage, error) {
+	return writeDocuments(ctx, q, t, docs, func(doc map[string]any) (string, error) {
+		return insertStatement(t, doc, "")
+	})
+}
+
+// UpsertDocuments insère les documents ou met à jour ceux dont la clé primaire existe déjà
+func UpsertDocuments(ctx context.Context, q Querier, t *Table, docs []map[string]any) ([]json.RawMessage, error) {
+	if len(t.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%w: la collection %q n'a pas de clé primaire", ErrInvalid, t.Name)
+	}
+
+	return writeDocuments(ctx, q, t, docs, func(doc map[string]any) (string, error) {
+		keys := make([]string, len(t.PrimaryKey))
+		for i, key := range t.PrimaryKey {
+			keys[i] = quoteIdent(key)
+		}
+
+		// Sans colonne hors clé à modifier, on réaffecte la clé pour que RETURNING renvoie la ligne
+		columns, err := t.columnsOf(doc)
+		if err != nil {
+			return "", err
+		}
+		updates := make([]string, 0, len(columns)+1)
+		for _, col := range columns {
+			if !isKey(t, col) {
+				updates = append(updates, fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", quoteIdent(col)))
+			}
+		}
+		if len(updates) == 0 {
+			updates = append(updates, fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", keys[0]))
+		}
+		if bump := t.versionBump(columns); bump != "" {
+			updates = append(updates, bump)
+		}
+
+		conflict := fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(updates, ", "))
+		return insertStatement(t, doc, conflict)
+	})
+}
+
+// UpdateDocument modifie les colonnes fournies du document et retourne la ligne mise à jour
+// et sa nouvelle version. Avec versions non nil, le document n'est modifié que si sa version
+// est l'une d'elles, ErrPreconditionFailed sinon.
+func UpdateDocument(ctx context.Context, q Querier, t *Table, id string, data map[string]any, versions []string) (json.RawMessage, string, error) {
+	key, err := t.documentKey()
+	if err != nil {
+		return nil, "", err
+	}
+	columns, err := t.columnsOf(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(columns) == 0 {
+		return nil, "", fmt.Errorf("%w: aucune colonne à mettre à jour", ErrInvalid)
+	}
+
+	args := []any{data, map[string]any{key: id}}
+	if versions != nil {
+		args = append(args, versions)
+	}
+
+	var doc json.RawMessage
+	var version string
+	if err := q.QueryRow(ctx, updateStatement(t, key, columns, versions != nil), args...).Scan(&doc, &version); err != nil {
+		if versions != nil && errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", versionMismatch(ctx, q, t, key, id)
+		}
+		return nil, "", translateError(err)
+	}
+	return doc, version, nil
+}
+
+// updateStatement construit la modification d'un document, dont les valeurs sont passées en
+// $1 et la clé en $2 ; une modification conditionnelle compare sa version à celles passées en $3
+func updateStatement(t *Table, key string, columns []string, conditional bool) string {
+	assignments := make([]string, 0, len(columns)+1)
+	for _, col := range columns {
+		assignments = append(assignments, fmt.Sprintf("%[1]s = p.%[1]s", quoteIdent(col)))
+	}
+	if bump := t.versionBump(columns); bump != "" {
+		assignments = append(assignments, bump)
+	}
+
+	where := t.liveCond("r", fmt.Sprintf("r.%[1]s = k.%[1]s", quoteIdent(key)))
+	if conditional {
+		where += fmt.Sprintf(" AND %s = ANY($3::text[])", t.versionExpr("r"))
+	}
+
+	return fmt.Sprintf(`UPDATE %[1]s AS r SET %[2]s FROM jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS p, jsonb_populate_record(NULL::%[1]s, $2::jsonb) AS k WHERE %[3]s RETURNING %[4]s, %[5]s`,
+		t.Identifier(), strings.Join(assignments, ", "), where, t.documentExpr(), t.versionExpr("r"))
+}
+
+// DeleteDocument supprime le document identifié par sa clé primaire, ou le place dans la
+// corbeille de la collection. Avec versions non nil, le document n'est supprimé que si sa
+// version est l'une d'elles, ErrPreconditionFailed sinon.
+func DeleteDocument(ctx context.Context, q Querier, t *Table, id string, versions []string) error {
+	if versions == nil {
+		deleted, err := DeleteDocuments(ctx, q, t, []string{id})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return fmt.Errorf("%w: document introuvable", ErrNotFound)
+		}
+		return nil
+	}
+
+	key, err := t.documentKey()
+	if err != nil {
+		return err
+	}
+	using := fmt.Sprintf("jsonb_populate_record(NULL::%s, $1::jsonb) AS k", t.Identifier())
+	where := fmt.Sprintf("r.%[1]s = k.%[1]s AND %[2]s = ANY($2::text[])", quoteIdent(key), t.versionExpr("r"))
+	tag, err := q.Exec(ctx, deleteStatement(t, using, where), map[string]any{key: id}, versions)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return versionMismatch(ctx, q, t, key, id)
+	}
+	return nil
+}
+
+// DeleteDocuments supprime les documents dont la clé primaire figure dans ids, ou les place
+// dans la corbeille de la collection
+func DeleteDocuments(ctx context.Context, q Querier, t *Table, ids []string) (int64, error) {
+	key, err := t.documentKey()
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		keys[i] = map[string]any{key: id}
+	}
+
+	using := fmt.Sprintf("jsonb_populate_recordset(NULL::%s, $1::jsonb) AS k", t.Identifier())
+	tag, err := q.Exec(ctx, deleteStatement(t, using, fmt.Sprintf("r.%[1]s = k.%[1]s", quoteIdent(key))), keys)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// writeDocuments exécute une instruction d'écriture par document dans une même transaction
+func writeDocuments(ctx context.Context, q Querier, t *Table, docs []map[string]any, statement func(map[string]any) (string, error)) ([]json.RawMessage, error) {
+	results := make([]json.RawMessage, 0, len(docs))
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		for i, doc := range docs {
+			sql, err := statement(doc)
+			if err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+
+			var row json.RawMessage
+			if err := tx.QueryRow(ctx, sql, doc).Scan(&row); err != nil {
+				return fmt.Errorf("document %d: %w", i, translateError(err))
+			}
+			results = append(results, row)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// insertStatement construit l'INSERT d'un document dont les valeurs sont passées en $1.
+// Le WHERE true lève l'ambiguïté entre la clause FROM et un éventuel ON CONFLICT.
+func insertStatement(t *Table, doc map[string]any, suffix string) (string, error) {
+	columns, err := t.columnsOf(doc)
+	if err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return fmt.Sprintf(`INSERT INTO %s AS r DEFAULT VALUES%s RETURNING %s`, t.Identifier(), suffix, t.documentExpr()), nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+	}
+	list := strings.Join(quoted, ", ")
+
+	return fmt.Sprintf(`INSERT INTO %[1]s AS r (%[2]s) SELECT %[2]s FROM jsonb_populate_record(NULL::%[1]s, $1::jsonb) WHERE true%[3]s RETURNING %[4]s`,
+		t.Identifier(), list, suffix, t.documentExpr()), nil
+}
+
+// collectDocuments lit une colonne jsonb par ligne
+func collectDocuments(rows pgx.Rows, err error) ([]json.RawMessage, error) {
+	if err != nil {
+		return nil, translateError(err)
+	}
+	docs, err := pgx.CollectRows(rows, pgx.RowTo[json.RawMessage])
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return docs, nil
+}
+
+// clampLimit ramène une limite demandée dans les bornes autorisées
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	return min(limit, MaxLimit)
+}
+
+// isKey indique si la colonne fait partie de la clé primaire
+func isKey(t *Table, column string) bool {
+	for _, key := range t.PrimaryKey {
+		if key == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// usersTable est une collection de test avec une clé primaire simple
+func usersTable() *Table {
+	return &Table{
+		Schema: DefaultSchema,
+		Name:   "users",
+		Columns: []Column{
+			{Name: "id", Type: "integer", HasDefault: true},
+			{Name: "email", Type: "text"},
+			{Name: "name", Type: "text", Nullable: true},
+		},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+func TestInsertStatement_OrdersColumnsLikeTable(t *testing.T) {
+	sql, err := insertStatement(usersTable(), map[string]any{"name": "John", "email": "john@example.com"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `INSERT INTO "public"."users" AS r ("email", "name") SELECT "email", "name" FROM jsonb_populate_record(NULL::"public"."users", $1::jsonb) WHERE true RETURNING to_jsonb(r.*)`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestInsertStatement_EmptyDocumentUsesDefaults(t *testing.T) {
+	sql, err := insertStatement(usersTable(), map[string]any{}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(sql, "DEFAULT VALUES") {
+		t.Errorf("expected DEFAULT VALUES insert, got %s", sql)
+	}
+}
+
+func TestInsertStatement_RejectsUnknownColumn(t *testing.T) {
+	_, err := insertStatement(usersTable(), map[string]any{`email"; DROP TABLE users; --`: "x"}, "")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestDocumentKey_RequiresSingleColumnPrimaryKey(t *testing.T) {
+	table := usersTable()
+	table.PrimaryKey = []string{"id", "email"}
+
+	if _, err := table.documentKey(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for composite key, got %v", err)
+	}
+
+	table.PrimaryKey = nil
+	if _, err := table.documentKey(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid without primary key, got %v", err)
+	}
+}
+
+func TestTableIdentifier_EscapesQuotes(t *testing.T) {
+	table := &Table{Schema: DefaultSchema, Name: `we"ird`}
+
+	expected := `"public"."we""ird"`
+	if got := table.Identifier(); got != expected {
+		t.Errorf("unexpected identifier: got %s want %s", got, expected)
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	cases := map[int]int{0: DefaultLimit, -5: DefaultLimit, 10: 10, MaxLimit + 1: MaxLimit}
+	for limit, expected := range cases {
+		if got := clampLimit(limit); got != expected {
+			t.Errorf("clampLimit(%d) = %d, want %d", limit, got, expected)
+		}
+	}
+}
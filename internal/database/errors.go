@@ -0,0 +1,69 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Erreurs renvoyées par la couche d'accès aux données
+var (
+	ErrNotFound  = errors.New("ressource introuvable")
+	ErrConflict  = errors.New("conflit avec une donnée existante")
+	ErrInvalid   = errors.New("données invalides")
+	ErrForbidden = errors.New("accès refusé")
+
+	ErrPreconditionFailed = errors.New("version du document différente de celle attendue")
+
+	ErrTooManyTransactions = errors.New("trop de transactions ouvertes")
+	ErrTimeout             = errors.New("délai d'exécution dépassé")
+)
+
+// translateError convertit une erreur PostgreSQL en erreur de la couche d'accès aux données
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: document introuvable", ErrNotFound)
+	}
+	if errors.Is(err, pgx.ErrTxCommitRollback) {
+		return fmt.Errorf("%w: la transaction a été annulée suite à une erreur", ErrConflict)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch {
+	case pgErr.Code == "42501", // insufficient_privilege, y compris les refus des politiques RLS
+		pgErr.Code == "25006": // read_only_sql_transaction
+		return fmt.Errorf("%w: %s", ErrForbidden, pgErr.Message)
+	case pgErr.Code == "57014": // query_canceled, dont le dépassement de statement_timeout
+		return fmt.Errorf("%w: %s", ErrTimeout, pgErr.Message)
+	case pgErr.Code == "23505", // unique_violation
+		pgErr.Code == "25P02", // in_failed_sql_transaction
+		pgErr.Code == "42P07", // duplicate_table
+		pgErr.Code == "42701", // duplicate_column
+		pgErr.Code == "42710", // duplicate_object
+		pgErr.Code == "2BP01", // dependent_objects_still_exist
+		pgErr.Code == "21000": // cardinality_violation, ex: ON CONFLICT DO UPDATE sur deux lignes de même clé
+		return fmt.Errorf("%w: %s", ErrConflict, pgErr.Message)
+	case strings.HasPrefix(pgErr.Code, "22"), // data_exception
+		strings.HasPrefix(pgErr.Code, "23"), // integrity_constraint_violation
+		pgErr.Code == "42703",               // undefined_column
+		pgErr.Code == "42704",               // undefined_object (type inconnu)
+		pgErr.Code == "42P01",               // undefined_table (clé étrangère)
+		pgErr.Code == "42804",               // datatype_mismatch
+		pgErr.Code == "42830",               // invalid_foreign_key
+		pgErr.Code == "42P16",               // invalid_table_definition
+		pgErr.Code == "42601":               // syntax_error
+		return fmt.Errorf("%w: %s", ErrInvalid, pgErr.Message)
+	}
+
+	return err
+}
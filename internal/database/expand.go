@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Limites de l'inclusion des documents liés
+const (
+	MaxExpandDepth     = 3  // Niveaux d'imbrication des relations incluses
+	MaxExpandRelations = 10 // Relations incluses dans une même lecture
+)
+
+// Expand décrit une relation à inclure dans les documents lus, en suivant une clé étrangère.
+// Une relation sortante (la collection référence l'autre) donne un document ou null, une
+// relation entrante (l'autre collection la référence) un tableau de documents.
+type Expand struct {
+	Relation string         // Colonne portant la clé étrangère, nom de la contrainte ou collection liée
+	As       string         // Clé du document recevant les documents liés, Relation par défaut
+	Select   []string       // Colonnes des documents liés, toutes si vide
+	Filter   map[string]any // Documents liés retenus
+	Sort     []string       // Ordre des documents d'une relation entrante, clé primaire par défaut
+	Limit    int            // Documents d'une relation entrante, DefaultLimit par défaut et MaxLimit au plus
+	Expand   []Expand       // Relations des documents liés
+}
+
+// expansion est une relation à inclure, résolue contre le catalogue
+type expansion struct {
+	key      string
+	relation Relation
+	table    *Table // Collection liée
+	expand   Expand
+	children []expansion
+}
+
+// expandResolver résout les relations demandées en gardant les collections déjà lues
+type expandResolver struct {
+	q         Querier
+	tables    map[string]*Table
+	relations map[string][]Relation
+	count     int
+}
+
+// resolveExpand valide les relations demandées et lit les collections qu'elles relient
+func resolveExpand(ctx context.Context, q Querier, t *Table, expands []Expand) ([]expansion, error) {
+	if len(expands) == 0 {
+		return nil, nil
+	}
+	resolver := &expandResolver{
+		q:         q,
+		tables:    map[string]*Table{t.Name: t},
+		relations: map[string][]Relation{},
+	}
+	return resolver.resolve(ctx, t, expands, 1)
+}
+
+func (r *expandResolver) resolve(ctx context.Context, t *Table, expands []Expand, depth int) ([]expansion, error) {
+	if len(expands) == 0 {
+		return nil, nil
+	}
+	if depth > MaxExpandDepth {
+		return nil, fmt.Errorf("%w: relations incluses trop profondes (maximum %d niveaux)", ErrInvalid, MaxExpandDepth)
+	}
+
+	relations, ok := r.relations[t.Name]
+	if !ok {
+		var err error
+		if relations, err = loadRelations(ctx, r.q, t); err != nil {
+			return nil, err
+		}
+		r.relations[t.Name] = relations
+	}
+
+	expansions := make([]expansion, 0, len(expands))
+	keys := make(map[string]bool)
+	for _, exp := range expands {
+		r.count++
+		if r.count > MaxExpandRelations {
+			return nil, fmt.Errorf("%w: trop de relations incluses (maximum %d)", ErrInvalid, MaxExpandRelations)
+		}
+
+		relation, err := findRelation(t, relations, exp.Relation)
+		if err != nil {
+			return nil, err
+		}
+		if relation.Direction == RelationOutgoing && (len(exp.Sort) > 0 || exp.Limit != 0) {
+			return nil, fmt.Errorf("%w: la relation %q désigne un seul document, sort et limit ne s'y appliquent pas", ErrInvalid, exp.Relation)
+		}
+		key := exp.As
+		if key == "" {
+			key = exp.Relation
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("%w: relation incluse deux fois sous la clé %q", ErrInvalid, key)
+		}
+		keys[key] = true
+
+		table, ok := r.tables[relation.ForeignCollection]
+		if !ok {
+			if table, err = LoadTable(ctx, r.q, relation.ForeignCollection); err != nil {
+				return nil, err
+			}
+			r.tables[table.Name] = table
+		}
+		children, err := r.resolve(ctx, table, exp.Expand, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		expansions = append(expansions, expansion{key: key, relation: relation, table: table, expand: exp, children: children})
+	}
+	return expansions, nil
+}
+
+// loadRelations lit les clés étrangères reliant la collection aux autres collections du schéma.
+// Une clé étrangère de la collection vers elle-même donne une relation dans chaque sens.
+func loadRelations(ctx context.Context, q Querier, t *Table) ([]Relation, error) {
+	rows, err := q.Query(ctx, `
+		SELECT con.conname,
+		       c.relname::text,
+		       ARRAY(SELECT a.attname::text FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord),
+		       fc.relname::text,
+		       ARRAY(SELECT a.attname::text FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord),
+		       con.confdeltype::text
+		FROM pg_catalog.pg_class t
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_catalog.pg_constraint con
+		  ON con.contype = 'f' AND (con.conrelid = t.oid OR con.confrelid = t.oid)
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid AND c.relnamespace = n.oid
+		JOIN pg_catalog.pg_class fc ON fc.oid = con.confrelid AND fc.relnamespace = n.oid
+		WHERE n.nspname = $1 AND t.relname = $2
+		ORDER BY con.conname`, t.Schema, t.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var relations []Relation
+	for rows.Next() {
+		var name, table, foreignTable, onDelete string
+		var columns, foreignColumns []string
+		if err := rows.Scan(&name, &table, &columns, &foreignTable, &foreignColumns, &onDelete); err != nil {
+			return nil, translateError(err)
+		}
+		if table == t.Name {
+			relations = append(relations, Relation{
+				Name:              name,
+				Direction:         RelationOutgoing,
+				Columns:           columns,
+				ForeignCollection: foreignTable,
+				ForeignColumns:    foreignColumns,
+				OnDelete:          foreignKeyActionNames[onDelete],
+			})
+		}
+		if foreignTable == t.Name {
+			relations = append(relations, Relation{
+				Name:              name,
+				Direction:         RelationIncoming,
+				Columns:           foreignColumns,
+				ForeignCollection: table,
+				ForeignColumns:    columns,
+				OnDelete:          foreignKeyActionNames[onDelete],
+			})
+		}
+	}
+	return relations, translateError(rows.Err())
+}
+
+// findRelation retrouve une relation par la colonne portant la clé étrangère, le nom de la
+// contrainte ou la collection liée, dans cet ordre. Pour une clé étrangère de la collection
+// vers elle-même, le nom de la contrainte désigne les documents qui la référencent.
+func findRelation(t *Table, relations []Relation, name string) (Relation, error) {
+	matchers := []func(Relation) bool{
+		func(r Relation) bool {
+			return r.Direction == RelationOutgoing && len(r.Columns) == 1 && r.Columns[0] == name
+		},
+		func(r Relation) bool {
+			return r.Name == name && (r.Direction == RelationIncoming || r.ForeignCollection != t.Name)
+		},
+		func(r Relation) bool {
+			return r.ForeignCollection == name
+		},
+	}
+
+	for _, match := range matchers {
+		var found []Relation
+		for _, relation := range relations {
+			if match(relation) {
+				found = append(found, relation)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			names := make([]string, len(found))
+			for i, relation := range found {
+				names[i] = relation.Name
+			}
+			return Relation{}, fmt.Errorf("%w: plusieurs clés étrangères relient la collection %q à %q, préciser la contrainte (%s)",
+				ErrInvalid, t.Name, name, strings.Join(names, ", "))
+		}
+	}
+	return Relation{}, fmt.Errorf("%w: aucune clé étrangère ne relie la collection %q à %q", ErrInvalid, t.Name, name)
+}
+
+// expandExpr ajoute à l'expression jsonb doc, document de l'alias parent, les documents liés
+// lus par des sous-requêtes corrélées. Les valeurs des filtres et des limites sont ajoutées à
+// args ; aliases numérote les alias des collections liées.
+func expandExpr(doc, parent string, expansions []expansion, args []any, aliases *int) (string, []any, error) {
+	if len(expansions) == 0 {
+		return doc, args, nil
+	}
+
+	fields := make([]string, 0, 2*len(expansions))
+	for _, e := range expansions {
+		*aliases++
+		alias := fmt.Sprintf("x%d", *aliases)
+
+		child, err := projectionOf(e.table, alias, e.expand.Select)
+		if err != nil {
+			return "", nil, err
+		}
+		if child, args, err = expandExpr(child, alias, e.children, args, aliases); err != nil {
+			return "", nil, err
+		}
+
+		c := &filterCompiler{table: e.table, args: args, alias: alias}
+		cond, err := c.object(e.expand.Filter, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		args = c.args
+
+		joins := make([]string, len(e.relation.Columns))
+		for i, column := range e.relation.Columns {
+			joins[i] = fmt.Sprintf("%s.%s = %s.%s", alias, quoteIdent(e.relation.ForeignColumns[i]), parent, quoteIdent(column))
+		}
+		where := e.table.liveCond(alias, strings.Join(append(joins, cond), " AND "))
+
+		var sub string
+		if e.relation.Direction == RelationOutgoing {
+			sub = fmt.Sprintf("(SELECT %s FROM %s AS %s WHERE %s)", child, e.table.Identifier(), alias, where)
+		} else {
+			// ARRAY() conserve l'ordre de la sous-requête, contrairement à jsonb_agg sans ORDER BY
+			terms, err := sortTerms(e.table, e.expand.Sort)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, clampLimit(e.expand.Limit))
+			sub = fmt.Sprintf("to_jsonb(ARRAY(SELECT %s FROM %s AS %s WHERE %s%s LIMIT $%d))",
+				child, e.table.Identifier(), alias, where, orderByOf(alias, terms), len(args))
+		}
+		fields = append(fields, quoteLiteral(e.key), sub)
+	}
+	return fmt.Sprintf("(%s || jsonb_build_object(%s))", doc, strings.Join(fields, ", ")), args, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func commentsTable() *Table {
+	return &Table{
+		Schema: DefaultSchema,
+		Name:   "comments",
+		Columns: []Column{
+			{Name: "id", Type: "integer"},
+			{Name: "post_id", Type: "integer"},
+			{Name: "author_id", Type: "integer"},
+			{Name: "body", Type: "text"},
+			{Name: "approved", Type: "boolean"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+// usersRelations sont les relations de users : ses employés et son responsable (clé
+// étrangère vers elle-même), ainsi que les commentaires dont l'utilisateur est l'auteur
+// ou le modérateur
+func usersRelations() []Relation {
+	return []Relation{
+		{Name: "comments_author_id_fkey", Direction: RelationIncoming, Columns: []string{"id"}, ForeignCollection: "comments", ForeignColumns: []string{"author_id"}},
+		{Name: "comments_moderator_id_fkey", Direction: RelationIncoming, Columns: []string{"id"}, ForeignCollection: "comments", ForeignColumns: []string{"moderator_id"}},
+		{Name: "users_manager_id_fkey", Direction: RelationOutgoing, Columns: []string{"manager_id"}, ForeignCollection: "users", ForeignColumns: []string{"id"}},
+		{Name: "users_manager_id_fkey", Direction: RelationIncoming, Columns: []string{"id"}, ForeignCollection: "users", ForeignColumns: []string{"manager_id"}},
+	}
+}
+
+func TestFindRelation(t *testing.T) {
+	relations := usersRelations()
+	cases := map[string]Relation{
+		"manager_id":                 relations[2],
+		"users_manager_id_fkey":      relations[3],
+		"comments_moderator_id_fkey": relations[1],
+	}
+	for name, want := range cases {
+		got, err := findRelation(usersTable(), relations, name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestFindRelation_Invalid(t *testing.T) {
+	for _, name := range []string{"comments", "users", "posts", "name"} {
+		if _, err := findRelation(usersTable(), usersRelations(), name); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestExpandExpr(t *testing.T) {
+	expansions := []expansion{
+		{
+			key:      "author",
+			relation: Relation{Direction: RelationOutgoing, Columns: []string{"author_id"}, ForeignCollection: "users", ForeignColumns: []string{"id"}},
+			table:    usersTable(),
+			expand:   Expand{Relation: "author_id", As: "author", Select: []string{"id", "name"}},
+		},
+		{
+			key:      "comments",
+			relation: Relation{Direction: RelationIncoming, Columns: []string{"id"}, ForeignCollection: "comments", ForeignColumns: []string{"post_id"}},
+			table:    commentsTable(),
+			expand: Expand{
+				Relation: "comments",
+				Filter:   map[string]any{"approved": "true"},
+				Sort:     []string{"-id"},
+				Limit:    5,
+			},
+			children: []expansion{{
+				key:      "author_id",
+				relation: Relation{Direction: RelationOutgoing, Columns: []string{"author_id"}, ForeignCollection: "users", ForeignColumns: []string{"id"}},
+				table:    usersTable(),
+				expand:   Expand{Relation: "author_id"},
+			}},
+		},
+	}
+
+	var aliases int
+	expr, args, err := expandExpr("to_jsonb(r.*)", "r", expansions, []any{"10"}, &aliases)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `(to_jsonb(r.*) || jsonb_build_object(` +
+		`'author', (SELECT (SELECT to_jsonb(s.*) FROM (SELECT x1."id", x1."name") AS s) FROM "public"."users" AS x1 WHERE x1."id" = r."author_id" AND TRUE), ` +
+		`'comments', to_jsonb(ARRAY(SELECT (to_jsonb(x2.*) || jsonb_build_object(` +
+		`'author_id', (SELECT to_jsonb(x3.*) FROM "public"."users" AS x3 WHERE x3."id" = x2."author_id" AND TRUE)))` +
+		` FROM "public"."comments" AS x2 WHERE x2."post_id" = r."id" AND x2."approved" = $2::text::boolean ORDER BY x2."id" DESC LIMIT $3))))`
+	if expr != expected {
+		t.Errorf("unexpected expression:\ngot  %s\nwant %s", expr, expected)
+	}
+	if want := []any{"10", "true", 5}; !reflect.DeepEqual(args, want) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+	if aliases != 3 {
+		t.Errorf("expected 3 aliases, got %d", aliases)
+	}
+}
+
+func TestExpandExpr_Invalid(t *testing.T) {
+	relation := Relation{Direction: RelationIncoming, Columns: []string{"id"}, ForeignCollection: "comments", ForeignColumns: []string{"post_id"}}
+	cases := map[string]Expand{
+		"select": {Relation: "comments", Select: []string{"title"}},
+		"filter": {Relation: "comments", Filter: map[string]any{"title": "x"}},
+		"sort":   {Relation: "comments", Sort: []string{"title"}},
+	}
+	for name, exp := range cases {
+		var aliases int
+		expansions := []expansion{{key: "comments", relation: relation, table: commentsTable(), expand: exp}}
+		if _, _, err := expandExpr("to_jsonb(r.*)", "r", expansions, nil, &aliases); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestResolveExpand_Empty(t *testing.T) {
+	expansions, err := resolveExpand(context.Background(), nil, postsTable(), nil)
+	if err != nil || expansions != nil {
+		t.Errorf("expected no expansion, got %v, %v", expansions, err)
+	}
+}
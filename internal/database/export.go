@@ -0,0 +1,312 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ketsuna-org/sovrabase/internal/parquet"
+)
+
+// Formats des imports et exports en masse
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// Export décrit un export en masse des documents d'une collection
+type Export struct {
+	Format string // FormatCSV, FormatNDJSON ou FormatParquet
+	Filter map[string]any
+	Select []string // Colonnes exportées, toutes si vide
+	Sort   []string // Clé primaire par défaut
+	Limit  int      // Nombre maximal de documents, tous si 0
+
+	Masking *Masking // Masquage des colonnes exportées, aucun si nil
+}
+
+// exportColumn décrit une colonne d'un export CSV ou Parquet
+type exportColumn struct {
+	name    string
+	expr    string // Expression sélectionnée, lue au format texte
+	parquet parquet.Column
+	convert func(text string) (any, error) // Valeur Parquet à partir du texte
+}
+
+// ExportDocuments écrit dans w les documents correspondant à l'export, au fil de leur lecture,
+// et retourne leur nombre. Rien n'est écrit dans w si la requête échoue avant le premier document.
+func ExportDocuments(ctx context.Context, q Querier, t *Table, exp Export, w io.Writer) (int64, error) {
+	statement, args, columns, err := exportStatement(t, exp)
+	if err != nil {
+		return 0, err
+	}
+
+	// Le format texte donne les valeurs telles que PostgreSQL les écrit, quel que soit leur type
+	rows, err := q.Query(ctx, statement, append([]any{pgx.QueryResultFormats{pgx.TextFormatCode}}, args...)...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer rows.Close()
+
+	var encoder exportEncoder
+	var count int64
+	for rows.Next() {
+		if encoder == nil {
+			if encoder, err = newExportEncoder(exp.Format, columns, w); err != nil {
+				return 0, err
+			}
+		}
+		if err := encoder.row(rows.RawValues()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, translateError(err)
+	}
+	if encoder == nil {
+		if encoder, err = newExportEncoder(exp.Format, columns, w); err != nil {
+			return 0, err
+		}
+	}
+	return count, encoder.close()
+}
+
+// exportStatement génère la lecture des documents exportés et décrit ses colonnes
+func exportStatement(t *Table, exp Export) (string, []any, []exportColumn, error) {
+	if exp.Format != FormatCSV && exp.Format != FormatNDJSON && exp.Format != FormatParquet {
+		return "", nil, nil, fmt.Errorf("%w: format d'export inconnu %q (csv, ndjson ou parquet)", ErrInvalid, exp.Format)
+	}
+	terms, err := sortTerms(t, exp.Sort)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cond, args, err := CompileFilter(t, exp.Filter, nil)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cond = t.liveCond("r", cond)
+
+	// Les filtres et le tri portent sur les valeurs masquées : ils ne révèlent rien de plus que l'export
+	source := t.Identifier()
+	if exp.Masking != nil {
+		if source, err = exp.Masking.source(t); err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	var selects []string
+	var columns []exportColumn
+	if exp.Format == FormatNDJSON {
+		projection, err := projectionExpr(t, exp.Select)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		selects = []string{projection}
+	} else {
+		names := exp.Select
+		if len(names) == 0 {
+			for _, col := range t.Columns {
+				if col.Name != SearchColumn || !t.hasSearchColumn() {
+					names = append(names, col.Name)
+				}
+			}
+		}
+		for _, name := range names {
+			col, ok := t.Column(name)
+			if !ok {
+				return "", nil, nil, fmt.Errorf("%w: sélection d'une colonne inconnue %q", ErrInvalid, name)
+			}
+			column := newExportColumn(col)
+			columns = append(columns, column)
+			selects = append(selects, column.expr)
+		}
+	}
+
+	statement := fmt.Sprintf(`SELECT %s FROM %s AS r WHERE %s%s`, strings.Join(selects, ", "), source, cond, orderBy(terms))
+	if exp.Limit > 0 {
+		args = append(args, exp.Limit)
+		statement += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return statement, args, columns, nil
+}
+
+// newExportColumn associe une colonne à son type Parquet. Les dates et horodatages sont lus
+// en jours et microsecondes depuis l'epoch ; les valeurs infinies sont exportées nulles.
+// Les types sans équivalent (numeric, uuid, tableaux, vecteurs…) sont exportés en texte.
+func newExportColumn(col Column) exportColumn {
+	ref := "r." + quoteIdent(col.Name)
+	column := exportColumn{name: col.Name, expr: ref, parquet: parquet.Column{Name: col.Name, Type: parquet.ByteArray, Logical: parquet.String}}
+	if strings.HasSuffix(col.Type, "[]") {
+		return column
+	}
+
+	switch baseType(col.Type) {
+	case "boolean":
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Boolean}
+		column.convert = func(text string) (any, error) { return text == "t", nil }
+	case "smallint", "integer":
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Int32}
+		column.convert = func(text string) (any, error) {
+			v, err := strconv.ParseInt(text, 10, 32)
+			return int32(v), err
+		}
+	case "bigint":
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Int64}
+		column.convert = parseInt64
+	case "real":
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Float}
+		column.convert = func(text string) (any, error) {
+			v, err := strconv.ParseFloat(text, 32)
+			return float32(v), err
+		}
+	case "double precision":
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Double}
+		column.convert = func(text string) (any, error) { return strconv.ParseFloat(text, 64) }
+	case "date":
+		column.expr = fmt.Sprintf("CASE WHEN isfinite(%[1]s) THEN %[1]s - DATE '1970-01-01' END", ref)
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Int32, Logical: parquet.Date}
+		column.convert = func(text string) (any, error) {
+			v, err := strconv.ParseInt(text, 10, 32)
+			return int32(v), err
+		}
+	case "timestamp with time zone", "timestamp without time zone":
+		column.expr = fmt.Sprintf("CASE WHEN isfinite(%[1]s) THEN (extract(epoch FROM %[1]s) * 1000000)::int8 END", ref)
+		column.parquet = parquet.Column{Name: col.Name, Type: parquet.Int64, Logical: parquet.Timestamp}
+		if strings.Contains(col.Type, "with time zone") {
+			column.parquet.Logical = parquet.TimestampUTC
+		}
+		column.convert = parseInt64
+	case "json", "jsonb":
+		column.parquet.Logical = parquet.JSON
+	}
+	return column
+}
+
+func parseInt64(text string) (any, error) {
+	return strconv.ParseInt(text, 10, 64)
+}
+
+// baseType retire le modificateur d'un type, ex: "timestamp(3) with time zone" devient
+// "timestamp with time zone" et "numeric(10,2)" devient "numeric"
+func baseType(columnType string) string {
+	open := strings.Index(columnType, "(")
+	if open < 0 {
+		return columnType
+	}
+	end := strings.Index(columnType[open:], ")")
+	if end < 0 {
+		return columnType
+	}
+	return columnType[:open] + columnType[open+end+1:]
+}
+
+// exportEncoder écrit les lignes d'un export dans un format de fichier
+type exportEncoder interface {
+	row(values [][]byte) error
+	close() error
+}
+
+// newExportEncoder commence l'écriture d'un export
+func newExportEncoder(format string, columns []exportColumn, w io.Writer) (exportEncoder, error) {
+	buffered := bufio.NewWriterSize(w, 64<<10)
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonEncoder{w: buffered}, nil
+	case FormatCSV:
+		encoder := &csvEncoder{w: buffered, csv: csv.NewWriter(buffered), record: make([]string, len(columns))}
+		for i, col := range columns {
+			encoder.record[i] = col.name
+		}
+		return encoder, encoder.csv.Write(encoder.record)
+	default:
+		encoder := &parquetEncoder{w: buffered, columns: columns, values: make([]any, len(columns))}
+		definitions := make([]parquet.Column, len(columns))
+		for i, col := range columns {
+			definitions[i] = col.parquet
+		}
+		var err error
+		encoder.parquet, err = parquet.NewWriter(buffered, definitions)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return encoder, nil
+	}
+}
+
+// ndjsonEncoder écrit un document JSON par ligne
+type ndjsonEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *ndjsonEncoder) row(values [][]byte) error {
+	e.w.Write(values[0])
+	return e.w.WriteByte('\n')
+}
+
+func (e *ndjsonEncoder) close() error {
+	return e.w.Flush()
+}
+
+// csvEncoder écrit une ligne d'en-tête puis les valeurs au format texte de PostgreSQL ;
+// NULL est un champ vide
+type csvEncoder struct {
+	w      *bufio.Writer
+	csv    *csv.Writer
+	record []string
+}
+
+func (e *csvEncoder) row(values [][]byte) error {
+	for i, value := range values {
+		e.record[i] = string(value)
+	}
+	return e.csv.Write(e.record)
+}
+
+func (e *csvEncoder) close() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// parquetEncoder convertit les valeurs dans le type Parquet de leur colonne
+type parquetEncoder struct {
+	w       *bufio.Writer
+	parquet *parquet.Writer
+	columns []exportColumn
+	values  []any
+}
+
+func (e *parquetEncoder) row(values [][]byte) error {
+	for i, value := range values {
+		col := e.columns[i]
+		switch {
+		case value == nil:
+			e.values[i] = nil
+		case col.convert == nil:
+			e.values[i] = value // Copié par le Writer avant la lecture de la ligne suivante
+		default:
+			converted, err := col.convert(string(value))
+			if err != nil {
+				return fmt.Errorf("colonne %q: valeur %q illisible: %w", col.name, value, err)
+			}
+			e.values[i] = converted
+		}
+	}
+	return e.parquet.Write(e.values)
+}
+
+func (e *parquetEncoder) close() error {
+	if err := e.parquet.Close(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
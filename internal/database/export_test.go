@@ -0,0 +1,153 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ketsuna-org/sovrabase/internal/parquet"
+)
+
+func TestExportStatement(t *testing.T) {
+	exp := Export{
+		Format: FormatCSV,
+		Filter: map[string]any{"views": map[string]any{"$gt": "10"}},
+		Select: []string{"id", "title", "meta"},
+		Sort:   []string{"-views"},
+		Limit:  100,
+	}
+	sql, args, columns, err := exportStatement(postsTable(), exp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT r."id", r."title", r."meta" FROM "public"."posts" AS r WHERE r."views" > $1::text::bigint ORDER BY r."views" DESC, r."id" ASC LIMIT $2`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+	if !reflect.DeepEqual(args, []any{"10", 100}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if len(columns) != 3 || columns[2].parquet.Logical != parquet.JSON {
+		t.Errorf("unexpected columns: %+v", columns)
+	}
+
+	exp = Export{Format: FormatNDJSON, Select: []string{"id"}}
+	sql, _, columns, err = exportStatement(postsTable(), exp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `SELECT (SELECT to_jsonb(s.*) FROM (SELECT r."id") AS s) FROM "public"."posts" AS r WHERE TRUE ORDER BY r."id" ASC`
+	if sql != expected || columns != nil {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestExportStatement_Invalid(t *testing.T) {
+	cases := map[string]Export{
+		"format": {Format: "xlsx"},
+		"select": {Format: FormatCSV, Select: []string{"author"}},
+		"sort":   {Format: FormatParquet, Sort: []string{"author"}},
+		"filter": {Format: FormatNDJSON, Filter: map[string]any{"author": "x"}},
+	}
+	for name, exp := range cases {
+		if _, _, _, err := exportStatement(postsTable(), exp); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestNewExportColumn(t *testing.T) {
+	cases := []struct {
+		column  Column
+		expr    string
+		typ     parquet.Type
+		logical parquet.Logical
+	}{
+		{Column{Name: "a", Type: "boolean"}, `r."a"`, parquet.Boolean, parquet.None},
+		{Column{Name: "a", Type: "smallint"}, `r."a"`, parquet.Int32, parquet.None},
+		{Column{Name: "a", Type: "bigint"}, `r."a"`, parquet.Int64, parquet.None},
+		{Column{Name: "a", Type: "double precision"}, `r."a"`, parquet.Double, parquet.None},
+		{Column{Name: "a", Type: "numeric(10,2)"}, `r."a"`, parquet.ByteArray, parquet.String},
+		{Column{Name: "a", Type: "integer[]"}, `r."a"`, parquet.ByteArray, parquet.String},
+		{Column{Name: "a", Type: "jsonb"}, `r."a"`, parquet.ByteArray, parquet.JSON},
+		{Column{Name: "a", Type: "date"}, `CASE WHEN isfinite(r."a") THEN r."a" - DATE '1970-01-01' END`, parquet.Int32, parquet.Date},
+		{Column{Name: "a", Type: "timestamp(3) with time zone"}, `CASE WHEN isfinite(r."a") THEN (extract(epoch FROM r."a") * 1000000)::int8 END`, parquet.Int64, parquet.TimestampUTC},
+		{Column{Name: "a", Type: "timestamp without time zone"}, `CASE WHEN isfinite(r."a") THEN (extract(epoch FROM r."a") * 1000000)::int8 END`, parquet.Int64, parquet.Timestamp},
+	}
+	for _, c := range cases {
+		column := newExportColumn(c.column)
+		if column.expr != c.expr || column.parquet.Type != c.typ || column.parquet.Logical != c.logical {
+			t.Errorf("%s: unexpected column %+v", c.column.Type, column)
+		}
+	}
+}
+
+func TestBaseType(t *testing.T) {
+	cases := map[string]string{
+		"integer":                     "integer",
+		"numeric(10,2)":               "numeric",
+		"character varying(20)":       "character varying",
+		"timestamp(3) with time zone": "timestamp with time zone",
+	}
+	for in, want := range cases {
+		if got := baseType(in); got != want {
+			t.Errorf("baseType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportEncoders(t *testing.T) {
+	columns := []exportColumn{
+		newExportColumn(Column{Name: "id", Type: "integer"}),
+		newExportColumn(Column{Name: "title", Type: "text"}),
+	}
+	rows := [][][]byte{
+		{[]byte("1"), []byte(`Bonjour, "monde"`)},
+		{[]byte("2"), nil},
+	}
+
+	var out bytes.Buffer
+	encoder, err := newExportEncoder(FormatCSV, columns, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, row := range rows {
+		encoder.row(row)
+	}
+	if err := encoder.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "id,title\n1,\"Bonjour, \"\"monde\"\"\"\n2,\n"; out.String() != want {
+		t.Errorf("unexpected csv:\ngot  %q\nwant %q", out.String(), want)
+	}
+
+	out.Reset()
+	encoder, _ = newExportEncoder(FormatNDJSON, nil, &out)
+	encoder.row([][]byte{[]byte(`{"id": 1}`)})
+	encoder.row([][]byte{[]byte(`{"id": 2}`)})
+	encoder.close()
+	if want := "{\"id\": 1}\n{\"id\": 2}\n"; out.String() != want {
+		t.Errorf("unexpected ndjson:\ngot  %q\nwant %q", out.String(), want)
+	}
+
+	out.Reset()
+	encoder, _ = newExportEncoder(FormatParquet, columns, &out)
+	for _, row := range rows {
+		if err := encoder.row(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := encoder.close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("PAR1")) || !bytes.HasSuffix(out.Bytes(), []byte("PAR1")) {
+		t.Errorf("unexpected parquet file: %q", out.Bytes())
+	}
+
+	encoder, _ = newExportEncoder(FormatParquet, columns, &bytes.Buffer{})
+	if err := encoder.row([][]byte{[]byte("x"), nil}); err == nil {
+		t.Error("expected an error for an unreadable integer")
+	}
+}
@@ -0,0 +1,468 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Le langage de filtre est décrit dans docs/filters.md. Un filtre est un objet
+// JSON dont chaque clé est soit une colonne (éventuellement suivie d'un chemin
+// JSON, ex: "profile->address->city"), soit un opérateur logique ($and, $or) :
+//
+//	{"age": {"$gte": 18}, "status": "active"}
+//	{"$or": [{"role": "admin"}, {"profile->plan": {"$in": ["pro", "team"]}}]}
+//
+// Toutes les valeurs sont transmises en paramètres. Les seuls identifiants
+// présents dans le SQL généré sont les colonnes validées contre le catalogue.
+
+// maxFilterDepth limite l'imbrication des opérateurs logiques
+const maxFilterDepth = 16
+
+// jsonPathSeparator sépare la colonne jsonb des clés du chemin JSON
+const jsonPathSeparator = "->"
+
+// claimOperator référence une revendication du jeton de l'utilisateur final
+const claimOperator = "$auth"
+
+// claimNamePattern valide le nom d'une revendication lue dans auth.jwt()
+var claimNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// jsonNull représente la valeur JSON null côté SQL
+const jsonNull = "'null'::jsonb"
+
+// filterCompiler traduit un filtre en condition SQL paramétrée
+type filterCompiler struct {
+	table *Table
+	args  []any
+	alias string // préfixe des colonnes, vide pour une contrainte CHECK
+	// inline remplace les paramètres par des littéraux échappés, pour le DDL
+	// qui n'accepte pas de paramètres
+	inline bool
+	// claims autorise les références {"$auth": ...} à l'identité de l'utilisateur final,
+	// pour les politiques de sécurité au niveau des lignes
+	claims bool
+}
+
+// operand est la cible d'une comparaison : une colonne ou un chemin dans une colonne jsonb
+type operand struct {
+	key    string
+	column Column
+	expr   string // expression SQL de la cible
+	text   string // expression SQL de la cible convertie en texte
+	path   bool
+}
+
+// CompileFilter compile un filtre en condition SQL sur l'alias r. Les valeurs
+// sont ajoutées à args et référencées par des paramètres numérotés à la suite.
+func CompileFilter(t *Table, filter map[string]any, args []any) (string, []any, error) {
+	c := &filterCompiler{table: t, args: args, alias: "r"}
+	cond, err := c.object(filter, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return cond, c.args, nil
+}
+
+// compileCheck compile un filtre en expression de contrainte CHECK, sans alias
+// ni paramètres : les valeurs y sont écrites sous forme de littéraux échappés
+func compileCheck(t *Table, filter map[string]any) (string, error) {
+	if len(filter) == 0 {
+		return "", fmt.Errorf("%w: contrainte CHECK vide", ErrInvalid)
+	}
+	c := &filterCompiler{table: t, inline: true}
+	return c.object(filter, 0)
+}
+
+// compilePolicy compile un filtre en expression de politique RLS : comme une contrainte
+// CHECK, avec en plus les références à l'identité de l'utilisateur final
+func compilePolicy(t *Table, filter map[string]any) (string, error) {
+	c := &filterCompiler{table: t, inline: true, claims: true}
+	return c.object(filter, 0)
+}
+
+// placeholder ajoute une valeur aux paramètres et retourne sa référence
+func (c *filterCompiler) placeholder(value any) string {
+	if c.inline {
+		return literal(value)
+	}
+	c.args = append(c.args, value)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+// object compile un objet filtre, dont les clés sont combinées par AND
+func (c *filterCompiler) object(filter map[string]any, depth int) (string, error) {
+	if depth > maxFilterDepth {
+		return "", fmt.Errorf("%w: filtre trop profond (maximum %d niveaux)", ErrInvalid, maxFilterDepth)
+	}
+
+	conds := make([]string, 0, len(filter))
+	for _, key := range sortedKeys(filter) {
+		var cond string
+		var err error
+
+		switch {
+		case key == "$and" || key == "$or":
+			cond, err = c.logical(key, filter[key], depth)
+		case strings.HasPrefix(key, "$"):
+			err = fmt.Errorf("%w: opérateur logique inconnu %q", ErrInvalid, key)
+		default:
+			cond, err = c.field(key, filter[key])
+		}
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+
+	return joinConditions(conds, " AND ", "TRUE"), nil
+}
+
+// logical compile $and et $or, qui attendent un tableau de filtres
+func (c *filterCompiler) logical(op string, value any, depth int) (string, error) {
+	items, ok := value.([]any)
+	if !ok || len(items) == 0 {
+		return "", fmt.Errorf("%w: %s attend un tableau non vide de filtres", ErrInvalid, op)
+	}
+
+	conds := make([]string, len(items))
+	for i, item := range items {
+		sub, ok := item.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("%w: %s[%d] doit être un objet", ErrInvalid, op, i)
+		}
+		cond, err := c.object(sub, depth+1)
+		if err != nil {
+			return "", err
+		}
+		conds[i] = cond
+	}
+
+	if op == "$or" {
+		return joinConditions(conds, " OR ", "FALSE"), nil
+	}
+	return joinConditions(conds, " AND ", "TRUE"), nil
+}
+
+// field compile les conditions portant sur une colonne ou un chemin JSON
+func (c *filterCompiler) field(key string, value any) (string, error) {
+	target, err := c.resolve(key)
+	if err != nil {
+		return "", err
+	}
+
+	ops, ok := operatorsOf(value)
+	if _, isClaim := c.claimRef(value); isClaim {
+		ok = false
+	}
+	if !ok {
+		// Une valeur simple est une égalité implicite
+		return c.compare(target, "$eq", value)
+	}
+
+	conds := make([]string, 0, len(ops))
+	for _, op := range sortedKeys(ops) {
+		if !strings.HasPrefix(op, "$") {
+			return "", fmt.Errorf("%w: %q mélange opérateurs et valeurs", ErrInvalid, key)
+		}
+		cond, err := c.compare(target, op, ops[op])
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+	return joinConditions(conds, " AND ", "TRUE"), nil
+}
+
+// resolve valide la colonne (et son éventuel chemin JSON) visée par une clé de filtre
+func (c *filterCompiler) resolve(key string) (operand, error) {
+	parts := strings.Split(key, jsonPathSeparator)
+	column, ok := c.table.Column(parts[0])
+	if !ok {
+		return operand{}, fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, parts[0], c.table.Name)
+	}
+
+	ref := quoteIdent(column.Name)
+	if c.alias != "" {
+		ref = c.alias + "." + ref
+	}
+	if len(parts) == 1 {
+		if isJSONType(column.Type) {
+			ref += "::jsonb"
+		}
+		return operand{key: key, column: column, expr: ref, text: ref + "::text"}, nil
+	}
+
+	if !isJSONType(column.Type) {
+		return operand{}, fmt.Errorf("%w: chemin JSON sur la colonne %q de type %s", ErrInvalid, column.Name, column.Type)
+	}
+	for _, part := range parts[1:] {
+		if part == "" {
+			return operand{}, fmt.Errorf("%w: chemin JSON invalide %q", ErrInvalid, key)
+		}
+	}
+
+	path := c.placeholder(parts[1:])
+	return operand{
+		key:    key,
+		column: column,
+		expr:   fmt.Sprintf("(%s::jsonb #> %s::text[])", ref, path),
+		text:   fmt.Sprintf("(%s::jsonb #>> %s::text[])", ref, path),
+		path:   true,
+	}, nil
+}
+
+// compare compile un opérateur de comparaison appliqué à une cible
+func (c *filterCompiler) compare(target operand, op string, value any) (string, error) {
+	switch op {
+	case "$eq":
+		if value == nil {
+			return c.isNull(target, true), nil
+		}
+		param, err := c.value(target, op, value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s = %s", target.expr, param), nil
+
+	case "$ne":
+		if value == nil {
+			return c.isNull(target, false), nil
+		}
+		param, err := c.value(target, op, value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s IS DISTINCT FROM %s", target.expr, param), nil
+
+	case "$gt", "$gte", "$lt", "$lte":
+		if value == nil {
+			return "", fmt.Errorf("%w: %s n'accepte pas null pour %q", ErrInvalid, op, target.key)
+		}
+		param, err := c.value(target, op, value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", target.expr, comparisonOperators[op], param), nil
+
+	case "$in", "$nin":
+		return c.membership(target, op, value)
+
+	case "$like", "$ilike":
+		pattern, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("%w: %s attend une chaîne pour %q", ErrInvalid, op, target.key)
+		}
+		keyword := "LIKE"
+		if op == "$ilike" {
+			keyword = "ILIKE"
+		}
+		return fmt.Sprintf("%s %s %s::text", target.text, keyword, c.placeholder(pattern)), nil
+
+	case "$null":
+		isNull, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("%w: $null attend un booléen pour %q", ErrInvalid, target.key)
+		}
+		return c.isNull(target, isNull), nil
+	}
+
+	return "", fmt.Errorf("%w: opérateur inconnu %q pour %q", ErrInvalid, op, target.key)
+}
+
+// comparisonOperators associe les opérateurs d'ordre à leur équivalent SQL
+var comparisonOperators = map[string]string{
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+// membership compile $in et $nin
+func (c *filterCompiler) membership(target operand, op string, value any) (string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return "", fmt.Errorf("%w: %s attend un tableau pour %q", ErrInvalid, op, target.key)
+	}
+	if len(items) == 0 {
+		if op == "$in" {
+			return "FALSE", nil
+		}
+		return "TRUE", nil
+	}
+
+	var cond string
+	if target.path || isJSONType(target.column.Type) {
+		encoded, err := json.Marshal(items)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s invalide pour %q", ErrInvalid, op, target.key)
+		}
+		cond = fmt.Sprintf("%s IN (SELECT jsonb_array_elements(%s::jsonb))", target.expr, c.placeholder(string(encoded)))
+	} else {
+		if err := checkScalarColumn(target); err != nil {
+			return "", err
+		}
+		texts := make([]string, len(items))
+		for i, item := range items {
+			text, err := scalarText(item)
+			if err != nil {
+				return "", fmt.Errorf("%w: %s[%d] pour %q: %v", ErrInvalid, op, i, target.key, err)
+			}
+			texts[i] = text
+		}
+		cond = fmt.Sprintf("%s = ANY(%s::text[]::%s[])", target.expr, c.placeholder(texts), target.column.Type)
+	}
+
+	if op == "$nin" {
+		return fmt.Sprintf("NOT COALESCE(%s, FALSE)", cond), nil
+	}
+	return cond, nil
+}
+
+// isNull compile un test de nullité ; pour un chemin JSON, une clé absente équivaut à null
+func (c *filterCompiler) isNull(target operand, isNull bool) string {
+	if target.path {
+		if isNull {
+			return fmt.Sprintf("COALESCE(%s, %s) = %s", target.expr, jsonNull, jsonNull)
+		}
+		return fmt.Sprintf("COALESCE(%s, %s) <> %s", target.expr, jsonNull, jsonNull)
+	}
+	if isNull {
+		return target.expr + " IS NULL"
+	}
+	return target.expr + " IS NOT NULL"
+}
+
+// value ajoute la valeur comparée aux paramètres, convertie vers le type de la cible
+func (c *filterCompiler) value(target operand, op string, value any) (string, error) {
+	if ref, ok := c.claimRef(value); ok {
+		return c.claimValue(target, ref)
+	}
+	if target.path || isJSONType(target.column.Type) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: valeur invalide pour %s sur %q", ErrInvalid, op, target.key)
+		}
+		return c.placeholder(string(encoded)) + "::jsonb", nil
+	}
+
+	if err := checkScalarColumn(target); err != nil {
+		return "", err
+	}
+	text, err := scalarText(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s pour %q: %v", ErrInvalid, op, target.key, err)
+	}
+	// Le type provient du catalogue (format_type), jamais de la requête
+	return fmt.Sprintf("%s::text::%s", c.placeholder(text), target.column.Type), nil
+}
+
+// claimRef retourne le nom de la revendication d'une valeur {"$auth": "uid"}
+func (c *filterCompiler) claimRef(value any) (any, bool) {
+	ref, ok := value.(map[string]any)
+	if !c.claims || !ok || len(ref) != 1 {
+		return nil, false
+	}
+	name, ok := ref[claimOperator]
+	return name, ok
+}
+
+// claimValue compile une référence à une revendication du jeton de l'utilisateur final,
+// convertie vers le type de la cible
+func (c *filterCompiler) claimValue(target operand, ref any) (string, error) {
+	name, ok := ref.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s attend le nom d'une revendication pour %q", ErrInvalid, claimOperator, target.key)
+	}
+
+	var expr string
+	switch name {
+	case "uid":
+		expr = "auth.uid()"
+	case "role":
+		expr = "auth.role()"
+	default:
+		if !claimNamePattern.MatchString(name) {
+			return "", fmt.Errorf("%w: revendication invalide %q pour %q", ErrInvalid, name, target.key)
+		}
+		expr = fmt.Sprintf("(auth.jwt() ->> %s)", quoteLiteral(name))
+	}
+
+	if target.path || isJSONType(target.column.Type) {
+		return fmt.Sprintf("to_jsonb(%s)", expr), nil
+	}
+	if err := checkScalarColumn(target); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s::%s", expr, target.column.Type), nil
+}
+
+// checkScalarColumn refuse les comparaisons de valeurs sur les colonnes de type tableau
+func checkScalarColumn(target operand) error {
+	if strings.HasSuffix(target.column.Type, "]") {
+		return fmt.Errorf("%w: comparaison non supportée sur la colonne tableau %q", ErrInvalid, target.column.Name)
+	}
+	return nil
+}
+
+// scalarText convertit une valeur JSON scalaire en sa représentation texte PostgreSQL
+func scalarText(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("null n'est pas accepté ici, utilisez $null")
+	default:
+		return "", fmt.Errorf("valeur scalaire attendue")
+	}
+}
+
+// operatorsOf retourne l'objet d'opérateurs d'une valeur de filtre, s'il en est un
+func operatorsOf(value any) (map[string]any, bool) {
+	ops, ok := value.(map[string]any)
+	if !ok || len(ops) == 0 {
+		return nil, false
+	}
+	for key := range ops {
+		if strings.HasPrefix(key, "$") {
+			return ops, true
+		}
+	}
+	// Un objet sans opérateur est une valeur JSON comparée par égalité
+	return nil, false
+}
+
+// isJSONType indique si le type de colonne est json ou jsonb
+func isJSONType(columnType string) bool {
+	return columnType == "json" || columnType == "jsonb"
+}
+
+// joinConditions combine des conditions, empty étant retourné s'il n'y en a aucune
+func joinConditions(conds []string, separator, empty string) string {
+	switch len(conds) {
+	case 0:
+		return empty
+	case 1:
+		return conds[0]
+	}
+	return "(" + strings.Join(conds, separator) + ")"
+}
+
+// sortedKeys retourne les clés triées pour générer un SQL déterministe
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
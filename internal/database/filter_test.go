@@ -0,0 +1,255 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// postsTable est une collection de test avec une colonne jsonb
+func postsTable() *Table {
+	return &Table{
+		Schema: DefaultSchema,
+		Name:   "posts",
+		Columns: []Column{
+			{Name: "id", Type: "integer"},
+			{Name: "title", Type: "text"},
+			{Name: "views", Type: "bigint"},
+			{Name: "tags", Type: "text[]", Nullable: true},
+			{Name: "meta", Type: "jsonb", Nullable: true},
+			{Name: "deleted_at", Type: "timestamp with time zone", Nullable: true},
+		},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+// decodeFilter décode un filtre JSON comme le font les handlers
+func decodeFilter(t testing.TB, raw string) map[string]any {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+
+	var filter map[string]any
+	if err := decoder.Decode(&filter); err != nil {
+		t.Fatalf("invalid test filter %s: %v", raw, err)
+	}
+	return filter
+}
+
+func TestCompileFilter(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter string
+		sql    string
+		args   []any
+	}{
+		{
+			name:   "empty",
+			filter: `{}`,
+			sql:    `TRUE`,
+		},
+		{
+			name:   "implicit equality",
+			filter: `{"title": "hello"}`,
+			sql:    `r."title" = $1::text::text`,
+			args:   []any{"hello"},
+		},
+		{
+			name:   "implicit null",
+			filter: `{"deleted_at": null}`,
+			sql:    `r."deleted_at" IS NULL`,
+		},
+		{
+			name:   "range",
+			filter: `{"views": {"$gte": 10, "$lt": 100}}`,
+			sql:    `(r."views" >= $1::text::bigint AND r."views" < $2::text::bigint)`,
+			args:   []any{"10", "100"},
+		},
+		{
+			name:   "in",
+			filter: `{"id": {"$in": [1, 2, 3]}}`,
+			sql:    `r."id" = ANY($1::text[]::integer[])`,
+			args:   []any{[]string{"1", "2", "3"}},
+		},
+		{
+			name:   "empty in",
+			filter: `{"id": {"$in": []}}`,
+			sql:    `FALSE`,
+		},
+		{
+			name:   "not in",
+			filter: `{"id": {"$nin": [4]}}`,
+			sql:    `NOT COALESCE(r."id" = ANY($1::text[]::integer[]), FALSE)`,
+			args:   []any{[]string{"4"}},
+		},
+		{
+			name:   "like",
+			filter: `{"title": {"$ilike": "%go%"}}`,
+			sql:    `r."title"::text ILIKE $1::text`,
+			args:   []any{"%go%"},
+		},
+		{
+			name:   "json path",
+			filter: `{"meta->author->name": "Ada"}`,
+			sql:    `(r."meta"::jsonb #> $1::text[]) = $2::jsonb`,
+			args:   []any{[]string{"author", "name"}, `"Ada"`},
+		},
+		{
+			name:   "json path null",
+			filter: `{"meta->draft": {"$null": true}}`,
+			sql:    `COALESCE((r."meta"::jsonb #> $1::text[]), 'null'::jsonb) = 'null'::jsonb`,
+			args:   []any{[]string{"draft"}},
+		},
+		{
+			name:   "or",
+			filter: `{"$or": [{"title": "a"}, {"views": {"$gt": 5}}], "deleted_at": {"$null": true}}`,
+			sql:    `((r."title" = $1::text::text OR r."views" > $2::text::bigint) AND r."deleted_at" IS NULL)`,
+			args:   []any{"a", "5"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := CompileFilter(postsTable(), decodeFilter(t, tc.filter), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tc.sql {
+				t.Errorf("unexpected SQL:\ngot  %s\nwant %s", sql, tc.sql)
+			}
+
+			gotArgs, _ := json.Marshal(args)
+			wantArgs, _ := json.Marshal(tc.args)
+			if len(tc.args) == 0 {
+				wantArgs = []byte("null")
+			}
+			if !bytes.Equal(gotArgs, wantArgs) {
+				t.Errorf("unexpected args: got %s want %s", gotArgs, wantArgs)
+			}
+		})
+	}
+}
+
+func TestCompileFilter_NumbersFollowExistingArgs(t *testing.T) {
+	sql, args, err := CompileFilter(postsTable(), decodeFilter(t, `{"title": "x"}`), []any{"first"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sql, "$2") || len(args) != 2 {
+		t.Errorf("expected filter to use $2 after existing args, got %s with %d args", sql, len(args))
+	}
+}
+
+func TestCompileFilter_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unknown column":         `{"nope": 1}`,
+		"unknown operator":       `{"views": {"$regex": "a"}}`,
+		"unknown logical":        `{"$nor": []}`,
+		"mixed operators":        `{"views": {"$gt": 1, "x": 2}}`,
+		"in without array":       `{"id": {"$in": 1}}`,
+		"in with object":         `{"id": {"$in": [{"a": 1}]}}`,
+		"like without string":    `{"title": {"$like": 1}}`,
+		"null without boolean":   `{"title": {"$null": "yes"}}`,
+		"ordering null":          `{"views": {"$gt": null}}`,
+		"path on scalar column":  `{"title->a": "b"}`,
+		"empty path segment":     `{"meta->": "b"}`,
+		"or without array":       `{"$or": {"title": "a"}}`,
+		"or with empty array":    `{"$or": []}`,
+		"comparison on array":    `{"tags": "a"}`,
+		"object on scalar":       `{"title": {"a": 1}}`,
+		"or with non-object":     `{"$or": ["title"]}`,
+		"injection in column":    `{"title\" = '' OR 1=1 --": "x"}`,
+		"injection in operators": `{"title": {"$eq; DROP TABLE posts": "x"}}`,
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := CompileFilter(postsTable(), decodeFilter(t, raw), nil)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompileFilter_DepthLimit(t *testing.T) {
+	raw := `{"title": "x"}`
+	for i := 0; i <= maxFilterDepth+1; i++ {
+		raw = `{"$and": [` + raw + `]}`
+	}
+
+	_, _, err := CompileFilter(postsTable(), decodeFilter(t, raw), nil)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for deep filter, got %v", err)
+	}
+}
+
+// quotedIdentifier repère les identifiants entre guillemets du SQL généré
+var quotedIdentifier = regexp.MustCompile(`"(?:[^"]|"")*"`)
+
+// placeholderRef repère les références de paramètres du SQL généré
+var placeholderRef = regexp.MustCompile(`\$([0-9]+)`)
+
+// FuzzCompileFilter vérifie qu'aucune donnée du filtre ne peut atteindre le SQL
+// autrement que sous forme de paramètre ou d'identifiant validé
+func FuzzCompileFilter(f *testing.F) {
+	seeds := []string{
+		`{"title": "hello"}`,
+		`{"views": {"$gte": 10, "$lt": 100}}`,
+		`{"$or": [{"title": "a"}, {"meta->a->b": {"$in": [1, "x", null]}}]}`,
+		`{"title": {"$like": "'; DROP TABLE posts; --"}}`,
+		`{"meta->x'y": {"$ne": "/* */"}}`,
+		`{"title\"--": 1}`,
+		`{"$and": [{"deleted_at": null}, {"id": {"$nin": [1, 2]}}]}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	table := postsTable()
+	allowed := map[string]bool{`"public"`: true, `"posts"`: true}
+	for _, col := range table.Columns {
+		allowed[quoteIdent(col.Name)] = true
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.UseNumber()
+		var filter map[string]any
+		if err := decoder.Decode(&filter); err != nil {
+			return
+		}
+
+		sql, args, err := CompileFilter(table, filter, nil)
+		if err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("unexpected error kind: %v", err)
+			}
+			return
+		}
+
+		for _, ident := range quotedIdentifier.FindAllString(sql, -1) {
+			if !allowed[ident] {
+				t.Fatalf("unexpected identifier %s in %s", ident, sql)
+			}
+		}
+
+		stripped := quotedIdentifier.ReplaceAllString(strings.ReplaceAll(sql, jsonNull, ""), "")
+		for _, forbidden := range []string{"'", ";", "--", "/*"} {
+			if strings.Contains(stripped, forbidden) {
+				t.Fatalf("unexpected %q in %s", forbidden, sql)
+			}
+		}
+
+		for _, match := range placeholderRef.FindAllStringSubmatch(sql, -1) {
+			n, err := strconv.Atoi(match[1])
+			if err != nil || n < 1 || n > len(args) {
+				t.Fatalf("placeholder %s does not match the %d args in %s", match[0], len(args), sql)
+			}
+		}
+	})
+}
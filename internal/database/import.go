@@ -0,0 +1,443 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Limites des imports en masse
+const (
+	MaxImportLine      = 16 << 20 // Taille maximale d'une ligne NDJSON
+	MaxReportedRejects = 1000     // Lignes rejetées détaillées dans le rapport, les suivantes sont seulement comptées
+)
+
+// Comportements d'un import face aux documents dont la clé primaire existe déjà
+const (
+	ImportConflictError  = "error"
+	ImportConflictSkip   = "skip"
+	ImportConflictUpdate = "update"
+)
+
+// importTable est la table temporaire où les lignes sont copiées avant d'être validées
+const importTable = "sovrabase_import"
+
+// Import décrit un import en masse dans une collection
+type Import struct {
+	Format      string // FormatCSV ou FormatNDJSON
+	Delimiter   rune   // Séparateur des champs CSV, la virgule par défaut
+	OnConflict  string // ImportConflictError par défaut
+	MaxRejected int    // Nombre de lignes rejetées au-delà duquel l'import est annulé, aucun si négatif
+}
+
+// RejectedRow est une ligne du fichier importé écartée, avec la raison du rejet
+type RejectedRow struct {
+	Line  int64  `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult est le rapport d'un import
+type ImportResult struct {
+	Imported int64         `json:"imported"`          // Documents insérés ou mis à jour
+	Skipped  int64         `json:"skipped"`           // Documents existants ignorés, avec ImportConflictSkip
+	Rejected int64         `json:"rejected"`          // Lignes rejetées
+	Rows     []RejectedRow `json:"rejected_rows"`     // Les MaxReportedRejects premières lignes rejetées
+	Aborted  bool          `json:"aborted,omitempty"` // L'import a été annulé, MaxRejected étant dépassé
+}
+
+// ImportDocuments importe les documents lus dans r, au fil de leur lecture. Les lignes sont
+// copiées avec COPY dans une table temporaire, puis celles dont une valeur est invalide ou
+// manquante sont écartées et les autres insérées, dans une même transaction. Une erreur
+// détectée à l'insertion (clé étrangère, contrainte CHECK, doublon avec ImportConflictError)
+// annule tout l'import. La validation des valeurs nécessite PostgreSQL 16.
+func ImportDocuments(ctx context.Context, q Querier, t *Table, r io.Reader, imp Import) (*ImportResult, error) {
+	if imp.OnConflict == "" {
+		imp.OnConflict = ImportConflictError
+	}
+	if imp.OnConflict != ImportConflictError && imp.OnConflict != ImportConflictSkip && imp.OnConflict != ImportConflictUpdate {
+		return nil, fmt.Errorf("%w: on_conflict inconnu %q (error, skip ou update)", ErrInvalid, imp.OnConflict)
+	}
+	if imp.OnConflict != ImportConflictError && len(t.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%w: la collection %q n'a pas de clé primaire", ErrInvalid, t.Name)
+	}
+
+	result := &ImportResult{Rows: []RejectedRow{}}
+	// Chaque ligne valide du fichier est copiée en un document jsonb, avec la liste triée de ses clés
+	var source pgx.CopyFromSource
+	switch imp.Format {
+	case FormatNDJSON:
+		source = newNDJSONSource(t, r, result)
+	case FormatCSV:
+		csvSource, err := newCSVSource(t, r, imp.Delimiter, result)
+		if err != nil {
+			return nil, err
+		}
+		source = csvSource
+	default:
+		return nil, fmt.Errorf("%w: format d'import inconnu %q (csv ou ndjson)", ErrInvalid, imp.Format)
+	}
+
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DROP TABLE IF EXISTS pg_temp.`+importTable); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `CREATE TEMP TABLE `+importTable+` (line bigint PRIMARY KEY, keys text[] NOT NULL, doc jsonb NOT NULL) ON COMMIT DROP`); err != nil {
+			return err
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{importTable}, []string{"line", "keys", "doc"}, source); err != nil {
+			return err
+		}
+
+		if err := rejectInvalidRows(ctx, tx, t, result); err != nil {
+			return err
+		}
+		if imp.MaxRejected >= 0 && result.Rejected > int64(imp.MaxRejected) {
+			result.Aborted = true
+			return errImportAborted
+		}
+		return insertImportedRows(ctx, tx, t, imp.OnConflict, result)
+	})
+	if errors.Is(err, errImportAborted) {
+		result.Imported, result.Skipped = 0, 0
+		return result, nil
+	}
+	if err != nil {
+		var reject *importLineError
+		if errors.As(err, &reject) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalid, reject.Error())
+		}
+		return nil, translateError(err)
+	}
+	return result, nil
+}
+
+// errImportAborted annule la transaction d'un import dont trop de lignes ont été rejetées
+var errImportAborted = errors.New("import annulé")
+
+// importLineError est une erreur de lecture qui empêche de poursuivre l'import
+type importLineError struct {
+	line int64
+	err  error
+}
+
+func (e *importLineError) Error() string {
+	return fmt.Sprintf("ligne %d: %v", e.line, e.err)
+}
+
+// reject ajoute une ligne rejetée au rapport
+func (r *ImportResult) reject(line int64, message string) {
+	r.Rejected++
+	if len(r.Rows) < MaxReportedRejects {
+		r.Rows = append(r.Rows, RejectedRow{Line: line, Error: message})
+	}
+}
+
+// ndjsonSource lit un document JSON par ligne ; les lignes vides sont ignorées
+type ndjsonSource struct {
+	table   *Table
+	scanner *bufio.Scanner
+	result  *ImportResult
+	line    int64
+	values  []any
+	err     error
+}
+
+func newNDJSONSource(t *Table, r io.Reader, result *ImportResult) *ndjsonSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), MaxImportLine)
+	return &ndjsonSource{table: t, scanner: scanner, result: result}
+}
+
+func (s *ndjsonSource) Next() bool {
+	for s.scanner.Scan() {
+		s.line++
+		raw := bytes.TrimSpace(s.scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &doc); err != nil || doc == nil {
+			s.result.reject(s.line, "la ligne n'est pas un objet JSON")
+			continue
+		}
+		if len(doc) == 0 {
+			s.result.reject(s.line, "document vide")
+			continue
+		}
+		keys := make([]string, 0, len(doc))
+		for key := range doc {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if err := s.table.checkWritable(keys...); err != nil {
+			s.result.reject(s.line, err.Error())
+			continue
+		}
+
+		s.values = []any{s.line, keys, json.RawMessage(slices.Clone(raw))}
+		return true
+	}
+	if err := s.scanner.Err(); err != nil {
+		s.err = &importLineError{line: s.line + 1, err: err}
+	}
+	return false
+}
+
+func (s *ndjsonSource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *ndjsonSource) Err() error {
+	return s.err
+}
+
+// csvSource lit un fichier CSV dont la première ligne nomme les colonnes. Un champ vide est NULL.
+type csvSource struct {
+	reader  *csv.Reader
+	result  *ImportResult
+	columns []string
+	keys    []string
+	values  []any
+	err     error
+}
+
+func newCSVSource(t *Table, r io.Reader, delimiter rune, result *ImportResult) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	if delimiter != 0 {
+		reader.Comma = delimiter
+	}
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: le fichier CSV est vide, une ligne d'en-tête est requise", ErrInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: en-tête CSV illisible: %v", ErrInvalid, err)
+	}
+
+	columns := slices.Clone(header)
+	keys := slices.Clone(columns)
+	sort.Strings(keys)
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] {
+			return nil, fmt.Errorf("%w: colonne %q présente deux fois dans l'en-tête CSV", ErrInvalid, keys[i])
+		}
+	}
+	if err := t.checkWritable(keys...); err != nil {
+		return nil, err
+	}
+	return &csvSource{reader: reader, result: result, columns: columns, keys: keys}, nil
+}
+
+func (s *csvSource) Next() bool {
+	for {
+		record, err := s.reader.Read()
+		if err == io.EOF {
+			return false
+		}
+		line, _ := s.reader.FieldPos(0)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Un nombre de champs incorrect n'empêche pas de lire la ligne suivante, une erreur de guillemets si
+			if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				s.result.reject(int64(parseErr.StartLine), parseErr.Err.Error())
+				continue
+			}
+			s.err = &importLineError{line: int64(parseErr.StartLine), err: parseErr.Err}
+			return false
+		}
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		doc := make(map[string]any, len(record))
+		for i, value := range record {
+			if value == "" {
+				doc[s.columns[i]] = nil
+			} else {
+				doc[s.columns[i]] = value
+			}
+		}
+		s.values = []any{int64(line), s.keys, doc}
+		return true
+	}
+}
+
+func (s *csvSource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *csvSource) Err() error {
+	return s.err
+}
+
+// rejectInvalidRows retire de la table temporaire les lignes dont une valeur manque ou n'est
+// pas valide pour le type de sa colonne, et les ajoute au rapport
+func rejectInvalidRows(ctx context.Context, tx pgx.Tx, t *Table, result *ImportResult) error {
+	statement := rejectStatement(t)
+	if statement == "" {
+		return nil
+	}
+	rows, err := tx.Query(ctx, statement, MaxReportedRejects)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var rejected []RejectedRow
+	var total int64
+	for rows.Next() {
+		var row RejectedRow
+		if err := rows.Scan(&row.Line, &row.Error, &total); err != nil {
+			return err
+		}
+		rejected = append(rejected, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Les rejets à la lecture et à la validation sont rapportés dans l'ordre du fichier
+	result.Rejected += total
+	result.Rows = append(result.Rows, rejected...)
+	sort.Slice(result.Rows, func(i, j int) bool { return result.Rows[i].Line < result.Rows[j].Line })
+	if len(result.Rows) > MaxReportedRejects {
+		result.Rows = result.Rows[:MaxReportedRejects]
+	}
+	return nil
+}
+
+// rejectStatement génère la suppression des lignes invalides de la table temporaire. Chaque
+// valeur scalaire est validée avec pg_input_is_valid contre le type de sa colonne, comme la
+// convertirait jsonb_populate_record ; la première erreur de la ligne est retournée.
+func rejectStatement(t *Table) string {
+	var checks []string
+	for _, col := range t.Columns {
+		if col.Name == SearchColumn && t.hasSearchColumn() {
+			continue
+		}
+		key := quoteLiteral(col.Name)
+		value := "s.doc -> " + key
+		text := "s.doc ->> " + key
+		typeName := quoteLiteral(col.Type)
+
+		if !col.Nullable {
+			missing := value + ` = 'null'`
+			if !col.HasDefault {
+				missing = fmt.Sprintf("%s IS NULL OR %s", value, missing)
+			}
+			checks = append(checks, fmt.Sprintf("WHEN %s THEN %s", missing, quoteLiteral(col.Name+": valeur requise")))
+		}
+		if isJSONType(col.Type) {
+			continue
+		}
+		// Un tableau JSON est converti élément par élément dans une colonne tableau
+		kinds := `('null')`
+		if strings.HasSuffix(col.Type, "[]") {
+			kinds = `('null', 'array')`
+		}
+		checks = append(checks, fmt.Sprintf("WHEN jsonb_typeof(%[1]s) NOT IN %[2]s AND NOT pg_input_is_valid(%[3]s, %[4]s) THEN %[5]s || (pg_input_error_info(%[3]s, %[4]s)).message",
+			value, kinds, text, typeName, quoteLiteral(col.Name+": ")))
+	}
+	if len(checks) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`WITH invalid AS (
+	SELECT s.line, CASE %[2]s END AS message FROM pg_temp.%[1]s AS s
+), deleted AS (
+	DELETE FROM pg_temp.%[1]s AS s USING invalid AS i WHERE s.line = i.line AND i.message IS NOT NULL
+)
+SELECT line, message, count(*) OVER () FROM invalid WHERE message IS NOT NULL ORDER BY line LIMIT $1`,
+		importTable, strings.Join(checks, " "))
+}
+
+// insertImportedRows insère les lignes validées, regroupées par ensemble de clés : comme pour
+// une insertion document par document, les colonnes absentes prennent leur valeur par défaut.
+// Les documents vides ayant été rejetés, chaque ensemble contient au moins une colonne.
+func insertImportedRows(ctx context.Context, tx pgx.Tx, t *Table, onConflict string, result *ImportResult) error {
+	rows, err := tx.Query(ctx, `SELECT keys, count(*) FROM pg_temp.`+importTable+` GROUP BY keys ORDER BY min(line)`)
+	if err != nil {
+		return err
+	}
+	type keySet struct {
+		keys  []string
+		count int64
+	}
+	sets, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (keySet, error) {
+		var set keySet
+		err := row.Scan(&set.keys, &set.count)
+		return set, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		statement := importInsertStatement(t, set.keys, onConflict)
+		tag, err := tx.Exec(ctx, statement, set.keys)
+		if err != nil {
+			return err
+		}
+		result.Imported += tag.RowsAffected()
+		result.Skipped += set.count - tag.RowsAffected()
+	}
+	return nil
+}
+
+// importInsertStatement génère l'insertion des lignes validées dont l'ensemble de clés est $1
+func importInsertStatement(t *Table, keys []string, onConflict string) string {
+	// Les colonnes sont insérées dans l'ordre de la table, comme par insertStatement
+	var columns []string
+	for _, col := range t.Columns {
+		if slices.Contains(keys, col.Name) {
+			columns = append(columns, col.Name)
+		}
+	}
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+	}
+	list := strings.Join(quoted, ", ")
+
+	var conflict string
+	switch onConflict {
+	case ImportConflictSkip:
+		conflict = " ON CONFLICT DO NOTHING"
+	case ImportConflictUpdate:
+		keyColumns := make([]string, len(t.PrimaryKey))
+		for i, key := range t.PrimaryKey {
+			keyColumns[i] = quoteIdent(key)
+		}
+		var updates []string
+		for _, col := range columns {
+			if !isKey(t, col) {
+				updates = append(updates, fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", quoteIdent(col)))
+			}
+		}
+		if len(updates) == 0 {
+			conflict = fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keyColumns, ", "))
+		} else {
+			if bump := t.versionBump(columns); bump != "" {
+				updates = append(updates, bump)
+			}
+			conflict = fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keyColumns, ", "), strings.Join(updates, ", "))
+		}
+	}
+
+	return fmt.Sprintf(`INSERT INTO %[1]s AS r (%[2]s) SELECT p.%[3]s FROM pg_temp.%[4]s AS s, jsonb_populate_record(NULL::%[1]s, s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line%[5]s`,
+		t.Identifier(), list, strings.Join(quoted, ", p."), importTable, conflict)
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONSource(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id": 1, "title": "Bonjour"}`,
+		``,
+		`{"title": "Annonce", "id": 2, "tags": ["a"]}`,
+		`[1, 2]`,
+		`{"id": 3, "author": "x"}`,
+		`{}`,
+		`{"id": 4`,
+	}, "\n")
+	result := &ImportResult{Rows: []RejectedRow{}}
+	source := newNDJSONSource(postsTable(), strings.NewReader(input), result)
+
+	var rows [][]any
+	for source.Next() {
+		values, _ := source.Values()
+		rows = append(rows, values)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := [][]any{
+		{int64(1), []string{"id", "title"}, json.RawMessage(`{"id": 1, "title": "Bonjour"}`)},
+		{int64(3), []string{"id", "tags", "title"}, json.RawMessage(`{"title": "Annonce", "id": 2, "tags": ["a"]}`)},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("unexpected rows: %#v", rows)
+	}
+
+	if result.Rejected != 4 {
+		t.Fatalf("expected 4 rejected rows, got %d: %v", result.Rejected, result.Rows)
+	}
+	lines := []int64{result.Rows[0].Line, result.Rows[1].Line, result.Rows[2].Line, result.Rows[3].Line}
+	if !reflect.DeepEqual(lines, []int64{4, 5, 6, 7}) {
+		t.Errorf("unexpected rejected lines: %v", result.Rows)
+	}
+	if !strings.Contains(result.Rows[1].Error, `colonne inconnue "author"`) {
+		t.Errorf("unexpected rejection: %s", result.Rows[1].Error)
+	}
+}
+
+func TestNDJSONSource_LineTooLong(t *testing.T) {
+	input := `{"id": 1}` + "\n" + `{"title": "` + strings.Repeat("x", MaxImportLine) + `"}`
+	source := newNDJSONSource(postsTable(), strings.NewReader(input), &ImportResult{})
+	for source.Next() {
+	}
+	var lineErr *importLineError
+	if !errors.As(source.Err(), &lineErr) || lineErr.line != 2 {
+		t.Errorf("expected an error on line 2, got %v", source.Err())
+	}
+}
+
+func TestCSVSource(t *testing.T) {
+	input := "title;id;views\nBonjour;1;10\nAnnonce;2\n\"Sans \"\"vues\"\"\";3;\n"
+	result := &ImportResult{Rows: []RejectedRow{}}
+	source, err := newCSVSource(postsTable(), strings.NewReader(input), ';', result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rows [][]any
+	for source.Next() {
+		values, _ := source.Values()
+		rows = append(rows, values)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := []string{"id", "title", "views"}
+	want := [][]any{
+		{int64(2), keys, map[string]any{"title": "Bonjour", "id": "1", "views": "10"}},
+		{int64(4), keys, map[string]any{"title": `Sans "vues"`, "id": "3", "views": nil}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("unexpected rows: %#v", rows)
+	}
+	if result.Rejected != 1 || result.Rows[0].Line != 3 {
+		t.Errorf("unexpected rejected rows: %v", result.Rows)
+	}
+}
+
+func TestCSVSource_InvalidHeader(t *testing.T) {
+	cases := map[string]string{
+		"empty":     "",
+		"unknown":   "id,author\n",
+		"duplicate": "id,title,id\n",
+	}
+	for name, input := range cases {
+		if _, err := newCSVSource(postsTable(), strings.NewReader(input), 0, &ImportResult{}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestRejectStatement(t *testing.T) {
+	table := &Table{
+		Schema: DefaultSchema,
+		Name:   "events",
+		Columns: []Column{
+			{Name: "id", Type: "bigint", HasDefault: true},
+			{Name: "name", Type: "character varying(20)"},
+			{Name: "labels", Type: "text[]", Nullable: true},
+			{Name: "payload", Type: "jsonb", Nullable: true},
+		},
+		PrimaryKey: []string{"id"},
+	}
+
+	expected := `WITH invalid AS (
+	SELECT s.line, CASE` +
+		` WHEN s.doc -> 'id' = 'null' THEN 'id: valeur requise'` +
+		` WHEN jsonb_typeof(s.doc -> 'id') NOT IN ('null') AND NOT pg_input_is_valid(s.doc ->> 'id', 'bigint') THEN 'id: ' || (pg_input_error_info(s.doc ->> 'id', 'bigint')).message` +
+		` WHEN s.doc -> 'name' IS NULL OR s.doc -> 'name' = 'null' THEN 'name: valeur requise'` +
+		` WHEN jsonb_typeof(s.doc -> 'name') NOT IN ('null') AND NOT pg_input_is_valid(s.doc ->> 'name', 'character varying(20)') THEN 'name: ' || (pg_input_error_info(s.doc ->> 'name', 'character varying(20)')).message` +
+		` WHEN jsonb_typeof(s.doc -> 'labels') NOT IN ('null', 'array') AND NOT pg_input_is_valid(s.doc ->> 'labels', 'text[]') THEN 'labels: ' || (pg_input_error_info(s.doc ->> 'labels', 'text[]')).message` +
+		` END AS message FROM pg_temp.sovrabase_import AS s
+), deleted AS (
+	DELETE FROM pg_temp.sovrabase_import AS s USING invalid AS i WHERE s.line = i.line AND i.message IS NOT NULL
+)
+SELECT line, message, count(*) OVER () FROM invalid WHERE message IS NOT NULL ORDER BY line LIMIT $1`
+
+	if sql := rejectStatement(table); sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestImportInsertStatement(t *testing.T) {
+	cases := []struct {
+		keys       []string
+		onConflict string
+		expected   string
+	}{
+		{
+			[]string{"id", "title"}, ImportConflictError,
+			`INSERT INTO "public"."posts" AS r ("id", "title") SELECT p."id", p."title" FROM pg_temp.sovrabase_import AS s, jsonb_populate_record(NULL::"public"."posts", s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line`,
+		},
+		{
+			[]string{"id", "title"}, ImportConflictSkip,
+			`INSERT INTO "public"."posts" AS r ("id", "title") SELECT p."id", p."title" FROM pg_temp.sovrabase_import AS s, jsonb_populate_record(NULL::"public"."posts", s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line ON CONFLICT DO NOTHING`,
+		},
+		{
+			[]string{"id", "title", "views"}, ImportConflictUpdate,
+			`INSERT INTO "public"."posts" AS r ("id", "title", "views") SELECT p."id", p."title", p."views" FROM pg_temp.sovrabase_import AS s, jsonb_populate_record(NULL::"public"."posts", s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line ON CONFLICT ("id") DO UPDATE SET "title" = EXCLUDED."title", "views" = EXCLUDED."views"`,
+		},
+		{
+			[]string{"id"}, ImportConflictUpdate,
+			`INSERT INTO "public"."posts" AS r ("id") SELECT p."id" FROM pg_temp.sovrabase_import AS s, jsonb_populate_record(NULL::"public"."posts", s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line ON CONFLICT ("id") DO NOTHING`,
+		},
+	}
+
+	for _, c := range cases {
+		if sql := importInsertStatement(postsTable(), c.keys, c.onConflict); sql != c.expected {
+			t.Errorf("%v %s:\ngot  %s\nwant %s", c.keys, c.onConflict, sql, c.expected)
+		}
+	}
+}
+
+func TestImportResult_Reject(t *testing.T) {
+	result := &ImportResult{}
+	for line := range int64(MaxReportedRejects + 5) {
+		result.reject(line+1, "invalide")
+	}
+	if result.Rejected != MaxReportedRejects+5 || len(result.Rows) != MaxReportedRejects {
+		t.Errorf("unexpected report: %d rejected, %d detailed", result.Rejected, len(result.Rows))
+	}
+}
@@ -0,0 +1,359 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Méthodes d'index acceptées
+const (
+	IndexBTree = "btree"
+	IndexHash  = "hash"
+	IndexGIN   = "gin"
+	IndexGiST  = "gist"
+	IndexBRIN  = "brin"
+	// Index de pgvector pour la recherche des plus proches voisins
+	IndexHNSW    = "hnsw"
+	IndexIVFFlat = "ivfflat"
+)
+
+// indexMethods liste les méthodes d'index acceptées
+var indexMethods = map[string]bool{
+	IndexBTree:   true,
+	IndexHash:    true,
+	IndexGIN:     true,
+	IndexGiST:    true,
+	IndexBRIN:    true,
+	IndexHNSW:    true,
+	IndexIVFFlat: true,
+}
+
+// indexOpClasses liste les classes d'opérateurs acceptées et les méthodes qui les utilisent
+var indexOpClasses = map[string][]string{
+	"jsonb_ops":           {IndexGIN},
+	"jsonb_path_ops":      {IndexGIN},
+	"array_ops":           {IndexGIN},
+	"tsvector_ops":        {IndexGIN},
+	"gin_trgm_ops":        {IndexGIN},
+	"gist_trgm_ops":       {IndexGiST},
+	"text_pattern_ops":    {IndexBTree},
+	"varchar_pattern_ops": {IndexBTree},
+	// Une classe par distance : l'index ne sert qu'aux recherches utilisant la même
+	"vector_cosine_ops":  {IndexHNSW, IndexIVFFlat},
+	"vector_l2_ops":      {IndexHNSW, IndexIVFFlat},
+	"vector_ip_ops":      {IndexHNSW, IndexIVFFlat},
+	"halfvec_cosine_ops": {IndexHNSW, IndexIVFFlat},
+	"halfvec_l2_ops":     {IndexHNSW, IndexIVFFlat},
+	"halfvec_ip_ops":     {IndexHNSW, IndexIVFFlat},
+}
+
+// languagePattern valide le nom d'une configuration de recherche plein texte (ex: "english")
+var languagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// IndexDefinition décrit un index à créer
+type IndexDefinition struct {
+	Name   string
+	Fields []IndexField
+	Unique bool
+	Method string
+	Where  map[string]any // Prédicat d'index partiel, en langage de filtre
+	With   map[string]int // Paramètres de construction : m et ef_construction (hnsw), lists (ivfflat)
+	// Concurrently crée l'index sans bloquer les écritures ; impossible dans une transaction
+	Concurrently bool
+}
+
+// IndexField est une colonne indexée
+type IndexField struct {
+	Column   string
+	Order    string // "asc" ou "desc"
+	Nulls    string // "first" ou "last"
+	OpClass  string
+	Language string // Indexe to_tsvector(language, colonne) pour la recherche plein texte
+}
+
+// Index décrit un index existant et ses statistiques d'utilisation
+type Index struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+	Method     string `json:"method"`
+	Unique     bool   `json:"unique"`
+	Primary    bool   `json:"primary"`
+	Valid      bool   `json:"valid"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Scans      int64  `json:"scans"`
+	TuplesRead int64  `json:"tuples_read"`
+}
+
+// TextSearchVector retourne l'expression tsvector d'une colonne ; la recherche plein texte
+// doit générer exactement la même expression pour utiliser l'index correspondant
+func TextSearchVector(language, column string) string {
+	return fmt.Sprintf("to_tsvector(%s::regconfig, COALESCE(%s::text, ''))", quoteLiteral(language), column)
+}
+
+// createIndexStatement génère le CREATE INDEX d'une collection
+func createIndexStatement(t *Table, def IndexDefinition) (string, error) {
+	if len(def.Fields) == 0 {
+		return "", fmt.Errorf("%w: l'index doit porter sur au moins une colonne", ErrInvalid)
+	}
+
+	method := strings.ToLower(def.Method)
+	if method == "" {
+		method = IndexBTree
+	}
+	if !indexMethods[method] {
+		return "", fmt.Errorf("%w: méthode d'index inconnue %q", ErrInvalid, def.Method)
+	}
+	if def.Unique && method != IndexBTree {
+		return "", fmt.Errorf("%w: un index unique doit utiliser la méthode btree", ErrInvalid)
+	}
+
+	name := def.Name
+	if name == "" {
+		name = defaultIndexName(t, def.Fields)
+	}
+	if err := checkIdentifier("index", name); err != nil {
+		return "", err
+	}
+
+	fields := make([]string, len(def.Fields))
+	for i, field := range def.Fields {
+		expr, err := indexField(t, method, field)
+		if err != nil {
+			return "", err
+		}
+		fields[i] = expr
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if def.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	if def.Concurrently {
+		b.WriteString("CONCURRENTLY ")
+	}
+	fmt.Fprintf(&b, "%s ON %s USING %s (%s)", quoteIdent(name), t.Identifier(), method, strings.Join(fields, ", "))
+
+	if len(def.With) > 0 {
+		with, err := indexWith(method, def.With)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" WITH (" + with + ")")
+	}
+
+	if len(def.Where) > 0 {
+		cond, err := compileCheck(t, def.Where)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" WHERE " + cond)
+	}
+	return b.String(), nil
+}
+
+// indexField génère l'élément d'index d'une colonne
+func indexField(t *Table, method string, field IndexField) (string, error) {
+	if _, ok := t.Column(field.Column); !ok {
+		return "", fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, field.Column, t.Name)
+	}
+
+	expr := quoteIdent(field.Column)
+	if field.Language != "" {
+		if !languagePattern.MatchString(field.Language) {
+			return "", fmt.Errorf("%w: langue de recherche invalide %q", ErrInvalid, field.Language)
+		}
+		if method != IndexGIN && method != IndexGiST {
+			return "", fmt.Errorf("%w: un index plein texte doit utiliser la méthode gin ou gist", ErrInvalid)
+		}
+		expr = "(" + TextSearchVector(field.Language, expr) + ")"
+	}
+
+	if field.OpClass != "" {
+		methods, ok := indexOpClasses[field.OpClass]
+		if !ok {
+			return "", fmt.Errorf("%w: classe d'opérateurs non supportée %q", ErrInvalid, field.OpClass)
+		}
+		if !slices.Contains(methods, method) {
+			return "", fmt.Errorf("%w: la classe d'opérateurs %q nécessite la méthode %s", ErrInvalid, field.OpClass, strings.Join(methods, " ou "))
+		}
+		expr += " " + field.OpClass
+	}
+
+	if (field.Order != "" || field.Nulls != "") && method != IndexBTree {
+		return "", fmt.Errorf("%w: l'ordre d'une colonne n'est supporté que par la méthode btree", ErrInvalid)
+	}
+	switch strings.ToLower(field.Order) {
+	case "", "asc":
+	case "desc":
+		expr += " DESC"
+	default:
+		return "", fmt.Errorf("%w: order attend \"asc\" ou \"desc\", reçu %q", ErrInvalid, field.Order)
+	}
+	switch strings.ToLower(field.Nulls) {
+	case "":
+	case "first":
+		expr += " NULLS FIRST"
+	case "last":
+		expr += " NULLS LAST"
+	default:
+		return "", fmt.Errorf("%w: nulls attend \"first\" ou \"last\", reçu %q", ErrInvalid, field.Nulls)
+	}
+	return expr, nil
+}
+
+// indexWith génère les paramètres de construction d'un index, dans l'ordre alphabétique
+func indexWith(method string, params map[string]int) (string, error) {
+	accepted := indexParameters[method]
+	names := slices.Sorted(maps.Keys(params))
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if !accepted[name] {
+			return "", fmt.Errorf("%w: paramètre %q non supporté par la méthode %s", ErrInvalid, name, method)
+		}
+		if params[name] <= 0 {
+			return "", fmt.Errorf("%w: le paramètre %q doit être positif", ErrInvalid, name)
+		}
+		parts[i] = fmt.Sprintf("%s = %d", name, params[name])
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// defaultIndexName nomme un index d'après sa table et ses colonnes, comme le ferait PostgreSQL
+func defaultIndexName(t *Table, fields []IndexField) string {
+	parts := []string{t.Name}
+	for _, field := range fields {
+		parts = append(parts, field.Column)
+	}
+	name := strings.Join(parts, "_")
+	if len(name) > maxIdentifierLength-4 {
+		name = name[:maxIdentifierLength-4]
+	}
+	return name + "_idx"
+}
+
+// ListIndexes liste les index d'une collection avec leur taille et leurs statistiques d'utilisation
+func ListIndexes(ctx context.Context, q Querier, t *Table) ([]Index, error) {
+	rows, err := q.Query(ctx, `
+		SELECT i.indexname, i.indexdef, am.amname, x.indisunique, x.indisprimary, x.indisvalid,
+		       pg_catalog.pg_relation_size(c.oid),
+		       COALESCE(s.idx_scan, 0), COALESCE(s.idx_tup_read, 0)
+		FROM pg_catalog.pg_indexes i
+		JOIN pg_catalog.pg_namespace n ON n.nspname = i.schemaname
+		JOIN pg_catalog.pg_class c ON c.relname = i.indexname AND c.relnamespace = n.oid
+		JOIN pg_catalog.pg_index x ON x.indexrelid = c.oid
+		JOIN pg_catalog.pg_am am ON am.oid = c.relam
+		LEFT JOIN pg_catalog.pg_stat_user_indexes s ON s.indexrelid = c.oid
+		WHERE i.schemaname = $1 AND i.tablename = $2
+		ORDER BY i.indexname`, t.Schema, t.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	indexes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Index, error) {
+		var index Index
+		err := row.Scan(&index.Name, &index.Definition, &index.Method, &index.Unique, &index.Primary,
+			&index.Valid, &index.SizeBytes, &index.Scans, &index.TuplesRead)
+		return index, err
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return indexes, nil
+}
+
+// CreateIndex crée un index et l'enregistre dans l'historique des migrations
+func CreateIndex(ctx context.Context, q Querier, t *Table, def IndexDefinition) (*Index, error) {
+	statement, err := createIndexStatement(t, def)
+	if err != nil {
+		return nil, err
+	}
+	name := def.Name
+	if name == "" {
+		name = defaultIndexName(t, def.Fields)
+	}
+	description := fmt.Sprintf("create index %s on %s", name, t.Name)
+
+	if def.Concurrently {
+		if err := execConcurrently(ctx, q, t, statement, name); err != nil {
+			return nil, err
+		}
+		_, err = recordMigration(ctx, q, description, []string{statement})
+	} else {
+		_, err = applyMigration(ctx, q, description, []string{statement})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return findIndex(ctx, q, t, name)
+}
+
+// DropIndex supprime un index de la collection et l'enregistre dans l'historique des migrations
+func DropIndex(ctx context.Context, q Querier, t *Table, name string, concurrently bool) error {
+	if _, err := findIndex(ctx, q, t, name); err != nil {
+		return err
+	}
+
+	keyword := "DROP INDEX "
+	if concurrently {
+		keyword = "DROP INDEX CONCURRENTLY "
+	}
+	statement := keyword + pgx.Identifier{t.Schema, name}.Sanitize()
+	description := fmt.Sprintf("drop index %s on %s", name, t.Name)
+
+	if !concurrently {
+		_, err := applyMigration(ctx, q, description, []string{statement})
+		return err
+	}
+	if _, err := q.Exec(ctx, statement); err != nil {
+		return translateError(err)
+	}
+	_, err := recordMigration(ctx, q, description, []string{statement})
+	return err
+}
+
+// execConcurrently exécute un CREATE INDEX CONCURRENTLY. En cas d'échec, PostgreSQL laisse
+// un index invalide qu'il faut supprimer pour pouvoir réessayer.
+func execConcurrently(ctx context.Context, q Querier, t *Table, statement, name string) error {
+	_, err := q.Exec(ctx, statement)
+	if err == nil {
+		return nil
+	}
+
+	// Si le nom est déjà pris, l'index existant n'est pas celui qui vient d'échouer
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P07" {
+		return translateError(err)
+	}
+
+	cleanup := "DROP INDEX CONCURRENTLY IF EXISTS " + pgx.Identifier{t.Schema, name}.Sanitize()
+	if _, cleanupErr := q.Exec(context.WithoutCancel(ctx), cleanup); cleanupErr != nil {
+		log.Printf("⚠️ Impossible de supprimer l'index invalide %s: %v", name, cleanupErr)
+	}
+	return translateError(err)
+}
+
+// findIndex retourne l'index de la collection portant ce nom
+func findIndex(ctx context.Context, q Querier, t *Table, name string) (*Index, error) {
+	indexes, err := ListIndexes(ctx, q, t)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		if index.Name == name {
+			return &index, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: index %q introuvable sur la collection %q", ErrNotFound, name, t.Name)
+}
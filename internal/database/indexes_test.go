@@ -0,0 +1,95 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateIndexStatement(t *testing.T) {
+	cases := []struct {
+		name     string
+		def      IndexDefinition
+		expected string
+	}{
+		{
+			name: "btree order and partial predicate",
+			def: IndexDefinition{
+				Fields:       []IndexField{{Column: "views", Order: "desc", Nulls: "last"}, {Column: "id"}},
+				Unique:       true,
+				Where:        map[string]any{"deleted_at": nil},
+				Concurrently: true,
+			},
+			expected: `CREATE UNIQUE INDEX CONCURRENTLY "posts_views_id_idx" ON "public"."posts" USING btree ("views" DESC NULLS LAST, "id") WHERE "deleted_at" IS NULL`,
+		},
+		{
+			name:     "gin jsonb",
+			def:      IndexDefinition{Name: "posts_meta", Method: "GIN", Fields: []IndexField{{Column: "meta", OpClass: "jsonb_path_ops"}}},
+			expected: `CREATE INDEX "posts_meta" ON "public"."posts" USING gin ("meta" jsonb_path_ops)`,
+		},
+		{
+			name:     "full-text",
+			def:      IndexDefinition{Name: "posts_title_fts", Method: "gin", Fields: []IndexField{{Column: "title", Language: "french"}}},
+			expected: `CREATE INDEX "posts_title_fts" ON "public"."posts" USING gin ((to_tsvector('french'::regconfig, COALESCE("title"::text, ''))))`,
+		},
+		{
+			name: "hnsw",
+			def: IndexDefinition{
+				Method: "hnsw",
+				Fields: []IndexField{{Column: "embedding", OpClass: "vector_cosine_ops"}},
+				With:   map[string]int{"m": 16, "ef_construction": 64},
+			},
+			expected: `CREATE INDEX "posts_embedding_idx" ON "public"."posts" USING hnsw ("embedding" vector_cosine_ops) WITH (ef_construction = 64, m = 16)`,
+		},
+		{
+			name:     "ivfflat",
+			def:      IndexDefinition{Method: "ivfflat", Fields: []IndexField{{Column: "embedding", OpClass: "vector_l2_ops"}}, With: map[string]int{"lists": 100}},
+			expected: `CREATE INDEX "posts_embedding_idx" ON "public"."posts" USING ivfflat ("embedding" vector_l2_ops) WITH (lists = 100)`,
+		},
+		{
+			name:     "brin",
+			def:      IndexDefinition{Method: "brin", Fields: []IndexField{{Column: "deleted_at"}}},
+			expected: `CREATE INDEX "posts_deleted_at_idx" ON "public"."posts" USING brin ("deleted_at")`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, err := createIndexStatement(embeddedPosts(), tc.def)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tc.expected {
+				t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCreateIndexStatement_Errors(t *testing.T) {
+	cases := map[string]IndexDefinition{
+		"no fields":         {},
+		"unknown column":    {Fields: []IndexField{{Column: "nope"}}},
+		"unknown method":    {Method: "spgist; DROP TABLE posts", Fields: []IndexField{{Column: "id"}}},
+		"unique gin":        {Method: "gin", Unique: true, Fields: []IndexField{{Column: "meta"}}},
+		"unknown opclass":   {Fields: []IndexField{{Column: "title", OpClass: "evil_ops"}}},
+		"opclass method":    {Fields: []IndexField{{Column: "meta", OpClass: "jsonb_ops"}}},
+		"order on gin":      {Method: "gin", Fields: []IndexField{{Column: "meta", Order: "desc"}}},
+		"invalid order":     {Fields: []IndexField{{Column: "id", Order: "sideways"}}},
+		"invalid nulls":     {Fields: []IndexField{{Column: "id", Nulls: "middle"}}},
+		"injected language": {Method: "gin", Fields: []IndexField{{Column: "title", Language: "english'); --"}}},
+		"language btree":    {Fields: []IndexField{{Column: "title", Language: "english"}}},
+		"predicate column":  {Fields: []IndexField{{Column: "id"}}, Where: map[string]any{"nope": "1"}},
+		"vector opclass":    {Method: "gin", Fields: []IndexField{{Column: "embedding", OpClass: "vector_l2_ops"}}},
+		"unknown parameter": {Method: "hnsw", Fields: []IndexField{{Column: "embedding"}}, With: map[string]int{"lists": 10}},
+		"btree parameter":   {Fields: []IndexField{{Column: "id"}}, With: map[string]int{"m": 16}},
+		"negative lists":    {Method: "ivfflat", Fields: []IndexField{{Column: "embedding"}}, With: map[string]int{"lists": -1}},
+	}
+
+	for name, def := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := createIndexStatement(embeddedPosts(), def); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
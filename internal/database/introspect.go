@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+)
+
+// Types de contraintes PostgreSQL (pg_constraint.contype)
+const (
+	ConstraintPrimaryKey = "primary_key"
+	ConstraintUnique     = "unique"
+	ConstraintCheck      = "check"
+	ConstraintForeignKey = "foreign_key"
+	ConstraintExclusion  = "exclusion"
+)
+
+// constraintTypes associe les codes de pg_constraint.contype aux noms exposés
+var constraintTypes = map[string]string{
+	"p": ConstraintPrimaryKey,
+	"u": ConstraintUnique,
+	"c": ConstraintCheck,
+	"f": ConstraintForeignKey,
+	"x": ConstraintExclusion,
+}
+
+// foreignKeyActionNames associe les codes de pg_constraint.confdeltype aux actions ON DELETE
+var foreignKeyActionNames = map[string]string{
+	"a": "no action",
+	"r": "restrict",
+	"c": "cascade",
+	"n": "set null",
+	"d": "set default",
+}
+
+// CollectionSchema décrit une collection avec ses contraintes et ses relations
+type CollectionSchema struct {
+	Schema      string         `json:"schema"`
+	Name        string         `json:"name"`
+	Comment     string         `json:"comment,omitempty"`
+	Columns     []ColumnSchema `json:"columns"`
+	PrimaryKey  []string       `json:"primary_key"`
+	Constraints []Constraint   `json:"constraints"`
+	Relations   []Relation     `json:"relations"`
+	SoftDelete  *SoftDelete    `json:"soft_delete,omitempty"`
+	JSONSchema  map[string]any `json:"json_schema"`
+}
+
+// ColumnSchema décrit une colonne avec sa valeur par défaut et son commentaire
+type ColumnSchema struct {
+	Column
+	Default  string `json:"default,omitempty"`
+	Identity bool   `json:"identity,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Constraint est une contrainte de la collection
+type Constraint struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Columns    []string `json:"columns"`
+	Definition string   `json:"definition"`
+}
+
+// Sens d'une relation entre deux collections
+const (
+	RelationOutgoing = "outgoing" // La collection référence l'autre
+	RelationIncoming = "incoming" // La collection est référencée par l'autre
+)
+
+// Relation est une clé étrangère reliant la collection à une autre
+type Relation struct {
+	Name              string   `json:"name"`
+	Direction         string   `json:"direction" enums:"outgoing,incoming"`
+	Columns           []string `json:"columns"`
+	ForeignCollection string   `json:"foreign_collection"`
+	ForeignColumns    []string `json:"foreign_columns"`
+	OnDelete          string   `json:"on_delete"`
+}
+
+// DescribeCollection lit la définition complète d'une collection depuis le catalogue
+func DescribeCollection(ctx context.Context, q Querier, name string) (*CollectionSchema, error) {
+	table, err := LoadTable(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &CollectionSchema{
+		Schema:      table.Schema,
+		Name:        table.Name,
+		PrimaryKey:  table.PrimaryKey,
+		Constraints: []Constraint{},
+		Relations:   []Relation{},
+		SoftDelete:  table.SoftDelete,
+	}
+
+	err = q.QueryRow(ctx, `
+		SELECT COALESCE(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`, table.Schema, table.Name).Scan(&schema.Comment)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if schema.Columns, err = describeColumns(ctx, q, table); err != nil {
+		return nil, err
+	}
+	if err := describeConstraints(ctx, q, schema); err != nil {
+		return nil, err
+	}
+
+	schema.JSONSchema = JSONSchema(schema)
+	return schema, nil
+}
+
+// describeColumns complète les colonnes avec leur valeur par défaut et leur commentaire
+func describeColumns(ctx context.Context, q Querier, t *Table) ([]ColumnSchema, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname,
+		       COALESCE(pg_catalog.pg_get_expr(d.adbin, d.adrelid), ''),
+		       a.attidentity <> '',
+		       COALESCE(pg_catalog.col_description(a.attrelid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped`, t.Schema, t.Name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	details := make(map[string]ColumnSchema)
+	for rows.Next() {
+		var name string
+		var col ColumnSchema
+		if err := rows.Scan(&name, &col.Default, &col.Identity, &col.Comment); err != nil {
+			return nil, translateError(err)
+		}
+		details[name] = col
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	columns := make([]ColumnSchema, len(t.Columns))
+	for i, col := range t.Columns {
+		columns[i] = details[col.Name]
+		columns[i].Column = col
+	}
+	return columns, nil
+}
+
+// describeConstraints lit les contraintes de la collection et les clés étrangères des
+// autres collections qui la référencent
+func describeConstraints(ctx context.Context, q Querier, s *CollectionSchema) error {
+	rows, err := q.Query(ctx, `
+		SELECT con.conname,
+		       con.contype::text,
+		       pg_catalog.pg_get_constraintdef(con.oid),
+		       c.relname::text,
+		       ARRAY(SELECT a.attname::text FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord),
+		       COALESCE(fc.relname::text, ''),
+		       ARRAY(SELECT a.attname::text FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord),
+		       con.confdeltype::text,
+		       con.conrelid = t.oid
+		FROM pg_catalog.pg_class t
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_catalog.pg_constraint con
+		  ON con.conrelid = t.oid OR (con.contype = 'f' AND con.confrelid = t.oid)
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		LEFT JOIN pg_catalog.pg_class fc ON fc.oid = con.confrelid
+		WHERE n.nspname = $1 AND t.relname = $2
+		ORDER BY con.conname`, s.Schema, s.Name)
+	if err != nil {
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, contype, definition, table, foreignTable, onDelete string
+		var columns, foreignColumns []string
+		var owned bool
+		if err := rows.Scan(&name, &contype, &definition, &table, &columns, &foreignTable, &foreignColumns, &onDelete, &owned); err != nil {
+			return translateError(err)
+		}
+
+		if !owned {
+			// Clé étrangère d'une autre collection pointant vers celle-ci
+			s.Relations = append(s.Relations, Relation{
+				Name:              name,
+				Direction:         RelationIncoming,
+				Columns:           foreignColumns,
+				ForeignCollection: table,
+				ForeignColumns:    columns,
+				OnDelete:          foreignKeyActionNames[onDelete],
+			})
+			continue
+		}
+
+		s.Constraints = append(s.Constraints, Constraint{
+			Name:       name,
+			Type:       constraintTypes[contype],
+			Columns:    columns,
+			Definition: definition,
+		})
+		if contype == "f" {
+			s.Relations = append(s.Relations, Relation{
+				Name:              name,
+				Direction:         RelationOutgoing,
+				Columns:           columns,
+				ForeignCollection: foreignTable,
+				ForeignColumns:    foreignColumns,
+				OnDelete:          foreignKeyActionNames[onDelete],
+			})
+		}
+	}
+	return translateError(rows.Err())
+}
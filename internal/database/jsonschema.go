@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDialect est la version de JSON Schema générée, également utilisée par OpenAPI 3.1
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema décrit un document de la collection tel que retourné par l'API de données
+func JSONSchema(s *CollectionSchema) map[string]any {
+	schema := DocumentSchema(s, false)
+	schema["$schema"] = JSONSchemaDialect
+	return schema
+}
+
+// DocumentSchema décrit un document de la collection. Pour une écriture (input), seules
+// les colonnes non nulles sans valeur par défaut sont requises ; en lecture, toutes le sont.
+func DocumentSchema(s *CollectionSchema, input bool) map[string]any {
+	properties := make(map[string]any, len(s.Columns))
+	required := make([]string, 0, len(s.Columns))
+	for _, col := range s.Columns {
+		properties[col.Name] = ColumnJSONSchema(col)
+		if !input || (!col.Nullable && !col.HasDefault && !col.Identity) {
+			required = append(required, col.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"title":                s.Name,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if s.Comment != "" {
+		schema["description"] = s.Comment
+	}
+	return schema
+}
+
+// ColumnJSONSchema traduit le type PostgreSQL d'une colonne en JSON Schema
+func ColumnJSONSchema(col ColumnSchema) map[string]any {
+	schema := typeJSONSchema(col.Type)
+	if col.Nullable {
+		if t, ok := schema["type"].(string); ok {
+			schema["type"] = []string{t, "null"}
+		}
+	}
+	if col.Comment != "" {
+		schema["description"] = col.Comment
+	}
+	return schema
+}
+
+// typeJSONSchema traduit un type issu de format_type en JSON Schema
+func typeJSONSchema(columnType string) map[string]any {
+	if element, ok := strings.CutSuffix(columnType, "[]"); ok {
+		return map[string]any{"type": "array", "items": typeJSONSchema(element)}
+	}
+
+	base, modifier, _ := strings.Cut(columnType, "(")
+	base = strings.TrimSpace(base)
+	if closing := strings.Index(modifier, ")"); closing >= 0 {
+		// Le modificateur peut précéder une partie du nom, ex: "timestamp(3) with time zone"
+		base = strings.TrimSpace(base + modifier[closing+1:])
+		modifier = modifier[:closing]
+	}
+
+	switch base {
+	case "smallint", "integer":
+		return map[string]any{"type": "integer", "format": "int32"}
+	case "bigint":
+		return map[string]any{"type": "integer", "format": "int64"}
+	case "numeric", "real", "double precision":
+		return map[string]any{"type": "number"}
+	case "boolean":
+		return map[string]any{"type": "boolean"}
+	case "json", "jsonb":
+		// N'importe quelle valeur JSON
+		return map[string]any{}
+	case "uuid":
+		return map[string]any{"type": "string", "format": "uuid"}
+	case "date":
+		return map[string]any{"type": "string", "format": "date"}
+	case "timestamp with time zone", "timestamp without time zone":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "time with time zone", "time without time zone":
+		return map[string]any{"type": "string", "format": "time"}
+	case "vector", "halfvec":
+		// pgvector n'a pas de conversion vers JSON : le vecteur est renvoyé sous sa forme texte
+		schema := map[string]any{"type": "string", "pattern": `^\[.*\]$`}
+		if dimensions, err := strconv.Atoi(modifier); err == nil {
+			schema["description"] = fmt.Sprintf("Vector of %d dimensions, e.g. [0.1,0.2]", dimensions)
+		}
+		return schema
+	case "character varying", "character":
+		schema := map[string]any{"type": "string"}
+		if length, err := strconv.Atoi(modifier); err == nil {
+			schema["maxLength"] = length
+		}
+		return schema
+	}
+	return map[string]any{"type": "string"}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTypeJSONSchema(t *testing.T) {
+	cases := map[string]string{
+		"integer":                     `{"format":"int32","type":"integer"}`,
+		"bigint":                      `{"format":"int64","type":"integer"}`,
+		"numeric(10,2)":               `{"type":"number"}`,
+		"jsonb":                       `{}`,
+		"timestamp(3) with time zone": `{"format":"date-time","type":"string"}`,
+		"character varying(255)":      `{"maxLength":255,"type":"string"}`,
+		"text[]":                      `{"items":{"type":"string"},"type":"array"}`,
+		"uuid":                        `{"format":"uuid","type":"string"}`,
+		"tsvector":                    `{"type":"string"}`,
+		"vector(3)":                   `{"description":"Vector of 3 dimensions, e.g. [0.1,0.2]","pattern":"^\\[.*\\]$","type":"string"}`,
+	}
+
+	for columnType, expected := range cases {
+		got, _ := json.Marshal(typeJSONSchema(columnType))
+		if string(got) != expected {
+			t.Errorf("typeJSONSchema(%q) = %s, want %s", columnType, got, expected)
+		}
+	}
+}
+
+func TestDocumentSchema_RequiredColumns(t *testing.T) {
+	schema := &CollectionSchema{
+		Name: "users",
+		Columns: []ColumnSchema{
+			{Column: Column{Name: "id", Type: "bigint"}, Identity: true},
+			{Column: Column{Name: "email", Type: "text"}},
+			{Column: Column{Name: "name", Type: "text", Nullable: true}},
+			{Column: Column{Name: "created_at", Type: "timestamp with time zone", HasDefault: true}},
+		},
+	}
+
+	input, _ := json.Marshal(DocumentSchema(schema, true)["required"])
+	if string(input) != `["email"]` {
+		t.Errorf("unexpected required input columns: %s", input)
+	}
+
+	output, _ := json.Marshal(DocumentSchema(schema, false)["required"])
+	if string(output) != `["id","email","name","created_at"]` {
+		t.Errorf("unexpected required document columns: %s", output)
+	}
+
+	name, _ := json.Marshal(DocumentSchema(schema, false)["properties"].(map[string]any)["name"])
+	if string(name) != `{"type":["string","null"]}` {
+		t.Errorf("unexpected nullable column schema: %s", name)
+	}
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ketsuna-org/sovrabase/internal/config"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+)
+
+// Resolver retrouve les informations de connexion d'une base gérée par l'orchestrateur
+type Resolver interface {
+	GetDatabaseInfo(ctx context.Context, projectID string) (*orchestrator.DatabaseInfo, error)
+	ListDatabases(ctx context.Context) ([]*orchestrator.DatabaseInfo, error)
+}
+
+// Querier regroupe les opérations communes à un pool, une connexion et une transaction
+type Querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Manager maintient un pool de connexions par base de données gérée
+type Manager struct {
+	resolver Resolver
+	config   *config.DataAPI
+
+	mu           sync.Mutex
+	pools        map[string]*pgxpool.Pool
+	owners       map[string]string // Projet propriétaire de chaque base résolue
+	transactions map[string]*Transaction
+	authReady    map[string]bool // Bases où le rôle des utilisateurs finaux est prêt
+
+	done      chan struct{} // Fermé par Close, arrête les tâches périodiques
+	closeOnce sync.Once
+}
+
+// NewManager crée un gestionnaire de pools de connexions
+func NewManager(resolver Resolver, cfg *config.DataAPI) *Manager {
+	return &Manager{
+		resolver:     resolver,
+		config:       cfg,
+		pools:        make(map[string]*pgxpool.Pool),
+		owners:       make(map[string]string),
+		transactions: make(map[string]*Transaction),
+		authReady:    make(map[string]bool),
+		done:         make(chan struct{}),
+	}
+}
+
+// Pool retourne le pool de connexions de la base, en le créant au premier appel. La base est
+// résolue hors du verrou, qui n'est repris que pour publier le pool : une résolution lente ne
+// bloque pas les requêtes des autres bases.
+func (m *Manager) Pool(ctx context.Context, dbID string) (*pgxpool.Pool, error) {
+	m.mu.Lock()
+	pool, ok := m.pools[dbID]
+	m.mu.Unlock()
+	if ok {
+		return pool, nil
+	}
+
+	info, err := m.resolve(ctx, dbID)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(info.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("chaîne de connexion invalide: %w", err)
+	}
+	poolConfig.MaxConns = m.config.MaxConns
+	poolConfig.MaxConnIdleTime = m.config.MaxConnIdleTime
+
+	pool, err = pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la création du pool: %w", err)
+	}
+
+	// Une requête concurrente a pu publier un pool entre-temps : le premier est conservé
+	m.mu.Lock()
+	if existing, ok := m.pools[dbID]; ok {
+		m.mu.Unlock()
+		pool.Close()
+		return existing, nil
+	}
+	m.pools[dbID] = pool
+	m.mu.Unlock()
+	return pool, nil
+}
+
+// Connect ouvre une connexion dédiée à la base, hors du pool, que l'appelant doit fermer. Elle
+// isole le SQL arbitraire : l'état de session qu'il laisse (SET ROLE, search_path, LISTEN,
+// tables temporaires) disparaît avec la connexion au lieu de passer aux requêtes suivantes.
+func (m *Manager) Connect(ctx context.Context, dbID string) (*pgx.Conn, error) {
+	pool, err := m.Pool(ctx, dbID)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pgx.ConnectConfig(ctx, pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la connexion à la base: %w", err)
+	}
+	return conn, nil
+}
+
+// Owner retourne le projet propriétaire de la base, résolu une fois par l'orchestrateur
+func (m *Manager) Owner(ctx context.Context, dbID string) (string, error) {
+	m.mu.Lock()
+	owner, ok := m.owners[dbID]
+	m.mu.Unlock()
+	if ok {
+		return owner, nil
+	}
+
+	if _, err := m.resolve(ctx, dbID); err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.owners[dbID], nil
+}
+
+// CheckOwner vérifie que la base appartient au projet. Une base d'un autre projet est
+// signalée comme inexistante (ErrNotFound), pour ne pas révéler son existence.
+func (m *Manager) CheckOwner(ctx context.Context, projectID, dbID string) error {
+	owner, err := m.Owner(ctx, dbID)
+	if err != nil {
+		return err
+	}
+	if owner != projectID {
+		return fmt.Errorf("%w: base de données %q", ErrNotFound, dbID)
+	}
+	return nil
+}
+
+// resolve retrouve la base auprès de l'orchestrateur et retient son projet propriétaire
+func (m *Manager) resolve(ctx context.Context, dbID string) (*orchestrator.DatabaseInfo, error) {
+	info, err := m.resolver.GetDatabaseInfo(ctx, dbID)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrDatabaseNotFound) {
+			return nil, fmt.Errorf("%w: base de données %q", ErrNotFound, dbID)
+		}
+		return nil, fmt.Errorf("erreur lors de la résolution de la base: %w", err)
+	}
+
+	owner := info.OwnerID
+	if owner == "" {
+		owner = dbID
+	}
+	m.mu.Lock()
+	m.owners[dbID] = owner
+	m.mu.Unlock()
+	return info, nil
+}
+
+// Release ferme le pool d'une base, par exemple après sa suppression
+func (m *Manager) Release(dbID string) {
+	// Le pool attend la restitution de ses connexions : on annule d'abord les transactions
+	m.abortTransactions(func(tx *Transaction) bool { return tx.DBID == dbID })
+
+	m.mu.Lock()
+	pool, ok := m.pools[dbID]
+	delete(m.pools, dbID)
+	delete(m.owners, dbID)
+	delete(m.authReady, dbID)
+	m.mu.Unlock()
+
+	if ok {
+		pool.Close()
+	}
+}
+
+// Close ferme tous les pools ouverts
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() { close(m.done) })
+	m.abortTransactions(func(*Transaction) bool { return true })
+
+	m.mu.Lock()
+	pools := m.pools
+	m.pools = make(map[string]*pgxpool.Pool)
+	m.owners = make(map[string]string)
+	m.authReady = make(map[string]bool)
+	m.mu.Unlock()
+
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ketsuna-org/sovrabase/internal/config"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+)
+
+// fakeResolver résout les bases d'une liste fixe et compte les résolutions
+type fakeResolver struct {
+	databases map[string]*orchestrator.DatabaseInfo
+	calls     int
+}
+
+func (f *fakeResolver) GetDatabaseInfo(ctx context.Context, dbID string) (*orchestrator.DatabaseInfo, error) {
+	f.calls++
+	info, ok := f.databases[dbID]
+	if !ok {
+		return nil, fmt.Errorf("%w pour le projet: %s", orchestrator.ErrDatabaseNotFound, dbID)
+	}
+	return info, nil
+}
+
+func (f *fakeResolver) ListDatabases(ctx context.Context) ([]*orchestrator.DatabaseInfo, error) {
+	databases := make([]*orchestrator.DatabaseInfo, 0, len(f.databases))
+	for _, info := range f.databases {
+		databases = append(databases, info)
+	}
+	return databases, nil
+}
+
+func TestCheckOwner(t *testing.T) {
+	ctx := context.Background()
+	resolver := &fakeResolver{databases: map[string]*orchestrator.DatabaseInfo{
+		"shop-db": {ProjectID: "shop-db", OwnerID: "shop"},
+		"legacy":  {ProjectID: "legacy"},
+	}}
+	m := NewManager(resolver, &config.DataAPI{})
+	defer m.Close()
+
+	if err := m.CheckOwner(ctx, "shop", "shop-db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Le jeton d'un autre projet ne donne pas accès à la base, signalée comme inexistante
+	if err := m.CheckOwner(ctx, "lab", "shop-db"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if resolver.calls != 1 {
+		t.Errorf("the owner should be resolved once, got %d resolutions", resolver.calls)
+	}
+
+	// Une base sans label de propriétaire appartient au projet de même identifiant
+	if err := m.CheckOwner(ctx, "legacy", "legacy"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := m.CheckOwner(ctx, "shop", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
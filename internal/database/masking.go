@@ -0,0 +1,385 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+)
+
+// Stratégies de masquage d'une colonne
+const (
+	MaskHash       = "hash"        // Empreinte SHA-256 en hexadécimal (ou UUID dérivé), identique pour des valeurs identiques
+	MaskRedact     = "redact"      // Valeur fixe, NULL par défaut
+	MaskFakeEmail  = "fake_email"  // Adresse user_<empreinte>@example.com
+	MaskKeepFormat = "keep_format" // Chiffres et lettres remplacés, casse, ponctuation et longueur conservées
+)
+
+// MaskingRule masque une colonne d'une collection
+type MaskingRule struct {
+	Collection string `json:"collection" example:"users"`
+	Column     string `json:"column" example:"email"`
+	Strategy   string `json:"strategy" enums:"hash,redact,fake_email,keep_format" example:"fake_email"`
+	Value      any    `json:"value,omitempty"` // Valeur de remplacement de redact, NULL si omise
+}
+
+// MaskingProfile regroupe les règles de masquage d'une base, appliquées aux données qui en
+// sortent : branches et exports
+type MaskingProfile struct {
+	Rules     []MaskingRule `json:"rules"`
+	Enforced  bool          `json:"enforced"` // Imposé par la conformité de l'organisation (RGPD) : le masquage ne peut être ni ignoré ni retiré
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// errNoMaskingProfile est retournée pour une base sans profil de masquage
+var errNoMaskingProfile = fmt.Errorf("%w: la base n'a pas de profil de masquage", ErrNotFound)
+
+// MaskingProfiles gère les profils de masquage des bases, conservés dans le plan de
+// contrôle. Le masquage est imposé aux bases des projets dont l'organisation l'exige : leur
+// profil ne peut alors ni être supprimé ni cesser de masquer une colonne. Le projet passé
+// aux méthodes est le propriétaire de la base (Manager.Owner), jamais celui d'une requête.
+type MaskingProfiles struct {
+	store      MaskingStore
+	compliance ComplianceResolver
+}
+
+// NewMaskingProfiles crée le gestionnaire des profils de masquage
+func NewMaskingProfiles(store MaskingStore, compliance ComplianceResolver) *MaskingProfiles {
+	return &MaskingProfiles{store: store, compliance: compliance}
+}
+
+// Enforced indique si l'organisation du projet impose le masquage de ses bases
+func (p *MaskingProfiles) Enforced(ctx context.Context, projectID string) (bool, error) {
+	compliance, err := p.compliance.ProjectCompliance(ctx, projectID)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la lecture de la conformité du projet: %w", err)
+	}
+	return compliance.RequiresMasking(), nil
+}
+
+// Get retourne le profil de masquage de la base
+func (p *MaskingProfiles) Get(ctx context.Context, projectID, dbID string) (*MaskingProfile, error) {
+	profile, err := p.store.GetMaskingProfile(ctx, projectID, dbID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Enforced, err = p.Enforced(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Set crée ou remplace le profil de masquage de la base. Chaque règle est vérifiée contre le
+// catalogue de q, et ses valeurs de remplacement contre le type de la colonne. Un profil
+// imposé peut être complété, mais chaque colonne qu'il masque et qui existe encore doit le rester.
+func (p *MaskingProfiles) Set(ctx context.Context, q Querier, projectID, dbID string, rules []MaskingRule) (*MaskingProfile, error) {
+	if err := validateMaskingRules(ctx, q, rules); err != nil {
+		return nil, err
+	}
+
+	enforced, err := p.Enforced(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if enforced {
+		current, err := p.store.GetMaskingProfile(ctx, projectID, dbID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if current != nil {
+			if err := checkStillMasked(ctx, q, current.Rules, rules); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	profile := &MaskingProfile{Rules: rules, Enforced: enforced, UpdatedAt: time.Now().UTC()}
+	if err := p.store.PutMaskingProfile(ctx, projectID, dbID, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Delete supprime le profil de masquage de la base, refusé quand le masquage est imposé
+func (p *MaskingProfiles) Delete(ctx context.Context, projectID, dbID string) error {
+	profile, err := p.Get(ctx, projectID, dbID)
+	if err != nil {
+		return err
+	}
+	if profile.Enforced {
+		return fmt.Errorf("%w: l'organisation du projet impose le masquage, le profil ne peut pas être supprimé", ErrForbidden)
+	}
+	return p.store.DeleteMaskingProfile(ctx, projectID, dbID)
+}
+
+// Copy copie le profil de la base vers sa branche, dont les exports restent ainsi masqués
+func (p *MaskingProfiles) Copy(ctx context.Context, projectID, sourceID, branchID string) error {
+	profile, err := p.store.GetMaskingProfile(ctx, projectID, sourceID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.store.PutMaskingProfile(ctx, projectID, branchID, profile)
+}
+
+// Forget supprime le profil d'une base qui n'existe plus, par exemple une branche expirée
+func (p *MaskingProfiles) Forget(ctx context.Context, projectID, dbID string) error {
+	return p.store.DeleteMaskingProfile(ctx, projectID, dbID)
+}
+
+// validateMaskingRules vérifie les règles contre le catalogue de la base
+func validateMaskingRules(ctx context.Context, q Querier, rules []MaskingRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("%w: le profil de masquage ne contient aucune règle", ErrInvalid)
+	}
+
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		// Le sel n'importe pas ici : les expressions ne sont évaluées que pour vérifier leurs types
+		masking := &Masking{Rules: rules}
+		tables, err := masking.load(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, t := range tables {
+			source, err := masking.source(t)
+			if err != nil {
+				return err
+			}
+			// Les littéraux sont convertis à la planification, même sans ligne à lire
+			if _, err := tx.Exec(ctx, `SELECT * FROM `+source+` AS r LIMIT 0`); err != nil {
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+	return translateError(err)
+}
+
+// checkStillMasked vérifie que les nouvelles règles masquent encore chaque colonne masquée par
+// les règles actuelles, sauf celles qui ont disparu du schéma
+func checkStillMasked(ctx context.Context, q Querier, current, rules []MaskingRule) error {
+	masked := make(map[[2]string]bool, len(rules))
+	for _, rule := range rules {
+		masked[[2]string{rule.Collection, rule.Column}] = true
+	}
+	for _, rule := range current {
+		if masked[[2]string{rule.Collection, rule.Column}] {
+			continue
+		}
+		t, err := LoadTable(ctx, q, rule.Collection)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := t.Column(rule.Column); ok {
+			return fmt.Errorf("%w: l'organisation du projet impose le masquage, la colonne %q de %q doit rester masquée", ErrForbidden, rule.Column, rule.Collection)
+		}
+	}
+	return nil
+}
+
+// Masking est le masquage appliqué à une copie des données : les règles d'un profil et le sel
+// de leurs empreintes. Le sel, aléatoire et jamais conservé, rend les empreintes cohérentes au
+// sein d'une copie (jointures, doublons) sans permettre de les recalculer à partir d'une valeur.
+type Masking struct {
+	Rules []MaskingRule
+	Salt  string
+}
+
+// NewMasking prépare le masquage des règles du profil avec un sel aléatoire
+func NewMasking(profile *MaskingProfile) (*Masking, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	return &Masking{Rules: profile.Rules, Salt: hex.EncodeToString(buf)}, nil
+}
+
+// Tables décrit la copie masquée de chaque collection masquée de la base : ses données sont
+// lues masquées dans la source, si bien que les valeurs d'origine n'atteignent pas la copie
+func (m *Masking) Tables(ctx context.Context, q Querier) ([]orchestrator.MaskedTable, error) {
+	tables, err := m.load(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	masked := make([]orchestrator.MaskedTable, 0, len(tables))
+	for _, t := range tables {
+		table, err := m.maskedTable(t)
+		if err != nil {
+			return nil, err
+		}
+		masked = append(masked, table)
+	}
+	return masked, nil
+}
+
+// load lit les collections des règles, dans l'ordre de leur nom
+func (m *Masking) load(ctx context.Context, q Querier) ([]*Table, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, rule := range m.Rules {
+		if !seen[rule.Collection] {
+			seen[rule.Collection] = true
+			names = append(names, rule.Collection)
+		}
+	}
+	sort.Strings(names)
+
+	tables := make([]*Table, len(names))
+	for i, name := range names {
+		t, err := LoadTable(ctx, q, name)
+		if err != nil {
+			return nil, fmt.Errorf("règle de masquage: %w", err)
+		}
+		tables[i] = t
+	}
+	return tables, nil
+}
+
+// columnExprs retourne l'expression masquée de chaque colonne masquée de la collection
+func (m *Masking) columnExprs(t *Table) (map[string]string, error) {
+	exprs := make(map[string]string)
+	for _, rule := range m.Rules {
+		if rule.Collection != t.Name {
+			continue
+		}
+		if _, ok := exprs[rule.Column]; ok {
+			return nil, fmt.Errorf("%w: plusieurs règles de masquage pour la colonne %q de %q", ErrInvalid, rule.Column, t.Name)
+		}
+		expr, err := maskExpr(t, rule, m.Salt)
+		if err != nil {
+			return nil, err
+		}
+		exprs[rule.Column] = expr
+	}
+	return exprs, nil
+}
+
+// source retourne la collection à lire à la place de la table : une sous-requête dont les
+// colonnes masquées sont remplacées, ou la table elle-même si aucune ne l'est
+func (m *Masking) source(t *Table) (string, error) {
+	exprs, err := m.columnExprs(t)
+	if err != nil {
+		return "", err
+	}
+	if len(exprs) == 0 {
+		return t.Identifier(), nil
+	}
+
+	columns := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		columns[i] = "r." + quoteIdent(col.Name)
+		if expr, ok := exprs[col.Name]; ok {
+			columns[i] = expr + " AS " + quoteIdent(col.Name)
+		}
+	}
+	return fmt.Sprintf("(SELECT %s FROM %s AS r)", strings.Join(columns, ", "), t.Identifier()), nil
+}
+
+// maskedTable construit la lecture masquée des lignes de la collection. La colonne de
+// recherche gérée, générée, n'est pas copiée : la copie la recalcule.
+func (m *Masking) maskedTable(t *Table) (orchestrator.MaskedTable, error) {
+	exprs, err := m.columnExprs(t)
+	if err != nil {
+		return orchestrator.MaskedTable{}, err
+	}
+	var columns, selects []string
+	for _, col := range t.Columns {
+		if col.Name == SearchColumn && t.hasSearchColumn() {
+			continue
+		}
+		expr, ok := exprs[col.Name]
+		if !ok {
+			expr = "r." + quoteIdent(col.Name)
+		}
+		columns = append(columns, quoteIdent(col.Name))
+		selects = append(selects, expr)
+	}
+	return orchestrator.MaskedTable{
+		Table:   t.Identifier(),
+		Columns: columns,
+		Query:   fmt.Sprintf("SELECT %s FROM %s AS r", strings.Join(selects, ", "), t.Identifier()),
+	}, nil
+}
+
+// maskExpr génère l'expression masquant la colonne de r, convertie vers son type.
+// Les valeurs NULL restent NULL, sauf avec redact.
+func maskExpr(t *Table, rule MaskingRule, salt string) (string, error) {
+	col, ok := t.Column(rule.Column)
+	if !ok {
+		return "", fmt.Errorf("%w: règle de masquage sur une colonne inconnue %q de %q", ErrInvalid, rule.Column, t.Name)
+	}
+	if isKey(t, col.Name) {
+		return "", fmt.Errorf("%w: la colonne %q fait partie de la clé primaire de %q et ne peut pas être masquée", ErrInvalid, col.Name, t.Name)
+	}
+	if col.Name == SearchColumn && t.hasSearchColumn() {
+		return "", fmt.Errorf("%w: la colonne de recherche gérée est générée et ne peut pas être masquée", ErrInvalid)
+	}
+
+	ref := "r." + quoteIdent(col.Name)
+	digest := func(value string) string {
+		return fmt.Sprintf("sha256(convert_to(%s || %s, 'UTF8'))", quoteLiteral(salt), value)
+	}
+	text := isTextType(col.Type)
+
+	switch rule.Strategy {
+	case MaskRedact:
+		if rule.Value == nil {
+			if !col.Nullable {
+				return "", fmt.Errorf("%w: la colonne %q n'accepte pas NULL, redact requiert une valeur", ErrInvalid, col.Name)
+			}
+			return "NULL::" + col.Type, nil
+		}
+		expr, err := defaultExpr(col.Type, rule.Value, "")
+		if err != nil {
+			return "", fmt.Errorf("règle de masquage de %q: %w", col.Name, err)
+		}
+		return expr, nil
+	case MaskHash:
+		if baseType(col.Type) == "uuid" {
+			return fmt.Sprintf("encode(substring(%s FROM 1 FOR 16), 'hex')::uuid", digest(ref+"::text")), nil
+		}
+		if text {
+			return fmt.Sprintf("encode(%s, 'hex')::%s", digest(ref+"::text"), col.Type), nil
+		}
+	case MaskFakeEmail:
+		if text {
+			return fmt.Sprintf("('user_' || left(encode(%s, 'hex'), 16) || '@example.com')::%s", digest(ref+"::text"), col.Type), nil
+		}
+	case MaskKeepFormat:
+		if text {
+			// Chaque caractère est tiré de l'empreinte de la valeur et de sa position
+			char := func(alphabet string) string {
+				return fmt.Sprintf("substr('%[1]s', 1 + get_byte(%[2]s, 0) %% %[3]d, 1)", alphabet, digest(ref+"::text || ':' || c.i"), len(alphabet))
+			}
+			return fmt.Sprintf(`(CASE WHEN %[1]s IS NULL THEN NULL ELSE COALESCE((SELECT string_agg(CASE `+
+				`WHEN c.ch ~ '^[0-9]$' THEN %[2]s WHEN c.ch ~ '^[a-z]$' THEN %[3]s WHEN c.ch ~ '^[A-Z]$' THEN %[4]s ELSE c.ch END, '' ORDER BY c.i) `+
+				`FROM regexp_split_to_table(%[1]s::text, '') WITH ORDINALITY AS c(ch, i)), '') END)::%[5]s`,
+				ref, char("0123456789"), char("abcdefghijklmnopqrstuvwxyz"), char("ABCDEFGHIJKLMNOPQRSTUVWXYZ"), col.Type), nil
+		}
+	default:
+		return "", fmt.Errorf("%w: stratégie de masquage inconnue %q (hash, redact, fake_email ou keep_format)", ErrInvalid, rule.Strategy)
+	}
+	return "", fmt.Errorf("%w: la stratégie %s ne s'applique pas à la colonne %q de type %s", ErrInvalid, rule.Strategy, col.Name, col.Type)
+}
+
+// isTextType indique si la colonne contient du texte
+func isTextType(columnType string) bool {
+	switch baseType(columnType) {
+	case "text", "character varying", "character", "citext":
+		return true
+	}
+	return false
+}
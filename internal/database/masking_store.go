@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ketsuna-org/sovrabase/internal/config"
+	"github.com/ketsuna-org/sovrabase/internal/models/organization"
+)
+
+// MaskingStore conserve les profils de masquage dans le plan de contrôle : ni les clés du
+// projet ni le SQL exécuté sur la base ne peuvent les modifier directement
+type MaskingStore interface {
+	// GetMaskingProfile retourne le profil de la base, ErrNotFound si elle n'en a pas
+	// ou appartient à un autre projet
+	GetMaskingProfile(ctx context.Context, projectID, dbID string) (*MaskingProfile, error)
+	// PutMaskingProfile crée ou remplace le profil de la base
+	PutMaskingProfile(ctx context.Context, projectID, dbID string, profile *MaskingProfile) error
+	// DeleteMaskingProfile supprime le profil de la base du projet, s'il existe
+	DeleteMaskingProfile(ctx context.Context, projectID, dbID string) error
+}
+
+// NewMaskingStore ouvre le store des profils de masquage dans la base interne du plan de contrôle
+func NewMaskingStore(ctx context.Context, cfg *config.InternalDB) (MaskingStore, error) {
+	switch cfg.Manager {
+	case "postgres":
+		return NewPostgresMaskingStore(ctx, cfg.URI)
+	case "memory":
+		return NewMemoryMaskingStore(), nil
+	default:
+		return nil, fmt.Errorf("base interne %q non supportée par le masquage (postgres ou memory)", cfg.Manager)
+	}
+}
+
+// MemoryMaskingStore conserve les profils en mémoire, perdus à l'arrêt du serveur : il est
+// destiné aux tests et au développement
+type MemoryMaskingStore struct {
+	mu       sync.RWMutex
+	profiles map[string]maskingEntry // Par base
+}
+
+type maskingEntry struct {
+	projectID string
+	profile   MaskingProfile
+}
+
+// NewMemoryMaskingStore crée un store en mémoire vide
+func NewMemoryMaskingStore() *MemoryMaskingStore {
+	return &MemoryMaskingStore{profiles: make(map[string]maskingEntry)}
+}
+
+func (s *MemoryMaskingStore) GetMaskingProfile(ctx context.Context, projectID, dbID string) (*MaskingProfile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.profiles[dbID]
+	if !ok || entry.projectID != projectID {
+		return nil, errNoMaskingProfile
+	}
+	profile := entry.profile
+	profile.Rules = append([]MaskingRule(nil), entry.profile.Rules...)
+	return &profile, nil
+}
+
+func (s *MemoryMaskingStore) PutMaskingProfile(ctx context.Context, projectID, dbID string, profile *MaskingProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *profile
+	copied.Rules = append([]MaskingRule(nil), profile.Rules...)
+	s.profiles[dbID] = maskingEntry{projectID: projectID, profile: copied}
+	return nil
+}
+
+func (s *MemoryMaskingStore) DeleteMaskingProfile(ctx context.Context, projectID, dbID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.profiles[dbID]; ok && entry.projectID == projectID {
+		delete(s.profiles, dbID)
+	}
+	return nil
+}
+
+// maskingSchema crée la table des profils dans la base interne
+const maskingSchema = `
+CREATE SCHEMA IF NOT EXISTS sovrabase;
+
+CREATE TABLE IF NOT EXISTS sovrabase.masking_profiles (
+	db_id text PRIMARY KEY,
+	project_id text NOT NULL,
+	rules jsonb NOT NULL,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);`
+
+// PostgresMaskingStore conserve les profils dans la base interne PostgreSQL
+type PostgresMaskingStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresMaskingStore se connecte à la base interne et crée la table des profils
+func NewPostgresMaskingStore(ctx context.Context, uri string) (*PostgresMaskingStore, error) {
+	pool, err := pgxpool.New(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("connexion à la base interne: %w", err)
+	}
+	if _, err := pool.Exec(ctx, maskingSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("création de la table des profils de masquage: %w", err)
+	}
+	return &PostgresMaskingStore{pool: pool}, nil
+}
+
+// Close ferme les connexions à la base interne
+func (s *PostgresMaskingStore) Close() {
+	s.pool.Close()
+}
+
+func (s *PostgresMaskingStore) GetMaskingProfile(ctx context.Context, projectID, dbID string) (*MaskingProfile, error) {
+	var profile MaskingProfile
+	var rules []byte
+	err := s.pool.QueryRow(ctx, `SELECT rules, updated_at FROM sovrabase.masking_profiles
+		WHERE db_id = $1 AND project_id = $2`, dbID, projectID).Scan(&rules, &profile.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, errNoMaskingProfile
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rules, &profile.Rules); err != nil {
+		return nil, fmt.Errorf("profil de masquage illisible: %w", err)
+	}
+	return &profile, nil
+}
+
+func (s *PostgresMaskingStore) PutMaskingProfile(ctx context.Context, projectID, dbID string, profile *MaskingProfile) error {
+	rules, err := json.Marshal(profile.Rules)
+	if err != nil {
+		return fmt.Errorf("%w: règles de masquage invalides", ErrInvalid)
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO sovrabase.masking_profiles (db_id, project_id, rules, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (db_id) DO UPDATE SET project_id = EXCLUDED.project_id, rules = EXCLUDED.rules, updated_at = EXCLUDED.updated_at`,
+		dbID, projectID, string(rules), profile.UpdatedAt)
+	return err
+}
+
+func (s *PostgresMaskingStore) DeleteMaskingProfile(ctx context.Context, projectID, dbID string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM sovrabase.masking_profiles WHERE db_id = $1 AND project_id = $2`, dbID, projectID)
+	return err
+}
+
+// ComplianceResolver retrouve les exigences de conformité de l'organisation propriétaire d'un projet
+type ComplianceResolver interface {
+	ProjectCompliance(ctx context.Context, projectID string) (organization.OrganisationCompliance, error)
+}
+
+// ConfiguredCompliance retrouve la conformité des organisations déclarées dans la
+// configuration, par projet. Un projet d'aucune organisation n'a pas d'exigence.
+type ConfiguredCompliance map[string]organization.OrganisationCompliance
+
+// NewConfiguredCompliance indexe la conformité des organisations par projet
+func NewConfiguredCompliance(organizations []config.Organization) ConfiguredCompliance {
+	compliance := make(ConfiguredCompliance)
+	for _, org := range organizations {
+		for _, projectID := range org.Projects {
+			compliance[projectID] = org.Compliance
+		}
+	}
+	return compliance
+}
+
+func (c ConfiguredCompliance) ProjectCompliance(ctx context.Context, projectID string) (organization.OrganisationCompliance, error) {
+	return c[projectID], nil
+}
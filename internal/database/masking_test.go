@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+)
+
+// customersTable est une collection contenant des données personnelles
+func customersTable() *Table {
+	return &Table{
+		Schema: DefaultSchema,
+		Name:   "customers",
+		Columns: []Column{
+			{Name: "id", Type: "uuid", HasDefault: true},
+			{Name: "email", Type: "character varying(255)"},
+			{Name: "phone", Type: "text", Nullable: true},
+			{Name: "ref", Type: "uuid", Nullable: true},
+			{Name: "age", Type: "integer"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+func TestMaskExpr(t *testing.T) {
+	cases := map[string]struct {
+		rule MaskingRule
+		want string
+	}{
+		"hash text": {
+			MaskingRule{Column: "phone", Strategy: MaskHash},
+			`encode(sha256(convert_to('s3' || r."phone"::text, 'UTF8')), 'hex')::text`,
+		},
+		"hash uuid": {
+			MaskingRule{Column: "ref", Strategy: MaskHash},
+			`encode(substring(sha256(convert_to('s3' || r."ref"::text, 'UTF8')) FROM 1 FOR 16), 'hex')::uuid`,
+		},
+		"fake email": {
+			MaskingRule{Column: "email", Strategy: MaskFakeEmail},
+			`('user_' || left(encode(sha256(convert_to('s3' || r."email"::text, 'UTF8')), 'hex'), 16) || '@example.com')::character varying(255)`,
+		},
+		"redact null": {
+			MaskingRule{Column: "phone", Strategy: MaskRedact},
+			`NULL::text`,
+		},
+		"redact value": {
+			MaskingRule{Column: "age", Strategy: MaskRedact, Value: float64(0)},
+			`'0'::integer`,
+		},
+	}
+	for name, c := range cases {
+		got, err := maskExpr(customersTable(), c.rule, "s3")
+		if err != nil || got != c.want {
+			t.Errorf("%s: got %s (%v), want %s", name, got, err, c.want)
+		}
+	}
+
+	got, err := maskExpr(customersTable(), MaskingRule{Column: "phone", Strategy: MaskKeepFormat}, "s3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, part := range []string{
+		`CASE WHEN r."phone" IS NULL THEN NULL`,
+		`regexp_split_to_table(r."phone"::text, '') WITH ORDINALITY AS c(ch, i)`,
+		`substr('0123456789', 1 + get_byte(sha256(convert_to('s3' || r."phone"::text || ':' || c.i, 'UTF8')), 0) % 10, 1)`,
+		`% 26, 1)`,
+		`ELSE c.ch END, '' ORDER BY c.i)`,
+		`)::text`,
+	} {
+		if !strings.Contains(got, part) {
+			t.Errorf("keep_format expression lacks %s:\n%s", part, got)
+		}
+	}
+}
+
+func TestMaskExpr_Invalid(t *testing.T) {
+	cases := map[string]MaskingRule{
+		"unknown column":    {Column: "address", Strategy: MaskHash},
+		"primary key":       {Column: "id", Strategy: MaskRedact},
+		"unknown strategy":  {Column: "email", Strategy: "shuffle"},
+		"redact not null":   {Column: "email", Strategy: MaskRedact},
+		"hash integer":      {Column: "age", Strategy: MaskHash},
+		"fake email uuid":   {Column: "ref", Strategy: MaskFakeEmail},
+		"keep format uuid":  {Column: "ref", Strategy: MaskKeepFormat},
+		"redact json value": {Column: "age", Strategy: MaskRedact, Value: map[string]any{"a": 1}},
+	}
+	for name, rule := range cases {
+		if _, err := maskExpr(customersTable(), rule, "s3"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := maskExpr(customersTable(), cases["primary key"], "s3"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestMaskingSource(t *testing.T) {
+	masking := &Masking{Salt: "s3", Rules: []MaskingRule{
+		{Collection: "customers", Column: "phone", Strategy: MaskRedact},
+		{Collection: "posts", Column: "title", Strategy: MaskHash},
+	}}
+
+	source, err := masking.source(customersTable())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `(SELECT r."id", r."email", NULL::text AS "phone", r."ref", r."age" FROM "public"."customers" AS r)`
+	if source != expected {
+		t.Errorf("unexpected source:\ngot  %s\nwant %s", source, expected)
+	}
+
+	// Une collection sans règle est lue directement
+	if source, err := masking.source(usersTable()); err != nil || source != `"public"."users"` {
+		t.Errorf("unexpected source: %s (%v)", source, err)
+	}
+
+	masking.Rules = append(masking.Rules, MaskingRule{Collection: "customers", Column: "phone", Strategy: MaskHash})
+	if _, err := masking.source(customersTable()); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for duplicate rules, got %v", err)
+	}
+}
+
+func TestMaskedTable(t *testing.T) {
+	masking := &Masking{Salt: "s3", Rules: []MaskingRule{
+		{Collection: "customers", Column: "phone", Strategy: MaskRedact},
+		{Collection: "customers", Column: "age", Strategy: MaskRedact, Value: float64(0)},
+	}}
+
+	table, err := masking.maskedTable(customersTable())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := orchestrator.MaskedTable{
+		Table:   `"public"."customers"`,
+		Columns: []string{`"id"`, `"email"`, `"phone"`, `"ref"`, `"age"`},
+		Query:   `SELECT r."id", r."email", NULL::text, r."ref", '0'::integer FROM "public"."customers" AS r`,
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Errorf("unexpected table:\ngot  %+v\nwant %+v", table, expected)
+	}
+
+	// La colonne de recherche, générée, est recalculée par la copie
+	searchable := customersTable()
+	searchable.Columns = append(searchable.Columns, Column{Name: SearchColumn, Type: "tsvector", Nullable: true})
+	if table, err := masking.maskedTable(searchable); err != nil || len(table.Columns) != 5 {
+		t.Errorf("unexpected columns %q (%v)", table.Columns, err)
+	}
+}
+
+func TestExportStatement_Masking(t *testing.T) {
+	exp := Export{
+		Format:  FormatCSV,
+		Select:  []string{"id", "phone"},
+		Masking: &Masking{Salt: "s3", Rules: []MaskingRule{{Collection: "customers", Column: "phone", Strategy: MaskRedact}}},
+	}
+	sql, _, _, err := exportStatement(customersTable(), exp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT r."id", r."phone" FROM (SELECT r."id", r."email", NULL::text AS "phone", r."ref", r."age" FROM "public"."customers" AS r) AS r WHERE TRUE ORDER BY r."id" ASC`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestNewMasking(t *testing.T) {
+	profile := &MaskingProfile{Rules: []MaskingRule{{Collection: "customers", Column: "phone", Strategy: MaskHash}}}
+	first, err := NewMasking(profile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := NewMasking(profile)
+	if len(first.Salt) != 32 || first.Salt == second.Salt {
+		t.Errorf("each masking should get its own random salt: %q, %q", first.Salt, second.Salt)
+	}
+}
+
+func TestMaskingProfiles_Enforced(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryMaskingStore()
+	profile := &MaskingProfile{Rules: []MaskingRule{{Collection: "customers", Column: "email", Strategy: MaskFakeEmail}}}
+	store.PutMaskingProfile(ctx, "shop", "shop-db", profile)
+	store.PutMaskingProfile(ctx, "lab", "lab-db", profile)
+	profiles := NewMaskingProfiles(store, ConfiguredCompliance{"shop": {RGPD: true}})
+
+	got, err := profiles.Get(ctx, "shop", "shop-db")
+	if err != nil || !got.Enforced || len(got.Rules) != 1 {
+		t.Fatalf("unexpected profile %+v: %v", got, err)
+	}
+
+	// La conformité de l'organisation impose le profil : la clé du projet ne peut pas le supprimer
+	if err := profiles.Delete(ctx, "shop", "shop-db"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+	if _, err := profiles.Get(ctx, "shop", "shop-db"); err != nil {
+		t.Errorf("the enforced profile should remain: %v", err)
+	}
+	// Un profil n'est visible que depuis le projet de sa base
+	if err := profiles.Delete(ctx, "lab", "shop-db"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.DeleteMaskingProfile(ctx, "lab", "shop-db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := profiles.Get(ctx, "shop", "shop-db"); err != nil {
+		t.Errorf("another project should not delete the profile: %v", err)
+	}
+
+	// Les branches héritent du profil, imposé lui aussi
+	if err := profiles.Copy(ctx, "shop", "shop-db", "shop-db-staging"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := profiles.Get(ctx, "shop", "shop-db-staging"); err != nil || !got.Enforced {
+		t.Errorf("unexpected branch profile %+v: %v", got, err)
+	}
+
+	// Sans exigence de conformité, le profil est facultatif
+	if got, _ := profiles.Get(ctx, "lab", "lab-db"); got == nil || got.Enforced {
+		t.Errorf("unexpected profile %+v", got)
+	}
+	if err := profiles.Delete(ctx, "lab", "lab-db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := profiles.Get(ctx, "lab", "lab-db"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
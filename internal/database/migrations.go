@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MetadataSchema est le schéma où Sovrabase conserve ses propres tables dans chaque base gérée
+const MetadataSchema = "sovrabase"
+
+// Migration est un changement de schéma appliqué à une base, conservé pour pouvoir être rejoué
+type Migration struct {
+	ID          int64     `json:"id"`
+	Description string    `json:"description"`
+	Statements  []string  `json:"statements"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+// migrationsTable est la table d'historique des migrations
+var migrationsTable = pgx.Identifier{MetadataSchema, "migrations"}.Sanitize()
+
+// ensureMigrationsTable crée le schéma et la table d'historique s'ils n'existent pas
+func ensureMigrationsTable(ctx context.Context, q Querier) error {
+	if _, err := q.Exec(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoteIdent(MetadataSchema)); err != nil {
+		return translateError(err)
+	}
+	_, err := q.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+		id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		description text NOT NULL,
+		statements text[] NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	return translateError(err)
+}
+
+// applyMigration exécute les instructions et les enregistre dans l'historique, dans une même transaction
+func applyMigration(ctx context.Context, q Querier, description string, statements []string) (*Migration, error) {
+	migration := &Migration{Description: description, Statements: statements}
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		if err := ensureMigrationsTable(ctx, tx); err != nil {
+			return err
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return translateError(err)
+			}
+		}
+		return insertMigration(ctx, tx, migration)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return migration, nil
+}
+
+// recordMigration enregistre dans l'historique des instructions déjà exécutées hors
+// transaction, comme CREATE INDEX CONCURRENTLY
+func recordMigration(ctx context.Context, q Querier, description string, statements []string) (*Migration, error) {
+	migration := &Migration{Description: description, Statements: statements}
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		if err := ensureMigrationsTable(ctx, tx); err != nil {
+			return err
+		}
+		return insertMigration(ctx, tx, migration)
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return migration, nil
+}
+
+// insertMigration ajoute une migration à l'historique et renseigne son identifiant et sa date
+func insertMigration(ctx context.Context, q Querier, migration *Migration) error {
+	return q.QueryRow(ctx,
+		`INSERT INTO `+migrationsTable+` (description, statements) VALUES ($1, $2) RETURNING id, applied_at`,
+		migration.Description, migration.Statements,
+	).Scan(&migration.ID, &migration.AppliedAt)
+}
+
+// ListMigrations retourne l'historique des migrations de la base, de la plus ancienne à la plus récente
+func ListMigrations(ctx context.Context, q Querier) ([]Migration, error) {
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, migrationsTable).Scan(&exists); err != nil {
+		return nil, translateError(err)
+	}
+	if !exists {
+		return []Migration{}, nil
+	}
+
+	rows, err := q.Query(ctx, `SELECT id, description, statements, applied_at FROM `+migrationsTable+` ORDER BY id`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	migrations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Migration, error) {
+		var m Migration
+		err := row.Scan(&m.ID, &m.Description, &m.Statements, &m.AppliedAt)
+		return m, err
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return migrations, nil
+}
+
+// MigrationScript assemble les migrations en un script SQL rejouable sur une autre base
+func MigrationScript(migrations []Migration) string {
+	var b strings.Builder
+	for _, m := range migrations {
+		fmt.Fprintf(&b, "-- Migration %d: %s (%s)\n", m.ID, m.Description, m.AppliedAt.UTC().Format(time.RFC3339))
+		for _, statement := range m.Statements {
+			b.WriteString(statement + ";\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// CreateCollection crée une collection et retourne sa définition
+func CreateCollection(ctx context.Context, q Querier, def CollectionDefinition) (*Table, error) {
+	statement, err := createTableStatement(def)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]string, len(def.Columns))
+	for i, col := range def.Columns {
+		types[i] = col.Type
+	}
+	if _, err := applyMigration(ctx, q, "create collection "+def.Name, withVectorExtension([]string{statement}, types...)); err != nil {
+		return nil, err
+	}
+	return LoadTable(ctx, q, def.Name)
+}
+
+// RenameCollection renomme une collection et retourne sa nouvelle définition
+func RenameCollection(ctx context.Context, q Querier, t *Table, name string) (*Table, error) {
+	statement, err := renameTableStatement(t, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, fmt.Sprintf("rename collection %s to %s", t.Name, name), []string{statement}); err != nil {
+		return nil, err
+	}
+	return LoadTable(ctx, q, name)
+}
+
+// DropCollection supprime une collection
+func DropCollection(ctx context.Context, q Querier, t *Table) error {
+	_, err := applyMigration(ctx, q, "drop collection "+t.Name, []string{dropTableStatement(t)})
+	return err
+}
+
+// AddColumn ajoute une colonne à une collection et retourne sa nouvelle définition
+func AddColumn(ctx context.Context, q Querier, t *Table, col ColumnDefinition) (*Table, error) {
+	statement, err := addColumnStatement(t, col)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, fmt.Sprintf("add column %s.%s", t.Name, col.Name), withVectorExtension([]string{statement}, col.Type)); err != nil {
+		return nil, err
+	}
+	return LoadTable(ctx, q, t.Name)
+}
+
+// AlterColumn modifie une colonne d'une collection et retourne sa nouvelle définition
+func AlterColumn(ctx context.Context, q Querier, t *Table, name string, change ColumnChange) (*Table, error) {
+	statements, err := alterColumnStatements(t, name, change)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, fmt.Sprintf("alter column %s.%s", t.Name, name), withVectorExtension(statements, change.Type)); err != nil {
+		return nil, err
+	}
+	return LoadTable(ctx, q, t.Name)
+}
+
+// DropColumn supprime une colonne d'une collection
+func DropColumn(ctx context.Context, q Querier, t *Table, name string) error {
+	statement, err := dropColumnStatement(t, name)
+	if err != nil {
+		return err
+	}
+	_, err = applyMigration(ctx, q, fmt.Sprintf("drop column %s.%s", t.Name, name), []string{statement})
+	return err
+}
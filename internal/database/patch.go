@@ -0,0 +1,399 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Opérations JSON Patch (RFC 6902)
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// Limites des patchs
+const (
+	MaxPatchOperations = 100 // Opérations d'un JSON Patch
+	maxPatchDepth      = 32  // Segments d'un chemin, ou niveaux d'un merge patch
+)
+
+// arrayIndexPattern valide un indice de tableau d'un JSON Pointer, sans zéro initial
+var arrayIndexPattern = regexp.MustCompile(`^(0|[1-9][0-9]{0,8})$`)
+
+// PatchOperation est une opération JSON Patch. Le premier segment des chemins est la colonne
+// visée ; les suivants descendent dans sa valeur JSON (jsonb, json ou tableau).
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string          // Source de move et copy
+	Value json.RawMessage // Valeur de add, replace et test
+}
+
+// patchStep est une étape d'un patch : la nouvelle valeur du document s.d, appliquée si la
+// condition est remplie, l'échec de l'étape annulant le patch sinon
+type patchStep struct {
+	expr string
+	cond string
+	desc string // Opération, pour l'erreur d'échec
+}
+
+// patchCompiler traduit un patch en opérations jsonb sur le document de la ligne
+type patchCompiler struct {
+	table   *Table
+	args    []any
+	steps   []patchStep
+	columns map[string]bool // Colonnes modifiées
+}
+
+// PatchDocument applique un JSON Patch (RFC 6902) au document et retourne la ligne mise à jour
+// et sa nouvelle version. Les opérations sont appliquées dans l'ordre, dans une même instruction :
+// si l'une échoue (chemin absent, test non vérifié), aucune n'est appliquée et l'erreur est
+// ErrConflict. Avec versions non nil, le document n'est modifié que si sa version est l'une d'elles.
+func PatchDocument(ctx context.Context, q Querier, t *Table, id string, ops []PatchOperation, versions []string) (json.RawMessage, string, error) {
+	if len(ops) == 0 {
+		return nil, "", fmt.Errorf("%w: le patch ne contient aucune opération", ErrInvalid)
+	}
+	if len(ops) > MaxPatchOperations {
+		return nil, "", fmt.Errorf("%w: trop d'opérations dans le patch (maximum %d)", ErrInvalid, MaxPatchOperations)
+	}
+	key, err := t.documentKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	c := newPatchCompiler(t, key, id, versions)
+	for i, op := range ops {
+		if err := c.operation(op); err != nil {
+			return nil, "", fmt.Errorf("opération %d: %w", i, err)
+		}
+	}
+	return c.run(ctx, q, key, id, versions)
+}
+
+// MergePatchDocument applique un merge patch (RFC 7396) au document : chaque clé remplace la
+// colonne, null la vide, et un objet appliqué à une colonne json ou jsonb y est fusionné
+// récursivement, null supprimant la clé. Voir PatchDocument pour versions.
+func MergePatchDocument(ctx context.Context, q Querier, t *Table, id string, patch map[string]any, versions []string) (json.RawMessage, string, error) {
+	key, err := t.documentKey()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(patch) == 0 {
+		return nil, "", fmt.Errorf("%w: aucune colonne à mettre à jour", ErrInvalid)
+	}
+
+	c := newPatchCompiler(t, key, id, versions)
+	if err := c.merge(patch); err != nil {
+		return nil, "", err
+	}
+	return c.run(ctx, q, key, id, versions)
+}
+
+// newPatchCompiler prépare la compilation d'un patch : la clé du document est passée en $1
+// et, pour une modification conditionnelle, les versions attendues en $2
+func newPatchCompiler(t *Table, key, id string, versions []string) *patchCompiler {
+	c := &patchCompiler{table: t, args: []any{map[string]any{key: id}}, columns: make(map[string]bool)}
+	if versions != nil {
+		c.args = append(c.args, versions)
+	}
+	return c
+}
+
+// run exécute le patch et traduit son résultat
+func (c *patchCompiler) run(ctx context.Context, q Querier, key, id string, versions []string) (json.RawMessage, string, error) {
+	var failed *int32
+	var doc json.RawMessage
+	var version *string
+	columns := make([]string, 0, len(c.columns))
+	for _, col := range c.table.Columns {
+		if c.columns[col.Name] {
+			columns = append(columns, col.Name)
+		}
+	}
+	sql := patchStatement(c.table, key, c.steps, columns, versions != nil)
+	if err := q.QueryRow(ctx, sql, c.args...).Scan(&failed, &doc, &version); err != nil {
+		if versions != nil && errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", versionMismatch(ctx, q, c.table, key, id)
+		}
+		return nil, "", translateError(err)
+	}
+	if failed != nil {
+		return nil, "", fmt.Errorf("%w: opération %d (%s) impossible sur le document", ErrConflict, *failed-1, c.steps[*failed-1].desc)
+	}
+	return doc, *version, nil
+}
+
+// patchStatement génère l'application des étapes. La ligne est verrouillée puis chaque étape
+// calcule le document suivant à partir du précédent ; les colonnes modifiées sont enfin
+// relues du dernier document par jsonb_populate_record, qui les convertit dans leur type.
+// Le numéro de la première étape en échec est retourné, sans modifier la ligne.
+func patchStatement(t *Table, key string, steps []patchStep, columns []string, conditional bool) string {
+	table, keyColumn := t.Identifier(), quoteIdent(key)
+	match := t.liveCond("r", fmt.Sprintf("r.%[1]s = k.%[1]s", keyColumn))
+	if conditional {
+		match += fmt.Sprintf(" AND %s = ANY($2::text[])", t.versionExpr("r"))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `WITH s0 AS (SELECT to_jsonb(r.*) AS d, NULL::int AS failed FROM %[1]s AS r, jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS k WHERE %[2]s FOR UPDATE OF r)`, table, match)
+	for i, step := range steps {
+		fmt.Fprintf(&b, `, s%[1]d AS (SELECT CASE WHEN s.failed IS NULL AND %[3]s THEN %[4]s ELSE s.d END AS d, CASE WHEN s.failed IS NULL AND (%[3]s) IS NOT TRUE THEN %[1]d ELSE s.failed END AS failed FROM s%[2]d AS s)`,
+			i+1, i, step.cond, step.expr)
+	}
+	last := fmt.Sprintf("s%d", len(steps))
+
+	if len(columns) == 0 {
+		// Un patch sans modification (uniquement des tests) relit le document sans l'écrire
+		fmt.Fprintf(&b, `, u AS (SELECT %[2]s AS doc, %[3]s AS version FROM %[1]s AS r, %[4]s AS s, jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS k WHERE r.%[5]s = k.%[5]s AND s.failed IS NULL)`,
+			table, t.documentExpr(), t.versionExpr("r"), last, keyColumn)
+	} else {
+		assignments := make([]string, 0, len(columns)+1)
+		for _, col := range columns {
+			assignments = append(assignments, fmt.Sprintf("%[1]s = p.%[1]s", quoteIdent(col)))
+		}
+		if bump := t.versionBump(columns); bump != "" {
+			assignments = append(assignments, bump)
+		}
+		fmt.Fprintf(&b, `, u AS (UPDATE %[1]s AS r SET %[2]s FROM %[3]s AS s, jsonb_populate_record(NULL::%[1]s, s.d) AS p, jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS k WHERE r.%[4]s = k.%[4]s AND s.failed IS NULL RETURNING %[5]s AS doc, %[6]s AS version)`,
+			table, strings.Join(assignments, ", "), last, keyColumn, t.documentExpr(), t.versionExpr("r"))
+	}
+	fmt.Fprintf(&b, ` SELECT s.failed, u.doc, u.version FROM %s AS s LEFT JOIN u ON TRUE`, last)
+	return b.String()
+}
+
+// param ajoute une valeur aux paramètres et retourne sa référence
+func (c *patchCompiler) param(value any, cast string) string {
+	c.args = append(c.args, value)
+	return "$" + strconv.Itoa(len(c.args)) + "::" + cast
+}
+
+// jsonParam ajoute une valeur JSON aux paramètres, transmise sous sa forme texte
+func (c *patchCompiler) jsonParam(value json.RawMessage) string {
+	return c.param(string(value), "jsonb")
+}
+
+// touch enregistre une colonne modifiée
+func (c *patchCompiler) touch(column string) error {
+	if err := c.table.checkWritable(column); err != nil {
+		return err
+	}
+	c.columns[column] = true
+	return nil
+}
+
+// pointer décode un JSON Pointer (RFC 6901) dont le premier segment est une colonne
+func (c *patchCompiler) pointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, fmt.Errorf("%w: le document entier ne peut pas être remplacé, le chemin doit désigner une colonne", ErrInvalid)
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: chemin invalide %q, un JSON Pointer commence par /", ErrInvalid, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	if len(tokens) > maxPatchDepth {
+		return nil, fmt.Errorf("%w: chemin trop profond %q (maximum %d segments)", ErrInvalid, pointer, maxPatchDepth)
+	}
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	if _, ok := c.table.Column(tokens[0]); !ok {
+		return nil, fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, tokens[0], c.table.Name)
+	}
+	return tokens, nil
+}
+
+// operation compile une opération JSON Patch en étape
+func (c *patchCompiler) operation(op PatchOperation) error {
+	path, err := c.pointer(op.Path)
+	if err != nil {
+		return err
+	}
+	needsValue := op.Op == PatchAdd || op.Op == PatchReplace || op.Op == PatchTest
+	if needsValue && len(op.Value) == 0 {
+		return fmt.Errorf("%w: %s attend une valeur", ErrInvalid, op.Op)
+	}
+	if needsValue && !json.Valid(op.Value) {
+		return fmt.Errorf("%w: valeur JSON invalide", ErrInvalid)
+	}
+
+	step := patchStep{desc: op.Op + " " + op.Path}
+	const d = "s.d"
+	switch op.Op {
+	case PatchAdd:
+		step.expr, step.cond = c.add(d, path, c.jsonParam(op.Value))
+	case PatchRemove:
+		step.expr, step.cond = fmt.Sprintf("(%s #- %s)", d, c.param(path, "text[]")), c.exists(d, path)
+	case PatchReplace:
+		step.expr, step.cond = fmt.Sprintf("jsonb_set(%s, %s, %s, false)", d, c.param(path, "text[]"), c.jsonParam(op.Value)), c.exists(d, path)
+	case PatchMove, PatchCopy:
+		from, err := c.pointer(op.From)
+		if err != nil {
+			return err
+		}
+		step.desc += " depuis " + op.From
+		if op.Op == PatchMove && len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return fmt.Errorf("%w: move ne peut pas déplacer %q dans l'un de ses descendants", ErrInvalid, op.From)
+		}
+		value := fmt.Sprintf("(%s #> %s)", d, c.param(from, "text[]"))
+		source := d
+		if op.Op == PatchMove {
+			if err := c.touch(from[0]); err != nil {
+				return err
+			}
+			source = fmt.Sprintf("(%s #- %s)", d, c.param(from, "text[]"))
+		}
+		expr, cond := c.add(source, path, value)
+		step.expr, step.cond = expr, fmt.Sprintf("(%s AND %s)", c.exists(d, from), cond)
+	case PatchTest:
+		step.expr = d
+		step.cond = fmt.Sprintf("(%s AND (%s #> %s) = %s)", c.exists(d, path), d, c.param(path, "text[]"), c.jsonParam(op.Value))
+		c.steps = append(c.steps, step)
+		return nil
+	default:
+		return fmt.Errorf("%w: opération inconnue %q (add, remove, replace, move, copy ou test)", ErrInvalid, op.Op)
+	}
+
+	if err := c.touch(path[0]); err != nil {
+		return err
+	}
+	c.steps = append(c.steps, step)
+	return nil
+}
+
+// exists retourne la condition d'existence de la valeur au chemin : une clé de l'objet parent,
+// ou un indice du tableau parent. Une colonne existe toujours.
+func (c *patchCompiler) exists(d string, path []string) string {
+	if len(path) == 1 {
+		return "TRUE"
+	}
+	parent, last := path[:len(path)-1], path[len(path)-1]
+	parentExpr := fmt.Sprintf("(%s #> %s)", d, c.param(parent, "text[]"))
+	inArray := "FALSE"
+	if arrayIndexPattern.MatchString(last) {
+		inArray = fmt.Sprintf("%s < jsonb_array_length(%s)", last, parentExpr)
+	}
+	return fmt.Sprintf("(CASE jsonb_typeof(%[1]s) WHEN 'object' THEN %[1]s ? %[2]s WHEN 'array' THEN %[3]s ELSE FALSE END)",
+		parentExpr, c.param(last, "text"), inArray)
+}
+
+// add retourne l'ajout de la valeur au chemin de d et sa condition : dans un objet, la clé est
+// créée ou remplacée ; dans un tableau, la valeur est insérée à l'indice, ou à la fin avec "-"
+func (c *patchCompiler) add(d string, path []string, value string) (string, string) {
+	target := c.param(path, "text[]")
+	if len(path) == 1 {
+		return fmt.Sprintf("jsonb_set(%s, %s, %s)", d, target, value), "TRUE"
+	}
+
+	parent, last := path[:len(path)-1], path[len(path)-1]
+	parentExpr := fmt.Sprintf("(%s #> %s)", d, c.param(parent, "text[]"))
+	inArray, arrayCond := "NULL", "FALSE"
+	switch {
+	case last == "-":
+		inArray = fmt.Sprintf("jsonb_insert(%s, %s, %s, true)", d, c.param(append(slices.Clone(parent), "-1"), "text[]"), value)
+		arrayCond = "TRUE"
+	case arrayIndexPattern.MatchString(last):
+		inArray = fmt.Sprintf("jsonb_insert(%s, %s, %s)", d, target, value)
+		arrayCond = fmt.Sprintf("%s <= jsonb_array_length(%s)", last, parentExpr)
+	}
+	expr := fmt.Sprintf("(CASE jsonb_typeof(%[1]s) WHEN 'object' THEN jsonb_set(%[2]s, %[3]s, %[4]s) WHEN 'array' THEN %[5]s END)",
+		parentExpr, d, target, value, inArray)
+	cond := fmt.Sprintf("(CASE jsonb_typeof(%s) WHEN 'object' THEN TRUE WHEN 'array' THEN %s ELSE FALSE END)", parentExpr, arrayCond)
+	return expr, cond
+}
+
+// merge compile un merge patch en une étape fixant les colonnes du patch
+func (c *patchCompiler) merge(patch map[string]any) error {
+	fields := make([]string, 0, 2*len(patch))
+	for _, name := range sortedKeys(patch) {
+		if err := c.touch(name); err != nil {
+			return err
+		}
+		col, _ := c.table.Column(name)
+		field := c.param(name, "text")
+
+		var value string
+		switch v := patch[name].(type) {
+		case nil:
+			value = "'null'::jsonb"
+		case map[string]any:
+			if !isJSONType(col.Type) {
+				return fmt.Errorf("%w: la colonne %q de type %s ne peut pas recevoir un objet", ErrInvalid, name, col.Type)
+			}
+			var err error
+			if value, err = c.mergeObject("s.d", []string{name}, v); err != nil {
+				return err
+			}
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalid, err)
+			}
+			value = c.jsonParam(raw)
+		}
+		fields = append(fields, field, value)
+	}
+
+	c.steps = append(c.steps, patchStep{
+		expr: fmt.Sprintf("(s.d || jsonb_build_object(%s))", strings.Join(fields, ", ")),
+		cond: "TRUE",
+		desc: "merge",
+	})
+	return nil
+}
+
+// mergeObject retourne la fusion d'un objet du patch avec la valeur au chemin de d, remplacée
+// par un objet vide si elle n'est pas un objet, elle ou l'un de ses parents
+func (c *patchCompiler) mergeObject(d string, path []string, patch map[string]any) (string, error) {
+	if len(path) > maxPatchDepth {
+		return "", fmt.Errorf("%w: merge patch trop profond (maximum %d niveaux)", ErrInvalid, maxPatchDepth)
+	}
+
+	guards := make([]string, len(path))
+	for i := range path {
+		guards[i] = fmt.Sprintf("jsonb_typeof(%s #> %s) = 'object'", d, c.param(slices.Clone(path[:i+1]), "text[]"))
+	}
+	expr := fmt.Sprintf("(CASE WHEN %s THEN %s #> %s ELSE '{}'::jsonb END)", strings.Join(guards, " AND "), d, c.param(slices.Clone(path), "text[]"))
+
+	var removed []string
+	var fields []string
+	for _, key := range sortedKeys(patch) {
+		if patch[key] == nil {
+			removed = append(removed, key)
+			continue
+		}
+		field := c.param(key, "text")
+		switch v := patch[key].(type) {
+		case map[string]any:
+			merged, err := c.mergeObject(d, append(slices.Clone(path), key), v)
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, field, merged)
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("%w: %v", ErrInvalid, err)
+			}
+			fields = append(fields, field, c.jsonParam(raw))
+		}
+	}
+	if len(removed) > 0 {
+		expr = fmt.Sprintf("(%s - %s)", expr, c.param(removed, "text[]"))
+	}
+	if len(fields) > 0 {
+		expr = fmt.Sprintf("(%s || jsonb_build_object(%s))", expr, strings.Join(fields, ", "))
+	}
+	return expr, nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatchStatement(t *testing.T) {
+	steps := []patchStep{{expr: "jsonb_set(s.d, $2::text[], $3::jsonb)", cond: "TRUE"}}
+	sql := patchStatement(postsTable(), "id", steps, []string{"meta"}, false)
+	expected := `WITH s0 AS (SELECT to_jsonb(r.*) AS d, NULL::int AS failed FROM "public"."posts" AS r, jsonb_populate_record(NULL::"public"."posts", $1::jsonb) AS k WHERE r."id" = k."id" FOR UPDATE OF r)` +
+		`, s1 AS (SELECT CASE WHEN s.failed IS NULL AND TRUE THEN jsonb_set(s.d, $2::text[], $3::jsonb) ELSE s.d END AS d, CASE WHEN s.failed IS NULL AND (TRUE) IS NOT TRUE THEN 1 ELSE s.failed END AS failed FROM s0 AS s)` +
+		`, u AS (UPDATE "public"."posts" AS r SET "meta" = p."meta" FROM s1 AS s, jsonb_populate_record(NULL::"public"."posts", s.d) AS p, jsonb_populate_record(NULL::"public"."posts", $1::jsonb) AS k WHERE r."id" = k."id" AND s.failed IS NULL RETURNING to_jsonb(r.*) AS doc, ('x' || r.xmin) AS version)` +
+		` SELECT s.failed, u.doc, u.version FROM s1 AS s LEFT JOIN u ON TRUE`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+
+	// Conditionnel, avec une colonne de version incrémentée
+	sql = patchStatement(versionedUsers(), "id", steps, []string{"name"}, true)
+	for _, want := range []string{
+		`WHERE r."id" = k."id" AND ('v' || r."version") = ANY($2::text[]) FOR UPDATE OF r`,
+		`SET "name" = p."name", "version" = r."version" + 1 FROM`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("statement should contain %s:\n%s", want, sql)
+		}
+	}
+
+	// Sans colonne modifiée, le document est relu sans être écrit
+	sql = patchStatement(postsTable(), "id", steps, nil, false)
+	if strings.Contains(sql, `UPDATE "public"`) || !strings.Contains(sql, `u AS (SELECT to_jsonb(r.*) AS doc`) {
+		t.Errorf("a patch without changes should not update the row:\n%s", sql)
+	}
+}
+
+func TestPatchCompiler_Operations(t *testing.T) {
+	c := newPatchCompiler(postsTable(), "id", "1", nil)
+	ops := []PatchOperation{
+		{Op: PatchTest, Path: "/views", Value: json.RawMessage(`10`)},
+		{Op: PatchReplace, Path: "/title", Value: json.RawMessage(`"new"`)},
+		{Op: PatchAdd, Path: "/tags/-", Value: json.RawMessage(`"go"`)},
+		{Op: PatchRemove, Path: "/meta/a~1b"},
+	}
+	for _, op := range ops {
+		if err := c.operation(op); err != nil {
+			t.Fatalf("unexpected error for %+v: %v", op, err)
+		}
+	}
+
+	if want := map[string]bool{"title": true, "tags": true, "meta": true}; !reflect.DeepEqual(c.columns, want) {
+		t.Errorf("unexpected columns: %v", c.columns)
+	}
+	if step := c.steps[0]; step.expr != "s.d" || step.cond != `(TRUE AND (s.d #> $2::text[]) = $3::jsonb)` {
+		t.Errorf("unexpected test step: %+v", step)
+	}
+	if step := c.steps[1]; step.expr != `jsonb_set(s.d, $4::text[], $5::jsonb, false)` || step.cond != "TRUE" {
+		t.Errorf("unexpected replace step: %+v", step)
+	}
+	if step := c.steps[2]; !strings.Contains(step.expr, `WHEN 'array' THEN jsonb_insert(s.d, $9::text[], $6::jsonb, true)`) ||
+		!strings.Contains(step.cond, `WHEN 'array' THEN TRUE`) {
+		t.Errorf("unexpected add step: %+v", step)
+	}
+	if step := c.steps[3]; step.expr != `(s.d #- $10::text[])` || !strings.Contains(step.cond, `(s.d #> $11::text[]) ? $12::text`) {
+		t.Errorf("unexpected remove step: %+v", step)
+	}
+
+	want := []any{map[string]any{"id": "1"}, []string{"views"}, `10`, []string{"title"}, `"new"`, `"go"`,
+		[]string{"tags", "-"}, []string{"tags"}, []string{"tags", "-1"}, []string{"meta", "a/b"}, []string{"meta"}, "a/b"}
+	if !reflect.DeepEqual(c.args, want) {
+		t.Errorf("unexpected args: %#v", c.args)
+	}
+}
+
+func TestPatchCompiler_ArrayIndex(t *testing.T) {
+	c := newPatchCompiler(postsTable(), "id", "1", nil)
+	if err := c.operation(PatchOperation{Op: PatchAdd, Path: "/tags/2", Value: json.RawMessage(`"x"`)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cond := c.steps[0].cond; !strings.Contains(cond, `WHEN 'array' THEN 2 <= jsonb_array_length(`) {
+		t.Errorf("an index should be checked against the array length: %s", cond)
+	}
+
+	// Un indice avec un zéro initial n'est pas un indice de tableau
+	c = newPatchCompiler(postsTable(), "id", "1", nil)
+	if err := c.operation(PatchOperation{Op: PatchRemove, Path: "/tags/01"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cond := c.steps[0].cond; !strings.Contains(cond, `WHEN 'array' THEN FALSE`) {
+		t.Errorf("01 should not address an array element: %s", cond)
+	}
+}
+
+func TestPatchCompiler_Errors(t *testing.T) {
+	cases := map[string]PatchOperation{
+		"whole document":   {Op: PatchReplace, Path: "", Value: json.RawMessage(`{}`)},
+		"relative path":    {Op: PatchRemove, Path: "meta"},
+		"unknown column":   {Op: PatchRemove, Path: "/nope"},
+		"unknown op":       {Op: "increment", Path: "/views"},
+		"missing value":    {Op: PatchAdd, Path: "/meta/a"},
+		"invalid value":    {Op: PatchAdd, Path: "/meta/a", Value: json.RawMessage(`{`)},
+		"missing from":     {Op: PatchCopy, Path: "/meta/a"},
+		"move into itself": {Op: PatchMove, From: "/meta/a", Path: "/meta/a/b"},
+		"too deep":         {Op: PatchRemove, Path: "/meta" + strings.Repeat("/a", maxPatchDepth)},
+	}
+	for name, op := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newPatchCompiler(postsTable(), "id", "1", nil)
+			if err := c.operation(op); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPatchCompiler_Merge(t *testing.T) {
+	c := newPatchCompiler(postsTable(), "id", "1", nil)
+	err := c.merge(map[string]any{
+		"title": "new",
+		"tags":  nil,
+		"meta":  map[string]any{"draft": nil, "lang": "fr"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Les objets sont fusionnés avec la valeur de la colonne, null supprimant la clé
+	meta := `(((CASE WHEN jsonb_typeof(s.d #> $3::text[]) = 'object' THEN s.d #> $4::text[] ELSE '{}'::jsonb END)` +
+		` - $7::text[]) || jsonb_build_object($5::text, $6::jsonb))`
+	expected := `(s.d || jsonb_build_object($2::text, ` + meta + `, $8::text, 'null'::jsonb, $9::text, $10::jsonb))`
+	if c.steps[0].expr != expected {
+		t.Errorf("unexpected merge:\ngot  %s\nwant %s", c.steps[0].expr, expected)
+	}
+	want := []any{map[string]any{"id": "1"}, "meta", []string{"meta"}, []string{"meta"}, "lang", `"fr"`, []string{"draft"}, "tags", "title", `"new"`}
+	if !reflect.DeepEqual(c.args, want) {
+		t.Errorf("unexpected args: %#v", c.args)
+	}
+	if want := map[string]bool{"title": true, "tags": true, "meta": true}; !reflect.DeepEqual(c.columns, want) {
+		t.Errorf("unexpected columns: %v", c.columns)
+	}
+}
+
+func TestPatchCompiler_MergeErrors(t *testing.T) {
+	cases := map[string]map[string]any{
+		"unknown column":       {"nope": 1},
+		"object into a column": {"title": map[string]any{"a": 1}},
+	}
+	for name, patch := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newPatchCompiler(postsTable(), "id", "1", nil)
+			if err := c.merge(patch); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
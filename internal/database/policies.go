@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Opérations couvertes par une politique
+const (
+	PolicyAll    = "all"
+	PolicySelect = "select"
+	PolicyInsert = "insert"
+	PolicyUpdate = "update"
+	PolicyDelete = "delete"
+)
+
+// policyCommands liste les opérations acceptées
+var policyCommands = map[string]bool{
+	PolicyAll:    true,
+	PolicySelect: true,
+	PolicyInsert: true,
+	PolicyUpdate: true,
+	PolicyDelete: true,
+}
+
+// PolicyDefinition décrit une règle d'accès aux lignes d'une collection, compilée en politique RLS
+type PolicyDefinition struct {
+	Name    string         `json:"name"`
+	Command string         `json:"command"`         // Opération couverte, "all" par défaut
+	Role    string         `json:"role,omitempty"`  // Rôle du projet concerné, tous les utilisateurs si vide
+	Using   map[string]any `json:"using,omitempty"` // Lignes visibles, modifiables ou supprimables
+	Check   map[string]any `json:"check,omitempty"` // Lignes acceptées en écriture, Using par défaut
+}
+
+// Policy décrit une politique existante
+type Policy struct {
+	PolicyDefinition
+	// Expressions SQL générées, lues dans le catalogue
+	UsingSQL string `json:"using_sql,omitempty"`
+	CheckSQL string `json:"check_sql,omitempty"`
+}
+
+// createPolicyStatements génère les instructions activant RLS sur la collection et créant la politique.
+// La définition d'origine est conservée en commentaire de la politique pour le listing.
+func createPolicyStatements(t *Table, def PolicyDefinition) ([]string, error) {
+	if err := checkIdentifier("politique", def.Name); err != nil {
+		return nil, err
+	}
+	command := strings.ToLower(def.Command)
+	if command == "" {
+		command = PolicyAll
+	}
+	if !policyCommands[command] {
+		return nil, fmt.Errorf("%w: opération inconnue %q", ErrInvalid, def.Command)
+	}
+	def.Command = command
+
+	using, err := policyExpression(t, def.Role, def.Using)
+	if err != nil {
+		return nil, err
+	}
+	check := using
+	if len(def.Check) > 0 {
+		if command == PolicySelect || command == PolicyDelete {
+			return nil, fmt.Errorf("%w: check ne s'applique qu'aux écritures (insert, update, all)", ErrInvalid)
+		}
+		if check, err = policyExpression(t, def.Role, def.Check); err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE POLICY %s ON %s FOR %s TO %s", quoteIdent(def.Name), t.Identifier(),
+		strings.ToUpper(command), quoteIdent(EndUserRole))
+	if command != PolicyInsert {
+		b.WriteString(" USING (" + using + ")")
+	}
+	if command != PolicySelect && command != PolicyDelete {
+		b.WriteString(" WITH CHECK (" + check + ")")
+	}
+
+	// Sans échappement HTML, les chemins JSON ("meta->org") restent lisibles dans le catalogue
+	var source strings.Builder
+	encoder := json.NewEncoder(&source)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(def); err != nil {
+		return nil, fmt.Errorf("%w: définition de politique invalide", ErrInvalid)
+	}
+
+	statements := append([]string{}, authSetupStatements...)
+	return append(statements,
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", t.Identifier()),
+		fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE ON %s TO %s", t.Identifier(), quoteIdent(EndUserRole)),
+		b.String(),
+		fmt.Sprintf("COMMENT ON POLICY %s ON %s IS %s", quoteIdent(def.Name), t.Identifier(), quoteLiteral(strings.TrimSpace(source.String()))),
+	), nil
+}
+
+// policyExpression restreint un filtre au rôle du projet auquel la politique s'applique
+func policyExpression(t *Table, role string, filter map[string]any) (string, error) {
+	cond := "TRUE"
+	if len(filter) > 0 {
+		var err error
+		if cond, err = compilePolicy(t, filter); err != nil {
+			return "", err
+		}
+	}
+	if role == "" {
+		return cond, nil
+	}
+	return fmt.Sprintf("auth.role() = %s AND %s", quoteLiteral(role), cond), nil
+}
+
+// ListPolicies liste les politiques d'une collection
+func ListPolicies(ctx context.Context, q Querier, t *Table) ([]Policy, error) {
+	rows, err := q.Query(ctx, `
+		SELECT p.polname,
+		       CASE p.polcmd WHEN 'r' THEN 'select' WHEN 'a' THEN 'insert'
+		                     WHEN 'w' THEN 'update' WHEN 'd' THEN 'delete' ELSE 'all' END,
+		       COALESCE(pg_catalog.pg_get_expr(p.polqual, p.polrelid), ''),
+		       COALESCE(pg_catalog.pg_get_expr(p.polwithcheck, p.polrelid), ''),
+		       COALESCE(pg_catalog.obj_description(p.oid, 'pg_policy'), '')
+		FROM pg_catalog.pg_policy p
+		WHERE p.polrelid = to_regclass($1)
+		ORDER BY p.polname`, t.Identifier())
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	policies, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Policy, error) {
+		var p Policy
+		var source string
+		if err := row.Scan(&p.Name, &p.Command, &p.UsingSQL, &p.CheckSQL, &source); err != nil {
+			return p, err
+		}
+		// Une politique créée hors de l'API n'a pas de définition d'origine
+		var def PolicyDefinition
+		if json.Unmarshal([]byte(source), &def) == nil && def.Name == p.Name {
+			p.Role, p.Using, p.Check = def.Role, def.Using, def.Check
+		}
+		return p, nil
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return policies, nil
+}
+
+// CreatePolicy crée une politique RLS et l'enregistre dans l'historique des migrations
+func CreatePolicy(ctx context.Context, q Querier, t *Table, def PolicyDefinition) (*Policy, error) {
+	statements, err := createPolicyStatements(t, def)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, fmt.Sprintf("create policy %s on %s", def.Name, t.Name), statements); err != nil {
+		return nil, err
+	}
+	return findPolicy(ctx, q, t, def.Name)
+}
+
+// DropPolicy supprime une politique. RLS reste actif sur la collection : sans politique,
+// les utilisateurs finaux n'y ont plus accès.
+func DropPolicy(ctx context.Context, q Querier, t *Table, name string) error {
+	if _, err := findPolicy(ctx, q, t, name); err != nil {
+		return err
+	}
+	statement := fmt.Sprintf("DROP POLICY %s ON %s", quoteIdent(name), t.Identifier())
+	_, err := applyMigration(ctx, q, fmt.Sprintf("drop policy %s on %s", name, t.Name), []string{statement})
+	return err
+}
+
+// findPolicy retourne la politique de la collection portant ce nom
+func findPolicy(ctx context.Context, q Querier, t *Table, name string) (*Policy, error) {
+	policies, err := ListPolicies(ctx, q, t)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: politique %q introuvable sur la collection %q", ErrNotFound, name, t.Name)
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreatePolicyStatements(t *testing.T) {
+	statements, err := createPolicyStatements(postsTable(), PolicyDefinition{
+		Name:  "owner_rows",
+		Role:  "owner",
+		Using: map[string]any{"id": map[string]any{"$auth": "uid"}, "meta->org": map[string]any{"$eq": map[string]any{"$auth": "org_id"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	setup := len(authSetupStatements)
+	expected := []string{
+		`ALTER TABLE "public"."posts" ENABLE ROW LEVEL SECURITY`,
+		`GRANT SELECT, INSERT, UPDATE, DELETE ON "public"."posts" TO "sovrabase_user"`,
+		`CREATE POLICY "owner_rows" ON "public"."posts" FOR ALL TO "sovrabase_user" ` +
+			`USING (auth.role() = 'owner' AND ("id" = auth.uid()::integer AND ("meta"::jsonb #> ARRAY['org']::text[]) = to_jsonb((auth.jwt() ->> 'org_id')))) ` +
+			`WITH CHECK (auth.role() = 'owner' AND ("id" = auth.uid()::integer AND ("meta"::jsonb #> ARRAY['org']::text[]) = to_jsonb((auth.jwt() ->> 'org_id'))))`,
+	}
+	if len(statements) != setup+len(expected)+1 {
+		t.Fatalf("unexpected statements: %v", statements)
+	}
+	for i, want := range expected {
+		if got := statements[setup+i]; got != want {
+			t.Errorf("statement %d:\ngot  %s\nwant %s", i, got, want)
+		}
+	}
+	if comment := statements[len(statements)-1]; !strings.HasPrefix(comment, `COMMENT ON POLICY "owner_rows" ON "public"."posts" IS '{"name":"owner_rows","command":"all"`) {
+		t.Errorf("unexpected comment: %s", comment)
+	}
+}
+
+func TestCreatePolicyStatements_Commands(t *testing.T) {
+	cases := map[string]string{
+		"select": `CREATE POLICY "p" ON "public"."posts" FOR SELECT TO "sovrabase_user" USING (TRUE)`,
+		"insert": `CREATE POLICY "p" ON "public"."posts" FOR INSERT TO "sovrabase_user" WITH CHECK (TRUE)`,
+		"delete": `CREATE POLICY "p" ON "public"."posts" FOR DELETE TO "sovrabase_user" USING (TRUE)`,
+	}
+	for command, want := range cases {
+		t.Run(command, func(t *testing.T) {
+			statements, err := createPolicyStatements(postsTable(), PolicyDefinition{Name: "p", Command: command})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := statements[len(statements)-2]; got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCreatePolicyStatements_Errors(t *testing.T) {
+	cases := map[string]PolicyDefinition{
+		"no name":         {},
+		"unknown command": {Name: "p", Command: "truncate"},
+		"check on select": {Name: "p", Command: "select", Check: map[string]any{"id": "1"}},
+		"unknown column":  {Name: "p", Using: map[string]any{"nope": map[string]any{"$auth": "uid"}}},
+		"invalid claim":   {Name: "p", Using: map[string]any{"title": map[string]any{"$auth": "x') OR ('1"}}},
+	}
+	for name, def := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := createPolicyStatements(postsTable(), def); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompileFilter_RejectsClaimReferences(t *testing.T) {
+	if _, _, err := CompileFilter(postsTable(), map[string]any{"title": map[string]any{"$auth": "uid"}}, nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid outside of policies, got %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Modes de comptage du total des documents correspondant à une requête
+const (
+	CountExact     = "exact"
+	CountEstimated = "estimated"
+)
+
+// Query décrit une lecture filtrée, triée et paginée d'une collection
+type Query struct {
+	Filter map[string]any
+	Select []string // colonnes retournées, toutes si vide
+	Sort   []string // colonnes, préfixées par "-" pour un tri décroissant
+	Limit  int
+	Offset int
+	Cursor string // curseur opaque retourné par la page précédente
+	Count  string // "", CountExact ou CountEstimated
+
+	Trashed string // Documents de la corbeille lus : TrashedExclude (par défaut), TrashedInclude ou TrashedOnly
+
+	Nearest *Nearest // Plus proches voisins d'un vecteur, triés par distance
+	Expand  []Expand // Documents liés inclus dans chaque document
+}
+
+// Page est une page de résultats d'une requête
+type Page struct {
+	Data           []json.RawMessage `json:"data"`
+	NextCursor     string            `json:"next_cursor,omitempty"`
+	Total          *int64            `json:"total,omitempty"`
+	TotalEstimated bool              `json:"total_estimated,omitempty"`
+	Distances      []float64         `json:"distances,omitempty"` // Distance de chaque document, avec Query.Nearest
+}
+
+// QueryDocuments retourne une page de documents de la collection correspondant à la requête
+func QueryDocuments(ctx context.Context, q Querier, t *Table, query Query) (*Page, error) {
+	if query.Nearest != nil {
+		return NearestDocuments(ctx, q, t, query)
+	}
+	if query.Count != "" && query.Count != CountExact && query.Count != CountEstimated {
+		return nil, fmt.Errorf("%w: mode de comptage inconnu %q (exact ou estimated)", ErrInvalid, query.Count)
+	}
+	terms, err := sortTerms(t, query.Sort)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := projectionExpr(t, query.Select)
+	if err != nil {
+		return nil, err
+	}
+	cond, args, err := CompileFilter(t, query.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cond, err = t.trashedCond("r", cond, query.Trashed); err != nil {
+		return nil, err
+	}
+	filterArgs := slices.Clone(args)
+	expansions, err := resolveExpand(ctx, q, t, query.Expand)
+	if err != nil {
+		return nil, err
+	}
+	var aliases int
+	if projection, args, err = expandExpr(projection, "r", expansions, args, &aliases); err != nil {
+		return nil, err
+	}
+
+	// La pagination par curseur n'est fiable que si le tri se termine par une clé unique
+	keyed := len(t.PrimaryKey) > 0
+	from := t.Identifier() + " AS r"
+	where := cond
+	if query.Cursor != "" {
+		if !keyed {
+			return nil, fmt.Errorf("%w: la collection %q n'a pas de clé primaire, la pagination par curseur est impossible", ErrInvalid, t.Name)
+		}
+		if query.Offset > 0 {
+			return nil, fmt.Errorf("%w: cursor et offset ne peuvent pas être combinés", ErrInvalid)
+		}
+		values, err := decodeCursor(query.Cursor, terms)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, values)
+		from += fmt.Sprintf(", jsonb_populate_record(NULL::%s, $%d::jsonb) AS c", t.Identifier(), len(args))
+		where = fmt.Sprintf("%s AND %s", cond, keysetCondition(terms))
+	}
+
+	keyExpr := "NULL::jsonb"
+	if keyed {
+		keyExpr = rowObject(termColumns(terms))
+	}
+
+	limit := clampLimit(query.Limit)
+	args = append(args, limit+1, max(query.Offset, 0))
+	sql := fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s%s LIMIT $%d OFFSET $%d`,
+		projection, keyExpr, from, where, orderBy(terms), len(args)-1, len(args))
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	page := &Page{Data: make([]json.RawMessage, 0, limit)}
+	var lastKey json.RawMessage
+	for rows.Next() {
+		var doc, key json.RawMessage
+		if err := rows.Scan(&doc, &key); err != nil {
+			return nil, translateError(err)
+		}
+		if len(page.Data) == limit {
+			// Une ligne de plus que la limite : il existe une page suivante
+			if keyed {
+				page.NextCursor = encodeCursor(terms, lastKey)
+			}
+			break
+		}
+		page.Data = append(page.Data, doc)
+		lastKey = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	rows.Close()
+
+	if query.Count != "" {
+		total, err := countDocuments(ctx, q, t, cond, filterArgs, query.Count)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+		page.TotalEstimated = query.Count == CountEstimated
+	}
+
+	return page, nil
+}
+
+// countDocuments compte les documents correspondant à la condition, exactement ou
+// à partir de l'estimation du planificateur
+func countDocuments(ctx context.Context, q Querier, t *Table, cond string, args []any, mode string) (int64, error) {
+	var total int64
+	switch mode {
+	case CountExact:
+		sql := fmt.Sprintf(`SELECT count(*) FROM %s AS r WHERE %s`, t.Identifier(), cond)
+		if err := q.QueryRow(ctx, sql, args...).Scan(&total); err != nil {
+			return 0, translateError(err)
+		}
+	case CountEstimated:
+		var plan []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+		var output string
+		sql := fmt.Sprintf(`EXPLAIN (FORMAT JSON) SELECT 1 FROM %s AS r WHERE %s`, t.Identifier(), cond)
+		if err := q.QueryRow(ctx, sql, args...).Scan(&output); err != nil {
+			return 0, translateError(err)
+		}
+		if err := json.Unmarshal([]byte(output), &plan); err != nil {
+			return 0, fmt.Errorf("plan d'exécution illisible: %w", err)
+		}
+		if len(plan) > 0 {
+			total = int64(plan[0].Plan.Rows)
+		}
+	}
+	return total, nil
+}
+
+// projectionExpr retourne l'expression jsonb d'un document limité aux colonnes demandées
+func projectionExpr(t *Table, columns []string) (string, error) {
+	return projectionOf(t, "r", columns)
+}
+
+// projectionOf retourne l'expression jsonb d'un document de l'alias limité aux colonnes demandées
+func projectionOf(t *Table, alias string, columns []string) (string, error) {
+	if len(columns) == 0 {
+		return t.documentOf(alias), nil
+	}
+	for _, name := range columns {
+		if _, ok := t.Column(name); !ok {
+			return "", fmt.Errorf("%w: sélection d'une colonne inconnue %q", ErrInvalid, name)
+		}
+	}
+	return objectOf(alias, columns), nil
+}
+
+// rowObject construit un objet jsonb à partir de colonnes de r
+func rowObject(columns []string) string {
+	return objectOf("r", columns)
+}
+
+// objectOf construit un objet jsonb à partir de colonnes de l'alias ; la sous-requête
+// donne leur nom aux clés sans passer par des littéraux
+func objectOf(alias string, columns []string) string {
+	refs := make([]string, len(columns))
+	for i, name := range columns {
+		refs[i] = alias + "." + quoteIdent(name)
+	}
+	return fmt.Sprintf("(SELECT to_jsonb(s.*) FROM (SELECT %s) AS s)", strings.Join(refs, ", "))
+}
+
+// DeleteMatching supprime les documents correspondant au filtre, qui ne peut pas être vide.
+// Les documents d'une collection avec corbeille y sont placés.
+func DeleteMatching(ctx context.Context, q Querier, t *Table, filter map[string]any) (int64, error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("%w: un filtre non vide est requis pour supprimer des documents", ErrInvalid)
+	}
+
+	cond, args, err := CompileFilter(t, filter, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := q.Exec(ctx, deleteStatement(t, "", cond), args...)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// BatchDelete supprime dans une même transaction les documents listés par ids
+// puis ceux correspondant au filtre. Sans ids, le filtre est obligatoire.
+func BatchDelete(ctx context.Context, q Querier, t *Table, ids []string, filter map[string]any) (int64, error) {
+	var deleted int64
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		if len(ids) > 0 {
+			count, err := DeleteDocuments(ctx, tx, t, ids)
+			if err != nil {
+				return err
+			}
+			deleted += count
+		}
+		if len(filter) > 0 || len(ids) == 0 {
+			count, err := DeleteMatching(ctx, tx, t, filter)
+			if err != nil {
+				return err
+			}
+			deleted += count
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// sortTerm est une colonne de tri et son sens
+type sortTerm struct {
+	column string
+	desc   bool
+}
+
+// String retourne la forme textuelle du terme, telle qu'acceptée dans Query.Sort
+func (s sortTerm) String() string {
+	if s.desc {
+		return "-" + s.column
+	}
+	return s.column
+}
+
+// sortTerms valide le tri demandé ; la clé primaire départage les égalités pour
+// que la pagination reste stable
+func sortTerms(t *Table, sort []string) ([]sortTerm, error) {
+	terms := make([]sortTerm, 0, len(sort)+len(t.PrimaryKey))
+	seen := make(map[string]bool)
+
+	for _, entry := range sort {
+		term := sortTerm{column: strings.TrimPrefix(entry, "-"), desc: strings.HasPrefix(entry, "-")}
+		if _, ok := t.Column(term.column); !ok {
+			return nil, fmt.Errorf("%w: tri sur une colonne inconnue %q", ErrInvalid, term.column)
+		}
+		if seen[term.column] {
+			continue
+		}
+		seen[term.column] = true
+		terms = append(terms, term)
+	}
+	for _, key := range t.PrimaryKey {
+		if !seen[key] {
+			terms = append(terms, sortTerm{column: key})
+		}
+	}
+	return terms, nil
+}
+
+// termColumns retourne les colonnes des termes de tri
+func termColumns(terms []sortTerm) []string {
+	columns := make([]string, len(terms))
+	for i, term := range terms {
+		columns[i] = term.column
+	}
+	return columns
+}
+
+// orderBy construit la clause ORDER BY des termes de tri
+func orderBy(terms []sortTerm) string {
+	return orderByOf("r", terms)
+}
+
+// orderByOf construit la clause ORDER BY des termes de tri sur les colonnes de l'alias
+func orderByOf(alias string, terms []sortTerm) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		direction := " ASC"
+		if term.desc {
+			direction = " DESC"
+		}
+		parts[i] = alias + "." + quoteIdent(term.column) + direction
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSortTerms_AppendsPrimaryKey(t *testing.T) {
+	terms, err := sortTerms(postsTable(), []string{"-views", "title", "views"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := ` ORDER BY r."views" DESC, r."title" ASC, r."id" ASC`
+	if got := orderBy(terms); got != expected {
+		t.Errorf("unexpected ORDER BY:\ngot  %s\nwant %s", got, expected)
+	}
+}
+
+func TestSortTerms_RejectsUnknownColumn(t *testing.T) {
+	if _, err := sortTerms(postsTable(), []string{"-nope"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	terms, _ := sortTerms(postsTable(), []string{"-views"})
+
+	token := encodeCursor(terms, []byte(`{"views": 9007199254740993, "id": 42}`))
+	key, err := decodeCursor(token, terms)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Les grands entiers ne doivent pas perdre de précision
+	expected := `{"views":9007199254740993,"id":42}`
+	if key != expected {
+		t.Errorf("unexpected cursor key: got %s want %s", key, expected)
+	}
+}
+
+func TestCursor_RejectsOtherSort(t *testing.T) {
+	byViews, _ := sortTerms(postsTable(), []string{"-views"})
+	byTitle, _ := sortTerms(postsTable(), []string{"title"})
+
+	token := encodeCursor(byViews, []byte(`{"views": 1, "id": 1}`))
+	if _, err := decodeCursor(token, byTitle); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a cursor of another sort, got %v", err)
+	}
+}
+
+func TestCursor_RejectsGarbage(t *testing.T) {
+	terms, _ := sortTerms(postsTable(), nil)
+
+	for _, token := range []string{"not base64!", "bm90IGpzb24", encodeCursor(terms, []byte(`{"other": 1}`))} {
+		if _, err := decodeCursor(token, terms); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestKeysetCondition_HandlesDirectionsAndNulls(t *testing.T) {
+	terms, _ := sortTerms(postsTable(), []string{"-views"})
+
+	expected := `((r."views" < c."views" OR (c."views" IS NULL AND r."views" IS NOT NULL)) OR ` +
+		`(r."views" IS NOT DISTINCT FROM c."views" AND (r."id" > c."id" OR (c."id" IS NOT NULL AND r."id" IS NULL))))`
+	if got := keysetCondition(terms); got != expected {
+		t.Errorf("unexpected keyset condition:\ngot  %s\nwant %s", got, expected)
+	}
+}
+
+func TestProjectionExpr(t *testing.T) {
+	expr, err := projectionExpr(postsTable(), []string{"id", "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `(SELECT to_jsonb(s.*) FROM (SELECT r."id", r."title") AS s)`
+	if expr != expected {
+		t.Errorf("unexpected projection: got %s want %s", expr, expected)
+	}
+
+	if _, err := projectionExpr(postsTable(), []string{"password"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for unknown column, got %v", err)
+	}
+}
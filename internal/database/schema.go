@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultSchema est le schéma PostgreSQL dans lequel vivent les collections
+const DefaultSchema = "public"
+
+// Column décrit une colonne d'une collection
+type Column struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	HasDefault bool   `json:"has_default"`
+}
+
+// Table décrit une collection, c'est-à-dire une table PostgreSQL
+type Table struct {
+	Schema     string      `json:"schema"`
+	Name       string      `json:"name"`
+	Columns    []Column    `json:"columns"`
+	PrimaryKey []string    `json:"primary_key"`
+	SoftDelete *SoftDelete `json:"soft_delete,omitempty"` // Corbeille, voir TrashColumn
+}
+
+// CollectionSummary résume une collection pour les listings
+type CollectionSummary struct {
+	Name          string `json:"name"`
+	EstimatedRows int64  `json:"estimated_rows"`
+}
+
+// Identifier retourne le nom qualifié et échappé de la table
+func (t *Table) Identifier() string {
+	return pgx.Identifier{t.Schema, t.Name}.Sanitize()
+}
+
+// Column retourne la colonne portant ce nom, si elle existe
+func (t *Table) Column(name string) (Column, bool) {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return Column{}, false
+}
+
+// documentExpr retourne l'expression jsonb d'un document de r, sans la colonne de recherche gérée
+func (t *Table) documentExpr() string {
+	return t.documentOf("r")
+}
+
+// documentOf retourne l'expression jsonb d'un document de l'alias, sans la colonne de recherche gérée
+func (t *Table) documentOf(alias string) string {
+	if t.hasSearchColumn() {
+		return "(to_jsonb(" + alias + ".*) - " + quoteLiteral(SearchColumn) + ")"
+	}
+	return "to_jsonb(" + alias + ".*)"
+}
+
+// documentKey retourne l'unique colonne de clé primaire identifiant un document
+func (t *Table) documentKey() (string, error) {
+	if len(t.PrimaryKey) != 1 {
+		return "", fmt.Errorf("%w: la collection %q n'a pas de clé primaire sur une seule colonne", ErrInvalid, t.Name)
+	}
+	return t.PrimaryKey[0], nil
+}
+
+// columnsOf valide les clés d'un document et les retourne dans l'ordre de la table
+func (t *Table) columnsOf(doc map[string]any) ([]string, error) {
+	for key := range doc {
+		if err := t.checkWritable(key); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := make([]string, 0, len(doc))
+	for _, col := range t.Columns {
+		if _, ok := doc[col.Name]; ok {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns, nil
+}
+
+// checkWritable vérifie que les clés sont des colonnes de la table pouvant être écrites
+func (t *Table) checkWritable(keys ...string) error {
+	for _, key := range keys {
+		if _, ok := t.Column(key); !ok {
+			return fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, key, t.Name)
+		}
+		if key == SearchColumn && t.hasSearchColumn() {
+			return fmt.Errorf("%w: la colonne %q est générée et ne peut pas être écrite", ErrInvalid, key)
+		}
+	}
+	return nil
+}
+
+// quoteIdent échappe un identifiant PostgreSQL
+func quoteIdent(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+// quoteLiteral échappe une chaîne en littéral SQL, quel que soit standard_conforming_strings
+func quoteLiteral(value string) string {
+	quoted := "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	if strings.Contains(value, `\`) {
+		return "E" + strings.ReplaceAll(quoted, `\`, `\\`)
+	}
+	return quoted
+}
+
+// literal écrit une valeur de paramètre (texte ou tableau de textes) sous forme de littéral SQL
+func literal(value any) string {
+	switch v := value.(type) {
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = quoteLiteral(item)
+		}
+		return "ARRAY[" + strings.Join(items, ", ") + "]"
+	default:
+		return quoteLiteral(fmt.Sprint(v))
+	}
+}
+
+// ListCollections liste les tables du schéma par défaut
+func ListCollections(ctx context.Context, q Querier) ([]CollectionSummary, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname`, DefaultSchema)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	collections, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (CollectionSummary, error) {
+		var summary CollectionSummary
+		err := row.Scan(&summary.Name, &summary.EstimatedRows)
+		return summary, err
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return collections, nil
+}
+
+// LoadTable lit la définition d'une collection depuis le catalogue PostgreSQL
+func LoadTable(ctx context.Context, q Querier, name string) (*Table, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname,
+		       pg_catalog.format_type(a.atttypid, a.atttypmod),
+		       NOT a.attnotnull,
+		       a.atthasdef OR a.attidentity <> '',
+		       COALESCE(array_position(i.indkey::int2[], a.attnum), 0),
+		       CASE WHEN a.attname = $3 THEN COALESCE(pg_catalog.col_description(a.attrelid, a.attnum), '') ELSE '' END
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_index i ON i.indrelid = c.oid AND i.indisprimary
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'p')
+		  AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, DefaultSchema, name, TrashColumn)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	table := &Table{Schema: DefaultSchema, Name: name}
+	keyPositions := make(map[string]int)
+	for rows.Next() {
+		var col Column
+		var keyPosition int
+		var comment string
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &col.HasDefault, &keyPosition, &comment); err != nil {
+			return nil, translateError(err)
+		}
+		table.Columns = append(table.Columns, col)
+		if config := parseSoftDelete(col, comment); config != nil {
+			table.SoftDelete = config
+		}
+		if keyPosition > 0 {
+			keyPositions[col.Name] = keyPosition
+			table.PrimaryKey = append(table.PrimaryKey, col.Name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("%w: la collection %q n'existe pas", ErrNotFound, name)
+	}
+
+	sort.Slice(table.PrimaryKey, func(i, j int) bool {
+		return keyPositions[table.PrimaryKey[i]] < keyPositions[table.PrimaryKey[j]]
+	})
+	return table, nil
+}
@@ -0,0 +1,400 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SearchColumn est la colonne tsvector générée gérée par Sovrabase pour la recherche plein texte
+const SearchColumn = "search_vector"
+
+// DefaultSearchLanguage est la configuration de recherche utilisée sans langue explicite :
+// elle découpe les mots sans racinisation ni mots vides
+const DefaultSearchLanguage = "simple"
+
+// Modes d'interprétation du texte recherché
+const (
+	SearchWeb    = "websearch" // Syntaxe des moteurs de recherche : "phrase exacte", -exclu, or
+	SearchPlain  = "plain"     // Tous les mots
+	SearchPhrase = "phrase"    // Les mots dans l'ordre
+	SearchRaw    = "raw"       // Syntaxe tsquery : chat & (chien | souris)
+)
+
+// searchFunctions associe chaque mode à sa fonction de construction de tsquery
+var searchFunctions = map[string]string{
+	SearchWeb:    "websearch_to_tsquery",
+	SearchPlain:  "plainto_tsquery",
+	SearchPhrase: "phraseto_tsquery",
+	SearchRaw:    "to_tsquery",
+}
+
+// Fonctions de classement des résultats
+const (
+	RankFrequency = "rank"  // ts_rank : fréquence des termes
+	RankCover     = "cover" // ts_rank_cd : proximité des termes
+)
+
+// maxNormalization est le plus grand masque de normalisation accepté par ts_rank
+const maxNormalization = 63
+
+// searchWeights liste les poids acceptés, de A (le plus fort) à D
+var searchWeights = map[string]bool{"A": true, "B": true, "C": true, "D": true}
+
+// Search décrit une recherche plein texte dans une collection
+type Search struct {
+	Query    string
+	Mode     string            // SearchWeb par défaut
+	Columns  []string          // Colonnes recherchées ; la colonne gérée si vide
+	Weights  map[string]string // Poids (A à D) par colonne
+	Language string            // Configuration de recherche, DefaultSearchLanguage par défaut
+
+	Ranking       string  // RankFrequency par défaut
+	Normalization int     // Masque de normalisation de ts_rank selon la longueur des documents
+	MinRank       float64 // Rang minimal d'un résultat
+
+	Filter    map[string]any
+	Select    []string
+	Limit     int
+	Offset    int
+	Highlight *Highlight // Extraits surlignés, aucun si nil
+}
+
+// Highlight règle les extraits produits par ts_headline
+type Highlight struct {
+	StartSel     string // Balise ouvrante, "<b>" par défaut
+	StopSel      string // Balise fermante, "</b>" par défaut
+	MaxWords     int
+	MinWords     int
+	MaxFragments int // Nombre d'extraits séparés ; 0 renvoie un seul passage
+}
+
+// SearchIndex décrit la colonne de recherche gérée d'une collection
+type SearchIndex struct {
+	Columns  []string          `json:"columns"`
+	Weights  map[string]string `json:"weights,omitempty"`
+	Language string            `json:"language"`
+}
+
+// SearchHit est un document trouvé, avec son rang et ses extraits surlignés par colonne
+type SearchHit struct {
+	Document   json.RawMessage   `json:"document"`
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchResult est une page de résultats d'une recherche, du plus pertinent au moins pertinent
+type SearchResult struct {
+	Data []SearchHit `json:"data"`
+}
+
+// hasSearchColumn indique si la collection possède la colonne de recherche gérée
+func (t *Table) hasSearchColumn() bool {
+	col, ok := t.Column(SearchColumn)
+	return ok && col.Type == "tsvector"
+}
+
+// SearchDocuments recherche les documents correspondant au texte, classés par pertinence
+func SearchDocuments(ctx context.Context, q Querier, t *Table, search Search) (*SearchResult, error) {
+	if strings.TrimSpace(search.Query) == "" {
+		return nil, fmt.Errorf("%w: le texte recherché est vide", ErrInvalid)
+	}
+
+	// Sans colonnes explicites, la recherche porte sur la colonne gérée et reprend sa configuration
+	var vector string
+	if len(search.Columns) == 0 {
+		index, err := GetSearchIndex(ctx, q, t)
+		if err != nil {
+			return nil, err
+		}
+		if search.Language != "" && search.Language != index.Language {
+			return nil, fmt.Errorf("%w: la colonne %q est indexée en %q", ErrInvalid, SearchColumn, index.Language)
+		}
+		search.Columns, search.Language = index.Columns, index.Language
+		vector = "r." + quoteIdent(SearchColumn)
+	}
+	if search.Language == "" {
+		search.Language = DefaultSearchLanguage
+	}
+
+	statement, args, err := searchStatement(t, search, vector)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, statement, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	result := &SearchResult{Data: []SearchHit{}}
+	for rows.Next() {
+		var hit SearchHit
+		if err := rows.Scan(&hit.Document, &hit.Rank, &hit.Highlights); err != nil {
+			return nil, translateError(err)
+		}
+		result.Data = append(result.Data, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return result, nil
+}
+
+// searchStatement génère la requête de recherche. vector est l'expression tsvector à
+// interroger ; vide, elle est calculée à partir des colonnes de la recherche.
+func searchStatement(t *Table, search Search, vector string) (string, []any, error) {
+	mode := search.Mode
+	if mode == "" {
+		mode = SearchWeb
+	}
+	toQuery, ok := searchFunctions[mode]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: mode de recherche inconnu %q", ErrInvalid, search.Mode)
+	}
+
+	rank := "ts_rank"
+	switch search.Ranking {
+	case "", RankFrequency:
+	case RankCover:
+		rank = "ts_rank_cd"
+	default:
+		return "", nil, fmt.Errorf("%w: classement inconnu %q (rank ou cover)", ErrInvalid, search.Ranking)
+	}
+	if search.Normalization < 0 || search.Normalization > maxNormalization {
+		return "", nil, fmt.Errorf("%w: normalization doit être comprise entre 0 et %d", ErrInvalid, maxNormalization)
+	}
+
+	if vector == "" {
+		var err error
+		if vector, err = searchVector(t, search.Columns, search.Weights, search.Language, "r."); err != nil {
+			return "", nil, err
+		}
+	} else if !languagePattern.MatchString(search.Language) {
+		return "", nil, fmt.Errorf("%w: langue de recherche invalide %q", ErrInvalid, search.Language)
+	}
+
+	projection, err := projectionExpr(t, search.Select)
+	if err != nil {
+		return "", nil, err
+	}
+	cond, args, err := CompileFilter(t, search.Filter, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	cond = t.liveCond("r", cond)
+
+	// La langue est écrite en littéral, comme dans les index, pour que le planificateur les utilise
+	language := quoteLiteral(search.Language) + "::regconfig"
+	args = append(args, search.Query)
+	query := fmt.Sprintf("%s(%s, $%d)", toQuery, language, len(args))
+	args = append(args, search.Normalization)
+	rankExpr := fmt.Sprintf("%s(%s, %s, $%d::int)::float8", rank, vector, query, len(args))
+
+	highlights := "NULL::jsonb"
+	if search.Highlight != nil {
+		options, err := headlineOptions(search.Highlight)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, options)
+		pairs := make([]string, 0, 2*len(search.Columns))
+		for _, name := range search.Columns {
+			pairs = append(pairs, quoteLiteral(name),
+				fmt.Sprintf("ts_headline(%s, COALESCE(r.%s::text, ''), %s, $%d)", language, quoteIdent(name), query, len(args)))
+		}
+		highlights = "jsonb_build_object(" + strings.Join(pairs, ", ") + ")"
+	}
+
+	where := fmt.Sprintf("%s @@ %s AND %s", vector, query, cond)
+	if search.MinRank > 0 {
+		args = append(args, search.MinRank)
+		where += fmt.Sprintf(" AND %s >= $%d", rankExpr, len(args))
+	}
+
+	// La clé primaire départage les rangs égaux pour une pagination stable
+	order := []string{"2 DESC"}
+	for _, key := range t.PrimaryKey {
+		order = append(order, "r."+quoteIdent(key))
+	}
+
+	args = append(args, clampLimit(search.Limit), max(search.Offset, 0))
+	statement := fmt.Sprintf(`SELECT %s, %s, %s FROM %s AS r WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		projection, rankExpr, highlights, t.Identifier(), where, strings.Join(order, ", "), len(args)-1, len(args))
+	return statement, args, nil
+}
+
+// searchVector construit l'expression tsvector des colonnes, pondérées si demandé. prefix
+// qualifie les colonnes ("r.") ; vide, l'expression convient à une colonne générée.
+func searchVector(t *Table, columns []string, weights map[string]string, language, prefix string) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%w: aucune colonne à rechercher et pas de colonne %q", ErrInvalid, SearchColumn)
+	}
+	if !languagePattern.MatchString(language) {
+		return "", fmt.Errorf("%w: langue de recherche invalide %q", ErrInvalid, language)
+	}
+	for name := range weights {
+		if !containsString(columns, name) {
+			return "", fmt.Errorf("%w: poids d'une colonne non recherchée %q", ErrInvalid, name)
+		}
+	}
+
+	parts := make([]string, len(columns))
+	for i, name := range columns {
+		if _, ok := t.Column(name); !ok || name == SearchColumn {
+			return "", fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, name, t.Name)
+		}
+		expr := TextSearchVector(language, prefix+quoteIdent(name))
+		if weight, ok := weights[name]; ok {
+			if !searchWeights[weight] {
+				return "", fmt.Errorf("%w: poids invalide %q pour %q (A, B, C ou D)", ErrInvalid, weight, name)
+			}
+			expr = fmt.Sprintf("setweight(%s, %s)", expr, quoteLiteral(weight))
+		}
+		parts[i] = expr
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " || ") + ")", nil
+}
+
+// headlineOptions construit les options de ts_headline, transmises en paramètre
+func headlineOptions(h *Highlight) (string, error) {
+	startSel, stopSel := h.StartSel, h.StopSel
+	if startSel == "" {
+		startSel = "<b>"
+	}
+	if stopSel == "" {
+		stopSel = "</b>"
+	}
+	for _, sel := range []string{startSel, stopSel} {
+		if strings.ContainsAny(sel, `,="`) {
+			return "", fmt.Errorf("%w: les balises de surlignage ne peuvent pas contenir , = ou \"", ErrInvalid)
+		}
+	}
+
+	options := []string{`StartSel="` + startSel + `"`, `StopSel="` + stopSel + `"`}
+	if h.MaxWords > 0 {
+		options = append(options, "MaxWords="+strconv.Itoa(h.MaxWords))
+	}
+	if h.MinWords > 0 {
+		options = append(options, "MinWords="+strconv.Itoa(h.MinWords))
+	}
+	if h.MaxFragments > 0 {
+		options = append(options, "MaxFragments="+strconv.Itoa(h.MaxFragments))
+	}
+	if h.MinWords > 0 && h.MaxWords > 0 && h.MinWords >= h.MaxWords {
+		return "", fmt.Errorf("%w: min_words doit être inférieur à max_words", ErrInvalid)
+	}
+	return strings.Join(options, ", "), nil
+}
+
+// searchIndexStatements génère la colonne de recherche gérée et son index GIN, en
+// remplaçant la colonne existante. La configuration est conservée en commentaire de la colonne.
+func searchIndexStatements(t *Table, index SearchIndex) ([]string, error) {
+	if index.Language == "" {
+		index.Language = DefaultSearchLanguage
+	}
+	// Une colonne générée n'accepte que des expressions immuables : la conversion en texte
+	// des dates ou des nombres dépend des paramètres de session
+	for _, name := range index.Columns {
+		if col, ok := t.Column(name); ok && !isTextSearchable(col.Type) {
+			return nil, fmt.Errorf("%w: la colonne %q de type %s ne peut pas être indexée, seuls les types texte et json le peuvent", ErrInvalid, name, col.Type)
+		}
+	}
+	vector, err := searchVector(t, index.Columns, index.Weights, index.Language, "")
+	if err != nil {
+		return nil, err
+	}
+	source, err := json.Marshal(index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: configuration de recherche invalide", ErrInvalid)
+	}
+
+	column := quoteIdent(SearchColumn)
+	var statements []string
+	if t.hasSearchColumn() {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Identifier(), column))
+	}
+	return append(statements,
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s tsvector GENERATED ALWAYS AS (%s) STORED", t.Identifier(), column, vector),
+		fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", t.Identifier(), column, quoteLiteral(string(source))),
+		fmt.Sprintf("CREATE INDEX %s ON %s USING gin (%s)", quoteIdent(searchIndexName(t)), t.Identifier(), column),
+	), nil
+}
+
+// searchIndexName nomme l'index GIN de la colonne de recherche gérée
+func searchIndexName(t *Table) string {
+	return defaultIndexName(t, []IndexField{{Column: SearchColumn}})
+}
+
+// isTextSearchable indique si une colonne peut alimenter une colonne de recherche générée
+func isTextSearchable(columnType string) bool {
+	base, _, _ := strings.Cut(columnType, "(")
+	switch base {
+	case "text", "character varying", "character", "json", "jsonb":
+		return true
+	}
+	return false
+}
+
+// GetSearchIndex retourne la configuration de la colonne de recherche gérée de la collection
+func GetSearchIndex(ctx context.Context, q Querier, t *Table) (*SearchIndex, error) {
+	if !t.hasSearchColumn() {
+		return nil, fmt.Errorf("%w: la collection %q n'a pas de colonne de recherche", ErrNotFound, t.Name)
+	}
+
+	var source string
+	err := q.QueryRow(ctx, `
+		SELECT COALESCE(pg_catalog.col_description(a.attrelid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attname = $2`, t.Identifier(), SearchColumn).Scan(&source)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	var index SearchIndex
+	if err := json.Unmarshal([]byte(source), &index); err != nil || len(index.Columns) == 0 {
+		return nil, fmt.Errorf("%w: la colonne %q n'a pas été créée par l'API de recherche", ErrInvalid, SearchColumn)
+	}
+	return &index, nil
+}
+
+// EnableSearch crée ou remplace la colonne de recherche gérée de la collection et son index GIN
+func EnableSearch(ctx context.Context, q Querier, t *Table, index SearchIndex) (*SearchIndex, error) {
+	statements, err := searchIndexStatements(t, index)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, "enable search on "+t.Name, statements); err != nil {
+		return nil, err
+	}
+	updated, err := LoadTable(ctx, q, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	return GetSearchIndex(ctx, q, updated)
+}
+
+// DisableSearch supprime la colonne de recherche gérée de la collection, et son index avec elle
+func DisableSearch(ctx context.Context, q Querier, t *Table) error {
+	if !t.hasSearchColumn() {
+		return fmt.Errorf("%w: la collection %q n'a pas de colonne de recherche", ErrNotFound, t.Name)
+	}
+	statement := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Identifier(), quoteIdent(SearchColumn))
+	_, err := applyMigration(ctx, q, "disable search on "+t.Name, []string{statement})
+	return err
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
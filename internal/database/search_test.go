@@ -0,0 +1,118 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// searchablePosts retourne la collection de test avec une colonne de recherche gérée
+func searchablePosts() *Table {
+	t := postsTable()
+	t.Columns = append(t.Columns, Column{Name: SearchColumn, Type: "tsvector"})
+	return t
+}
+
+func TestSearchStatement(t *testing.T) {
+	search := Search{
+		Query:     "chat noir",
+		Columns:   []string{"title", "meta"},
+		Weights:   map[string]string{"title": "A"},
+		Language:  "french",
+		Ranking:   RankCover,
+		MinRank:   0.1,
+		Filter:    map[string]any{"views": "3"},
+		Highlight: &Highlight{MaxWords: 10},
+	}
+
+	sql, args, err := searchStatement(postsTable(), search, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vector := `(setweight(to_tsvector('french'::regconfig, COALESCE(r."title"::text, '')), 'A') || to_tsvector('french'::regconfig, COALESCE(r."meta"::text, '')))`
+	query := `websearch_to_tsquery('french'::regconfig, $2)`
+	rank := `ts_rank_cd(` + vector + `, ` + query + `, $3::int)::float8`
+	expected := `SELECT to_jsonb(r.*), ` + rank + `, jsonb_build_object(` +
+		`'title', ts_headline('french'::regconfig, COALESCE(r."title"::text, ''), ` + query + `, $4), ` +
+		`'meta', ts_headline('french'::regconfig, COALESCE(r."meta"::text, ''), ` + query + `, $4))` +
+		` FROM "public"."posts" AS r WHERE ` + vector + ` @@ ` + query + ` AND r."views" = $1::text::bigint AND ` + rank + ` >= $5` +
+		` ORDER BY 2 DESC, r."id" LIMIT $6 OFFSET $7`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+
+	expectedArgs := []any{"3", "chat noir", 0, `StartSel="<b>", StopSel="</b>", MaxWords=10`, 0.1, DefaultLimit, 0}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
+
+func TestSearchStatement_ManagedColumn(t *testing.T) {
+	sql, args, err := searchStatement(searchablePosts(), Search{Query: "chat", Mode: SearchPhrase, Columns: []string{"title"}, Language: "english"}, `r."search_vector"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// La colonne gérée n'apparaît pas dans les documents renvoyés
+	expected := `SELECT (to_jsonb(r.*) - 'search_vector'), ts_rank(r."search_vector", phraseto_tsquery('english'::regconfig, $1), $2::int)::float8, NULL::jsonb` +
+		` FROM "public"."posts" AS r WHERE r."search_vector" @@ phraseto_tsquery('english'::regconfig, $1) AND TRUE ORDER BY 2 DESC, r."id" LIMIT $3 OFFSET $4`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+	if len(args) != 4 || args[0] != "chat" {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
+
+func TestSearchStatement_Errors(t *testing.T) {
+	cases := map[string]Search{
+		"no columns":         {Query: "chat", Language: "simple"},
+		"unknown column":     {Query: "chat", Columns: []string{"nope"}, Language: "simple"},
+		"managed column":     {Query: "chat", Columns: []string{SearchColumn}, Language: "simple"},
+		"unknown mode":       {Query: "chat", Mode: "fuzzy", Columns: []string{"title"}, Language: "simple"},
+		"unknown ranking":    {Query: "chat", Ranking: "bm25", Columns: []string{"title"}, Language: "simple"},
+		"normalization":      {Query: "chat", Normalization: 64, Columns: []string{"title"}, Language: "simple"},
+		"injected language":  {Query: "chat", Columns: []string{"title"}, Language: "english'::regconfig); --"},
+		"invalid weight":     {Query: "chat", Columns: []string{"title"}, Weights: map[string]string{"title": "E"}, Language: "simple"},
+		"weight not search":  {Query: "chat", Columns: []string{"title"}, Weights: map[string]string{"meta": "A"}, Language: "simple"},
+		"injected highlight": {Query: "chat", Columns: []string{"title"}, Language: "simple", Highlight: &Highlight{StartSel: `<b class="x">`}},
+		"highlight words":    {Query: "chat", Columns: []string{"title"}, Language: "simple", Highlight: &Highlight{MinWords: 20, MaxWords: 10}},
+	}
+
+	for name, search := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := searchStatement(searchablePosts(), search, ""); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSearchIndexStatements(t *testing.T) {
+	statements, err := searchIndexStatements(searchablePosts(), SearchIndex{Columns: []string{"title", "meta"}, Weights: map[string]string{"title": "A"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`ALTER TABLE "public"."posts" DROP COLUMN "search_vector"`,
+		`ALTER TABLE "public"."posts" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS ((setweight(to_tsvector('simple'::regconfig, COALESCE("title"::text, '')), 'A') || to_tsvector('simple'::regconfig, COALESCE("meta"::text, '')))) STORED`,
+		`COMMENT ON COLUMN "public"."posts"."search_vector" IS '{"columns":["title","meta"],"weights":{"title":"A"},"language":"simple"}'`,
+		`CREATE INDEX "posts_search_vector_idx" ON "public"."posts" USING gin ("search_vector")`,
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("unexpected statements:\ngot  %q\nwant %q", statements, expected)
+	}
+
+	// Une colonne générée n'accepte que des expressions immuables
+	if _, err := searchIndexStatements(postsTable(), SearchIndex{Columns: []string{"deleted_at"}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a timestamp column, got %v", err)
+	}
+}
+
+func TestColumnsOf_RejectsSearchColumn(t *testing.T) {
+	if _, err := searchablePosts().columnsOf(map[string]any{"title": "a", SearchColumn: "b"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
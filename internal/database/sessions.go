@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EndUserRole est le rôle PostgreSQL endossé par les requêtes des utilisateurs finaux :
+// il ne possède aucune table, les politiques RLS s'appliquent donc à lui
+const EndUserRole = "sovrabase_user"
+
+// Claims identifie l'utilisateur final d'une requête de l'API de données
+type Claims struct {
+	Subject string         // Identifiant de l'utilisateur, exposé par auth.uid()
+	Role    string         // Rôle de l'utilisateur dans le projet, exposé par auth.role()
+	Raw     map[string]any // Toutes les revendications du jeton, exposées par auth.jwt()
+}
+
+// identity résume l'utilisateur pour vérifier qu'une transaction est reprise par la même personne
+func (c *Claims) identity() string {
+	if c == nil {
+		return ""
+	}
+	return c.Role + "/" + c.Subject
+}
+
+// authSetupStatements crée le rôle des utilisateurs finaux et les fonctions auth.*
+// utilisées par les politiques ; toutes les instructions sont idempotentes
+var authSetupStatements = []string{
+	`DO $$ BEGIN CREATE ROLE ` + quoteIdent(EndUserRole) + ` NOLOGIN; EXCEPTION WHEN duplicate_object THEN NULL; END $$`,
+	`GRANT ` + quoteIdent(EndUserRole) + ` TO CURRENT_USER`,
+	`CREATE SCHEMA IF NOT EXISTS auth`,
+	`CREATE OR REPLACE FUNCTION auth.uid() RETURNS text LANGUAGE sql STABLE AS
+		$$ SELECT NULLIF(current_setting('request.jwt.claim.sub', true), '') $$`,
+	`CREATE OR REPLACE FUNCTION auth.role() RETURNS text LANGUAGE sql STABLE AS
+		$$ SELECT NULLIF(current_setting('request.jwt.claim.role', true), '') $$`,
+	`CREATE OR REPLACE FUNCTION auth.jwt() RETURNS jsonb LANGUAGE sql STABLE AS
+		$$ SELECT COALESCE(NULLIF(current_setting('request.jwt.claims', true), ''), '{}')::jsonb $$`,
+	`GRANT USAGE ON SCHEMA auth, ` + quoteIdent(DefaultSchema) + ` TO ` + quoteIdent(EndUserRole),
+	`GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA auth TO ` + quoteIdent(EndUserRole),
+}
+
+// Session ouvre une transaction au nom d'un utilisateur final : les politiques RLS de la
+// base filtrent alors chaque ligne lue ou écrite. La transaction est validée par
+// CommitSession, avant d'écrire la réponse ; la fonction retournée doit être appelée à la
+// fin de la requête et annule une transaction qui n'a pas été validée.
+func (m *Manager) Session(ctx context.Context, dbID string, claims *Claims) (Querier, func(), error) {
+	pool, err := m.Pool(ctx, dbID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.ensureAuth(ctx, dbID, pool); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erreur lors de l'ouverture de la transaction: %w", err)
+	}
+	if err := applyClaims(ctx, tx, claims); err != nil {
+		rollback(tx)
+		return nil, nil, err
+	}
+
+	session := &sessionTx{Tx: tx}
+	return session, func() {
+		if !session.done {
+			session.done = true
+			rollback(tx)
+		}
+	}, nil
+}
+
+// sessionTx est la transaction d'une session, terminée une seule fois
+type sessionTx struct {
+	pgx.Tx
+	done bool
+}
+
+// CommitSession valide la transaction d'une session ouverte par Session. Elle n'a pas
+// d'effet sur un pool, ni sur une transaction interactive, validée par CommitTransaction.
+// Un échec de validation (conflit de sérialisation, contrainte différée, politique
+// WITH CHECK) est retourné : les écritures de la requête sont alors annulées.
+func CommitSession(ctx context.Context, q Querier) error {
+	session, ok := q.(*sessionTx)
+	if !ok || session.done {
+		return nil
+	}
+	session.done = true
+	return translateError(session.Tx.Commit(ctx))
+}
+
+// ensureAuth prépare une fois par base le rôle et les fonctions utilisés par les politiques
+func (m *Manager) ensureAuth(ctx context.Context, dbID string, q Querier) error {
+	m.mu.Lock()
+	ready := m.authReady[dbID]
+	m.mu.Unlock()
+	if ready {
+		return nil
+	}
+
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		for _, statement := range authSetupStatements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la préparation de la sécurité au niveau des lignes: %w", translateError(err))
+	}
+
+	m.mu.Lock()
+	m.authReady[dbID] = true
+	m.mu.Unlock()
+	return nil
+}
+
+// applyClaims endosse le rôle des utilisateurs finaux et expose l'identité de l'utilisateur
+// aux politiques, pour la durée de la transaction (SET LOCAL)
+func applyClaims(ctx context.Context, tx pgx.Tx, claims *Claims) error {
+	raw, err := json.Marshal(claims.Raw)
+	if err != nil {
+		return fmt.Errorf("%w: revendications du jeton invalides", ErrInvalid)
+	}
+
+	if _, err := tx.Exec(ctx, "SET LOCAL ROLE "+quoteIdent(EndUserRole)); err != nil {
+		return translateError(err)
+	}
+	_, err = tx.Exec(ctx, `SELECT set_config('request.jwt.claims', $1, true),
+		set_config('request.jwt.claim.sub', $2, true),
+		set_config('request.jwt.claim.role', $3, true)`,
+		string(raw), claims.Subject, claims.Role)
+	return translateError(err)
+}
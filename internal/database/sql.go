@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Limites de l'exécution de SQL brut
+const (
+	DefaultSQLTimeout = 10 * time.Second
+	MaxSQLTimeout     = 5 * time.Minute
+	DefaultSQLRows    = 1000
+	MaxSQLRows        = 100000
+)
+
+// TxStarter ouvre des transactions, comme le pool d'une base gérée
+type TxStarter interface {
+	BeginTx(ctx context.Context, options pgx.TxOptions) (pgx.Tx, error)
+}
+
+// RawSQL décrit une instruction SQL arbitraire et ses paramètres ($1, $2…)
+type RawSQL struct {
+	Statement string
+	Params    []any
+	ReadOnly  bool          // Exécute l'instruction dans une transaction en lecture seule
+	Timeout   time.Duration // statement_timeout, DefaultSQLTimeout par défaut
+	MaxRows   int           // Lignes renvoyées au plus, DefaultSQLRows par défaut
+}
+
+// SQLColumn décrit une colonne du résultat d'une instruction
+type SQLColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // Nom du type PostgreSQL, vide s'il n'est pas connu du pilote
+	oid  uint32
+}
+
+// SQLResultWriter reçoit le résultat d'une instruction au fil de sa lecture. Les valeurs
+// sont au format texte de PostgreSQL, nil pour NULL ; elles ne sont valides que pendant l'appel.
+type SQLResultWriter interface {
+	Columns(columns []SQLColumn) error
+	Row(values [][]byte) error
+}
+
+// SQLOutcome résume l'exécution d'une instruction
+type SQLOutcome struct {
+	Command   string `json:"command"` // Étiquette de commande, ex: "INSERT 0 3"
+	Rows      int64  `json:"row_count"`
+	Truncated bool   `json:"truncated"` // Des lignes au-delà de MaxRows n'ont pas été renvoyées
+}
+
+// ExecSQL exécute une instruction dans sa propre transaction et transmet son résultat à w
+func ExecSQL(ctx context.Context, db TxStarter, raw RawSQL, w SQLResultWriter) (*SQLOutcome, error) {
+	if strings.TrimSpace(raw.Statement) == "" {
+		return nil, fmt.Errorf("%w: instruction SQL vide", ErrInvalid)
+	}
+	timeout := raw.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSQLTimeout
+	}
+	timeout = min(timeout, MaxSQLTimeout)
+	maxRows := raw.MaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultSQLRows
+	}
+	maxRows = min(maxRows, MaxSQLRows)
+
+	options := pgx.TxOptions{}
+	if raw.ReadOnly {
+		options.AccessMode = pgx.ReadOnly
+	}
+	tx, err := db.BeginTx(ctx, options)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	if _, err := tx.Exec(ctx, `SELECT set_config('statement_timeout', $1, true)`, strconv.FormatInt(timeout.Milliseconds(), 10)); err != nil {
+		return nil, translateError(err)
+	}
+
+	// Le format texte rend chaque valeur telle que PostgreSQL l'affiche, quel que soit son type
+	args := append([]any{pgx.QueryResultFormats{pgx.TextFormatCode}}, sqlParams(raw.Params)...)
+	rows, err := tx.Query(ctx, raw.Statement, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	columns := make([]SQLColumn, len(fields))
+	for i, field := range fields {
+		columns[i] = SQLColumn{Name: field.Name, oid: field.DataTypeOID}
+		if t, ok := tx.Conn().TypeMap().TypeForOID(field.DataTypeOID); ok {
+			columns[i].Type = t.Name
+		}
+	}
+	if err := w.Columns(columns); err != nil {
+		return nil, err
+	}
+
+	outcome := &SQLOutcome{}
+	for rows.Next() {
+		if outcome.Rows == int64(maxRows) {
+			outcome.Truncated = true
+			break
+		}
+		if err := w.Row(rows.RawValues()); err != nil {
+			return nil, err
+		}
+		outcome.Rows++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	outcome.Command = rows.CommandTag().String()
+	if len(fields) == 0 {
+		outcome.Rows = rows.CommandTag().RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, translateError(err)
+	}
+	return outcome, nil
+}
+
+// sqlParams transmet les paramètres en texte : PostgreSQL les convertit dans le type
+// qu'il a déduit de l'instruction. Objets et tableaux sont transmis en JSON.
+func sqlParams(params []any) []any {
+	converted := make([]any, len(params))
+	for i, param := range params {
+		switch v := param.(type) {
+		case nil:
+			converted[i] = nil
+		case string:
+			converted[i] = v
+		case json.Number:
+			converted[i] = v.String()
+		case bool, float64, int, int64:
+			converted[i] = fmt.Sprint(v)
+		default:
+			encoded, _ := json.Marshal(v)
+			converted[i] = string(encoded)
+		}
+	}
+	return converted
+}
+
+// JSONValue convertit une valeur du résultat en JSON : les nombres, booléens et documents
+// JSON gardent leur type, les autres valeurs sont des chaînes dans le format de PostgreSQL
+func (c SQLColumn) JSONValue(raw []byte) json.RawMessage {
+	if raw == nil {
+		return json.RawMessage("null")
+	}
+	switch c.oid {
+	case pgtype.BoolOID:
+		if string(raw) == "t" {
+			return json.RawMessage("true")
+		}
+		return json.RawMessage("false")
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.OIDOID,
+		pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		// NaN et Infinity n'existent pas en JSON
+		if json.Valid(raw) {
+			return json.RawMessage(raw)
+		}
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		return json.RawMessage(raw)
+	}
+	encoded, _ := json.Marshal(string(raw))
+	return encoded
+}
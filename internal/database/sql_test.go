@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSQLParams(t *testing.T) {
+	params := sqlParams([]any{
+		nil,
+		"hello",
+		json.Number("12.50"),
+		true,
+		[]any{"a", "b"},
+		map[string]any{"k": json.Number("1")},
+	})
+
+	want := []any{nil, "hello", "12.50", "true", `["a","b"]`, `{"k":1}`}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("unexpected params: %#v", params)
+	}
+}
+
+func TestSQLColumn_JSONValue(t *testing.T) {
+	cases := []struct {
+		oid  uint32
+		raw  []byte
+		want string
+	}{
+		{pgtype.Int8OID, []byte("42"), `42`},
+		{pgtype.NumericOID, []byte("12.50"), `12.50`},
+		{pgtype.NumericOID, []byte("NaN"), `"NaN"`},
+		{pgtype.Float8OID, []byte("-Infinity"), `"-Infinity"`},
+		{pgtype.BoolOID, []byte("t"), `true`},
+		{pgtype.BoolOID, []byte("f"), `false`},
+		{pgtype.JSONBOID, []byte(`{"a": [1, 2]}`), `{"a": [1, 2]}`},
+		{pgtype.TextOID, []byte(`say "hi"`), `"say \"hi\""`},
+		{pgtype.TimestamptzOID, []byte("2024-01-02 03:04:05+00"), `"2024-01-02 03:04:05+00"`},
+		{pgtype.TextArrayOID, []byte("{a,b}"), `"{a,b}"`},
+		{pgtype.TextOID, nil, `null`},
+	}
+
+	for _, c := range cases {
+		got := SQLColumn{oid: c.oid}.JSONValue(c.raw)
+		if string(got) != c.want {
+			t.Errorf("oid %d, %q: got %s, want %s", c.oid, c.raw, got, c.want)
+		}
+	}
+}
+
+func TestExecSQL_RejectsEmptyStatement(t *testing.T) {
+	_, err := ExecSQL(context.Background(), nil, RawSQL{Statement: "  \n"}, nil)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// rollbackTimeout borne l'annulation d'une transaction hors de toute requête
+const rollbackTimeout = 10 * time.Second
+
+// Transaction est une transaction interactive conservée par le serveur entre plusieurs requêtes
+type Transaction struct {
+	ID        string
+	ProjectID string
+	DBID      string
+
+	tx       pgx.Tx
+	identity string // Utilisateur final ayant ouvert la transaction, vide pour un accès de service
+
+	// mu sérialise les requêtes : une connexion ne traite qu'une commande à la fois
+	mu     sync.Mutex
+	timer  *time.Timer
+	closed bool
+}
+
+// BeginTransaction ouvre une transaction sur une connexion dédiée du pool de la base, qui
+// doit appartenir au projet. Avec claims, la transaction s'exécute au nom de cet utilisateur
+// final (voir Session). La limite de transactions ouvertes porte sur le projet propriétaire,
+// toutes bases confondues.
+func (m *Manager) BeginTransaction(ctx context.Context, projectID, dbID string, claims *Claims) (*Transaction, error) {
+	if err := m.CheckOwner(ctx, projectID, dbID); err != nil {
+		return nil, err
+	}
+	pool, err := m.Pool(ctx, dbID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkTransactionLimit(projectID); err != nil {
+		return nil, err
+	}
+
+	id, err := newTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	if claims != nil {
+		if err := m.ensureAuth(ctx, dbID, pool); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de l'ouverture de la transaction: %w", err)
+	}
+	if claims != nil {
+		if err := applyClaims(ctx, tx, claims); err != nil {
+			rollback(tx)
+			return nil, err
+		}
+	}
+
+	t := &Transaction{ID: id, ProjectID: projectID, DBID: dbID, tx: tx, identity: claims.identity()}
+
+	// La limite est vérifiée à nouveau : d'autres transactions ont pu s'ouvrir entre-temps
+	m.mu.Lock()
+	if err := m.checkTransactionLimitLocked(projectID); err != nil {
+		m.mu.Unlock()
+		rollback(tx)
+		return nil, err
+	}
+	t.timer = time.AfterFunc(m.config.TransactionTimeout, func() { m.expireTransaction(t) })
+	m.transactions[id] = t
+	m.mu.Unlock()
+
+	return t, nil
+}
+
+// AcquireTransaction réserve une transaction ouverte pour la durée d'une requête.
+// La fonction retournée doit être appelée à la fin de la requête pour relancer le délai d'inactivité.
+func (m *Manager) AcquireTransaction(txID, projectID, dbID string, claims *Claims) (Querier, func(), error) {
+	t, err := m.acquire(txID, projectID, dbID, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t.tx, func() { m.releaseTransaction(t) }, nil
+}
+
+// CommitTransaction valide une transaction et restitue sa connexion au pool
+func (m *Manager) CommitTransaction(ctx context.Context, txID, projectID, dbID string, claims *Claims) error {
+	return m.endTransaction(ctx, txID, projectID, dbID, claims, true)
+}
+
+// RollbackTransaction annule une transaction et restitue sa connexion au pool
+func (m *Manager) RollbackTransaction(ctx context.Context, txID, projectID, dbID string, claims *Claims) error {
+	return m.endTransaction(ctx, txID, projectID, dbID, claims, false)
+}
+
+// TransactionTimeout retourne le délai d'inactivité avant annulation automatique
+func (m *Manager) TransactionTimeout() time.Duration {
+	return m.config.TransactionTimeout
+}
+
+func (m *Manager) checkTransactionLimit(projectID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkTransactionLimitLocked(projectID)
+}
+
+// checkTransactionLimitLocked compte les transactions du projet. Le projet d'une transaction
+// est celui qui possède sa base, vérifié à son ouverture par CheckOwner.
+func (m *Manager) checkTransactionLimitLocked(projectID string) error {
+	open := 0
+	for _, t := range m.transactions {
+		if t.ProjectID == projectID {
+			open++
+		}
+	}
+	if open >= m.config.MaxTransactions {
+		return fmt.Errorf("%w: %d transactions au maximum par projet", ErrTooManyTransactions, m.config.MaxTransactions)
+	}
+	return nil
+}
+
+// acquire verrouille la transaction et suspend son délai d'inactivité
+func (m *Manager) acquire(txID, projectID, dbID string, claims *Claims) (*Transaction, error) {
+	m.mu.Lock()
+	t, ok := m.transactions[txID]
+	m.mu.Unlock()
+
+	// Une transaction d'un autre projet, d'une autre base ou d'un autre utilisateur est traitée comme inexistante
+	if !ok || t.ProjectID != projectID || t.DBID != dbID || t.identity != claims.identity() {
+		return nil, fmt.Errorf("%w: transaction %q", ErrNotFound, txID)
+	}
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%w: transaction %q", ErrNotFound, txID)
+	}
+	t.timer.Stop()
+	return t, nil
+}
+
+func (m *Manager) releaseTransaction(t *Transaction) {
+	if !t.closed {
+		t.timer.Reset(m.config.TransactionTimeout)
+	}
+	t.mu.Unlock()
+}
+
+func (m *Manager) endTransaction(ctx context.Context, txID, projectID, dbID string, claims *Claims, commit bool) error {
+	t, err := m.acquire(txID, projectID, dbID, claims)
+	if err != nil {
+		return err
+	}
+	defer m.releaseTransaction(t)
+
+	t.closed = true
+	m.mu.Lock()
+	delete(m.transactions, txID)
+	m.mu.Unlock()
+
+	if commit {
+		return translateError(t.tx.Commit(ctx))
+	}
+	return translateError(t.tx.Rollback(ctx))
+}
+
+// expireTransaction annule une transaction restée inactive trop longtemps
+func (m *Manager) expireTransaction(t *Transaction) {
+	m.mu.Lock()
+	if m.transactions[t.ID] != t {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.transactions, t.ID)
+	m.mu.Unlock()
+
+	// Attend la fin d'une éventuelle requête en cours sur la transaction
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+
+	rollback(t.tx)
+	log.Printf("⏱️ Transaction %s annulée après %s d'inactivité", t.ID, m.config.TransactionTimeout)
+}
+
+// abortTransactions annule les transactions sélectionnées, par exemple avant la fermeture d'un pool
+func (m *Manager) abortTransactions(match func(*Transaction) bool) {
+	m.mu.Lock()
+	var aborted []*Transaction
+	for id, t := range m.transactions {
+		if match(t) {
+			aborted = append(aborted, t)
+			delete(m.transactions, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, t := range aborted {
+		t.mu.Lock()
+		if !t.closed {
+			t.closed = true
+			t.timer.Stop()
+			rollback(t.tx)
+		}
+		t.mu.Unlock()
+	}
+}
+
+func rollback(tx pgx.Tx) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+	if err := tx.Rollback(ctx); err != nil {
+		log.Printf("⚠️ Erreur lors de l'annulation d'une transaction: %v", err)
+	}
+}
+
+// newTransactionID génère un identifiant de transaction non devinable
+func newTransactionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erreur lors de la génération de l'identifiant: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ketsuna-org/sovrabase/internal/config"
+)
+
+// fakeTx enregistre l'issue d'une transaction sans connexion réelle
+type fakeTx struct {
+	pgx.Tx
+	outcome chan string
+}
+
+func newFakeTx() *fakeTx {
+	return &fakeTx{outcome: make(chan string, 1)}
+}
+
+func (f *fakeTx) Commit(ctx context.Context) error {
+	f.outcome <- "commit"
+	return nil
+}
+
+func (f *fakeTx) Rollback(ctx context.Context) error {
+	f.outcome <- "rollback"
+	return nil
+}
+
+// openFakeTransaction enregistre une transaction sur la base "db" comme le ferait BeginTransaction
+func openFakeTransaction(m *Manager, id, projectID string, tx pgx.Tx) {
+	openFakeTransactionOn(m, id, projectID, "db", tx)
+}
+
+func openFakeTransactionOn(m *Manager, id, projectID, dbID string, tx pgx.Tx) {
+	t := &Transaction{ID: id, ProjectID: projectID, DBID: dbID, tx: tx}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.timer = time.AfterFunc(m.config.TransactionTimeout, func() { m.expireTransaction(t) })
+	m.transactions[id] = t
+}
+
+func TestTransactionLimit_IsPerProject(t *testing.T) {
+	m := NewManager(nil, &config.DataAPI{TransactionTimeout: time.Minute, MaxTransactions: 2})
+	defer m.Close()
+
+	// Répartir les transactions sur plusieurs bases ne permet pas de dépasser la limite du projet
+	openFakeTransactionOn(m, "a", "project-1", "db-1", newFakeTx())
+	openFakeTransactionOn(m, "b", "project-1", "db-2", newFakeTx())
+
+	if err := m.checkTransactionLimit("project-1"); !errors.Is(err, ErrTooManyTransactions) {
+		t.Errorf("expected ErrTooManyTransactions, got %v", err)
+	}
+	if err := m.checkTransactionLimit("project-2"); err != nil {
+		t.Errorf("unexpected error for another project: %v", err)
+	}
+}
+
+func TestAcquireTransaction_RejectsOtherOwner(t *testing.T) {
+	m := NewManager(nil, &config.DataAPI{TransactionTimeout: time.Minute, MaxTransactions: 5})
+	defer m.Close()
+
+	openFakeTransaction(m, "a", "project-1", newFakeTx())
+
+	if _, _, err := m.AcquireTransaction("a", "project-2", "db", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another project, got %v", err)
+	}
+	if _, _, err := m.AcquireTransaction("a", "project-1", "db", &Claims{Subject: "u1"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an end user, got %v", err)
+	}
+
+	_, release, err := m.AcquireTransaction("a", "project-1", "db", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+}
+
+func TestCommitTransaction_ClosesTransaction(t *testing.T) {
+	m := NewManager(nil, &config.DataAPI{TransactionTimeout: time.Minute, MaxTransactions: 5})
+	defer m.Close()
+
+	tx := newFakeTx()
+	openFakeTransaction(m, "a", "project-1", tx)
+
+	if err := m.CommitTransaction(context.Background(), "a", "project-1", "db", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-tx.outcome; got != "commit" {
+		t.Errorf("unexpected outcome: got %s want commit", got)
+	}
+
+	if _, _, err := m.AcquireTransaction("a", "project-1", "db", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after commit, got %v", err)
+	}
+}
+
+func TestTransaction_RolledBackWhenIdle(t *testing.T) {
+	m := NewManager(nil, &config.DataAPI{TransactionTimeout: 10 * time.Millisecond, MaxTransactions: 5})
+	defer m.Close()
+
+	tx := newFakeTx()
+	openFakeTransaction(m, "a", "project-1", tx)
+
+	select {
+	case got := <-tx.outcome:
+		if got != "rollback" {
+			t.Errorf("unexpected outcome: got %s want rollback", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("idle transaction was not rolled back")
+	}
+
+	if err := m.checkTransactionLimit("project-1"); err != nil {
+		t.Errorf("expired transaction still counts towards the limit: %v", err)
+	}
+}
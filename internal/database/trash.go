@@ -0,0 +1,343 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ketsuna-org/sovrabase/internal/orchestrator"
+)
+
+// TrashColumn est la colonne datant la mise à la corbeille des documents d'une collection
+// avec corbeille. Une colonne deleted_at ne l'active pas à elle seule : la configuration de la
+// corbeille est conservée en commentaire de la colonne.
+const TrashColumn = "deleted_at"
+
+// Documents lus selon leur présence dans la corbeille (Query.Trashed)
+const (
+	TrashedExclude = "exclude" // Documents hors de la corbeille, par défaut
+	TrashedInclude = "include" // Tous les documents
+	TrashedOnly    = "only"    // Documents de la corbeille
+)
+
+// SoftDelete décrit la corbeille d'une collection
+type SoftDelete struct {
+	RetentionDays int `json:"retention_days"` // Jours avant la suppression définitive, 0 pour conserver indéfiniment
+}
+
+// softDeleteComment est le commentaire de la colonne TrashColumn d'une collection avec corbeille
+type softDeleteComment struct {
+	SoftDelete *SoftDelete `json:"soft_delete"`
+}
+
+// parseSoftDelete lit la configuration de la corbeille dans le commentaire de la colonne
+func parseSoftDelete(col Column, comment string) *SoftDelete {
+	if col.Name != TrashColumn || col.Type != "timestamp with time zone" || comment == "" {
+		return nil
+	}
+	var source softDeleteComment
+	if err := json.Unmarshal([]byte(comment), &source); err != nil {
+		return nil
+	}
+	return source.SoftDelete
+}
+
+// liveCond ajoute à la condition l'exclusion des documents de la corbeille de l'alias
+func (t *Table) liveCond(alias, cond string) string {
+	if t.SoftDelete == nil {
+		return cond
+	}
+	return fmt.Sprintf("%s AND %s.%s IS NULL", cond, alias, quoteIdent(TrashColumn))
+}
+
+// trashedCond ajoute à la condition la sélection des documents selon leur présence dans la corbeille
+func (t *Table) trashedCond(alias, cond, trashed string) (string, error) {
+	switch trashed {
+	case "", TrashedExclude:
+		return t.liveCond(alias, cond), nil
+	case TrashedInclude:
+		return cond, nil
+	case TrashedOnly:
+		if t.SoftDelete == nil {
+			return "", fmt.Errorf("%w: la collection %q n'a pas de corbeille", ErrInvalid, t.Name)
+		}
+		return fmt.Sprintf("%s AND %s.%s IS NOT NULL", cond, alias, quoteIdent(TrashColumn)), nil
+	default:
+		return "", fmt.Errorf("%w: valeur de trashed inconnue %q (exclude, include ou only)", ErrInvalid, trashed)
+	}
+}
+
+// deleteStatement construit la suppression des documents de r désignés par la condition,
+// avec la clause using éventuelle : avec une corbeille, les documents y sont placés
+func deleteStatement(t *Table, using, where string) string {
+	if t.SoftDelete != nil {
+		return trashStatement(t, "now()", using, where+" AND r."+quoteIdent(TrashColumn)+" IS NULL")
+	}
+	if using != "" {
+		using = " USING " + using
+	}
+	return fmt.Sprintf(`DELETE FROM %s AS r%s WHERE %s`, t.Identifier(), using, where)
+}
+
+// trashStatement construit l'affectation de la date de mise à la corbeille des documents de r
+func trashStatement(t *Table, value, using, where string) string {
+	assignments := quoteIdent(TrashColumn) + " = " + value
+	if bump := t.versionBump(nil); bump != "" {
+		assignments += ", " + bump
+	}
+	if using != "" {
+		using = " FROM " + using
+	}
+	return fmt.Sprintf(`UPDATE %s AS r SET %s%s WHERE %s`, t.Identifier(), assignments, using, where)
+}
+
+// RestoreDocuments sort de la corbeille les documents listés par ids puis ceux correspondant
+// au filtre, dans une même transaction. Un filtre vide mais non nil restaure toute la corbeille.
+func RestoreDocuments(ctx context.Context, q Querier, t *Table, ids []string, filter map[string]any) (int64, error) {
+	return changeTrash(ctx, q, t, ids, filter, func(using, where string) string {
+		return trashStatement(t, "NULL", using, where+" AND r."+quoteIdent(TrashColumn)+" IS NOT NULL")
+	})
+}
+
+// PurgeDocuments supprime définitivement les documents de la corbeille listés par ids puis ceux
+// correspondant au filtre. Un filtre vide mais non nil vide toute la corbeille.
+func PurgeDocuments(ctx context.Context, q Querier, t *Table, ids []string, filter map[string]any) (int64, error) {
+	return changeTrash(ctx, q, t, ids, filter, func(using, where string) string {
+		if using != "" {
+			using = " USING " + using
+		}
+		return fmt.Sprintf(`DELETE FROM %s AS r%s WHERE %s AND r.%s IS NOT NULL`, t.Identifier(), using, where, quoteIdent(TrashColumn))
+	})
+}
+
+// changeTrash exécute l'instruction sur les documents de la corbeille désignés par ids puis
+// sur ceux correspondant au filtre, et retourne le nombre de documents concernés
+func changeTrash(ctx context.Context, q Querier, t *Table, ids []string, filter map[string]any, statement func(using, where string) string) (int64, error) {
+	if t.SoftDelete == nil {
+		return 0, fmt.Errorf("%w: la collection %q n'a pas de corbeille", ErrInvalid, t.Name)
+	}
+	if len(ids) == 0 && filter == nil {
+		return 0, fmt.Errorf("%w: des ids ou un filtre sont requis, {} désignant toute la corbeille", ErrInvalid)
+	}
+
+	var count int64
+	err := pgx.BeginFunc(ctx, q, func(tx pgx.Tx) error {
+		if len(ids) > 0 {
+			key, err := t.documentKey()
+			if err != nil {
+				return err
+			}
+			keys := make([]map[string]any, len(ids))
+			for i, id := range ids {
+				keys[i] = map[string]any{key: id}
+			}
+			using := fmt.Sprintf("jsonb_populate_recordset(NULL::%s, $1::jsonb) AS k", t.Identifier())
+			tag, err := tx.Exec(ctx, statement(using, fmt.Sprintf("r.%[1]s = k.%[1]s", quoteIdent(key))), keys)
+			if err != nil {
+				return translateError(err)
+			}
+			count += tag.RowsAffected()
+		}
+		if filter != nil {
+			cond, args, err := CompileFilter(t, filter, nil)
+			if err != nil {
+				return err
+			}
+			tag, err := tx.Exec(ctx, statement("", cond), args...)
+			if err != nil {
+				return translateError(err)
+			}
+			count += tag.RowsAffected()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetSoftDelete retourne la configuration de la corbeille de la collection
+func GetSoftDelete(t *Table) (*SoftDelete, error) {
+	if t.SoftDelete == nil {
+		return nil, fmt.Errorf("%w: la collection %q n'a pas de corbeille", ErrNotFound, t.Name)
+	}
+	return t.SoftDelete, nil
+}
+
+// softDeleteStatements génère l'activation de la corbeille : la colonne TrashColumn, créée si
+// besoin, porte la configuration en commentaire, et un index partiel sert la purge
+func softDeleteStatements(t *Table, config SoftDelete) ([]string, error) {
+	if config.RetentionDays < 0 {
+		return nil, fmt.Errorf("%w: retention_days ne peut pas être négatif", ErrInvalid)
+	}
+	column := quoteIdent(TrashColumn)
+	var statements []string
+	if col, ok := t.Column(TrashColumn); !ok {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s timestamptz", t.Identifier(), column))
+	} else if col.Type != "timestamp with time zone" {
+		return nil, fmt.Errorf("%w: la colonne %q existe avec le type %s, timestamptz est requis", ErrConflict, TrashColumn, col.Type)
+	}
+
+	source, err := json.Marshal(softDeleteComment{SoftDelete: &config})
+	if err != nil {
+		return nil, fmt.Errorf("%w: configuration de corbeille invalide", ErrInvalid)
+	}
+	return append(statements,
+		fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", t.Identifier(), column, quoteLiteral(string(source))),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s) WHERE %s IS NOT NULL",
+			quoteIdent(defaultIndexName(t, []IndexField{{Column: TrashColumn}})), t.Identifier(), column, column),
+	), nil
+}
+
+// EnableSoftDelete active ou reconfigure la corbeille de la collection
+func EnableSoftDelete(ctx context.Context, q Querier, t *Table, config SoftDelete) (*SoftDelete, error) {
+	statements, err := softDeleteStatements(t, config)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := applyMigration(ctx, q, "enable soft delete on "+t.Name, statements); err != nil {
+		return nil, err
+	}
+	updated, err := LoadTable(ctx, q, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	return GetSoftDelete(updated)
+}
+
+// DisableSoftDelete désactive la corbeille de la collection, qui doit être vide : les documents
+// qu'elle contient redeviendraient visibles. La colonne TrashColumn est conservée.
+func DisableSoftDelete(ctx context.Context, q Querier, t *Table) error {
+	if t.SoftDelete == nil {
+		return fmt.Errorf("%w: la collection %q n'a pas de corbeille", ErrNotFound, t.Name)
+	}
+
+	var trashed bool
+	sql := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s IS NOT NULL)`, t.Identifier(), quoteIdent(TrashColumn))
+	if err := q.QueryRow(ctx, sql).Scan(&trashed); err != nil {
+		return translateError(err)
+	}
+	if trashed {
+		return fmt.Errorf("%w: la corbeille de la collection %q n'est pas vide, restaurer ou purger ses documents d'abord", ErrConflict, t.Name)
+	}
+
+	statement := fmt.Sprintf("COMMENT ON COLUMN %s.%s IS NULL", t.Identifier(), quoteIdent(TrashColumn))
+	_, err := applyMigration(ctx, q, "disable soft delete on "+t.Name, []string{statement})
+	return err
+}
+
+// PurgeExpiredTrash supprime définitivement, dans chaque collection avec une durée de rétention,
+// les documents restés dans la corbeille plus longtemps qu'elle, et retourne leur nombre
+func PurgeExpiredTrash(ctx context.Context, q Querier) (int64, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.relname, pg_catalog.col_description(a.attrelid, a.attnum)
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND a.attname = $2 AND NOT a.attisdropped
+		  AND a.atttypid = 'timestamptz'::regtype AND pg_catalog.col_description(a.attrelid, a.attnum) IS NOT NULL`,
+		DefaultSchema, TrashColumn)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	retentions := make(map[string]int)
+	for rows.Next() {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			rows.Close()
+			return 0, translateError(err)
+		}
+		config := parseSoftDelete(Column{Name: TrashColumn, Type: "timestamp with time zone"}, comment)
+		if config != nil && config.RetentionDays > 0 {
+			retentions[name] = config.RetentionDays
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, translateError(err)
+	}
+
+	var purged int64
+	for name, days := range retentions {
+		t := &Table{Schema: DefaultSchema, Name: name}
+		tag, err := q.Exec(ctx, expiredTrashStatement(t), days)
+		if err != nil {
+			return purged, fmt.Errorf("collection %q: %w", name, translateError(err))
+		}
+		purged += tag.RowsAffected()
+	}
+	return purged, nil
+}
+
+// expiredTrashStatement construit la purge des documents mis à la corbeille depuis plus de $1 jours
+func expiredTrashStatement(t *Table) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE %s < now() - make_interval(days => $1)`, t.Identifier(), quoteIdent(TrashColumn))
+}
+
+// StartTrashPurge purge périodiquement les corbeilles expirées des bases gérées, jusqu'à la
+// fermeture du gestionnaire. Un intervalle nul désactive la purge.
+func (m *Manager) StartTrashPurge(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				m.purgeExpiredTrash()
+			}
+		}
+	}()
+}
+
+// purgeExpiredTrash purge les corbeilles expirées de chaque base démarrée, listée par
+// l'orchestrateur : les bases sans requête depuis le démarrage du serveur sont aussi purgées
+func (m *Manager) purgeExpiredTrash() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	databases, err := m.resolver.ListDatabases(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("⚠️ Erreur lors de la liste des bases à purger: %v", err)
+		return
+	}
+
+	for _, info := range databases {
+		if info.Status != "running" {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		purged, err := m.purgeDatabaseTrash(ctx, info)
+		cancel()
+		if err != nil {
+			log.Printf("⚠️ Erreur lors de la purge de la corbeille de la base %s: %v", info.ProjectID, err)
+		} else if purged > 0 {
+			log.Printf("🗑️ %d documents purgés de la corbeille de la base %s", purged, info.ProjectID)
+		}
+	}
+}
+
+// purgeDatabaseTrash purge la corbeille de la base avec son pool s'il est ouvert. Sinon, la
+// base est ouverte le temps de la purge, sans créer de pool qui resterait inutilisé.
+func (m *Manager) purgeDatabaseTrash(ctx context.Context, info *orchestrator.DatabaseInfo) (int64, error) {
+	m.mu.Lock()
+	pool, ok := m.pools[info.ProjectID]
+	m.mu.Unlock()
+	if ok {
+		return PurgeExpiredTrash(ctx, pool)
+	}
+
+	conn, err := pgx.Connect(ctx, info.ConnectionString)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la connexion à la base: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+	return PurgeExpiredTrash(ctx, conn)
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// trashedPosts est la collection postsTable avec une corbeille
+func trashedPosts() *Table {
+	t := postsTable()
+	t.SoftDelete = &SoftDelete{RetentionDays: 30}
+	return t
+}
+
+func TestParseSoftDelete(t *testing.T) {
+	col := Column{Name: TrashColumn, Type: "timestamp with time zone", Nullable: true}
+	if config := parseSoftDelete(col, `{"soft_delete":{"retention_days":7}}`); config == nil || config.RetentionDays != 7 {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	// Une colonne deleted_at ordinaire n'active pas la corbeille
+	cases := map[string]struct {
+		col     Column
+		comment string
+	}{
+		"no comment":      {col, ""},
+		"foreign comment": {col, "supprimé le"},
+		"other json":      {col, `{"note":"x"}`},
+		"wrong type":      {Column{Name: TrashColumn, Type: "date"}, `{"soft_delete":{}}`},
+		"other column":    {Column{Name: "removed_at", Type: col.Type}, `{"soft_delete":{}}`},
+	}
+	for name, c := range cases {
+		if config := parseSoftDelete(c.col, c.comment); config != nil {
+			t.Errorf("%s: unexpected config %+v", name, config)
+		}
+	}
+}
+
+func TestTrashedCond(t *testing.T) {
+	cases := map[string]string{
+		"":             `TRUE AND r."deleted_at" IS NULL`,
+		TrashedExclude: `TRUE AND r."deleted_at" IS NULL`,
+		TrashedInclude: `TRUE`,
+		TrashedOnly:    `TRUE AND r."deleted_at" IS NOT NULL`,
+	}
+	for trashed, want := range cases {
+		cond, err := trashedPosts().trashedCond("r", "TRUE", trashed)
+		if err != nil || cond != want {
+			t.Errorf("%q: got %s (%v), want %s", trashed, cond, err, want)
+		}
+	}
+
+	if cond, err := postsTable().trashedCond("r", "TRUE", ""); err != nil || cond != "TRUE" {
+		t.Errorf("a collection without trash should be unfiltered: %s (%v)", cond, err)
+	}
+	if _, err := postsTable().trashedCond("r", "TRUE", TrashedOnly); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid without trash, got %v", err)
+	}
+	if _, err := trashedPosts().trashedCond("r", "TRUE", "all"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown value, got %v", err)
+	}
+}
+
+func TestDeleteStatement(t *testing.T) {
+	using := `jsonb_populate_recordset(NULL::"public"."posts", $1::jsonb) AS k`
+	if sql, want := deleteStatement(postsTable(), using, `r."id" = k."id"`),
+		`DELETE FROM "public"."posts" AS r USING `+using+` WHERE r."id" = k."id"`; sql != want {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, want)
+	}
+	if sql, want := deleteStatement(trashedPosts(), using, `r."id" = k."id"`),
+		`UPDATE "public"."posts" AS r SET "deleted_at" = now() FROM `+using+` WHERE r."id" = k."id" AND r."deleted_at" IS NULL`; sql != want {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, want)
+	}
+
+	// La mise à la corbeille incrémente la version
+	table := versionedUsers()
+	table.Columns = append(table.Columns, Column{Name: TrashColumn, Type: "timestamp with time zone", Nullable: true})
+	table.SoftDelete = &SoftDelete{}
+	if sql := deleteStatement(table, "", "TRUE"); !strings.Contains(sql, `SET "deleted_at" = now(), "version" = r."version" + 1 WHERE`) {
+		t.Errorf("soft delete should bump the version: %s", sql)
+	}
+}
+
+func TestTrashHidesDocuments(t *testing.T) {
+	sql := updateStatement(trashedPosts(), "id", []string{"title"}, false)
+	if !strings.Contains(sql, `WHERE r."id" = k."id" AND r."deleted_at" IS NULL RETURNING`) {
+		t.Errorf("updates should skip trashed documents: %s", sql)
+	}
+
+	sql, _, err := nearestStatement(func() *Table {
+		table := embeddedPosts()
+		table.SoftDelete = &SoftDelete{}
+		return table
+	}(), Query{Nearest: &Nearest{Column: "embedding", Vector: []float64{1, 2, 3}}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sql, `WHERE TRUE AND r."deleted_at" IS NULL ORDER BY`) {
+		t.Errorf("nearest documents should exclude trashed documents: %s", sql)
+	}
+}
+
+func TestSoftDeleteStatements(t *testing.T) {
+	// La colonne deleted_at de postsTable est reprise
+	statements, err := softDeleteStatements(postsTable(), SoftDelete{RetentionDays: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		`COMMENT ON COLUMN "public"."posts"."deleted_at" IS '{"soft_delete":{"retention_days":30}}'`,
+		`CREATE INDEX IF NOT EXISTS "posts_deleted_at_idx" ON "public"."posts" ("deleted_at") WHERE "deleted_at" IS NOT NULL`,
+	}
+	if strings.Join(statements, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected statements:\n%s", strings.Join(statements, "\n"))
+	}
+
+	statements, err = softDeleteStatements(usersTable(), SoftDelete{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `ALTER TABLE "public"."users" ADD COLUMN "deleted_at" timestamptz`; len(statements) != 3 || statements[0] != want {
+		t.Errorf("the trash column should be added: %q", statements)
+	}
+
+	if _, err := softDeleteStatements(usersTable(), SoftDelete{RetentionDays: -1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+	table := usersTable()
+	table.Columns = append(table.Columns, Column{Name: TrashColumn, Type: "boolean"})
+	if _, err := softDeleteStatements(table, SoftDelete{}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestExpiredTrashStatement(t *testing.T) {
+	if sql, want := expiredTrashStatement(postsTable()), `DELETE FROM "public"."posts" WHERE "deleted_at" < now() - make_interval(days => $1)`; sql != want {
+		t.Errorf("got %s, want %s", sql, want)
+	}
+}
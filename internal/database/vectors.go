@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// vectorExtension active pgvector dans la base ; sans effet s'il l'est déjà
+const vectorExtension = "CREATE EXTENSION IF NOT EXISTS vector"
+
+// vectorTypes liste les types de colonnes fournis par pgvector
+var vectorTypes = map[string]bool{"vector": true, "halfvec": true, "sparsevec": true}
+
+// Mesures de distance d'une recherche des plus proches voisins
+const (
+	DistanceCosine       = "cosine"
+	DistanceL2           = "l2"
+	DistanceInnerProduct = "inner_product"
+)
+
+// distanceOperators associe chaque mesure à son opérateur pgvector. Le produit scalaire
+// est renvoyé négatif par <#> pour que les plus proches aient la plus petite distance.
+var distanceOperators = map[string]string{
+	DistanceCosine:       "<=>",
+	DistanceL2:           "<->",
+	DistanceInnerProduct: "<#>",
+}
+
+// indexParameters liste les paramètres de construction acceptés par chaque méthode d'index
+var indexParameters = map[string]map[string]bool{
+	IndexHNSW:    {"m": true, "ef_construction": true},
+	IndexIVFFlat: {"lists": true},
+}
+
+// Nearest décrit une recherche des plus proches voisins d'un vecteur
+type Nearest struct {
+	Column      string
+	Vector      []float64
+	Distance    string   // DistanceCosine par défaut
+	MaxDistance *float64 // Distance maximale des résultats, aucune si nil
+}
+
+// isVectorType indique si un type de colonne est fourni par pgvector
+func isVectorType(columnType string) bool {
+	base, _, _ := strings.Cut(strings.TrimSuffix(columnType, "[]"), "(")
+	return vectorTypes[strings.TrimSpace(base)]
+}
+
+// withVectorExtension active pgvector avant des instructions utilisant l'un de ses types.
+// L'extension fait partie de la migration pour que l'historique reste rejouable.
+func withVectorExtension(statements []string, types ...string) []string {
+	for _, columnType := range types {
+		if isVectorType(strings.ToLower(columnType)) {
+			return append([]string{vectorExtension}, statements...)
+		}
+	}
+	return statements
+}
+
+// vectorLiteral écrit un vecteur dans le format texte de pgvector : [1,2.5,3]
+func vectorLiteral(values []float64) (string, error) {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("%w: le vecteur contient une valeur non finie", ErrInvalid)
+		}
+		parts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return "[" + strings.Join(parts, ",") + "]", nil
+}
+
+// distanceExpr génère l'expression de distance entre la colonne de r et le vecteur recherché,
+// ajouté aux arguments. Le vecteur est converti dans le type de la colonne, ce qui vérifie
+// sa dimension et permet à PostgreSQL d'utiliser un index HNSW ou IVFFlat.
+func distanceExpr(t *Table, nearest *Nearest, args []any) (string, []any, error) {
+	col, ok := t.Column(nearest.Column)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: colonne inconnue %q dans la collection %q", ErrInvalid, nearest.Column, t.Name)
+	}
+	base, _, _ := strings.Cut(col.Type, "(")
+	if base != "vector" && base != "halfvec" {
+		return "", nil, fmt.Errorf("%w: la colonne %q n'est pas de type vector ou halfvec", ErrInvalid, nearest.Column)
+	}
+	if len(nearest.Vector) == 0 {
+		return "", nil, fmt.Errorf("%w: le vecteur recherché est vide", ErrInvalid)
+	}
+
+	distance := nearest.Distance
+	if distance == "" {
+		distance = DistanceCosine
+	}
+	operator, ok := distanceOperators[distance]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: distance inconnue %q (cosine, l2 ou inner_product)", ErrInvalid, nearest.Distance)
+	}
+
+	literal, err := vectorLiteral(nearest.Vector)
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(args, literal)
+	return fmt.Sprintf("(r.%s %s $%d::text::%s)", quoteIdent(nearest.Column), operator, len(args), col.Type), args, nil
+}
+
+// NearestDocuments retourne les documents les plus proches du vecteur, du plus proche au plus
+// éloigné, parmi ceux correspondant au filtre. La distance de chaque document est dans Page.Distances.
+func NearestDocuments(ctx context.Context, q Querier, t *Table, query Query) (*Page, error) {
+	if query.Cursor != "" || len(query.Sort) > 0 {
+		return nil, fmt.Errorf("%w: nearest trie par distance et ne peut pas être combiné à sort ou cursor", ErrInvalid)
+	}
+	if query.Count != "" && query.Count != CountExact && query.Count != CountEstimated {
+		return nil, fmt.Errorf("%w: mode de comptage inconnu %q (exact ou estimated)", ErrInvalid, query.Count)
+	}
+
+	expansions, err := resolveExpand(ctx, q, t, query.Expand)
+	if err != nil {
+		return nil, err
+	}
+	statement, args, err := nearestStatement(t, query, expansions)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, statement, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	page := &Page{Data: []json.RawMessage{}, Distances: []float64{}}
+	for rows.Next() {
+		var doc json.RawMessage
+		var distance float64
+		if err := rows.Scan(&doc, &distance); err != nil {
+			return nil, translateError(err)
+		}
+		page.Data = append(page.Data, doc)
+		page.Distances = append(page.Distances, distance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	rows.Close()
+
+	if query.Count != "" {
+		cond, args, err := nearestCondition(t, query)
+		if err != nil {
+			return nil, err
+		}
+		total, err := countDocuments(ctx, q, t, cond, args, query.Count)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+		page.TotalEstimated = query.Count == CountEstimated
+	}
+	return page, nil
+}
+
+// nearestStatement génère la recherche des plus proches voisins. Le tri porte sur la seule
+// distance pour que PostgreSQL puisse parcourir un index HNSW ou IVFFlat.
+func nearestStatement(t *Table, query Query, expansions []expansion) (string, []any, error) {
+	projection, err := projectionExpr(t, query.Select)
+	if err != nil {
+		return "", nil, err
+	}
+	cond, args, err := CompileFilter(t, query.Filter, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if cond, err = t.trashedCond("r", cond, query.Trashed); err != nil {
+		return "", nil, err
+	}
+	var aliases int
+	if projection, args, err = expandExpr(projection, "r", expansions, args, &aliases); err != nil {
+		return "", nil, err
+	}
+	distance, args, err := distanceExpr(t, query.Nearest, args)
+	if err != nil {
+		return "", nil, err
+	}
+	if maxDistance := query.Nearest.MaxDistance; maxDistance != nil {
+		args = append(args, *maxDistance)
+		cond = fmt.Sprintf("%s AND %s < $%d", cond, distance, len(args))
+	}
+
+	args = append(args, clampLimit(query.Limit), max(query.Offset, 0))
+	statement := fmt.Sprintf(`SELECT %s, %s::float8 FROM %s AS r WHERE %s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		projection, distance, t.Identifier(), cond, distance, len(args)-1, len(args))
+	return statement, args, nil
+}
+
+// nearestCondition retourne la condition de la recherche, pour le comptage
+func nearestCondition(t *Table, query Query) (string, []any, error) {
+	cond, args, err := CompileFilter(t, query.Filter, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if cond, err = t.trashedCond("r", cond, query.Trashed); err != nil || query.Nearest.MaxDistance == nil {
+		return cond, args, err
+	}
+	distance, args, err := distanceExpr(t, query.Nearest, args)
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(args, *query.Nearest.MaxDistance)
+	return fmt.Sprintf("%s AND %s < $%d", cond, distance, len(args)), args, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// embeddedPosts retourne la collection de test avec une colonne pgvector
+func embeddedPosts() *Table {
+	t := postsTable()
+	t.Columns = append(t.Columns, Column{Name: "embedding", Type: "vector(3)", Nullable: true})
+	return t
+}
+
+func TestNearestStatement(t *testing.T) {
+	maxDistance := 0.5
+	sql, args, err := nearestStatement(embeddedPosts(), Query{
+		Filter:  map[string]any{"views": "3"},
+		Select:  []string{"id", "title"},
+		Limit:   5,
+		Nearest: &Nearest{Column: "embedding", Vector: []float64{0.1, 2, -3e-7}, MaxDistance: &maxDistance},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	distance := `(r."embedding" <=> $2::text::vector(3))`
+	expected := `SELECT (SELECT to_jsonb(s.*) FROM (SELECT r."id", r."title") AS s), ` + distance + `::float8 FROM "public"."posts" AS r` +
+		` WHERE r."views" = $1::text::bigint AND ` + distance + ` < $3 ORDER BY ` + distance + ` LIMIT $4 OFFSET $5`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+	if want := []any{"3", "[0.1,2,-3e-07]", 0.5, 5, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
+
+func TestNearestStatement_Distances(t *testing.T) {
+	cases := map[string]string{
+		DistanceL2:           `(r."embedding" <-> $1::text::vector(3))`,
+		DistanceInnerProduct: `(r."embedding" <#> $1::text::vector(3))`,
+	}
+	for distance, expr := range cases {
+		t.Run(distance, func(t *testing.T) {
+			sql, _, err := nearestStatement(embeddedPosts(), Query{Nearest: &Nearest{Column: "embedding", Vector: []float64{1, 2, 3}, Distance: distance}}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := `SELECT to_jsonb(r.*), ` + expr + `::float8 FROM "public"."posts" AS r WHERE TRUE ORDER BY ` + expr + ` LIMIT $2 OFFSET $3`
+			if sql != expected {
+				t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+			}
+		})
+	}
+}
+
+func TestNearestStatement_Errors(t *testing.T) {
+	cases := map[string]Nearest{
+		"unknown column":   {Column: "nope", Vector: []float64{1}},
+		"not a vector":     {Column: "title", Vector: []float64{1}},
+		"empty vector":     {Column: "embedding"},
+		"unknown distance": {Column: "embedding", Vector: []float64{1}, Distance: "manhattan"},
+	}
+	for name, nearest := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := nearestStatement(embeddedPosts(), Query{Nearest: &nearest}, nil); !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithVectorExtension(t *testing.T) {
+	statements := withVectorExtension([]string{"CREATE TABLE t ()"}, "text", "VECTOR(1536)")
+	if want := []string{vectorExtension, "CREATE TABLE t ()"}; !reflect.DeepEqual(statements, want) {
+		t.Errorf("unexpected statements: %q", statements)
+	}
+	if statements := withVectorExtension([]string{"CREATE TABLE t ()"}, "text", "vectors"); len(statements) != 1 {
+		t.Errorf("unexpected extension for non-vector types: %q", statements)
+	}
+}
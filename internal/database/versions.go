@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// VersionColumn est la colonne de version des documents : un entier non nul, incrémenté par
+// chaque modification faite par l'API. Sans elle, la version d'un document est la transaction
+// qui a écrit la ligne (xmin), qui change à chaque modification, quelle qu'en soit l'origine.
+const VersionColumn = "version"
+
+// hasVersionColumn indique si la collection possède une colonne de version
+func (t *Table) hasVersionColumn() bool {
+	col, ok := t.Column(VersionColumn)
+	return ok && !col.Nullable && (col.Type == "smallint" || col.Type == "integer" || col.Type == "bigint")
+}
+
+// versionExpr retourne l'expression texte de la version d'un document de l'alias. Le préfixe
+// distingue les deux sources, pour qu'une version lue avant l'ajout de la colonne ne soit pas
+// confondue avec une version lue après.
+func (t *Table) versionExpr(alias string) string {
+	if t.hasVersionColumn() {
+		return "('v' || " + alias + "." + quoteIdent(VersionColumn) + ")"
+	}
+	return "('x' || " + alias + ".xmin)"
+}
+
+// versionBump retourne l'affectation incrémentant la version des lignes de r, ou une chaîne
+// vide sans colonne de version ou quand la modification fixe elle-même la version
+func (t *Table) versionBump(columns []string) string {
+	if !t.hasVersionColumn() || slices.Contains(columns, VersionColumn) {
+		return ""
+	}
+	return fmt.Sprintf("%[1]s = r.%[1]s + 1", quoteIdent(VersionColumn))
+}
+
+// versionMismatch explique une écriture conditionnelle restée sans effet : le document
+// n'existe pas, ou sa version n'est plus l'une de celles attendues
+func versionMismatch(ctx context.Context, q Querier, t *Table, key, id string) error {
+	sql := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %[1]s AS r, jsonb_populate_record(NULL::%[1]s, $1::jsonb) AS k WHERE %[2]s)`,
+		t.Identifier(), t.liveCond("r", fmt.Sprintf("r.%[1]s = k.%[1]s", quoteIdent(key))))
+
+	var exists bool
+	if err := q.QueryRow(ctx, sql, map[string]any{key: id}).Scan(&exists); err != nil {
+		return translateError(err)
+	}
+	if exists {
+		return fmt.Errorf("%w: le document a été modifié depuis sa lecture", ErrPreconditionFailed)
+	}
+	return fmt.Errorf("%w: document introuvable", ErrNotFound)
+}
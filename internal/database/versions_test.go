@@ -0,0 +1,62 @@
+package database
+
+import "testing"
+
+// versionedUsers est la collection usersTable avec une colonne de version
+func versionedUsers() *Table {
+	t := usersTable()
+	t.Columns = append(t.Columns, Column{Name: VersionColumn, Type: "bigint", HasDefault: true})
+	return t
+}
+
+func TestVersionExpr(t *testing.T) {
+	if got, want := usersTable().versionExpr("r"), `('x' || r.xmin)`; got != want {
+		t.Errorf("without version column: got %s, want %s", got, want)
+	}
+	if got, want := versionedUsers().versionExpr("r"), `('v' || r."version")`; got != want {
+		t.Errorf("with version column: got %s, want %s", got, want)
+	}
+
+	// Une colonne version nullable ou non entière n'est pas une colonne de version
+	for _, col := range []Column{{Name: VersionColumn, Type: "integer", Nullable: true}, {Name: VersionColumn, Type: "text"}} {
+		table := usersTable()
+		table.Columns = append(table.Columns, col)
+		if table.hasVersionColumn() {
+			t.Errorf("%+v should not be a version column", col)
+		}
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	if bump := usersTable().versionBump([]string{"name"}); bump != "" {
+		t.Errorf("unexpected bump without version column: %s", bump)
+	}
+	if bump, want := versionedUsers().versionBump([]string{"name"}), `"version" = r."version" + 1`; bump != want {
+		t.Errorf("got %s, want %s", bump, want)
+	}
+	if bump := versionedUsers().versionBump([]string{"name", VersionColumn}); bump != "" {
+		t.Errorf("an explicit version should not be bumped: %s", bump)
+	}
+}
+
+func TestUpdateStatement(t *testing.T) {
+	sql := updateStatement(usersTable(), "id", []string{"email", "name"}, false)
+	expected := `UPDATE "public"."users" AS r SET "email" = p."email", "name" = p."name" FROM jsonb_populate_record(NULL::"public"."users", $1::jsonb) AS p, jsonb_populate_record(NULL::"public"."users", $2::jsonb) AS k WHERE r."id" = k."id" RETURNING to_jsonb(r.*), ('x' || r.xmin)`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+
+	sql = updateStatement(versionedUsers(), "id", []string{"name"}, true)
+	expected = `UPDATE "public"."users" AS r SET "name" = p."name", "version" = r."version" + 1 FROM jsonb_populate_record(NULL::"public"."users", $1::jsonb) AS p, jsonb_populate_record(NULL::"public"."users", $2::jsonb) AS k WHERE r."id" = k."id" AND ('v' || r."version") = ANY($3::text[]) RETURNING to_jsonb(r.*), ('v' || r."version")`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
+
+func TestImportInsertStatement_BumpsVersion(t *testing.T) {
+	sql := importInsertStatement(versionedUsers(), []string{"id", "name"}, ImportConflictUpdate)
+	expected := `INSERT INTO "public"."users" AS r ("id", "name") SELECT p."id", p."name" FROM pg_temp.sovrabase_import AS s, jsonb_populate_record(NULL::"public"."users", s.doc) AS p WHERE s.keys = $1::text[] ORDER BY s.line ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "version" = r."version" + 1`
+	if sql != expected {
+		t.Errorf("unexpected statement:\ngot  %s\nwant %s", sql, expected)
+	}
+}
@@ -79,8 +79,12 @@ func CORSMiddleware(config *CORSConfig) func(http.Handler) http.Handler {
 
 				// Définir les headers CORS appropriés
 				w.Header().Set("Access-Control-Allow-Origin", matchedOrigin)
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS, PATCH")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Transaction-ID, If-Match, If-None-Match, "+
+					"If-Modified-Since, If-Range, Range, "+
+					"Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata, Upload-Defer-Length")
+				w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, Accept-Ranges, Content-Range, Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, "+
+					"Upload-Offset, Upload-Length, Upload-Expires, File-Id")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 				w.Header().Set("Access-Control-Max-Age", "3600")
 
@@ -28,3 +28,9 @@ type OrganisationCompliance struct {
 	RGPD  bool `json:"rgpd"`
 	HIPAA bool `json:"hipaa"`
 }
+
+// RequiresMasking reports whether the databases of the organization must have an enforced
+// masking profile, so that personal data never leaves them unmasked in branches or exports
+func (c OrganisationCompliance) RequiresMasking() bool {
+	return c.RGPD
+}
@@ -11,6 +11,7 @@ type APIKey struct {
 	ExpiresAt   *time.Time `json:"expires_at"`
 	Active      bool       `json:"active"`
 	Permissions []string   `json:"permissions"`
+	Key         string     `json:"key,omitempty"` // Signed key, only returned on creation
 }
 
 // Project represents a project in the system
@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required" example:"john_doe"`
@@ -100,7 +102,7 @@ type UpdateProjectMemberRequest struct {
 type CreateAPIKeyRequest struct {
 	Name        string   `json:"name" binding:"required" example:"Production Key"`
 	Description string   `json:"description" binding:"required" example:"Key for production environment"`
-	Permissions []string `json:"permissions,omitempty" example:"read,write"`
+	Permissions []string `json:"permissions,omitempty" example:"sql"`
 	ExpiresAt   string   `json:"expires_at,omitempty" example:"2025-12-31T23:59:59Z"`
 }
 
@@ -145,17 +147,141 @@ type RestoreDatabaseRequest struct {
 	BackupID string `json:"backup_id" binding:"required"`
 }
 
+// CreateDatabaseBranchRequest represents database branch creation request
+type CreateDatabaseBranchRequest struct {
+	Name       string `json:"name,omitempty" example:"pr-123"`       // Suffix of the branch ID, generated if empty
+	TTLSeconds int    `json:"ttl_seconds,omitempty" example:"86400"` // Lifetime of the branch, 0 to keep it until deleted
+	Unmasked   bool   `json:"unmasked,omitempty"`                    // Copy the data without the masking profile (requires the masking permission)
+}
+
+// CreateCollectionRequest represents collection creation request
+type CreateCollectionRequest struct {
+	Name       string             `json:"name" binding:"required" example:"posts"`
+	Columns    []ColumnDefinition `json:"columns" binding:"required"`
+	PrimaryKey []string           `json:"primary_key,omitempty" example:"id"`
+	Checks     []CheckConstraint  `json:"checks,omitempty"`
+}
+
+// ColumnDefinition represents a column to create
+type ColumnDefinition struct {
+	Name            string                 `json:"name" binding:"required" example:"title"`
+	Type            string                 `json:"type" binding:"required" example:"text"`
+	NotNull         bool                   `json:"not_null,omitempty" example:"true"`
+	Unique          bool                   `json:"unique,omitempty" example:"false"`
+	Identity        bool                   `json:"identity,omitempty" example:"false"` // Auto-incremented integer
+	Default         interface{}            `json:"default,omitempty"`
+	DefaultFunction string                 `json:"default_function,omitempty" enums:"now,current_date,current_timestamp,gen_random_uuid"`
+	References      *ForeignKeyDefinition  `json:"references,omitempty"`
+	Check           map[string]interface{} `json:"check,omitempty"` // Filter every row must match
+}
+
+// ForeignKeyDefinition represents the column referenced by a foreign key
+type ForeignKeyDefinition struct {
+	Collection string `json:"collection" binding:"required" example:"users"`
+	Column     string `json:"column" binding:"required" example:"id"`
+	OnDelete   string `json:"on_delete,omitempty" enums:"no action,restrict,cascade,set null,set default" example:"cascade"`
+}
+
+// CheckConstraint represents a table check constraint expressed as a filter
+type CheckConstraint struct {
+	Name   string                 `json:"name,omitempty" example:"positive_price"`
+	Filter map[string]interface{} `json:"filter" binding:"required"`
+}
+
+// AlterColumnRequest represents column update request; omitted fields are left unchanged
+type AlterColumnRequest struct {
+	Name            string      `json:"name,omitempty" example:"headline"`
+	Type            string      `json:"type,omitempty" example:"varchar(200)"`
+	NotNull         *bool       `json:"not_null,omitempty" example:"true"`
+	Default         interface{} `json:"default,omitempty"`
+	DefaultFunction string      `json:"default_function,omitempty" enums:"now,current_date,current_timestamp,gen_random_uuid"`
+	DropDefault     bool        `json:"drop_default,omitempty" example:"false"`
+}
+
 // UpdateCollectionRequest represents collection update request
 type UpdateCollectionRequest struct {
 	Name string `json:"name,omitempty" example:"updated_collection"`
 }
 
-// QueryCollectionRequest represents data query request
+// QueryCollectionRequest represents data query request.
+// The filter grammar is described in docs/filters.md.
 type QueryCollectionRequest struct {
 	Filter map[string]interface{} `json:"filter,omitempty"`
-	Sort   map[string]interface{} `json:"sort,omitempty"`
+	Select []string               `json:"select,omitempty" example:"id,title,created_at"`
+	Sort   []string               `json:"sort,omitempty" example:"-created_at,name"`
 	Limit  int                    `json:"limit,omitempty" example:"10"`
 	Offset int                    `json:"offset,omitempty" example:"0"`
+	Cursor string                 `json:"cursor,omitempty"`
+	Count  string                 `json:"count,omitempty" enums:"exact,estimated" example:"estimated"`
+	// Nearest returns the documents closest to a vector, ordered by distance
+	Nearest *NearestQuery `json:"nearest,omitempty"`
+	// Expand embeds related documents, following foreign keys (docs/relations.md)
+	Expand []ExpandRequest `json:"expand,omitempty"`
+	// Trashed reads the documents of the trash of a collection with soft delete (docs/trash.md)
+	Trashed string `json:"trashed,omitempty" enums:"exclude,include,only" example:"exclude"`
+}
+
+// ExpandRequest represents related documents embedded in the documents read.
+// A relation the collection references gives a document or null, a relation
+// referencing the collection gives an array of documents.
+type ExpandRequest struct {
+	Relation string                 `json:"relation" binding:"required" example:"comments"` // Foreign key column, constraint name or related collection
+	As       string                 `json:"as,omitempty" example:"latest_comments"`         // Key of the embedded documents, relation by default
+	Select   []string               `json:"select,omitempty" example:"id,body"`
+	Filter   map[string]interface{} `json:"filter,omitempty"`
+	Sort     []string               `json:"sort,omitempty" example:"-created_at"` // Referencing documents only
+	Limit    int                    `json:"limit,omitempty" example:"5"`          // Referencing documents only, 100 by default and 1000 at most
+	Expand   []ExpandRequest        `json:"expand,omitempty"`                     // Relations of the related documents
+}
+
+// NearestQuery represents a k-nearest-neighbour search on a pgvector column
+type NearestQuery struct {
+	Column      string    `json:"column" binding:"required" example:"embedding"`
+	Vector      []float64 `json:"vector" binding:"required"`
+	Distance    string    `json:"distance,omitempty" enums:"cosine,l2,inner_product" example:"cosine"`
+	MaxDistance *float64  `json:"max_distance,omitempty" example:"0.5"`
+}
+
+// AggregateRequest represents an aggregation query, grouped or over the whole collection.
+// Filter and having use the grammar of docs/filters.md.
+type AggregateRequest struct {
+	GroupBy    []string               `json:"group_by,omitempty" example:"status"`
+	Aggregates []AggregateField       `json:"aggregates,omitempty"`
+	Filter     map[string]interface{} `json:"filter,omitempty"` // Documents to aggregate
+	Having     map[string]interface{} `json:"having,omitempty"` // Groups to return, on group columns and aggregate aliases
+	Sort       []string               `json:"sort,omitempty" example:"-count"`
+	Limit      int                    `json:"limit,omitempty" example:"10"`
+	Offset     int                    `json:"offset,omitempty" example:"0"`
+}
+
+// AggregateField represents an aggregate function applied to a column
+type AggregateField struct {
+	Function string `json:"function" binding:"required" enums:"count,sum,avg,min,max" example:"sum"`
+	Column   string `json:"column,omitempty" example:"amount"`  // Empty for count(*)
+	Alias    string `json:"alias,omitempty" example:"total"`    // Defaults to <function>_<column>
+	Distinct bool   `json:"distinct,omitempty" example:"false"` // Aggregate distinct values only
+}
+
+// SQLRequest represents a raw SQL statement with its positional parameters ($1, $2…)
+type SQLRequest struct {
+	Query     string        `json:"query" binding:"required" example:"SELECT id, title FROM posts WHERE views > $1"`
+	Params    []interface{} `json:"params,omitempty"`
+	ReadOnly  bool          `json:"read_only,omitempty" example:"true"`               // Run the statement in a read-only transaction
+	TimeoutMs int           `json:"timeout_ms,omitempty" example:"5000"`              // Statement timeout, 10s by default and 5 minutes at most
+	MaxRows   int           `json:"max_rows,omitempty" example:"1000"`                // Rows returned at most, 1000 by default and 100000 at most
+	Format    string        `json:"format,omitempty" enums:"json,csv" example:"json"` // Defaults to the Accept header, then json
+}
+
+// ExportRequest represents a bulk export of the documents matching a query.
+// The filter grammar is described in docs/filters.md.
+type ExportRequest struct {
+	Format string                 `json:"format,omitempty" enums:"csv,ndjson,parquet" example:"parquet"` // Defaults to the Accept header, then ndjson
+	Filter map[string]interface{} `json:"filter,omitempty"`
+	Select []string               `json:"select,omitempty" example:"id,title,created_at"` // All columns when empty
+	Sort   []string               `json:"sort,omitempty" example:"-created_at"`           // Primary key order by default
+	Limit  int                    `json:"limit,omitempty" example:"100000"`               // All documents when zero
+
+	Unmasked bool `json:"unmasked,omitempty"` // Export the data without the masking profile (requires the masking permission)
 }
 
 // InsertDataRequest represents data insertion request
@@ -168,9 +294,25 @@ type UpsertDataRequest struct {
 	Data interface{} `json:"data" binding:"required"`
 }
 
-// BatchDeleteRequest represents batch delete request
+// BatchDeleteRequest represents batch delete request (by IDs and/or filter)
 type BatchDeleteRequest struct {
-	IDs []string `json:"ids" binding:"required"`
+	IDs    []string               `json:"ids,omitempty"`
+	Filter map[string]interface{} `json:"filter,omitempty"`
+}
+
+// BatchRequest represents an ordered list of write operations run atomically
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+// BatchOperation represents a single write operation of a batch
+type BatchOperation struct {
+	Op         string                 `json:"op" binding:"required" enums:"insert,update,upsert,delete" example:"insert"`
+	Collection string                 `json:"collection" binding:"required" example:"posts"`
+	ID         string                 `json:"id,omitempty" example:"42"` // Document targeted by update or delete
+	Data       interface{}            `json:"data,omitempty"`            // Document(s) for insert and upsert, changes for update
+	IDs        []string               `json:"ids,omitempty"`             // Documents targeted by delete
+	Filter     map[string]interface{} `json:"filter,omitempty"`          // Documents targeted by delete
 }
 
 // UpdateDocumentRequest represents document update request
@@ -178,23 +320,115 @@ type UpdateDocumentRequest struct {
 	Data map[string]interface{} `json:"data" binding:"required"`
 }
 
+// PatchOperation represents a JSON Patch (RFC 6902) operation of an application/json-patch+json update
+type PatchOperation struct {
+	Op    string          `json:"op" binding:"required" enums:"add,remove,replace,move,copy,test" example:"add"`
+	Path  string          `json:"path" binding:"required" example:"/meta/tags/-"` // JSON Pointer whose first segment is a column
+	From  string          `json:"from,omitempty" example:"/meta/draft"`           // Source of move and copy
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`           // Value of add, replace and test
+}
+
 // CreateIndexRequest represents index creation request
 type CreateIndexRequest struct {
-	Name   string                 `json:"name" binding:"required" example:"idx_email"`
-	Fields map[string]interface{} `json:"fields" binding:"required"`
+	Name   string                 `json:"name,omitempty" example:"idx_email"` // Generated from the collection and columns when empty
+	Fields []IndexField           `json:"fields" binding:"required"`
 	Unique bool                   `json:"unique,omitempty" example:"true"`
+	Method string                 `json:"method,omitempty" enums:"btree,hash,gin,gist,brin,hnsw,ivfflat" example:"btree"`
+	Where  map[string]interface{} `json:"where,omitempty"` // Partial index predicate, in the filter language
+	With   map[string]int         `json:"with,omitempty"`  // Build parameters: m and ef_construction (hnsw), lists (ivfflat)
+}
+
+// IndexField represents an indexed column
+type IndexField struct {
+	Column   string `json:"column" binding:"required" example:"email"`
+	Order    string `json:"order,omitempty" enums:"asc,desc" example:"asc"`
+	Nulls    string `json:"nulls,omitempty" enums:"first,last" example:"last"`
+	OpClass  string `json:"opclass,omitempty" enums:"jsonb_ops,jsonb_path_ops,array_ops,tsvector_ops,gin_trgm_ops,gist_trgm_ops,text_pattern_ops,varchar_pattern_ops,vector_cosine_ops,vector_l2_ops,vector_ip_ops,halfvec_cosine_ops,halfvec_l2_ops,halfvec_ip_ops"`
+	Language string `json:"language,omitempty" example:"english"` // Index to_tsvector(language, column) for full-text search
+}
+
+// SearchRequest represents a full-text search request.
+// The search grammar is described in docs/search.md.
+type SearchRequest struct {
+	Query         string                 `json:"query" binding:"required" example:"postgres -mysql"`
+	Mode          string                 `json:"mode,omitempty" enums:"websearch,plain,phrase,raw" example:"websearch"`
+	Columns       []string               `json:"columns,omitempty" example:"title,body"` // Searches the managed search column when empty
+	Weights       map[string]string      `json:"weights,omitempty"`                      // Weight (A to D) of each column
+	Language      string                 `json:"language,omitempty" example:"english"`   // Text search configuration, simple by default
+	Ranking       string                 `json:"ranking,omitempty" enums:"rank,cover" example:"rank"`
+	Normalization int                    `json:"normalization,omitempty" example:"0"` // ts_rank normalization bit mask (0 to 63)
+	MinRank       float64                `json:"min_rank,omitempty" example:"0.01"`
+	Filter        map[string]interface{} `json:"filter,omitempty"`
+	Select        []string               `json:"select,omitempty" example:"id,title"`
+	Limit         int                    `json:"limit,omitempty" example:"10"`
+	Offset        int                    `json:"offset,omitempty" example:"0"`
+	Highlight     *SearchHighlight       `json:"highlight,omitempty"` // Returns highlighted snippets of each column
+}
+
+// SearchHighlight represents the snippet options of a full-text search
+type SearchHighlight struct {
+	StartSel     string `json:"start_sel,omitempty" example:"<mark>"`
+	StopSel      string `json:"stop_sel,omitempty" example:"</mark>"`
+	MaxWords     int    `json:"max_words,omitempty" example:"35"`
+	MinWords     int    `json:"min_words,omitempty" example:"15"`
+	MaxFragments int    `json:"max_fragments,omitempty" example:"0"`
+}
+
+// SearchIndexRequest represents the managed search column of a collection
+type SearchIndexRequest struct {
+	Columns  []string          `json:"columns" binding:"required" example:"title,body"`
+	Weights  map[string]string `json:"weights,omitempty"`
+	Language string            `json:"language,omitempty" example:"english"`
+}
+
+// SoftDeleteRequest represents the trash settings of a collection
+type SoftDeleteRequest struct {
+	RetentionDays int `json:"retention_days,omitempty" example:"30"` // Days before trashed documents are purged, 0 to keep them
+}
+
+// MaskingProfileRequest represents the masking rules of a database
+type MaskingProfileRequest struct {
+	Rules []MaskingRule `json:"rules" binding:"required"`
+}
+
+// MaskingRule represents the masking of a column
+type MaskingRule struct {
+	Collection string      `json:"collection" binding:"required" example:"users"`
+	Column     string      `json:"column" binding:"required" example:"email"`
+	Strategy   string      `json:"strategy" binding:"required" enums:"hash,redact,fake_email,keep_format" example:"fake_email"`
+	Value      interface{} `json:"value,omitempty"` // Replacement value of redact, null when omitted
+}
+
+// TrashRequest represents trashed documents to restore or purge (by IDs and/or filter).
+// An empty filter ({}) targets the whole trash.
+type TrashRequest struct {
+	IDs    []string               `json:"ids,omitempty"`
+	Filter map[string]interface{} `json:"filter,omitempty"`
+}
+
+// CreatePolicyRequest represents a row-level access rule of a collection
+type CreatePolicyRequest struct {
+	Name    string                 `json:"name" binding:"required" example:"owner_rows"`
+	Command string                 `json:"command,omitempty" enums:"all,select,insert,update,delete" example:"all"`
+	Role    string                 `json:"role,omitempty" example:"owner"` // Project role the rule applies to, every end user when empty
+	Using   map[string]interface{} `json:"using,omitempty"`                // Rows the end user can read, update or delete, in the filter language
+	Check   map[string]interface{} `json:"check,omitempty"`                // Rows the end user can write, defaults to using
 }
 
 // CreateStorageBucketRequest represents storage bucket creation request
 type CreateStorageBucketRequest struct {
-	Name   string `json:"name" binding:"required" example:"images"`
-	Public bool   `json:"public,omitempty" example:"false"`
+	Name         string `json:"name" binding:"required" example:"images"`
+	Public       bool   `json:"public,omitempty" example:"false"`
+	MaxFileSize  int64  `json:"max_file_size,omitempty" example:"10485760"`      // Bytes, 0 for no limit
+	CacheControl string `json:"cache_control,omitempty" example:"max-age=86400"` // Cache-Control directives of the served files, without public or private
 }
 
-// UploadFileRequest represents file upload request (multipart form)
-type UploadFileRequest struct {
-	File     []byte            `json:"file" binding:"required"`
-	Metadata map[string]string `json:"metadata,omitempty"`
+// UpdateStorageBucketRequest represents storage bucket settings update request; omitted
+// settings are unchanged
+type UpdateStorageBucketRequest struct {
+	Public       *bool   `json:"public,omitempty" example:"true"`
+	MaxFileSize  *int64  `json:"max_file_size,omitempty" example:"52428800"`                    // Bytes, 0 for no limit
+	CacheControl *string `json:"cache_control,omitempty" example:"max-age=31536000, immutable"` // Empty for the default directives
 }
 
 // UpdateFileMetadataRequest represents file metadata update request
@@ -202,6 +436,20 @@ type UpdateFileMetadataRequest struct {
 	Metadata map[string]interface{} `json:"metadata" binding:"required"`
 }
 
+// CreateSignedURLRequest represents signed download URL creation request
+type CreateSignedURLRequest struct {
+	ExpiresIn int    `json:"expires_in,omitempty" example:"3600"`     // Seconds, default 3600, at most 604800 (7 days)
+	Download  bool   `json:"download,omitempty" example:"false"`      // Serve the file as an attachment rather than inline
+	Filename  string `json:"filename,omitempty" example:"report.pdf"` // File name proposed to the browser, default the last segment of the file name
+}
+
+// CreateSignedUploadURLRequest represents signed upload URL creation request
+type CreateSignedUploadURLRequest struct {
+	Name      string `json:"name" binding:"required" example:"users/42.png"`
+	ExpiresIn int    `json:"expires_in,omitempty" example:"3600"` // Seconds, default 3600, at most 604800 (7 days)
+	Upsert    bool   `json:"upsert,omitempty" example:"false"`    // Replace an existing file with the same name
+}
+
 // BatchDeleteFilesRequest represents batch file delete request
 type BatchDeleteFilesRequest struct {
 	FileIDs []string `json:"file_ids" binding:"required"`
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ErrorResponse represents the JSON body returned when a request fails
+type ErrorResponse struct {
+	Error string `json:"error" example:"ressource introuvable: la collection \"posts\" n'existe pas"`
+}
+
+// TransactionResponse is returned when a transaction is started
+type TransactionResponse struct {
+	TransactionID string `json:"transaction_id" example:"3f2b9c0e8a4d4c1b9e6f7a2d5c8b1e04"`
+	IdleTimeout   int    `json:"idle_timeout" example:"30"` // Seconds of inactivity before the transaction is rolled back
+}
+
+// BatchErrorResponse is returned when an operation of a batch fails and the batch is rolled back
+type BatchErrorResponse struct {
+	Error string `json:"error" example:"données invalides: colonne inconnue \"titel\""`
+	Index int    `json:"index" example:"2"` // Position of the failing operation
+}
+
+// DatabaseBranchResponse is returned when a database branch is created
+type DatabaseBranchResponse struct {
+	ID               string     `json:"id" example:"staging-pr-123"`
+	ParentID         string     `json:"parent_id" example:"staging"`
+	Host             string     `json:"host" example:"localhost"`
+	Port             string     `json:"port" example:"5434"`
+	Database         string     `json:"database" example:"staging_pr_123"`
+	User             string     `json:"user" example:"staging_pr_123_owner"`
+	Password         string     `json:"password"`
+	ConnectionString string     `json:"connection_string"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"` // Absent when the branch does not expire
+}
+
+// SignedURLResponse is returned when a signed storage URL is created
+type SignedURLResponse struct {
+	URL       string    `json:"url" example:"/storage/signed/my-project/avatars/3f2a9c1e7b4d8e6f0a1b2c3d4e5f6a7b?expires=1767225600&signature=9b1c..."` // Path and query, relative to the API base URL
+	Method    string    `json:"method" example:"download"`                                                                                              // download or upload
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,358 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// BranchDatabase crée un nouveau conteneur PostgreSQL, de la même version que la base source,
+// puis y restaure un dump de la source. La branche appartient au projet de la source. Les
+// identifiants retournés sont ceux d'un rôle propre à la branche, sans privilège de
+// superutilisateur, propriétaire de la base et des objets copiés ; le superutilisateur du
+// conteneur reste réservé au serveur. Une branche dont la copie échoue est supprimée.
+func (d *DockerOrchestrator) BranchDatabase(ctx context.Context, sourceID, branchID string, options *BranchOptions) (*DatabaseInfo, error) {
+	if options == nil {
+		options = &BranchOptions{}
+	}
+
+	source, err := d.GetDatabaseInfo(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source.Status != "running" {
+		return nil, fmt.Errorf("la base de données source n'est pas démarrée: %s", sourceID)
+	}
+
+	labels := map[string]string{parentLabel: sourceID}
+	if options.TTL > 0 {
+		labels[expiresAtLabel] = time.Now().Add(options.TTL).UTC().Format(time.RFC3339)
+	}
+	// Une branche copie les données de production : son mot de passe doit être imprévisible
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	branch, err := d.createDatabase(ctx, branchID, &DatabaseOptions{PostgresVersion: source.PostgresVersion, Password: password, OwnerID: source.OwnerID}, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := branchOwner(branch)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.restoreBranch(ctx, source, branch, owner, options); err != nil {
+		// La suppression doit aboutir même si la requête a été annulée
+		if cleanupErr := d.DeleteDatabase(context.WithoutCancel(ctx), branchID); cleanupErr != nil {
+			fmt.Printf("Warning: impossible de supprimer la branche incomplète %s: %v\n", branchID, cleanupErr)
+		}
+		return nil, err
+	}
+
+	return owner, nil
+}
+
+// branchOwner retourne les informations de la branche avec les identifiants de son rôle
+// propriétaire, au mot de passe aléatoire
+func branchOwner(branch *DatabaseInfo) (*DatabaseInfo, error) {
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	owner := *branch
+	owner.User = branch.User + "_owner"
+	owner.Password = password
+	owner.ConnectionString = fmt.Sprintf("postgresql://%s:%s@localhost:%s/%s?sslmode=disable", owner.User, password, branch.Port, branch.Database)
+	return &owner, nil
+}
+
+// randomPassword génère un mot de passe de 256 bits aléatoires, en hexadécimal
+func randomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erreur lors de la génération du mot de passe: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// restoreBranch crée les rôles puis copie le contenu de la source dans la branche. La copie se
+// fait avec le rôle propriétaire, superutilisateur le temps de la restauration pour recréer
+// les objets qui l'exigent (extensions), puis privé de ce privilège avant d'être remis à
+// l'appelant.
+func (d *DockerOrchestrator) restoreBranch(ctx context.Context, source, branch, owner *DatabaseInfo, options *BranchOptions) error {
+	// Pendant son initialisation, le conteneur n'écoute que sur le socket local :
+	// la base est prête lorsqu'elle accepte les connexions TCP
+	if err := d.waitForTCP(ctx, branch, 30*time.Second); err != nil {
+		return fmt.Errorf("la branche n'a pas démarré correctement: %w", err)
+	}
+
+	statements := []string{fmt.Sprintf("CREATE ROLE %s LOGIN SUPERUSER PASSWORD %s;", quoteIdent(owner.User), quoteLiteral(owner.Password))}
+	for _, role := range options.Roles {
+		statements = append(statements, fmt.Sprintf("CREATE ROLE %s NOLOGIN;", quoteIdent(role)))
+	}
+	if err := d.runExec(ctx, branch, []string{"psql", "--quiet", "--set", "ON_ERROR_STOP=1", "--command", strings.Join(statements, " ")}); err != nil {
+		return fmt.Errorf("erreur lors de la création des rôles: %w", err)
+	}
+
+	if err := d.copyDatabase(ctx, source, owner, options.Masked); err != nil {
+		return fmt.Errorf("erreur lors de la copie de la base: %w", err)
+	}
+
+	statements = []string{
+		fmt.Sprintf("ALTER ROLE %s NOSUPERUSER;", quoteIdent(owner.User)),
+		fmt.Sprintf("ALTER DATABASE %s OWNER TO %s;", quoteIdent(branch.Database), quoteIdent(owner.User)),
+	}
+	if err := d.runExec(ctx, branch, []string{"psql", "--quiet", "--set", "ON_ERROR_STOP=1", "--command", strings.Join(statements, " ")}); err != nil {
+		return fmt.Errorf("erreur lors du retrait des privilèges du rôle de la branche: %w", err)
+	}
+	return nil
+}
+
+// copyDatabase envoie le contenu de la source à psql dans le conteneur de la branche, sans
+// fichier intermédiaire. La restauration se fait dans une seule transaction : en cas
+// d'erreur, la branche reste vide. Les tables masquées sont lues masquées dans la source :
+// leurs données d'origine n'atteignent jamais la branche, ni ses fichiers ni son WAL.
+func (d *DockerOrchestrator) copyDatabase(ctx context.Context, source, branch *DatabaseInfo, masked []MaskedTable) error {
+	holder, snapshot, err := d.exportSnapshot(ctx, source)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'export de l'instantané de la source: %w", err)
+	}
+	defer holder.Close()
+
+	restore, err := d.startExec(ctx, branch, []string{"psql", "--quiet", "--single-transaction", "--set", "ON_ERROR_STOP=1"}, true)
+	if err != nil {
+		return fmt.Errorf("erreur lors du lancement de psql: %w", err)
+	}
+	defer restore.Close()
+
+	var restoreErrors bytes.Buffer
+	copied := make(chan error, 1)
+	go func() {
+		err := d.dumpSource(ctx, source, snapshot, masked, restore.Conn)
+		if closeErr := restore.CloseWrite(); err == nil {
+			err = closeErr
+		}
+		copied <- err
+	}()
+	_, readErr := stdcopy.StdCopy(io.Discard, &restoreErrors, restore.Reader)
+	copyErr := <-copied
+
+	if err := d.execResult(ctx, restore.id, &restoreErrors); err != nil {
+		return fmt.Errorf("psql: %w", err)
+	}
+	if copyErr != nil {
+		return copyErr
+	}
+	if readErr != nil {
+		return fmt.Errorf("erreur lors de la lecture de psql: %w", readErr)
+	}
+	return nil
+}
+
+// dumpSource écrit dans w le script de restauration de la source, lue dans l'instantané :
+// le schéma, les données des tables non masquées, celles des tables masquées, puis les index,
+// contraintes et triggers. Ces derniers sont créés après les données, qui ne les déclenchent pas.
+func (d *DockerOrchestrator) dumpSource(ctx context.Context, source *DatabaseInfo, snapshot string, masked []MaskedTable, w io.Writer) error {
+	if err := d.pipeExec(ctx, source, dumpCommand(snapshot, "pre-data", nil), w); err != nil {
+		return fmt.Errorf("pg_dump: %w", err)
+	}
+	if err := d.pipeExec(ctx, source, dumpCommand(snapshot, "data", masked), w); err != nil {
+		return fmt.Errorf("pg_dump: %w", err)
+	}
+	for _, table := range masked {
+		if _, err := fmt.Fprintf(w, "COPY %s (%s) FROM stdin;\n", table.Table, strings.Join(table.Columns, ", ")); err != nil {
+			return fmt.Errorf("erreur lors du transfert du dump: %w", err)
+		}
+		if err := d.pipeExec(ctx, source, maskedCopyCommand(snapshot, table), w); err != nil {
+			return fmt.Errorf("copie masquée de %s: %w", table.Table, err)
+		}
+		if _, err := io.WriteString(w, "\\.\n"); err != nil {
+			return fmt.Errorf("erreur lors du transfert du dump: %w", err)
+		}
+	}
+	if err := d.pipeExec(ctx, source, dumpCommand(snapshot, "post-data", nil), w); err != nil {
+		return fmt.Errorf("pg_dump: %w", err)
+	}
+	return nil
+}
+
+// dumpCommand construit le pg_dump d'une section de la source, dans l'instantané, sans les
+// données des tables masquées
+func dumpCommand(snapshot, section string, masked []MaskedTable) []string {
+	cmd := []string{"pg_dump", "--no-owner", "--snapshot=" + snapshot, "--section=" + section}
+	for _, table := range masked {
+		cmd = append(cmd, "--exclude-table-data="+table.Table)
+	}
+	return cmd
+}
+
+// maskedCopyCommand construit la lecture, dans l'instantané, des données masquées d'une table
+// au format de COPY
+func maskedCopyCommand(snapshot string, table MaskedTable) []string {
+	return []string{
+		"psql", "--quiet", "--set", "ON_ERROR_STOP=1",
+		"--command", "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY",
+		"--command", "SET TRANSACTION SNAPSHOT " + quoteLiteral(snapshot),
+		"--command", "COPY (" + table.Query + ") TO STDOUT",
+		"--command", "COMMIT",
+	}
+}
+
+// exportSnapshot ouvre dans la source une transaction en lecture seule et exporte son
+// instantané, que partagent les dumps et les copies masquées : la branche reçoit un état
+// cohérent de la source. L'instantané reste valide jusqu'à la fermeture du flux retourné.
+func (d *DockerOrchestrator) exportSnapshot(ctx context.Context, source *DatabaseInfo) (*execStream, string, error) {
+	holder, err := d.startExec(ctx, source, []string{"psql", "--quiet", "--tuples-only", "--no-align", "--set", "ON_ERROR_STOP=1"}, true)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.WriteString(holder.Conn, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY;\nSELECT pg_catalog.pg_export_snapshot();\n"); err != nil {
+		holder.Close()
+		return nil, "", err
+	}
+
+	// Le flux reste ouvert : sa sortie standard est lue au fil de l'eau jusqu'à l'identifiant
+	output, writer := io.Pipe()
+	var stderr bytes.Buffer
+	go func() {
+		_, err := stdcopy.StdCopy(writer, &stderr, holder.Reader)
+		writer.CloseWithError(err)
+	}()
+	line, err := bufio.NewReader(output).ReadString('\n')
+	if err != nil {
+		// psql s'est arrêté : sa sortie d'erreur est complète
+		if resultErr := d.execResult(ctx, holder.id, &stderr); resultErr != nil {
+			err = resultErr
+		}
+		holder.Close()
+		return nil, "", err
+	}
+	go io.Copy(io.Discard, output)
+	return holder, strings.TrimSpace(line), nil
+}
+
+// execStream est une commande lancée dans un conteneur, dont les flux sont attachés
+type execStream struct {
+	id string
+	types.HijackedResponse
+}
+
+// startExec lance une commande cliente PostgreSQL dans le conteneur de la base. La connexion
+// (TCP, avec les identifiants de la base) est passée par les variables d'environnement libpq.
+func (d *DockerOrchestrator) startExec(ctx context.Context, info *DatabaseInfo, cmd []string, stdin bool) (*execStream, error) {
+	execResp, err := d.client.ContainerExecCreate(ctx, info.ContainerID, container.ExecOptions{
+		Cmd: cmd,
+		Env: []string{
+			"PGHOST=127.0.0.1",
+			fmt.Sprintf("PGUSER=%s", info.User),
+			fmt.Sprintf("PGPASSWORD=%s", info.Password),
+			fmt.Sprintf("PGDATABASE=%s", info.Database),
+		},
+		AttachStdin:  stdin,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attachResp, err := d.client.ContainerExecAttach(ctx, execResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &execStream{id: execResp.ID, HijackedResponse: attachResp}, nil
+}
+
+// pipeExec exécute une commande dans le conteneur de la base jusqu'à sa fin, en envoyant sa
+// sortie standard à w
+func (d *DockerOrchestrator) pipeExec(ctx context.Context, info *DatabaseInfo, cmd []string, w io.Writer) error {
+	stream, err := d.startExec(ctx, info, cmd, false)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(w, &stderr, stream.Reader); err != nil {
+		return err
+	}
+	return d.execResult(ctx, stream.id, &stderr)
+}
+
+// runExec exécute une commande cliente PostgreSQL dans le conteneur de la base jusqu'à sa fin
+func (d *DockerOrchestrator) runExec(ctx context.Context, info *DatabaseInfo, cmd []string) error {
+	stream, err := d.startExec(ctx, info, cmd, false)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, stream.Reader); err != nil {
+		return err
+	}
+	return d.execResult(ctx, stream.id, &output)
+}
+
+// execResult attend la fin d'une commande et renvoie une erreur, avec sa sortie d'erreur, si
+// son code de sortie n'est pas nul
+func (d *DockerOrchestrator) execResult(ctx context.Context, execID string, output *bytes.Buffer) error {
+	for {
+		execInspect, err := d.client.ContainerExecInspect(ctx, execID)
+		if err != nil {
+			return fmt.Errorf("erreur lors de l'inspection: %w", err)
+		}
+		if !execInspect.Running {
+			if execInspect.ExitCode != 0 {
+				return fmt.Errorf("code de sortie %d: %s", execInspect.ExitCode, strings.TrimSpace(output.String()))
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// waitForTCP attend que PostgreSQL accepte les connexions TCP dans le conteneur de la base
+func (d *DockerOrchestrator) waitForTCP(ctx context.Context, info *DatabaseInfo, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if err := d.runExec(ctx, info, []string{"pg_isready", "--quiet"}); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return fmt.Errorf("timeout en attendant que PostgreSQL accepte les connexions")
+}
+
+// quoteIdent échappe un identifiant PostgreSQL
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral échappe une chaîne littérale PostgreSQL
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
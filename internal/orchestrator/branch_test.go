@@ -0,0 +1,58 @@
+package orchestrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDumpCommand(t *testing.T) {
+	masked := []MaskedTable{{Table: `"public"."users"`}, {Table: `"public"."orders"`}}
+	got := dumpCommand("00000003-0000001B-1", "data", masked)
+	want := []string{"pg_dump", "--no-owner", "--snapshot=00000003-0000001B-1", "--section=data",
+		`--exclude-table-data="public"."users"`, `--exclude-table-data="public"."orders"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dumpCommand = %q, want %q", got, want)
+	}
+}
+
+func TestMaskedCopyCommand(t *testing.T) {
+	table := MaskedTable{Table: `"public"."users"`, Columns: []string{`"id"`, `"email"`}, Query: `SELECT r."id", NULL::text FROM "public"."users" AS r`}
+	got := maskedCopyCommand("00000003-0000001B-1", table)
+	want := []string{
+		"psql", "--quiet", "--set", "ON_ERROR_STOP=1",
+		"--command", "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY",
+		"--command", "SET TRANSACTION SNAPSHOT '00000003-0000001B-1'",
+		"--command", `COPY (SELECT r."id", NULL::text FROM "public"."users" AS r) TO STDOUT`,
+		"--command", "COMMIT",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("maskedCopyCommand = %q, want %q", got, want)
+	}
+}
+
+func TestBranchOwner(t *testing.T) {
+	branch := &DatabaseInfo{User: "staging_pr_1", Password: "superuser", Port: "5434", Database: "staging_pr_1"}
+	owner, err := branchOwner(branch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Le superutilisateur du conteneur n'est jamais remis à l'appelant
+	if owner.User != "staging_pr_1_owner" || owner.Password == branch.Password || branch.User != "staging_pr_1" {
+		t.Errorf("unexpected owner %+v", owner)
+	}
+	want := "postgresql://staging_pr_1_owner:" + owner.Password + "@localhost:5434/staging_pr_1?sslmode=disable"
+	if owner.ConnectionString != want {
+		t.Errorf("ConnectionString = %q, want %q", owner.ConnectionString, want)
+	}
+}
+
+func TestRandomPassword(t *testing.T) {
+	first, err := randomPassword()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := randomPassword()
+	if len(first) != 64 || first == second {
+		t.Errorf("each branch should get its own random password: %q, %q", first, second)
+	}
+}
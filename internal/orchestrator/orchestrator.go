@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -17,6 +18,12 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// ErrDatabaseNotFound est renvoyée lorsqu'aucune base de données n'est gérée pour l'identifiant demandé
+var ErrDatabaseNotFound = errors.New("base de données non trouvée")
+
+// ErrDatabaseExists est renvoyée lorsqu'une base de données est déjà gérée pour l'identifiant demandé
+var ErrDatabaseExists = errors.New("base de données déjà existante")
+
 // Orchestrator interface pour gérer les conteneurs de bases de données
 type Orchestrator interface {
 	// CreateDatabase crée une nouvelle instance de base de données pour un projet
@@ -33,12 +40,16 @@ type Orchestrator interface {
 
 	// DatabaseExists vérifie si une base de données existe déjà
 	DatabaseExists(ctx context.Context, projectID string) (bool, error)
+
+	// BranchDatabase crée une copie d'une base de données existante, avec ses propres identifiants
+	BranchDatabase(ctx context.Context, sourceID, branchID string, options *BranchOptions) (*DatabaseInfo, error)
 }
 
 // DatabaseOptions contient les options pour créer une base de données
 type DatabaseOptions struct {
 	PostgresVersion string // Version de PostgreSQL (défaut: "16-alpine")
 	Password        string // Mot de passe (généré si vide)
+	OwnerID         string // Projet propriétaire (défaut : l'identifiant de la base)
 	Port            int    // Port hôte (auto-assigné si 0)
 	Memory          string // Limite mémoire (ex: "512m")
 	CPUs            string // Limite CPU (ex: "0.5")
@@ -46,7 +57,8 @@ type DatabaseOptions struct {
 
 // DatabaseInfo contient les informations d'une base de données
 type DatabaseInfo struct {
-	ProjectID        string
+	ProjectID        string // Identifiant de la base
+	OwnerID          string // Projet propriétaire de la base
 	ContainerID      string
 	ContainerName    string
 	Status           string
@@ -58,8 +70,32 @@ type DatabaseInfo struct {
 	Password         string
 	ConnectionString string
 	CreatedAt        time.Time
+	ParentID         string    // Base copiée, pour une branche
+	ExpiresAt        time.Time // Expiration d'une branche, zéro si elle n'expire pas
+}
+
+// BranchOptions contient les options pour créer une branche d'une base de données
+type BranchOptions struct {
+	TTL    time.Duration // Durée de vie de la branche, supprimée ensuite par le Reconciler (0 : pas d'expiration)
+	Roles  []string      // Rôles NOLOGIN à créer avant la restauration, référencés par les droits et politiques
+	Masked []MaskedTable // Tables dont les données sont copiées masquées
 }
 
+// MaskedTable remplace, dans la copie d'une branche, les données d'une table par le résultat
+// d'une requête sur la source qui en masque des colonnes
+type MaskedTable struct {
+	Table   string   // Identifiant qualifié et échappé de la table
+	Columns []string // Colonnes copiées, échappées, dans l'ordre de la requête
+	Query   string   // Requête lisant ces colonnes, masquées, dans la source
+}
+
+// Labels des conteneurs : projet propriétaire, et base copiée et expiration des branches
+const (
+	ownerLabel     = "sovrabase.owner_id"
+	parentLabel    = "sovrabase.parent_id"
+	expiresAtLabel = "sovrabase.expires_at"
+)
+
 // DockerOrchestrator gère les bases de données via Docker/Podman
 type DockerOrchestrator struct {
 	client *client.Client
@@ -125,13 +161,18 @@ func NewKubernetesOrchestrator(cfg *config.Orchestrator) (*KubernetesOrchestrato
 
 // CreateDatabase crée une nouvelle instance PostgreSQL dans un conteneur
 func (d *DockerOrchestrator) CreateDatabase(ctx context.Context, projectID string, options *DatabaseOptions) (*DatabaseInfo, error) {
+	return d.createDatabase(ctx, projectID, options, nil)
+}
+
+// createDatabase crée le conteneur PostgreSQL en ajoutant les labels fournis aux labels gérés
+func (d *DockerOrchestrator) createDatabase(ctx context.Context, projectID string, options *DatabaseOptions, labels map[string]string) (*DatabaseInfo, error) {
 	// Vérifier si la base existe déjà
 	exists, err := d.DatabaseExists(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la vérification de l'existence: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("une base de données existe déjà pour le projet: %s", projectID)
+		return nil, fmt.Errorf("%w pour le projet: %s", ErrDatabaseExists, projectID)
 	}
 
 	// Définir les valeurs par défaut
@@ -147,6 +188,9 @@ func (d *DockerOrchestrator) CreateDatabase(ctx context.Context, projectID strin
 	if options.Port == 0 {
 		options.Port = findAvailablePort(ctx, d.client)
 	}
+	if options.OwnerID == "" {
+		options.OwnerID = projectID
+	}
 
 	containerName := fmt.Sprintf("sovrabase-db-%s", projectID)
 	imageName := fmt.Sprintf("docker.io/library/postgres:%s", options.PostgresVersion)
@@ -176,11 +220,15 @@ func (d *DockerOrchestrator) CreateDatabase(ctx context.Context, projectID strin
 		Labels: map[string]string{
 			"sovrabase.managed":    "true",
 			"sovrabase.project_id": projectID,
+			ownerLabel:             options.OwnerID,
 			"sovrabase.type":       "postgres",
 			"sovrabase.version":    options.PostgresVersion,
 			"sovrabase.created_at": time.Now().UTC().Format(time.RFC3339),
 		},
 	}
+	for key, value := range labels {
+		containerConfig.Labels[key] = value
+	}
 
 	// Configuration de l'hôte
 	hostConfig := &container.HostConfig{
@@ -234,6 +282,7 @@ func (d *DockerOrchestrator) CreateDatabase(ctx context.Context, projectID strin
 	// Créer les informations de la base de données
 	dbInfo := &DatabaseInfo{
 		ProjectID:        projectID,
+		OwnerID:          options.OwnerID,
 		ContainerID:      resp.ID,
 		ContainerName:    containerName,
 		Status:           "running",
@@ -245,7 +294,9 @@ func (d *DockerOrchestrator) CreateDatabase(ctx context.Context, projectID strin
 		Password:         options.Password,
 		ConnectionString: fmt.Sprintf("postgresql://%s:%s@localhost:%d/%s?sslmode=disable", dbUser, options.Password, options.Port, dbName),
 		CreatedAt:        time.Now().UTC(),
+		ParentID:         labels[parentLabel],
 	}
+	dbInfo.ExpiresAt, _ = time.Parse(time.RFC3339, labels[expiresAtLabel])
 
 	return dbInfo, nil
 }
@@ -295,7 +346,7 @@ func (d *DockerOrchestrator) GetDatabaseInfo(ctx context.Context, projectID stri
 	containerJSON, err := d.client.ContainerInspect(ctx, containerName)
 	if err != nil {
 		if client.IsErrNotFound(err) {
-			return nil, fmt.Errorf("base de données non trouvée pour le projet: %s", projectID)
+			return nil, fmt.Errorf("%w pour le projet: %s", ErrDatabaseNotFound, projectID)
 		}
 		return nil, fmt.Errorf("erreur lors de l'inspection du conteneur: %w", err)
 	}
@@ -318,10 +369,17 @@ func (d *DockerOrchestrator) GetDatabaseInfo(ctx context.Context, projectID stri
 		status = "running"
 	}
 
+	// Les bases créées avant le label appartiennent au projet de même identifiant
+	owner := labels[ownerLabel]
+	if owner == "" {
+		owner = projectID
+	}
 	createdAt, _ := time.Parse(time.RFC3339, labels["sovrabase.created_at"])
+	expiresAt, _ := time.Parse(time.RFC3339, labels[expiresAtLabel])
 
 	dbInfo := &DatabaseInfo{
 		ProjectID:        projectID,
+		OwnerID:          owner,
 		ContainerID:      containerJSON.ID,
 		ContainerName:    containerName,
 		Status:           status,
@@ -333,6 +391,8 @@ func (d *DockerOrchestrator) GetDatabaseInfo(ctx context.Context, projectID stri
 		Password:         dbPassword,
 		ConnectionString: fmt.Sprintf("postgresql://%s:%s@localhost:%s/%s?sslmode=disable", dbUser, dbPassword, port, dbName),
 		CreatedAt:        createdAt,
+		ParentID:         labels[parentLabel],
+		ExpiresAt:        expiresAt,
 	}
 
 	return dbInfo, nil
@@ -414,6 +474,11 @@ func (k *KubernetesOrchestrator) DatabaseExists(ctx context.Context, projectID s
 	return false, fmt.Errorf("non implémenté pour Kubernetes")
 }
 
+func (k *KubernetesOrchestrator) BranchDatabase(ctx context.Context, sourceID, branchID string, options *BranchOptions) (*DatabaseInfo, error) {
+	// TODO: Restaurer un VolumeSnapshot du PVC source dans un nouveau StatefulSet
+	return nil, fmt.Errorf("non implémenté pour Kubernetes")
+}
+
 // Fonctions utilitaires
 
 // waitForPostgres attend que PostgreSQL soit prêt
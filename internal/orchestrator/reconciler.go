@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Reconciler supprime périodiquement les branches de bases de données dont la durée de vie
+// (BranchOptions.TTL) est écoulée
+type Reconciler struct {
+	orchestrator Orchestrator
+	interval     time.Duration
+	release      func(dbID string) // Appelée avant la suppression d'une branche, pour fermer ses connexions
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReconciler crée un reconciler ; release peut être nil
+func NewReconciler(orchestrator Orchestrator, interval time.Duration, release func(dbID string)) *Reconciler {
+	return &Reconciler{
+		orchestrator: orchestrator,
+		interval:     interval,
+		release:      release,
+		done:         make(chan struct{}),
+	}
+}
+
+// Start lance la réconciliation périodique jusqu'à l'appel de Stop. Un intervalle nul la désactive.
+func (r *Reconciler) Start() {
+	if r.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+				deleted, err := r.Reconcile(ctx)
+				cancel()
+				if err != nil {
+					log.Printf("⚠️ Erreur lors de la réconciliation des bases de données: %v", err)
+				}
+				for _, dbID := range deleted {
+					log.Printf("🗑️ Branche expirée %s supprimée", dbID)
+				}
+			}
+		}
+	}()
+}
+
+// Stop arrête la réconciliation périodique
+func (r *Reconciler) Stop() {
+	r.stopOnce.Do(func() { close(r.done) })
+}
+
+// Reconcile supprime les branches expirées et retourne leurs identifiants. Une suppression
+// en échec n'empêche pas les suivantes ; la première erreur est retournée.
+func (r *Reconciler) Reconcile(ctx context.Context) ([]string, error) {
+	databases, err := r.orchestrator.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	var firstErr error
+	for _, dbID := range expiredBranches(databases, time.Now()) {
+		if r.release != nil {
+			r.release(dbID)
+		}
+		if err := r.orchestrator.DeleteDatabase(ctx, dbID); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		deleted = append(deleted, dbID)
+	}
+	return deleted, firstErr
+}
+
+// expiredBranches retourne les identifiants des branches dont l'expiration est passée
+func expiredBranches(databases []*DatabaseInfo, now time.Time) []string {
+	var expired []string
+	for _, db := range databases {
+		if db.ParentID != "" && !db.ExpiresAt.IsZero() && !db.ExpiresAt.After(now) {
+			expired = append(expired, db.ProjectID)
+		}
+	}
+	return expired
+}
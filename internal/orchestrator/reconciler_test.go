@@ -0,0 +1,30 @@
+package orchestrator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpiredBranches(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	databases := []*DatabaseInfo{
+		{ProjectID: "staging"},
+		{ProjectID: "staging-pr-1", ParentID: "staging", ExpiresAt: now.Add(-time.Minute)},
+		{ProjectID: "staging-pr-2", ParentID: "staging", ExpiresAt: now.Add(time.Hour)},
+		{ProjectID: "staging-pr-3", ParentID: "staging"},
+		{ProjectID: "staging-pr-4", ParentID: "staging", ExpiresAt: now},
+		// Une base qui n'est pas une branche n'est jamais supprimée
+		{ProjectID: "other", ExpiresAt: now.Add(-time.Hour)},
+	}
+
+	if got, want := expiredBranches(databases, now), []string{"staging-pr-1", "staging-pr-4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expiredBranches = %v, want %v", got, want)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	if got, want := quoteIdent(`sovra"base`), `"sovra""base"`; got != want {
+		t.Errorf("quoteIdent = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package parquet
+
+import "encoding/binary"
+
+// Types du protocole compact de Thrift, qui encode les métadonnées Parquet
+const (
+	compactBooleanTrue  = 1
+	compactBooleanFalse = 2
+	compactI32          = 5
+	compactI64          = 6
+	compactBinary       = 8
+	compactList         = 9
+	compactStruct       = 12
+)
+
+// thriftWriter encode des structures Thrift dans le protocole compact. Les champs d'une
+// structure doivent être écrits par identifiant croissant.
+type thriftWriter struct {
+	buf    []byte
+	last   int16   // Identifiant du dernier champ de la structure en cours
+	parent []int16 // Dernier champ des structures englobantes
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+// field écrit l'en-tête d'un champ, avec l'écart depuis le champ précédent quand il tient sur 4 bits
+func (w *thriftWriter) field(id int16, kind byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|kind)
+	} else {
+		w.buf = append(w.buf, kind)
+		w.zigzag(int64(id))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, compactI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, compactI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	if v {
+		w.field(id, compactBooleanTrue)
+	} else {
+		w.field(id, compactBooleanFalse)
+	}
+}
+
+func (w *thriftWriter) binary(id int16, v string) {
+	w.field(id, compactBinary)
+	w.varint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// list écrit l'en-tête d'une liste de size éléments de type kind, suivi de ses éléments
+func (w *thriftWriter) list(id int16, kind byte, size int) {
+	w.field(id, compactList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|kind)
+		return
+	}
+	w.buf = append(w.buf, 0xF0|kind)
+	w.varint(uint64(size))
+}
+
+// i32Element écrit un élément entier d'une liste
+func (w *thriftWriter) i32Element(v int32) {
+	w.zigzag(int64(v))
+}
+
+// binaryElement écrit un élément chaîne d'une liste
+func (w *thriftWriter) binaryElement(v string) {
+	w.varint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// beginStruct ouvre une structure, champ d'une autre structure ou élément d'une liste (id 0)
+func (w *thriftWriter) beginStruct(id int16) {
+	if id != 0 {
+		w.field(id, compactStruct)
+	}
+	w.parent = append(w.parent, w.last)
+	w.last = 0
+}
+
+// endStruct ferme la structure en cours
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, 0)
+	w.last = w.parent[len(w.parent)-1]
+	w.parent = w.parent[:len(w.parent)-1]
+}
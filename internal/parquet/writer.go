@@ -0,0 +1,424 @@
+// Package parquet écrit des fichiers Apache Parquet au fil de l'eau.
+//
+// Le format produit est volontairement simple : colonnes plates et optionnelles, encodage
+// PLAIN sans compression, une page par colonne et par groupe de lignes. Seul le groupe de
+// lignes en cours est conservé en mémoire, ce qui permet d'exporter des tables de
+// plusieurs gigaoctets.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// magic encadre tout fichier Parquet
+const magic = "PAR1"
+
+// DefaultRowGroupBytes est la taille des valeurs d'un groupe de lignes au-delà de laquelle il est écrit
+const DefaultRowGroupBytes = 64 << 20
+
+// Type est le type physique d'une colonne
+type Type int32
+
+// Types physiques pris en charge
+const (
+	Boolean   Type = 0
+	Int32     Type = 1
+	Int64     Type = 2
+	Float     Type = 4
+	Double    Type = 5
+	ByteArray Type = 6
+)
+
+// Logical précise l'interprétation d'un type physique
+type Logical int
+
+// Types logiques pris en charge
+const (
+	None         Logical = iota
+	String               // ByteArray en UTF-8
+	JSON                 // ByteArray contenant un document JSON
+	Date                 // Int32, jours depuis le 1er janvier 1970
+	Timestamp            // Int64, microsecondes depuis l'epoch, sans fuseau horaire
+	TimestampUTC         // Int64, microsecondes depuis l'epoch en UTC
+)
+
+// Constantes du format (parquet.thrift)
+const (
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageTypeData      = 0
+)
+
+// Column décrit une colonne du fichier ; toutes les colonnes acceptent les valeurs nulles
+type Column struct {
+	Name    string
+	Type    Type
+	Logical Logical
+}
+
+// columnChunk décrit une colonne écrite dans un groupe de lignes
+type columnChunk struct {
+	offset int64
+	size   int64
+}
+
+// rowGroup décrit un groupe de lignes écrit
+type rowGroup struct {
+	rows   int64
+	size   int64
+	chunks []columnChunk
+}
+
+// columnBuffer accumule les valeurs d'une colonne pour le groupe de lignes en cours
+type columnBuffer struct {
+	levels  []byte // Niveaux de définition, un bit par ligne : 1 si la valeur n'est pas nulle
+	values  []byte // Valeurs non nulles encodées en PLAIN
+	present int    // Nombre de valeurs non nulles, pour regrouper les booléens par bit
+}
+
+// Writer écrit un fichier Parquet ligne par ligne
+type Writer struct {
+	// RowGroupBytes est la taille des valeurs au-delà de laquelle un groupe de lignes est
+	// écrit ; elle borne la mémoire utilisée. DefaultRowGroupBytes par défaut.
+	RowGroupBytes int
+
+	w       io.Writer
+	columns []Column
+	buffers []columnBuffer
+	rows    int   // Lignes du groupe en cours
+	pending int   // Taille des valeurs du groupe en cours
+	offset  int64 // Octets déjà écrits
+	groups  []rowGroup
+	closed  bool
+}
+
+// NewWriter retourne un Writer écrivant dans w un fichier aux colonnes données
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: au moins une colonne est requise")
+	}
+	for _, col := range columns {
+		if err := col.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Writer{
+		RowGroupBytes: DefaultRowGroupBytes,
+		w:             w,
+		columns:       columns,
+		buffers:       make([]columnBuffer, len(columns)),
+	}, nil
+}
+
+// validate vérifie que le type logique s'applique au type physique de la colonne
+func (c Column) validate() error {
+	switch c.Logical {
+	case None:
+	case String, JSON:
+		if c.Type != ByteArray {
+			return fmt.Errorf("parquet: la colonne %q doit être de type ByteArray", c.Name)
+		}
+	case Date:
+		if c.Type != Int32 {
+			return fmt.Errorf("parquet: la colonne %q doit être de type Int32", c.Name)
+		}
+	case Timestamp, TimestampUTC:
+		if c.Type != Int64 {
+			return fmt.Errorf("parquet: la colonne %q doit être de type Int64", c.Name)
+		}
+	default:
+		return fmt.Errorf("parquet: type logique inconnu pour la colonne %q", c.Name)
+	}
+	switch c.Type {
+	case Boolean, Int32, Int64, Float, Double, ByteArray:
+		return nil
+	}
+	return fmt.Errorf("parquet: type physique inconnu pour la colonne %q", c.Name)
+}
+
+// Write ajoute une ligne. Chaque valeur est nil ou du type Go de sa colonne : bool, int32,
+// int64, float32, float64, ou []byte et string pour ByteArray.
+func (w *Writer) Write(values []any) error {
+	if w.closed {
+		return errors.New("parquet: écriture après Close")
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: %d valeurs pour %d colonnes", len(values), len(w.columns))
+	}
+
+	// Les valeurs sont vérifiées avant d'être ajoutées pour qu'une ligne invalide ne laisse pas de trace
+	for i, value := range values {
+		if value != nil && !w.columns[i].accepts(value) {
+			return fmt.Errorf("parquet: valeur de type %T invalide pour la colonne %q", value, w.columns[i].Name)
+		}
+	}
+
+	row := w.rows
+	for i, value := range values {
+		buf := &w.buffers[i]
+		if row%8 == 0 {
+			buf.levels = append(buf.levels, 0)
+		}
+		if value == nil {
+			continue
+		}
+		buf.levels[row/8] |= 1 << (row % 8)
+
+		size := len(buf.values)
+		buf.append(value)
+		w.pending += len(buf.values) - size
+	}
+	w.rows++
+
+	if w.pending >= w.RowGroupBytes {
+		return w.flush()
+	}
+	return nil
+}
+
+// accepts indique si la valeur est du type Go de la colonne
+func (c Column) accepts(value any) bool {
+	switch value.(type) {
+	case bool:
+		return c.Type == Boolean
+	case int32:
+		return c.Type == Int32
+	case int64:
+		return c.Type == Int64
+	case float32:
+		return c.Type == Float
+	case float64:
+		return c.Type == Double
+	case []byte, string:
+		return c.Type == ByteArray
+	}
+	return false
+}
+
+// append encode une valeur non nulle, du type de la colonne, en PLAIN
+func (b *columnBuffer) append(value any) {
+	switch v := value.(type) {
+	case bool:
+		if b.present%8 == 0 {
+			b.values = append(b.values, 0)
+		}
+		if v {
+			b.values[len(b.values)-1] |= 1 << (b.present % 8)
+		}
+	case int32:
+		b.values = binary.LittleEndian.AppendUint32(b.values, uint32(v))
+	case int64:
+		b.values = binary.LittleEndian.AppendUint64(b.values, uint64(v))
+	case float32:
+		b.values = binary.LittleEndian.AppendUint32(b.values, math.Float32bits(v))
+	case float64:
+		b.values = binary.LittleEndian.AppendUint64(b.values, math.Float64bits(v))
+	case []byte:
+		b.values = binary.LittleEndian.AppendUint32(b.values, uint32(len(v)))
+		b.values = append(b.values, v...)
+	case string:
+		b.values = binary.LittleEndian.AppendUint32(b.values, uint32(len(v)))
+		b.values = append(b.values, v...)
+	}
+	b.present++
+}
+
+// flush écrit le groupe de lignes en cours
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	group := rowGroup{rows: int64(w.rows), chunks: make([]columnChunk, len(w.columns))}
+	for i := range w.columns {
+		buf := &w.buffers[i]
+		page := encodePage(w.rows, buf)
+		chunk := columnChunk{offset: w.offset, size: int64(len(page))}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		group.chunks[i] = chunk
+		group.size += chunk.size
+		*buf = columnBuffer{levels: buf.levels[:0], values: buf.values[:0]}
+	}
+	w.groups = append(w.groups, group)
+	w.rows = 0
+	w.pending = 0
+	return nil
+}
+
+// encodePage encode une page de données : en-tête, niveaux de définition, puis valeurs
+func encodePage(rows int, buf *columnBuffer) []byte {
+	// Niveaux de définition en RLE/bit-packing hybride, précédés de leur longueur :
+	// une seule séquence bit-packed de groupes de 8 niveaux sur 1 bit
+	groups := (rows + 7) / 8
+	levels := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	levels = append(levels, buf.levels[:groups]...)
+
+	size := 4 + len(levels) + len(buf.values)
+	header := thriftWriter{}
+	header.beginStruct(0)
+	header.i32(1, pageTypeData)
+	header.i32(2, int32(size))
+	header.i32(3, int32(size))
+	header.beginStruct(5)
+	header.i32(1, int32(rows))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.endStruct()
+	header.endStruct()
+
+	page := make([]byte, 0, len(header.buf)+size)
+	page = append(page, header.buf...)
+	page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, buf.values...)
+}
+
+// begin écrit l'en-tête du fichier avant le premier groupe de lignes
+func (w *Writer) begin() error {
+	if w.offset > 0 {
+		return nil
+	}
+	return w.write([]byte(magic))
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+// Close écrit le dernier groupe de lignes et les métadonnées du fichier. Le fichier
+// n'est lisible qu'après Close ; le io.Writer sous-jacent n'est pas fermé.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if err := w.begin(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	return w.write(append(footer, magic...))
+}
+
+// footer encode les métadonnées du fichier (FileMetaData)
+func (w *Writer) footer() []byte {
+	var total int64
+	for _, group := range w.groups {
+		total += group.rows
+	}
+
+	t := thriftWriter{}
+	t.beginStruct(0)
+	t.i32(1, 1) // version
+
+	t.list(2, compactStruct, len(w.columns)+1)
+	t.beginStruct(0)
+	t.binary(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.endStruct()
+	for _, col := range w.columns {
+		t.beginStruct(0)
+		t.i32(1, int32(col.Type))
+		t.i32(3, repetitionOptional)
+		t.binary(4, col.Name)
+		col.writeLogical(&t)
+		t.endStruct()
+	}
+
+	t.i64(3, total)
+
+	t.list(4, compactStruct, len(w.groups))
+	for _, group := range w.groups {
+		t.beginStruct(0)
+		t.list(1, compactStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			col := w.columns[i]
+			t.beginStruct(0)
+			t.i64(2, chunk.offset)
+			t.beginStruct(3)
+			t.i32(1, int32(col.Type))
+			t.list(2, compactI32, 2)
+			t.i32Element(encodingPlain)
+			t.i32Element(encodingRLE)
+			t.list(3, compactBinary, 1)
+			t.binaryElement(col.Name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, group.rows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.endStruct()
+	}
+
+	t.binary(6, "sovrabase")
+	t.endStruct()
+	return t.buf
+}
+
+// writeLogical écrit le type converti (ancien format) et le type logique d'un élément de schéma
+func (c Column) writeLogical(t *thriftWriter) {
+	switch c.Logical {
+	case String:
+		t.i32(6, convertedUTF8)
+		t.beginStruct(10)
+		t.beginStruct(1) // STRING
+		t.endStruct()
+		t.endStruct()
+	case JSON:
+		t.i32(6, convertedJSON)
+		t.beginStruct(10)
+		t.beginStruct(12) // JSON
+		t.endStruct()
+		t.endStruct()
+	case Date:
+		t.i32(6, convertedDate)
+		t.beginStruct(10)
+		t.beginStruct(6) // DATE
+		t.endStruct()
+		t.endStruct()
+	case Timestamp, TimestampUTC:
+		// Le type converti TIMESTAMP_MICROS implique l'UTC : il est omis sans fuseau horaire
+		if c.Logical == TimestampUTC {
+			t.i32(6, convertedTimestampMicros)
+		}
+		t.beginStruct(10)
+		t.beginStruct(8) // TIMESTAMP
+		t.bool(1, c.Logical == TimestampUTC)
+		t.beginStruct(2)
+		t.beginStruct(2) // MICROS
+		t.endStruct()
+		t.endStruct()
+		t.endStruct()
+		t.endStruct()
+	}
+}
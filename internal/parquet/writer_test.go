@@ -0,0 +1,216 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// thriftReader décode le protocole compact en valeurs génériques, pour vérifier les métadonnées
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(kind byte) any {
+	switch kind {
+	case compactBooleanTrue:
+		return true
+	case compactBooleanFalse:
+		return false
+	case compactI32, compactI64:
+		return r.zigzag()
+	case compactBinary:
+		n := int(r.varint())
+		r.pos += n
+		return string(r.buf[r.pos-n : r.pos])
+	case compactList:
+		header := r.buf[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		items := make([]any, size)
+		for i := range items {
+			items[i] = r.value(header & 0x0F)
+		}
+		return items
+	case compactStruct:
+		return r.structure()
+	}
+	panic("type compact inattendu")
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := map[int16]any{}
+	var last int16
+	for {
+		header := r.buf[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(header & 0x0F)
+		last = id
+	}
+}
+
+// readFile vérifie l'encadrement du fichier et retourne ses métadonnées
+func readFile(t *testing.T, file []byte) map[int16]any {
+	t.Helper()
+	if !bytes.HasPrefix(file, []byte(magic)) || !bytes.HasSuffix(file, []byte(magic)) {
+		t.Fatalf("missing PAR1 magic: %q", file)
+	}
+	length := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-length : len(file)-8]
+	return (&thriftReader{buf: footer}).structure()
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, []Column{
+		{Name: "id", Type: Int64},
+		{Name: "title", Type: ByteArray, Logical: String},
+		{Name: "published", Type: Boolean},
+		{Name: "score", Type: Double},
+		{Name: "created_at", Type: Int64, Logical: TimestampUTC},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := [][]any{
+		{int64(1), "Bonjour", true, 1.5, int64(1700000000000000)},
+		{int64(2), nil, false, nil, nil},
+		{int64(3), []byte("Annonce"), true, math.Inf(1), int64(0)},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file := out.Bytes()
+	meta := readFile(t, file)
+	if meta[3] != int64(3) {
+		t.Errorf("num_rows: got %v", meta[3])
+	}
+	if meta[6] != "sovrabase" {
+		t.Errorf("created_by: got %v", meta[6])
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != 6 || schema[0].(map[int16]any)[5] != int64(5) {
+		t.Fatalf("unexpected schema root: %v", schema)
+	}
+	title := schema[2].(map[int16]any)
+	if title[1] != int64(ByteArray) || title[3] != int64(repetitionOptional) || title[4] != "title" || title[6] != int64(convertedUTF8) {
+		t.Errorf("unexpected title element: %v", title)
+	}
+	created := schema[5].(map[int16]any)
+	timestamp := created[10].(map[int16]any)[8].(map[int16]any)
+	if timestamp[1] != true || created[6] != int64(convertedTimestampMicros) {
+		t.Errorf("unexpected created_at element: %v", created)
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("expected one row group, got %d", len(groups))
+	}
+	chunks := groups[0].(map[int16]any)[1].([]any)
+	titleMeta := chunks[1].(map[int16]any)[3].(map[int16]any)
+	if !reflect.DeepEqual(titleMeta[3], []any{"title"}) || titleMeta[5] != int64(3) {
+		t.Errorf("unexpected title chunk: %v", titleMeta)
+	}
+
+	// Page de la colonne title : en-tête, niveaux de définition 1, 0, 1 puis deux chaînes
+	offset := int(titleMeta[9].(int64))
+	page := &thriftReader{buf: file[offset:]}
+	header := page.structure()
+	if header[5].(map[int16]any)[1] != int64(3) {
+		t.Errorf("unexpected page header: %v", header)
+	}
+	data := file[offset+page.pos : offset+page.pos+int(header[2].(int64))]
+	want := []byte{2, 0, 0, 0, 0x03, 0b101}
+	want = binary.LittleEndian.AppendUint32(want, 7)
+	want = append(want, "Bonjour"...)
+	want = binary.LittleEndian.AppendUint32(want, 7)
+	want = append(want, "Annonce"...)
+	if !bytes.Equal(data, want) {
+		t.Errorf("unexpected title page:\ngot  %v\nwant %v", data, want)
+	}
+}
+
+func TestWriter_RowGroups(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(&out, []Column{{Name: "n", Type: Int32}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.RowGroupBytes = 40
+	for i := range 25 {
+		if err := w.Write([]any{int32(i)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta := readFile(t, out.Bytes())
+	groups := meta[4].([]any)
+	if len(groups) != 3 || meta[3] != int64(25) {
+		t.Fatalf("expected 3 row groups of 25 rows, got %d groups, %v rows", len(groups), meta[3])
+	}
+	if groups[2].(map[int16]any)[3] != int64(5) {
+		t.Errorf("unexpected last row group: %v", groups[2])
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var out bytes.Buffer
+	w, _ := NewWriter(&out, []Column{{Name: "n", Type: Int32}})
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := readFile(t, out.Bytes())
+	if meta[3] != int64(0) || len(meta[4].([]any)) != 0 {
+		t.Errorf("unexpected metadata: %v", meta)
+	}
+}
+
+func TestWriter_InvalidValue(t *testing.T) {
+	w, _ := NewWriter(&bytes.Buffer{}, []Column{{Name: "n", Type: Int32}})
+	if err := w.Write([]any{int64(1)}); err == nil {
+		t.Error("expected an error for an int64 in an Int32 column")
+	}
+	if err := w.Write([]any{int32(1), int32(2)}); err == nil {
+		t.Error("expected an error for a row with too many values")
+	}
+}
+
+func TestNewWriter_InvalidLogical(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "d", Type: Int64, Logical: Date}}); err == nil {
+		t.Error("expected an error for a Date on Int64")
+	}
+}
@@ -0,0 +1,432 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// storageSchema crée les tables des métadonnées dans la base interne
+const storageSchema = `
+CREATE SCHEMA IF NOT EXISTS sovrabase;
+
+CREATE TABLE IF NOT EXISTS sovrabase.storage_buckets (
+	project_id text NOT NULL,
+	name text NOT NULL,
+	public boolean NOT NULL DEFAULT false,
+	max_file_size bigint NOT NULL DEFAULT 0,
+	cache_control text NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS sovrabase.storage_objects (
+	project_id text NOT NULL,
+	bucket text NOT NULL,
+	id text NOT NULL,
+	name text NOT NULL,
+	size bigint NOT NULL,
+	content_type text NOT NULL,
+	etag text NOT NULL,
+	metadata jsonb NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (project_id, bucket, id),
+	UNIQUE (project_id, bucket, name),
+	FOREIGN KEY (project_id, bucket) REFERENCES sovrabase.storage_buckets (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS sovrabase.storage_uploads (
+	project_id text NOT NULL,
+	bucket text NOT NULL,
+	id text NOT NULL,
+	name text NOT NULL,
+	content_type text NOT NULL,
+	size bigint NOT NULL,
+	"offset" bigint NOT NULL DEFAULT 0,
+	metadata jsonb NOT NULL DEFAULT '{}',
+	upsert boolean NOT NULL DEFAULT false,
+	chunks jsonb NOT NULL DEFAULT '[]',
+	created_at timestamptz NOT NULL DEFAULT now(),
+	expires_at timestamptz NOT NULL,
+	PRIMARY KEY (project_id, bucket, id),
+	FOREIGN KEY (project_id, bucket) REFERENCES sovrabase.storage_buckets (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS sovrabase.storage_variants (
+	project_id text NOT NULL,
+	bucket text NOT NULL,
+	object_id text NOT NULL,
+	transform text NOT NULL,
+	source_etag text NOT NULL,
+	content_type text NOT NULL,
+	size bigint NOT NULL,
+	etag text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (project_id, bucket, object_id, transform)
+);`
+
+// bucketColumns sont les colonnes lues par scanBucket
+const bucketColumns = `project_id, name, public, max_file_size, cache_control, created_at, updated_at`
+
+// objectColumns sont les colonnes lues par scanObject
+const objectColumns = `project_id, bucket, id, name, size, content_type, etag, metadata, created_at, updated_at`
+
+// uploadColumns sont les colonnes lues par scanResumableUpload
+const uploadColumns = `project_id, bucket, id, name, content_type, size, "offset", metadata, upsert, chunks, created_at, expires_at`
+
+// variantColumns sont les colonnes lues par scanVariant
+const variantColumns = `project_id, bucket, object_id, transform, source_etag, content_type, size, etag, created_at`
+
+// PostgresStore conserve les métadonnées dans la base interne PostgreSQL
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore se connecte à la base interne et crée les tables du stockage
+func NewPostgresStore(ctx context.Context, uri string) (*PostgresStore, error) {
+	pool, err := pgxpool.New(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("connexion à la base interne: %w", err)
+	}
+	if _, err := pool.Exec(ctx, storageSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("création des tables du stockage: %w", err)
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Close ferme les connexions à la base interne
+func (s *PostgresStore) Close() {
+	s.pool.Close()
+}
+
+func (s *PostgresStore) CreateBucket(ctx context.Context, bucket *Bucket) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO sovrabase.storage_buckets (`+bucketColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		bucket.ProjectID, bucket.Name, bucket.Public, bucket.MaxFileSize, bucket.CacheControl, bucket.CreatedAt, bucket.UpdatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: le bucket %q existe déjà", ErrConflict, bucket.Name)
+	}
+	return err
+}
+
+func (s *PostgresStore) ListBuckets(ctx context.Context, projectID string) ([]*Bucket, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+bucketColumns+` FROM sovrabase.storage_buckets
+		WHERE project_id = $1 ORDER BY name`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []*Bucket{}
+	for rows.Next() {
+		bucket, err := scanBucket(rows, "")
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
+
+func (s *PostgresStore) GetBucket(ctx context.Context, projectID, name string) (*Bucket, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+bucketColumns+` FROM sovrabase.storage_buckets
+		WHERE project_id = $1 AND name = $2`, projectID, name)
+	return scanBucket(row, name)
+}
+
+func (s *PostgresStore) UpdateBucket(ctx context.Context, bucket *Bucket) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE sovrabase.storage_buckets SET public = $3, max_file_size = $4, cache_control = $5, updated_at = $6
+		WHERE project_id = $1 AND name = $2`,
+		bucket.ProjectID, bucket.Name, bucket.Public, bucket.MaxFileSize, bucket.CacheControl, bucket.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, bucket.Name)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteBucket(ctx context.Context, projectID, name string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM sovrabase.storage_buckets WHERE project_id = $1 AND name = $2`, projectID, name)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return fmt.Errorf("%w: le bucket %q contient des fichiers ou des envois en cours", ErrConflict, name)
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, name)
+	}
+	return nil
+}
+
+func (s *PostgresStore) PutObject(ctx context.Context, object *Object) error {
+	metadata, err := json.Marshal(object.Metadata)
+	if err != nil {
+		return fmt.Errorf("%w: métadonnées invalides", ErrInvalid)
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO sovrabase.storage_objects (`+objectColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (project_id, bucket, id) DO UPDATE SET
+			name = EXCLUDED.name, size = EXCLUDED.size, content_type = EXCLUDED.content_type,
+			etag = EXCLUDED.etag, metadata = EXCLUDED.metadata, updated_at = EXCLUDED.updated_at`,
+		object.ProjectID, object.Bucket, object.ID, object.Name, object.Size, object.ContentType,
+		object.ETag, string(metadata), object.CreatedAt, object.UpdatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: le fichier %q existe déjà", ErrConflict, object.Name)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, object.Bucket)
+	}
+	return err
+}
+
+func (s *PostgresStore) GetObject(ctx context.Context, projectID, bucket, id string) (*Object, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+objectColumns+` FROM sovrabase.storage_objects
+		WHERE project_id = $1 AND bucket = $2 AND id = $3`, projectID, bucket, id)
+	return scanObject(row, id)
+}
+
+func (s *PostgresStore) FindObject(ctx context.Context, projectID, bucket, name string) (*Object, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+objectColumns+` FROM sovrabase.storage_objects
+		WHERE project_id = $1 AND bucket = $2 AND name = $3`, projectID, bucket, name)
+	return scanObject(row, name)
+}
+
+func (s *PostgresStore) ListObjects(ctx context.Context, projectID, bucket string, options ListOptions) ([]*Object, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+objectColumns+` FROM sovrabase.storage_objects
+		WHERE project_id = $1 AND bucket = $2 AND starts_with(name, $3)
+		ORDER BY name LIMIT $4 OFFSET $5`, projectID, bucket, options.Prefix, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := []*Object{}
+	for rows.Next() {
+		object, err := scanObject(rows, "")
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+func (s *PostgresStore) DeleteObject(ctx context.Context, projectID, bucket, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM sovrabase.storage_objects WHERE project_id = $1 AND bucket = $2 AND id = $3`,
+		projectID, bucket, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: fichier %q", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *PostgresStore) CreateResumableUpload(ctx context.Context, upload *ResumableUpload) error {
+	metadata, err := json.Marshal(upload.Metadata)
+	if err != nil {
+		return fmt.Errorf("%w: métadonnées invalides", ErrInvalid)
+	}
+	chunks, err := json.Marshal(upload.Chunks)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO sovrabase.storage_uploads (`+uploadColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		upload.ProjectID, upload.Bucket, upload.ID, upload.Name, upload.ContentType, upload.Size, upload.Offset,
+		string(metadata), upload.Upsert, string(chunks), upload.CreatedAt, upload.ExpiresAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, upload.Bucket)
+	}
+	return err
+}
+
+func (s *PostgresStore) GetResumableUpload(ctx context.Context, projectID, bucket, id string) (*ResumableUpload, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+uploadColumns+` FROM sovrabase.storage_uploads
+		WHERE project_id = $1 AND bucket = $2 AND id = $3`, projectID, bucket, id)
+	return scanResumableUpload(row, id)
+}
+
+func (s *PostgresStore) UpdateResumableUpload(ctx context.Context, upload *ResumableUpload, previous int64) error {
+	chunks, err := json.Marshal(upload.Chunks)
+	if err != nil {
+		return err
+	}
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE sovrabase.storage_uploads SET "offset" = $4, chunks = $5, expires_at = $6
+		WHERE project_id = $1 AND bucket = $2 AND id = $3 AND "offset" = $7`,
+		upload.ProjectID, upload.Bucket, upload.ID, upload.Offset, string(chunks), upload.ExpiresAt, previous)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: l'envoi %q a progressé entre-temps ou n'existe plus", ErrConflict, upload.ID)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteResumableUpload(ctx context.Context, projectID, bucket, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM sovrabase.storage_uploads WHERE project_id = $1 AND bucket = $2 AND id = $3`,
+		projectID, bucket, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: envoi %q", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ExpiredResumableUploads(ctx context.Context, before time.Time) ([]*ResumableUpload, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+uploadColumns+` FROM sovrabase.storage_uploads
+		WHERE expires_at < $1 ORDER BY expires_at`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []*ResumableUpload
+	for rows.Next() {
+		upload, err := scanResumableUpload(rows, "")
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+func (s *PostgresStore) PutVariant(ctx context.Context, variant *Variant) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO sovrabase.storage_variants (`+variantColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (project_id, bucket, object_id, transform) DO UPDATE
+		SET source_etag = EXCLUDED.source_etag, content_type = EXCLUDED.content_type, size = EXCLUDED.size,
+			etag = EXCLUDED.etag, created_at = EXCLUDED.created_at`,
+		variant.ProjectID, variant.Bucket, variant.ObjectID, variant.Transform, variant.SourceETag,
+		variant.ContentType, variant.Size, variant.ETag, variant.CreatedAt)
+	return err
+}
+
+func (s *PostgresStore) GetVariant(ctx context.Context, projectID, bucket, objectID, transform string) (*Variant, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+variantColumns+` FROM sovrabase.storage_variants
+		WHERE project_id = $1 AND bucket = $2 AND object_id = $3 AND transform = $4`, projectID, bucket, objectID, transform)
+	return scanVariant(row, transform)
+}
+
+func (s *PostgresStore) ListVariants(ctx context.Context, projectID, bucket, objectID string) ([]*Variant, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+variantColumns+` FROM sovrabase.storage_variants
+		WHERE project_id = $1 AND bucket = $2 AND object_id = $3`, projectID, bucket, objectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []*Variant
+	for rows.Next() {
+		variant, err := scanVariant(rows, "")
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+	return variants, rows.Err()
+}
+
+func (s *PostgresStore) DeleteVariants(ctx context.Context, projectID, bucket, objectID string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM sovrabase.storage_variants WHERE project_id = $1 AND bucket = $2 AND object_id = $3`,
+		projectID, bucket, objectID)
+	return err
+}
+
+// scanBucket lit un bucket lu avec bucketColumns
+func scanBucket(row pgx.Row, name string) (*Bucket, error) {
+	var bucket Bucket
+	err := row.Scan(&bucket.ProjectID, &bucket.Name, &bucket.Public, &bucket.MaxFileSize, &bucket.CacheControl, &bucket.CreatedAt, &bucket.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: bucket %q", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// scanResumableUpload lit un envoi lu avec uploadColumns
+func scanResumableUpload(row pgx.Row, id string) (*ResumableUpload, error) {
+	var upload ResumableUpload
+	var metadata, chunks []byte
+	err := row.Scan(&upload.ProjectID, &upload.Bucket, &upload.ID, &upload.Name, &upload.ContentType, &upload.Size,
+		&upload.Offset, &metadata, &upload.Upsert, &chunks, &upload.CreatedAt, &upload.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: envoi %q", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &upload.Metadata); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(chunks, &upload.Chunks); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// scanObject lit un fichier lu avec objectColumns
+func scanObject(row pgx.Row, name string) (*Object, error) {
+	var object Object
+	var metadata []byte
+	err := row.Scan(&object.ProjectID, &object.Bucket, &object.ID, &object.Name, &object.Size, &object.ContentType,
+		&object.ETag, &metadata, &object.CreatedAt, &object.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: fichier %q", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &object.Metadata); err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// scanVariant lit une variante lue avec variantColumns
+func scanVariant(row pgx.Row, transform string) (*Variant, error) {
+	var variant Variant
+	err := row.Scan(&variant.ProjectID, &variant.Bucket, &variant.ObjectID, &variant.Transform, &variant.SourceETag,
+		&variant.ContentType, &variant.Size, &variant.ETag, &variant.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: variante %q", ErrNotFound, transform)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// isUniqueViolation indique si l'erreur est une violation de contrainte d'unicité
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ResumableUploadExpiry est la durée de vie d'un envoi reprenable, prolongée à chaque portion reçue
+const ResumableUploadExpiry = 24 * time.Hour
+
+// ResumableUpload est un fichier envoyé en plusieurs portions, éventuellement sur plusieurs
+// connexions : chaque portion reçue est conservée dans le backend, et le fichier est créé
+// lorsque Offset atteint Size.
+type ResumableUpload struct {
+	ID          string         `json:"id"`
+	ProjectID   string         `json:"project_id"`
+	Bucket      string         `json:"bucket"`
+	Name        string         `json:"name"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`   // Taille totale annoncée
+	Offset      int64          `json:"offset"` // Octets reçus
+	Metadata    map[string]any `json:"metadata"`
+	Upsert      bool           `json:"upsert"`
+	Chunks      []Chunk        `json:"chunks"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+}
+
+// Chunk est une portion reçue d'un envoi reprenable, rangée dans le backend sous sa clé
+type Chunk struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// chunkKey retourne une clé nouvelle pour une portion de l'envoi. Les portions sont rangées
+// à côté des fichiers du bucket, sous un dossier qui ne peut pas être un identifiant de fichier.
+func (u *ResumableUpload) chunkKey() (string, error) {
+	id, err := newObjectID()
+	if err != nil {
+		return "", err
+	}
+	return u.ProjectID + "/" + u.Bucket + "/.uploads/" + u.ID + "/" + id, nil
+}
+
+// CreateResumableUpload commence un envoi reprenable du fichier décrit par upload, dont la
+// taille doit être connue. Le nom est vérifié dès maintenant, pour qu'un conflit ne soit
+// pas découvert après l'envoi du contenu.
+func (s *Service) CreateResumableUpload(ctx context.Context, projectID, bucket string, upload Upload) (*ResumableUpload, error) {
+	if upload.Size < 0 {
+		return nil, fmt.Errorf("%w: la taille d'un envoi reprenable doit être connue", ErrInvalid)
+	}
+	if _, err := s.prepareUpload(ctx, projectID, bucket, upload); err != nil {
+		return nil, err
+	}
+	if !upload.Upsert {
+		_, err := s.store.FindObject(ctx, projectID, bucket, upload.Name)
+		if err == nil {
+			return nil, fmt.Errorf("%w: le fichier %q existe déjà", ErrConflict, upload.Name)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	id, err := newObjectID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	resumable := &ResumableUpload{
+		ID:          id,
+		ProjectID:   projectID,
+		Bucket:      bucket,
+		Name:        upload.Name,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		Metadata:    upload.Metadata,
+		Upsert:      upload.Upsert,
+		Chunks:      []Chunk{},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ResumableUploadExpiry),
+	}
+	if err := s.store.CreateResumableUpload(ctx, resumable); err != nil {
+		return nil, err
+	}
+	return resumable, nil
+}
+
+// ResumableUpload retourne un envoi reprenable, ErrNotFound s'il a expiré
+func (s *Service) ResumableUpload(ctx context.Context, projectID, bucket, id string) (*ResumableUpload, error) {
+	upload, err := s.store.GetResumableUpload(ctx, projectID, bucket, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, fmt.Errorf("%w: envoi %q expiré", ErrNotFound, id)
+	}
+	return upload, nil
+}
+
+// WriteChunk ajoute à l'envoi le contenu lu depuis body, qui doit commencer à offset. Si la
+// lecture est interrompue, les octets déjà reçus sont conservés et l'envoi peut reprendre
+// après eux. Lorsque tout le contenu est reçu, le fichier est créé et retourné.
+func (s *Service) WriteChunk(ctx context.Context, projectID, bucket, id string, offset int64, body io.Reader) (*ResumableUpload, *Object, error) {
+	upload, err := s.ResumableUpload(ctx, projectID, bucket, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset {
+		return upload, nil, fmt.Errorf("%w: l'envoi %q est à l'offset %d, pas %d", ErrConflict, id, upload.Offset, offset)
+	}
+
+	// Les octets au-delà de la taille annoncée font échouer la portion entière. La portion
+	// est enregistrée même si le client se déconnecte, ce qui annule le contexte de la requête.
+	chunk := &interruptibleReader{r: &sizedReader{r: body, remaining: upload.Size - offset, partial: true}}
+	persist := context.WithoutCancel(ctx)
+	key, err := upload.chunkKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.backend.Put(persist, key, chunk, -1, "application/octet-stream"); err != nil {
+		return nil, nil, err
+	}
+	if chunk.n > 0 {
+		upload.Chunks = append(upload.Chunks, Chunk{Key: key, Size: chunk.n})
+		upload.Offset += chunk.n
+		upload.ExpiresAt = time.Now().UTC().Add(ResumableUploadExpiry)
+		if err := s.store.UpdateResumableUpload(persist, upload, offset); err != nil {
+			s.backend.Delete(persist, key)
+			return nil, nil, err
+		}
+	} else {
+		s.backend.Delete(persist, key)
+	}
+	if chunk.err != nil {
+		return upload, nil, fmt.Errorf("%w: envoi interrompu après %d octets: %v", ErrInvalid, chunk.n, chunk.err)
+	}
+	if upload.Offset < upload.Size {
+		return upload, nil, nil
+	}
+
+	object, err := s.completeResumableUpload(ctx, upload)
+	if err != nil {
+		return upload, nil, err
+	}
+	return upload, object, nil
+}
+
+// completeResumableUpload crée le fichier à partir des portions de l'envoi, puis les supprime
+func (s *Service) completeResumableUpload(ctx context.Context, upload *ResumableUpload) (*Object, error) {
+	content := &chunksReader{ctx: ctx, backend: s.backend, chunks: upload.Chunks}
+	defer content.Close()
+	object, err := s.Upload(ctx, upload.ProjectID, upload.Bucket, Upload{
+		Name:        upload.Name,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		Metadata:    upload.Metadata,
+		Upsert:      upload.Upsert,
+		Body:        content,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.discardResumableUpload(context.WithoutCancel(ctx), upload); err != nil {
+		log.Printf("⚠️ storage: upload %s completed but not cleaned up: %v", upload.ID, err)
+	}
+	return object, nil
+}
+
+// CancelResumableUpload abandonne un envoi et supprime les portions reçues
+func (s *Service) CancelResumableUpload(ctx context.Context, projectID, bucket, id string) error {
+	upload, err := s.ResumableUpload(ctx, projectID, bucket, id)
+	if err != nil {
+		return err
+	}
+	return s.discardResumableUpload(ctx, upload)
+}
+
+// discardResumableUpload supprime l'envoi, puis ses portions
+func (s *Service) discardResumableUpload(ctx context.Context, upload *ResumableUpload) error {
+	if err := s.store.DeleteResumableUpload(ctx, upload.ProjectID, upload.Bucket, upload.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	for _, chunk := range upload.Chunks {
+		if err := s.backend.Delete(ctx, chunk.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredUploads supprime les envois reprenables expirés et retourne leur nombre
+func (s *Service) PurgeExpiredUploads(ctx context.Context) (int, error) {
+	uploads, err := s.store.ExpiredResumableUploads(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, upload := range uploads {
+		if err := s.discardResumableUpload(ctx, upload); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartUploadPurge purge périodiquement les envois reprenables expirés, jusqu'à la
+// fermeture du service. Un intervalle nul désactive la purge.
+func (s *Service) StartUploadPurge(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				purged, err := s.PurgeExpiredUploads(ctx)
+				cancel()
+				if err != nil {
+					log.Printf("⚠️ storage: failed to purge expired uploads: %v", err)
+				} else if purged > 0 {
+					log.Printf("🧹 storage: purged %d expired uploads", purged)
+				}
+			}
+		}
+	}()
+}
+
+// interruptibleReader termine la lecture sans erreur lorsque r échoue, en retenant l'erreur :
+// le backend enregistre alors les octets reçus avant l'interruption. Les erreurs de contenu
+// (ErrInvalid) font toujours échouer la lecture.
+type interruptibleReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (i *interruptibleReader) Read(p []byte) (int, error) {
+	if i.err != nil {
+		return 0, io.EOF
+	}
+	n, err := i.r.Read(p)
+	i.n += int64(n)
+	if err != nil && err != io.EOF && !errors.Is(err, ErrInvalid) {
+		i.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// chunksReader lit à la suite les portions d'un envoi, en ouvrant chacune au moment de la lire
+type chunksReader struct {
+	ctx     context.Context
+	backend Backend
+	chunks  []Chunk
+	current io.ReadCloser
+}
+
+func (c *chunksReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			content, err := c.backend.Get(c.ctx, c.chunks[0].Key, 0)
+			if err != nil {
+				return 0, err
+			}
+			c.current = content
+			c.chunks = c.chunks[1:]
+		}
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunksReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
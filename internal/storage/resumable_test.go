@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumableUpload(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	upload, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "videos/clip.txt", ContentType: "text/plain", Size: 11})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	progress, object, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 0, strings.NewReader("hello "))
+	if err != nil || object != nil || progress.Offset != 6 {
+		t.Fatalf("unexpected progress: %+v, %+v (%v)", progress, object, err)
+	}
+	// Une portion qui ne commence pas à l'offset courant est refusée
+	if _, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 0, strings.NewReader("hello ")); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for a stale offset, got %v", err)
+	}
+	// Le contenu ne peut pas dépasser la taille annoncée
+	if _, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 6, strings.NewReader("world and more")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a chunk past the size, got %v", err)
+	}
+
+	progress, object, err = service.WriteChunk(ctx, "proj", "avatars", upload.ID, 6, strings.NewReader("world"))
+	if err != nil || object == nil {
+		t.Fatalf("the upload should be complete: %+v (%v)", progress, err)
+	}
+	if object.Name != "videos/clip.txt" || object.Size != 11 || object.ContentType != "text/plain" {
+		t.Errorf("unexpected object: %+v", object)
+	}
+	if got := readAll(t, service, object.ID); got != "hello world" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if _, err := service.ResumableUpload(ctx, "proj", "avatars", upload.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("a completed upload should be removed, got %v", err)
+	}
+	for _, chunk := range progress.Chunks {
+		if _, err := service.backend.Get(ctx, chunk.Key, 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("chunk %s should be deleted, got %v", chunk.Key, err)
+		}
+	}
+}
+
+// failingReader renvoie son contenu, puis l'erreur d'une connexion coupée
+type failingReader struct {
+	content io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.content.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestResumableUpload_Interrupted(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	upload, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "a.txt", Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Les octets reçus avant la coupure sont conservés
+	progress, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 0, &failingReader{strings.NewReader("0123")})
+	if !errors.Is(err, ErrInvalid) || progress == nil || progress.Offset != 4 {
+		t.Fatalf("unexpected progress: %+v (%v)", progress, err)
+	}
+	current, err := service.ResumableUpload(ctx, "proj", "avatars", upload.ID)
+	if err != nil || current.Offset != 4 {
+		t.Fatalf("the offset should be saved: %+v (%v)", current, err)
+	}
+
+	_, object, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 4, strings.NewReader("456789"))
+	if err != nil || object == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readAll(t, service, object.ID); got != "0123456789" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestResumableUpload_Checks(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	if _, err := service.Upload(ctx, "proj", "avatars", upload("taken.txt", "x")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdateBucket(ctx, "proj", "avatars", BucketSettings{MaxFileSize: 100}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "taken.txt", Size: 1}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for an existing name, got %v", err)
+	}
+	if _, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "big.bin", Size: 101}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "unknown.bin", Size: -1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown size, got %v", err)
+	}
+
+	upload, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "pending.bin", Size: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 0, strings.NewReader("ab")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.DeleteBucket(ctx, "proj", "avatars"); !errors.Is(err, ErrConflict) {
+		t.Errorf("a pending upload should keep the bucket, got %v", err)
+	}
+	if err := service.CancelResumableUpload(ctx, "proj", "avatars", upload.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 2, strings.NewReader("cd")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a cancelled upload, got %v", err)
+	}
+}
+
+func TestPurgeExpiredUploads(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	upload, err := service.CreateResumableUpload(ctx, "proj", "avatars", Upload{Name: "a.bin", Size: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	progress, _, err := service.WriteChunk(ctx, "proj", "avatars", upload.ID, 0, strings.NewReader("ab"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if purged, err := service.PurgeExpiredUploads(ctx); err != nil || purged != 0 {
+		t.Errorf("unexpected purge: %d (%v)", purged, err)
+	}
+	progress.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := service.store.UpdateResumableUpload(ctx, progress, progress.Offset); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.ResumableUpload(ctx, "proj", "avatars", upload.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an expired upload, got %v", err)
+	}
+	if purged, err := service.PurgeExpiredUploads(ctx); err != nil || purged != 1 {
+		t.Errorf("unexpected purge: %d (%v)", purged, err)
+	}
+	if _, err := service.backend.Get(ctx, progress.Chunks[0].Key, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired chunks should be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config contient la connexion à un service compatible S3 (AWS, MinIO, Garage…)
+type S3Config struct {
+	Endpoint  string // URL du service (ex : "https://s3.eu-west-1.amazonaws.com")
+	Region    string // Région de signature (défaut : "us-east-1")
+	Bucket    string // Bucket S3 recevant le contenu de tous les buckets des projets
+	AccessKey string
+	SecretKey string
+	PathStyle bool // Adresse le bucket dans le chemin plutôt que dans le nom d'hôte
+}
+
+// S3Backend range le contenu des fichiers dans un bucket S3, sous des clés préfixées par le
+// projet et le bucket. Les requêtes sont signées en AWS Signature Version 4.
+type S3Backend struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+	partSize int // Taille des parties d'un envoi multipart
+}
+
+// Limites des envois multipart S3 : toutes les parties sauf la dernière font au moins 5 Mio,
+// et un envoi compte au plus 10 000 parties
+const (
+	defaultPartSize = 8 << 20
+	maxParts        = 10000
+)
+
+// emptyPayloadHash est l'empreinte SHA-256 d'un corps vide
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// unsignedPayload remplace l'empreinte d'un corps lu en flux, qui n'est pas connue à l'envoi
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// NewS3Backend crée le backend S3
+func NewS3Backend(config S3Config) (*S3Backend, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint S3 invalide %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket S3 manquant")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3Backend{config: config, endpoint: endpoint, client: http.DefaultClient, now: time.Now, partSize: defaultPartSize}, nil
+}
+
+// Put envoie le contenu en un seul PUT lorsque sa taille est connue. Sinon, le contenu est
+// lu par parties de partSize octets : un contenu plus petit est envoyé en un seul PUT, un
+// plus grand par un envoi multipart, sans jamais garder plus d'une partie en mémoire.
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	r = readerWithContext(ctx, r)
+	if size < 0 {
+		part := make([]byte, b.partSize)
+		n, err := readPart(r, part)
+		switch err {
+		case nil:
+			return b.putMultipart(ctx, key, r, part, contentType)
+		case io.EOF:
+			r, size = bytes.NewReader(part[:n]), int64(n)
+		default:
+			return err
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	resp, err := b.do(ctx, http.MethodPut, key, nil, r, size, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putMultipart envoie le contenu par un envoi multipart, dont part est la première partie.
+// L'envoi est abandonné en cas d'erreur : le contenu précédent de la clé est conservé.
+func (b *S3Backend) putMultipart(ctx context.Context, key string, r io.Reader, part []byte, contentType string) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	resp, err := b.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, header)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("s3: création de l'envoi multipart de %s: réponse invalide", key)
+	}
+
+	if err := b.uploadParts(ctx, key, initiated.UploadID, r, part); err != nil {
+		if resp, abortErr := b.do(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil, 0, nil); abortErr == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+// completedPart est une partie envoyée, citée dans la requête de fin d'un envoi multipart
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts envoie les parties lues depuis r, en commençant par part, puis termine l'envoi
+func (b *S3Backend) uploadParts(ctx context.Context, key, uploadID string, r io.Reader, part []byte) error {
+	var parts []completedPart
+	n := len(part)
+	for number := 1; n > 0; number++ {
+		if number > maxParts {
+			return fmt.Errorf("%w: contenu de plus de %d parties de %d octets", ErrTooLarge, maxParts, b.partSize)
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := b.do(ctx, http.MethodPut, key, query, bytes.NewReader(part[:n]), int64(n), nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		n, err = readPart(r, part)
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// La fin d'un envoi peut échouer après une réponse 200, l'erreur étant dans le corps
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("s3: fin de l'envoi multipart de %s: %w", key, err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3: fin de l'envoi multipart de %s: %s %s", key, result.Code, result.Message)
+	}
+	return nil
+}
+
+// readPart remplit part depuis r, et retourne io.EOF à la fin du contenu. Contrairement à
+// io.ReadFull, une erreur io.ErrUnexpectedEOF de r (un corps tronqué) n'est pas confondue
+// avec une dernière partie incomplète.
+func readPart(r io.Reader, part []byte) (int, error) {
+	n := 0
+	for n < len(part) {
+		read, err := r.Read(part[n:])
+		n += read
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Get lit le contenu de la clé à partir d'offset, avec une requête Range
+func (b *S3Backend) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {"bytes=" + strconv.FormatInt(offset, 10) + "-"}}
+	}
+	resp, err := b.do(ctx, http.MethodGet, key, nil, nil, 0, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete supprime la clé, S3 ne signalant pas les clés absentes
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, key, nil, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// objectURL retourne l'URL de la clé
+func (b *S3Backend) objectURL(key string) *url.URL {
+	u := *b.endpoint
+	path := "/" + key
+	if b.config.PathStyle {
+		path = "/" + b.config.Bucket + path
+	} else {
+		u.Host = b.config.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(b.endpoint.Path, "/") + path
+	u.RawPath = ""
+	return &u
+}
+
+// do envoie une requête signée et retourne sa réponse lorsqu'elle est un succès
+func (b *S3Backend) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	u := b.objectURL(key)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+	b.sign(req, payloadHash, b.now().UTC())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: contenu absent pour %q", ErrNotFound, key)
+	}
+	return nil, fmt.Errorf("s3: %s %s: %s %s %s", method, key, resp.Status, s3Err.Code, s3Err.Message)
+}
+
+// sign ajoute à la requête les en-têtes de la signature AWS Signature Version 4
+func (b *S3Backend) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// En-têtes signés : l'hôte et tous ceux de la requête
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+b.config.SecretKey), date)
+	key = hmacSHA256(key, b.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encode les paramètres de la requête dans l'ordre de leur nom
+func canonicalQuery(values url.Values) string {
+	pairs := make([]string, 0, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encode une valeur comme l'exige la signature : tout sauf les caractères non
+// réservés, et les / sauf dans les paramètres
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidSignature est renvoyée pour une URL signée altérée, expirée ou utilisée avec une
+// autre méthode que celle signée
+var ErrInvalidSignature = errors.New("signature d'URL invalide ou expirée")
+
+// Méthodes autorisées par une URL signée
+const (
+	SignedDownload = "download" // Lecture d'un fichier, désigné par son identifiant
+	SignedUpload   = "upload"   // Envoi d'un fichier, désigné par son nom
+)
+
+// Durées de validité des URL signées
+const (
+	DefaultSignedURLExpiry = time.Hour
+	MaxSignedURLExpiry     = 7 * 24 * time.Hour
+)
+
+// SignedURL décrit l'accès accordé par une URL signée. Tous les champs sont couverts par la
+// signature : modifier l'un d'eux dans l'URL la rend invalide.
+type SignedURL struct {
+	Method      string // SignedDownload ou SignedUpload
+	ProjectID   string
+	Bucket      string
+	Object      string // Identifiant du fichier à lire, ou nom du fichier à envoyer
+	Expires     time.Time
+	Disposition string // En-tête Content-Disposition imposé à la lecture, vide pour celui par défaut
+	Upsert      bool   // Envoi : remplace le fichier de même nom
+}
+
+// URLSigner signe et vérifie les URL d'accès aux fichiers, en HMAC-SHA256
+type URLSigner struct {
+	key []byte
+}
+
+// NewURLSigner crée un signataire à partir du secret du serveur. La clé de signature en est
+// dérivée, pour qu'une signature d'URL ne puisse servir dans un autre usage du même secret.
+func NewURLSigner(secret []byte) (*URLSigner, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("aucun secret de signature configuré")
+	}
+	return &URLSigner{key: hmacSHA256(secret, "sovrabase storage signed url")}, nil
+}
+
+// Sign retourne la signature de l'accès, encodée en hexadécimal
+func (s *URLSigner) Sign(u SignedURL) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(u.canonical())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify vérifie la signature de l'accès et son expiration à l'instant now
+func (s *URLSigner) Verify(u SignedURL, signature string, now time.Time) error {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature mal encodée", ErrInvalidSignature)
+	}
+	expected, _ := hex.DecodeString(s.Sign(u))
+	if !hmac.Equal(decoded, expected) {
+		return fmt.Errorf("%w: signature incorrecte", ErrInvalidSignature)
+	}
+	if !now.Before(u.Expires) {
+		return fmt.Errorf("%w: URL expirée depuis %s", ErrInvalidSignature, u.Expires.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// canonical retourne la forme signée de l'accès. Un tableau JSON délimite chaque champ sans
+// ambiguïté, quels que soient les caractères des noms.
+func (u SignedURL) canonical() []byte {
+	data, _ := json.Marshal([]any{u.Method, u.ProjectID, u.Bucket, u.Object, u.Expires.Unix(), u.Disposition, u.Upsert})
+	return data
+}
+
+// CheckSignedURLExpiry vérifie la durée de validité demandée pour une URL signée
+func CheckSignedURLExpiry(expiresIn time.Duration) error {
+	if expiresIn <= 0 || expiresIn > MaxSignedURLExpiry {
+		return fmt.Errorf("%w: la validité d'une URL signée doit être comprise entre 1 seconde et %s", ErrInvalid, MaxSignedURLExpiry)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer, err := NewURLSigner([]byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	signed := SignedURL{Method: SignedDownload, ProjectID: "proj", Bucket: "avatars", Object: "abc", Expires: now.Add(time.Hour)}
+	signature := signer.Sign(signed)
+
+	if err := signer.Verify(signed, signature, now); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := signer.Verify(signed, signature, now.Add(time.Hour)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected an expired URL to be refused, got %v", err)
+	}
+
+	// Chaque champ est couvert par la signature
+	tampered := map[string]func(u *SignedURL){
+		"method":      func(u *SignedURL) { u.Method = SignedUpload },
+		"project":     func(u *SignedURL) { u.ProjectID = "other" },
+		"bucket":      func(u *SignedURL) { u.Bucket = "private" },
+		"object":      func(u *SignedURL) { u.Object = "def" },
+		"expires":     func(u *SignedURL) { u.Expires = u.Expires.Add(time.Hour) },
+		"disposition": func(u *SignedURL) { u.Disposition = "attachment" },
+		"upsert":      func(u *SignedURL) { u.Upsert = true },
+	}
+	for name, tamper := range tampered {
+		changed := signed
+		tamper(&changed)
+		if err := signer.Verify(changed, signature, now); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+
+	// Les champs sont délimités : déplacer un séparateur change la signature
+	split := SignedURL{Method: SignedDownload, ProjectID: "proj", Bucket: "ava", Object: "tarsabc", Expires: signed.Expires}
+	if signer.Sign(split) == signature {
+		t.Error("fields should be delimited in the signed form")
+	}
+
+	other, _ := NewURLSigner([]byte("other secret"))
+	if err := other.Verify(signed, signature, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature with another secret, got %v", err)
+	}
+	if err := signer.Verify(signed, "not-hex", now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a malformed signature, got %v", err)
+	}
+	if _, err := NewURLSigner(nil); err == nil {
+		t.Error("expected an error without secret")
+	}
+}
+
+func TestCheckSignedURLExpiry(t *testing.T) {
+	for _, expiry := range []time.Duration{0, -time.Second, MaxSignedURLExpiry + time.Second} {
+		if err := CheckSignedURLExpiry(expiry); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", expiry, err)
+		}
+	}
+	if err := CheckSignedURLExpiry(MaxSignedURLExpiry); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,587 @@
+// Package storage gère les buckets et les fichiers des projets. Le contenu des fichiers est
+// confié à un Backend (disque local ou service compatible S3), leurs métadonnées au Store du
+// plan de contrôle.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ketsuna-org/sovrabase/internal/config"
+)
+
+// Erreurs renvoyées par le stockage
+var (
+	ErrNotFound = errors.New("ressource de stockage introuvable")
+	ErrConflict = errors.New("conflit avec une ressource de stockage existante")
+	ErrInvalid  = errors.New("requête de stockage invalide")
+	ErrTooLarge = errors.New("fichier trop volumineux")
+)
+
+// Bucket est un espace de fichiers d'un projet, identifié par son nom
+type Bucket struct {
+	ProjectID string `json:"project_id"`
+	Name      string `json:"name" example:"avatars"`
+	BucketSettings
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BucketSettings sont les réglages modifiables d'un bucket
+type BucketSettings struct {
+	Public       bool   `json:"public"`                                // Fichiers lisibles sans authentification
+	MaxFileSize  int64  `json:"max_file_size" example:"10485760"`      // Taille maximale d'un fichier en octets, 0 sans limite
+	CacheControl string `json:"cache_control" example:"max-age=86400"` // Directives Cache-Control des fichiers servis, vide pour celles par défaut
+}
+
+// Object est un fichier d'un bucket. Son contenu est rangé dans le backend sous une clé
+// dérivée de son identifiant : renommer un fichier ne déplace pas son contenu.
+type Object struct {
+	ID          string         `json:"id" example:"3f2a9c1e7b4d8e6f0a1b2c3d4e5f6a7b"`
+	ProjectID   string         `json:"project_id"`
+	Bucket      string         `json:"bucket" example:"avatars"`
+	Name        string         `json:"name" example:"users/42.png"`
+	Size        int64          `json:"size" example:"48213"`
+	ContentType string         `json:"content_type" example:"image/png"`
+	ETag        string         `json:"etag"` // Empreinte SHA-256 du contenu
+	Metadata    map[string]any `json:"metadata"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// key retourne la clé du contenu du fichier dans le backend
+func (o *Object) key() string {
+	return o.ProjectID + "/" + o.Bucket + "/" + o.ID
+}
+
+// Backend conserve le contenu des fichiers
+type Backend interface {
+	// Put enregistre le contenu de size octets lu depuis r, en remplaçant celui de la clé ;
+	// une taille négative est inconnue, le contenu étant lu jusqu'à la fin de r. Si la
+	// lecture de r échoue, le contenu précédent de la clé est conservé.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get ouvre le contenu de la clé à partir de l'octet offset, ErrNotFound s'il n'existe pas
+	Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	// Delete supprime le contenu de la clé, sans erreur s'il n'existe pas
+	Delete(ctx context.Context, key string) error
+}
+
+// Store conserve les métadonnées des buckets et des fichiers
+type Store interface {
+	CreateBucket(ctx context.Context, bucket *Bucket) error
+	ListBuckets(ctx context.Context, projectID string) ([]*Bucket, error)
+	GetBucket(ctx context.Context, projectID, name string) (*Bucket, error)
+	// UpdateBucket remplace les réglages d'un bucket
+	UpdateBucket(ctx context.Context, bucket *Bucket) error
+	// DeleteBucket supprime un bucket vide, ErrConflict s'il contient des fichiers ou des envois reprenables
+	DeleteBucket(ctx context.Context, projectID, name string) error
+
+	// PutObject crée le fichier, ou remplace celui de même identifiant
+	PutObject(ctx context.Context, object *Object) error
+	GetObject(ctx context.Context, projectID, bucket, id string) (*Object, error)
+	// FindObject retourne le fichier du bucket portant ce nom
+	FindObject(ctx context.Context, projectID, bucket, name string) (*Object, error)
+	ListObjects(ctx context.Context, projectID, bucket string, options ListOptions) ([]*Object, error)
+	DeleteObject(ctx context.Context, projectID, bucket, id string) error
+
+	CreateResumableUpload(ctx context.Context, upload *ResumableUpload) error
+	GetResumableUpload(ctx context.Context, projectID, bucket, id string) (*ResumableUpload, error)
+	// UpdateResumableUpload enregistre la progression d'un envoi s'il était toujours à
+	// l'offset previous, ErrConflict sinon : deux envois concurrents ne peuvent pas écrire
+	// la même portion du fichier
+	UpdateResumableUpload(ctx context.Context, upload *ResumableUpload, previous int64) error
+	DeleteResumableUpload(ctx context.Context, projectID, bucket, id string) error
+	// ExpiredResumableUploads retourne les envois expirés avant la date
+	ExpiredResumableUploads(ctx context.Context, before time.Time) ([]*ResumableUpload, error)
+
+	// PutVariant crée la variante, ou remplace celle de la même transformation du fichier
+	PutVariant(ctx context.Context, variant *Variant) error
+	GetVariant(ctx context.Context, projectID, bucket, objectID, transform string) (*Variant, error)
+	ListVariants(ctx context.Context, projectID, bucket, objectID string) ([]*Variant, error)
+	// DeleteVariants supprime toutes les variantes du fichier
+	DeleteVariants(ctx context.Context, projectID, bucket, objectID string) error
+}
+
+// ListOptions sélectionne les fichiers listés, dans l'ordre de leur nom
+type ListOptions struct {
+	Prefix string // Début du nom des fichiers
+	Limit  int    // Nombre maximal de fichiers (défaut : DefaultListLimit)
+	Offset int    // Fichiers à sauter
+}
+
+// Limites des listings de fichiers
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// MaxNameLength est la longueur maximale, en octets, du nom d'un fichier
+const MaxNameLength = 1024
+
+// cacheDirectivePattern valide une directive Cache-Control d'un bucket (max-age=3600, immutable)
+var cacheDirectivePattern = regexp.MustCompile(`^[a-z][a-z-]*(=[0-9]+)?$`)
+
+// maxCacheControlLength est la longueur maximale des directives Cache-Control d'un bucket
+const maxCacheControlLength = 256
+
+// bucketNamePattern valide les noms de buckets, utilisés dans les URL et les clés du backend
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,62}$`)
+
+// Upload décrit un fichier à enregistrer
+type Upload struct {
+	Name        string         // Nom du fichier dans le bucket, éventuellement avec des dossiers (users/42.png)
+	ContentType string         // application/octet-stream si vide
+	Size        int64          // Taille du contenu en octets, négative si elle n'est connue qu'à la fin du flux
+	Metadata    map[string]any // Métadonnées libres
+	Upsert      bool           // Remplace le fichier de même nom au lieu de répondre ErrConflict
+	Body        io.Reader
+}
+
+// Service réunit le contenu et les métadonnées des fichiers
+type Service struct {
+	store      Store
+	backend    Backend
+	done       chan struct{}
+	close      sync.Once
+	transforms chan struct{} // Places des transformations d'images en cours
+}
+
+// NewService crée le service de stockage
+func NewService(store Store, backend Backend) *Service {
+	return &Service{store: store, backend: backend, done: make(chan struct{}), transforms: make(chan struct{}, maxConcurrentTransforms)}
+}
+
+// Close arrête les tâches périodiques du service
+func (s *Service) Close() {
+	s.close.Do(func() { close(s.done) })
+}
+
+// NewBackend crée le backend configuré
+func NewBackend(cfg *config.Storage) (Backend, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocalBackend(cfg.LocalPath)
+	case "s3":
+		return NewS3Backend(S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("backend de stockage inconnu %q (local ou s3)", cfg.Backend)
+	}
+}
+
+// CreateBucket crée un bucket du projet
+func (s *Service) CreateBucket(ctx context.Context, projectID, name string, settings BucketSettings) (*Bucket, error) {
+	if !bucketNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: le nom du bucket doit comporter 2 à 63 minuscules, chiffres, points, tirets ou soulignés", ErrInvalid)
+	}
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	bucket := &Bucket{ProjectID: projectID, Name: name, BucketSettings: settings, CreatedAt: now, UpdatedAt: now}
+	if err := s.store.CreateBucket(ctx, bucket); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// UpdateBucket remplace les réglages d'un bucket. Une taille maximale réduite ne
+// s'applique qu'aux envois suivants.
+func (s *Service) UpdateBucket(ctx context.Context, projectID, name string, settings BucketSettings) (*Bucket, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	bucket, err := s.store.GetBucket(ctx, projectID, name)
+	if err != nil {
+		return nil, err
+	}
+	bucket.BucketSettings = settings
+	bucket.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateBucket(ctx, bucket); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// validate vérifie les réglages d'un bucket
+func (b BucketSettings) validate() error {
+	if b.MaxFileSize < 0 {
+		return fmt.Errorf("%w: max_file_size ne peut pas être négatif", ErrInvalid)
+	}
+	if b.CacheControl == "" {
+		return nil
+	}
+	if len(b.CacheControl) > maxCacheControlLength {
+		return fmt.Errorf("%w: cache_control ne peut pas dépasser %d caractères", ErrInvalid, maxCacheControlLength)
+	}
+	for _, directive := range strings.Split(b.CacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !cacheDirectivePattern.MatchString(directive) {
+			return fmt.Errorf("%w: directive cache_control invalide %q", ErrInvalid, directive)
+		}
+		// La portée de la réponse dépend de l'accès au fichier, et non du bucket
+		if name, _, _ := strings.Cut(directive, "="); name == "public" || name == "private" {
+			return fmt.Errorf("%w: cache_control ne peut pas contenir %s, fixé selon l'accès au fichier", ErrInvalid, name)
+		}
+	}
+	return nil
+}
+
+// checkSize vérifie qu'un fichier de size octets est accepté par le bucket ; une taille
+// négative, inconnue, est vérifiée pendant la lecture
+func (b *Bucket) checkSize(size int64) error {
+	if b.MaxFileSize > 0 && size > b.MaxFileSize {
+		return fmt.Errorf("%w: le bucket %q accepte des fichiers de %d octets au plus", ErrTooLarge, b.Name, b.MaxFileSize)
+	}
+	return nil
+}
+
+// ListBuckets retourne les buckets du projet
+func (s *Service) ListBuckets(ctx context.Context, projectID string) ([]*Bucket, error) {
+	return s.store.ListBuckets(ctx, projectID)
+}
+
+// Bucket retourne un bucket du projet
+func (s *Service) Bucket(ctx context.Context, projectID, name string) (*Bucket, error) {
+	return s.store.GetBucket(ctx, projectID, name)
+}
+
+// DeleteBucket supprime un bucket vide
+func (s *Service) DeleteBucket(ctx context.Context, projectID, name string) error {
+	return s.store.DeleteBucket(ctx, projectID, name)
+}
+
+// Upload enregistre un fichier dans le bucket. Le contenu est écrit avant les métadonnées :
+// un fichier listé a toujours un contenu.
+func (s *Service) Upload(ctx context.Context, projectID, bucket string, upload Upload) (*Object, error) {
+	settings, err := s.prepareUpload(ctx, projectID, bucket, upload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	object, err := s.store.FindObject(ctx, projectID, bucket, upload.Name)
+	switch {
+	case err == nil && !upload.Upsert:
+		return nil, fmt.Errorf("%w: le fichier %q existe déjà", ErrConflict, upload.Name)
+	case err == nil:
+		// Le fichier remplacé garde son identifiant, et donc sa clé
+	case errors.Is(err, ErrNotFound):
+		id, err := newObjectID()
+		if err != nil {
+			return nil, err
+		}
+		object = &Object{ID: id, ProjectID: projectID, Bucket: bucket, Name: upload.Name, CreatedAt: now}
+	default:
+		return nil, err
+	}
+
+	object.ContentType = upload.ContentType
+	if object.ContentType == "" {
+		object.ContentType = "application/octet-stream"
+	}
+	object.Metadata = upload.Metadata
+	if object.Metadata == nil {
+		object.Metadata = map[string]any{}
+	}
+	object.UpdatedAt = now
+
+	hash := sha256.New()
+	counted := &countingReader{r: io.TeeReader(upload.Body, hash), bucket: settings}
+	var body io.Reader = counted
+	if upload.Size >= 0 {
+		body = &sizedReader{r: counted, remaining: upload.Size}
+	}
+	if err := s.backend.Put(ctx, object.key(), body, upload.Size, object.ContentType); err != nil {
+		return nil, err
+	}
+	object.Size = counted.n
+	object.ETag = hex.EncodeToString(hash.Sum(nil))
+
+	if err := s.store.PutObject(ctx, object); err != nil {
+		if object.CreatedAt.Equal(now) {
+			s.backend.Delete(context.WithoutCancel(ctx), object.key())
+		}
+		return nil, err
+	}
+	// Les variantes du contenu remplacé sont périmées
+	if !object.CreatedAt.Equal(now) {
+		if err := s.discardVariants(context.WithoutCancel(ctx), object); err != nil {
+			log.Printf("⚠️ storage: variants of %s not deleted: %v", object.ID, err)
+		}
+	}
+	return object, nil
+}
+
+// prepareUpload vérifie le nom et la taille d'un fichier à enregistrer dans le bucket
+func (s *Service) prepareUpload(ctx context.Context, projectID, bucket string, upload Upload) (*Bucket, error) {
+	if err := CheckObjectName(upload.Name); err != nil {
+		return nil, err
+	}
+	settings, err := s.store.GetBucket(ctx, projectID, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.checkSize(upload.Size); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ListObjects liste les fichiers du bucket
+func (s *Service) ListObjects(ctx context.Context, projectID, bucket string, options ListOptions) ([]*Object, error) {
+	if options.Limit <= 0 {
+		options.Limit = DefaultListLimit
+	}
+	if options.Limit > MaxListLimit {
+		return nil, fmt.Errorf("%w: limit ne peut pas dépasser %d", ErrInvalid, MaxListLimit)
+	}
+	if options.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset ne peut pas être négatif", ErrInvalid)
+	}
+	if _, err := s.store.GetBucket(ctx, projectID, bucket); err != nil {
+		return nil, err
+	}
+	return s.store.ListObjects(ctx, projectID, bucket, options)
+}
+
+// Object retourne les métadonnées d'un fichier
+func (s *Service) Object(ctx context.Context, projectID, bucket, id string) (*Object, error) {
+	return s.store.GetObject(ctx, projectID, bucket, id)
+}
+
+// Open retourne un fichier et son contenu, à fermer par l'appelant
+func (s *Service) Open(ctx context.Context, projectID, bucket, id string) (*Object, *Content, error) {
+	object, err := s.store.GetObject(ctx, projectID, bucket, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.openContent(ctx, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, content, nil
+}
+
+// OpenByName retourne le fichier du bucket portant ce nom et son contenu, à fermer par l'appelant
+func (s *Service) OpenByName(ctx context.Context, projectID, bucket, name string) (*Object, *Content, error) {
+	object, err := s.store.FindObject(ctx, projectID, bucket, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.openContent(ctx, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, content, nil
+}
+
+// openContent ouvre le contenu du fichier dès maintenant, pour qu'un contenu absent soit
+// signalé avant la première lecture
+func (s *Service) openContent(ctx context.Context, object *Object) (*Content, error) {
+	reader, err := s.backend.Get(ctx, object.key(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Content{ctx: ctx, backend: s.backend, key: object.key(), size: object.Size, reader: reader}, nil
+}
+
+// Content est le contenu d'un fichier. Il peut être repositionné (io.Seeker) sans être lu :
+// la lecture reprend alors dans le backend à partir de la nouvelle position, ce qui permet
+// de servir une portion d'un fichier volumineux.
+type Content struct {
+	ctx     context.Context
+	backend Backend
+	key     string
+	size    int64
+	offset  int64         // Position de lecture
+	reader  io.ReadCloser // Contenu ouvert, à la position read
+	read    int64
+}
+
+func (c *Content) Read(p []byte) (int, error) {
+	if c.offset >= c.size {
+		return 0, io.EOF
+	}
+	if c.reader != nil && c.read != c.offset {
+		c.reader.Close()
+		c.reader = nil
+	}
+	if c.reader == nil {
+		reader, err := c.backend.Get(c.ctx, c.key, c.offset)
+		if err != nil {
+			return 0, err
+		}
+		c.reader, c.read = reader, c.offset
+	}
+	if remaining := c.size - c.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := c.reader.Read(p)
+	c.offset += int64(n)
+	c.read += int64(n)
+	if err == io.EOF && c.offset < c.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (c *Content) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += c.offset
+	case io.SeekEnd:
+		offset += c.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%w: position de lecture négative", ErrInvalid)
+	}
+	c.offset = offset
+	return offset, nil
+}
+
+func (c *Content) Close() error {
+	if c.reader == nil {
+		return nil
+	}
+	err := c.reader.Close()
+	c.reader = nil
+	return err
+}
+
+// UpdateMetadata remplace les métadonnées d'un fichier
+func (s *Service) UpdateMetadata(ctx context.Context, projectID, bucket, id string, metadata map[string]any) (*Object, error) {
+	object, err := s.store.GetObject(ctx, projectID, bucket, id)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	object.Metadata = metadata
+	object.UpdatedAt = time.Now().UTC()
+	if err := s.store.PutObject(ctx, object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// Delete supprime des fichiers du bucket et retourne le nombre de fichiers supprimés. Les
+// métadonnées sont supprimées avant le contenu : un fichier n'est jamais listé sans contenu.
+func (s *Service) Delete(ctx context.Context, projectID, bucket string, ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		object, err := s.store.GetObject(ctx, projectID, bucket, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		if err := s.store.DeleteObject(ctx, projectID, bucket, id); err != nil {
+			return deleted, err
+		}
+		if err := s.backend.Delete(ctx, object.key()); err != nil {
+			return deleted, err
+		}
+		if err := s.discardVariants(ctx, object); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// CheckObjectName vérifie le nom d'un fichier : un chemin relatif, sans segment vide, . ou ..
+func CheckObjectName(name string) error {
+	if name == "" || len(name) > MaxNameLength || !utf8.ValidString(name) {
+		return fmt.Errorf("%w: le nom du fichier doit comporter 1 à %d octets UTF-8", ErrInvalid, MaxNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%w: le nom du fichier contient des caractères de contrôle", ErrInvalid)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: nom de fichier invalide %q", ErrInvalid, name)
+		}
+	}
+	return nil
+}
+
+// newObjectID génère l'identifiant d'un fichier
+func newObjectID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// countingReader compte les octets lus, et fait échouer la lecture au-delà de la taille
+// maximale du bucket
+type countingReader struct {
+	r      io.Reader
+	bucket *Bucket
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err := c.bucket.checkSize(c.n); err != nil {
+		return n, err
+	}
+	return n, err
+}
+
+// sizedReader lit exactement remaining octets : un contenu plus court ou plus long que la
+// taille annoncée fait échouer la lecture, et donc l'écriture du backend. Avec partial,
+// remaining n'est qu'un maximum.
+type sizedReader struct {
+	r         io.Reader
+	remaining int64
+	partial   bool
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.remaining == 0 {
+		var extra [1]byte
+		if n, _ := io.ReadFull(s.r, extra[:]); n > 0 {
+			return 0, fmt.Errorf("%w: contenu plus long que la taille annoncée", ErrInvalid)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+	n, err := s.r.Read(p)
+	s.remaining -= int64(n)
+	if err == io.EOF && s.remaining > 0 {
+		if s.partial {
+			return n, io.EOF
+		}
+		return n, fmt.Errorf("%w: contenu plus court que la taille annoncée", ErrInvalid)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestService crée un service sur le disque local et un store en mémoire, avec un bucket
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	backend, err := NewLocalBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service := NewService(NewMemoryStore(), backend)
+	if _, err := service.CreateBucket(context.Background(), "proj", "avatars", BucketSettings{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return service
+}
+
+func upload(name, content string) Upload {
+	return Upload{Name: name, ContentType: "text/plain", Size: int64(len(content)), Body: strings.NewReader(content)}
+}
+
+func readAll(t *testing.T, service *Service, id string) string {
+	t.Helper()
+	_, content, err := service.Open(context.Background(), "proj", "avatars", id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(data)
+}
+
+func TestServiceBuckets(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	if _, err := service.CreateBucket(ctx, "proj", "avatars", BucketSettings{Public: true}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for a duplicate bucket, got %v", err)
+	}
+	if _, err := service.CreateBucket(ctx, "proj", "Avatars!", BucketSettings{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an invalid name, got %v", err)
+	}
+	// Les noms sont propres à chaque projet
+	if _, err := service.CreateBucket(ctx, "other", "avatars", BucketSettings{Public: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	buckets, err := service.ListBuckets(ctx, "proj")
+	if err != nil || len(buckets) != 1 || buckets[0].Name != "avatars" || buckets[0].Public {
+		t.Errorf("unexpected buckets: %+v (%v)", buckets, err)
+	}
+
+	object, err := service.Upload(ctx, "proj", "avatars", upload("a.txt", "hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.DeleteBucket(ctx, "proj", "avatars"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for a non-empty bucket, got %v", err)
+	}
+	if _, err := service.Delete(ctx, "proj", "avatars", []string{object.ID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.DeleteBucket(ctx, "proj", "avatars"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := service.Bucket(ctx, "proj", "avatars"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestServiceUpload(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	object, err := service.Upload(ctx, "proj", "avatars", upload("users/42.txt", "hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if object.Size != 5 || object.ContentType != "text/plain" || object.ETag != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected object: %+v", object)
+	}
+	if got := readAll(t, service, object.ID); got != "hello" {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	if _, err := service.Upload(ctx, "proj", "avatars", upload("users/42.txt", "again")); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for an existing name, got %v", err)
+	}
+
+	replace := upload("users/42.txt", "replaced")
+	replace.Upsert = true
+	replaced, err := service.Upload(ctx, "proj", "avatars", replace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replaced.ID != object.ID || replaced.Size != 8 || !replaced.CreatedAt.Equal(object.CreatedAt) {
+		t.Errorf("an upsert should keep the file identity: %+v", replaced)
+	}
+	if got := readAll(t, service, object.ID); got != "replaced" {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	if _, err := service.Upload(ctx, "proj", "missing", upload("a.txt", "x")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing bucket, got %v", err)
+	}
+}
+
+func TestServiceUpload_SizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	object, err := service.Upload(ctx, "proj", "avatars", upload("a.txt", "hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, size := range map[string]int64{"shorter": 10, "longer": 2} {
+		bad := upload("a.txt", "world")
+		bad.Size = size
+		bad.Upsert = true
+		if _, err := service.Upload(ctx, "proj", "avatars", bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+	// Le contenu précédent est conservé
+	if got := readAll(t, service, object.ID); got != "hello" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestServiceUpload_InvalidNames(t *testing.T) {
+	service := newTestService(t)
+	for _, name := range []string{"", "/a", "a/", "a//b", "../a", "a/./b", "a\x00b", strings.Repeat("a", MaxNameLength+1)} {
+		if _, err := service.Upload(context.Background(), "proj", "avatars", upload(name, "x")); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestServiceListAndMetadata(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	for _, name := range []string{"b.txt", "users/2.txt", "users/1.txt"} {
+		if _, err := service.Upload(ctx, "proj", "avatars", upload(name, name)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	objects, err := service.ListObjects(ctx, "proj", "avatars", ListOptions{Prefix: "users/"})
+	if err != nil || len(objects) != 2 || objects[0].Name != "users/1.txt" || objects[1].Name != "users/2.txt" {
+		t.Fatalf("unexpected objects: %+v (%v)", objects, err)
+	}
+	page, err := service.ListObjects(ctx, "proj", "avatars", ListOptions{Limit: 1, Offset: 1})
+	if err != nil || len(page) != 1 || page[0].Name != "users/1.txt" {
+		t.Errorf("unexpected page: %+v (%v)", page, err)
+	}
+	if _, err := service.ListObjects(ctx, "proj", "avatars", ListOptions{Limit: MaxListLimit + 1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a large limit, got %v", err)
+	}
+
+	updated, err := service.UpdateMetadata(ctx, "proj", "avatars", objects[0].ID, map[string]any{"owner": "42"})
+	if err != nil || updated.Metadata["owner"] != "42" {
+		t.Fatalf("unexpected object: %+v (%v)", updated, err)
+	}
+	if object, _ := service.Object(ctx, "proj", "avatars", objects[0].ID); object.Metadata["owner"] != "42" {
+		t.Errorf("metadata not saved: %+v", object.Metadata)
+	}
+
+	deleted, err := service.Delete(ctx, "proj", "avatars", []string{objects[0].ID, objects[1].ID, "missing"})
+	if err != nil || deleted != 2 {
+		t.Errorf("unexpected deletion: %d (%v)", deleted, err)
+	}
+	if _, _, err := service.Open(ctx, "proj", "avatars", objects[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLocalBackend_RejectsEscapingKeys(t *testing.T) {
+	backend, err := NewLocalBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := backend.Put(context.Background(), "../outside", strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestBucketSettings_CacheControl(t *testing.T) {
+	for _, value := range []string{"", "max-age=3600", "max-age=31536000, immutable", "no-cache", "s-maxage=60, stale-while-revalidate=30"} {
+		if err := (BucketSettings{CacheControl: value}).validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", value, err)
+		}
+	}
+	for _, value := range []string{"public, max-age=60", "private", "max-age=abc", "max-age=60\r\nSet-Cookie: x=y", "max-age=60,,", strings.Repeat("immutable,", 30)} {
+		if err := (BucketSettings{CacheControl: value}).validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestServiceOpen_Seek(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	object, err := service.Upload(ctx, "proj", "avatars", upload("docs/a.txt", "0123456789"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found, content, err := service.OpenByName(ctx, "proj", "avatars", "docs/a.txt")
+	if err != nil || found.ID != object.ID {
+		t.Fatalf("unexpected file: %+v (%v)", found, err)
+	}
+	defer content.Close()
+	if size, err := content.Seek(0, io.SeekEnd); size != 10 || err != nil {
+		t.Errorf("unexpected size: %d (%v)", size, err)
+	}
+	content.Seek(4, io.SeekStart)
+	part := make([]byte, 3)
+	if _, err := io.ReadFull(content, part); err != nil || string(part) != "456" {
+		t.Errorf("unexpected content %q (%v)", part, err)
+	}
+	content.Seek(-2, io.SeekCurrent)
+	if rest, err := io.ReadAll(content); err != nil || string(rest) != "56789" {
+		t.Errorf("unexpected content %q (%v)", rest, err)
+	}
+	if _, err := content.Seek(-1, io.SeekStart); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a negative offset, got %v", err)
+	}
+
+	if _, _, err := service.OpenByName(ctx, "proj", "avatars", "docs/missing.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestServiceUpload_UnknownSize(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	stream := upload("stream.txt", "streamed content")
+	stream.Size = -1
+	object, err := service.Upload(ctx, "proj", "avatars", stream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if object.Size != 16 {
+		t.Errorf("the size should be counted while streaming: %+v", object)
+	}
+	if got := readAll(t, service, object.ID); got != "streamed content" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+func TestServiceUpload_MaxFileSize(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	if _, err := service.UpdateBucket(ctx, "proj", "avatars", BucketSettings{MaxFileSize: 5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdateBucket(ctx, "proj", "avatars", BucketSettings{MaxFileSize: -1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a negative size, got %v", err)
+	}
+
+	if _, err := service.Upload(ctx, "proj", "avatars", upload("a.txt", "hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Une taille annoncée trop grande est refusée avant la lecture du contenu
+	if _, err := service.Upload(ctx, "proj", "avatars", upload("b.txt", "hello!")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	// Une taille inconnue est vérifiée pendant la lecture
+	stream := upload("c.txt", "hello!")
+	stream.Size = -1
+	if _, err := service.Upload(ctx, "proj", "avatars", stream); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if objects, _ := service.ListObjects(ctx, "proj", "avatars", ListOptions{}); len(objects) != 1 {
+		t.Errorf("refused files should not be listed: %+v", objects)
+	}
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ketsuna-org/sovrabase/internal/config"
+)
+
+// NewStore ouvre le store des métadonnées dans la base interne du plan de contrôle
+func NewStore(ctx context.Context, cfg *config.InternalDB) (Store, error) {
+	switch cfg.Manager {
+	case "postgres":
+		return NewPostgresStore(ctx, cfg.URI)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("base interne %q non supportée par le stockage (postgres ou memory)", cfg.Manager)
+	}
+}
+
+// MemoryStore conserve les métadonnées en mémoire, perdues à l'arrêt du serveur : il est
+// destiné aux tests et au développement
+type MemoryStore struct {
+	mu       sync.RWMutex
+	buckets  map[string]*Bucket          // Par projet et nom
+	objects  map[string]*Object          // Par projet, bucket et identifiant
+	uploads  map[string]*ResumableUpload // Par projet, bucket et identifiant
+	variants map[string]*Variant         // Par projet, bucket, fichier et transformation
+}
+
+// NewMemoryStore crée un store en mémoire vide
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*Bucket),
+		objects:  make(map[string]*Object),
+		uploads:  make(map[string]*ResumableUpload),
+		variants: make(map[string]*Variant),
+	}
+}
+
+func bucketKey(projectID, name string) string {
+	return projectID + "/" + name
+}
+
+func (s *MemoryStore) CreateBucket(ctx context.Context, bucket *Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := bucketKey(bucket.ProjectID, bucket.Name)
+	if _, ok := s.buckets[key]; ok {
+		return fmt.Errorf("%w: le bucket %q existe déjà", ErrConflict, bucket.Name)
+	}
+	copied := *bucket
+	s.buckets[key] = &copied
+	return nil
+}
+
+func (s *MemoryStore) ListBuckets(ctx context.Context, projectID string) ([]*Bucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	buckets := []*Bucket{}
+	for _, bucket := range s.buckets {
+		if bucket.ProjectID == projectID {
+			copied := *bucket
+			buckets = append(buckets, &copied)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets, nil
+}
+
+func (s *MemoryStore) GetBucket(ctx context.Context, projectID, name string) (*Bucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	bucket, ok := s.buckets[bucketKey(projectID, name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: bucket %q", ErrNotFound, name)
+	}
+	copied := *bucket
+	return &copied, nil
+}
+
+func (s *MemoryStore) UpdateBucket(ctx context.Context, bucket *Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := bucketKey(bucket.ProjectID, bucket.Name)
+	if _, ok := s.buckets[key]; !ok {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, bucket.Name)
+	}
+	copied := *bucket
+	s.buckets[key] = &copied
+	return nil
+}
+
+func (s *MemoryStore) DeleteBucket(ctx context.Context, projectID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := bucketKey(projectID, name)
+	if _, ok := s.buckets[key]; !ok {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, name)
+	}
+	for _, object := range s.objects {
+		if object.ProjectID == projectID && object.Bucket == name {
+			return fmt.Errorf("%w: le bucket %q contient des fichiers ou des envois en cours", ErrConflict, name)
+		}
+	}
+	for _, upload := range s.uploads {
+		if upload.ProjectID == projectID && upload.Bucket == name {
+			return fmt.Errorf("%w: le bucket %q contient des fichiers ou des envois en cours", ErrConflict, name)
+		}
+	}
+	delete(s.buckets, key)
+	return nil
+}
+
+func (s *MemoryStore) PutObject(ctx context.Context, object *Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[bucketKey(object.ProjectID, object.Bucket)]; !ok {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, object.Bucket)
+	}
+	for _, other := range s.objects {
+		if other.ProjectID == object.ProjectID && other.Bucket == object.Bucket && other.Name == object.Name && other.ID != object.ID {
+			return fmt.Errorf("%w: le fichier %q existe déjà", ErrConflict, object.Name)
+		}
+	}
+	s.objects[object.key()] = copyObject(object)
+	return nil
+}
+
+func (s *MemoryStore) GetObject(ctx context.Context, projectID, bucket, id string) (*Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[projectID+"/"+bucket+"/"+id]
+	if !ok {
+		return nil, fmt.Errorf("%w: fichier %q", ErrNotFound, id)
+	}
+	return copyObject(object), nil
+}
+
+func (s *MemoryStore) FindObject(ctx context.Context, projectID, bucket, name string) (*Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, object := range s.objects {
+		if object.ProjectID == projectID && object.Bucket == bucket && object.Name == name {
+			return copyObject(object), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: fichier %q", ErrNotFound, name)
+}
+
+func (s *MemoryStore) ListObjects(ctx context.Context, projectID, bucket string, options ListOptions) ([]*Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var objects []*Object
+	for _, object := range s.objects {
+		if object.ProjectID == projectID && object.Bucket == bucket && strings.HasPrefix(object.Name, options.Prefix) {
+			objects = append(objects, copyObject(object))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+
+	objects = objects[min(options.Offset, len(objects)):]
+	if options.Limit > 0 && len(objects) > options.Limit {
+		objects = objects[:options.Limit]
+	}
+	return append([]*Object{}, objects...), nil
+}
+
+func (s *MemoryStore) DeleteObject(ctx context.Context, projectID, bucket, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := projectID + "/" + bucket + "/" + id
+	if _, ok := s.objects[key]; !ok {
+		return fmt.Errorf("%w: fichier %q", ErrNotFound, id)
+	}
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *MemoryStore) CreateResumableUpload(ctx context.Context, upload *ResumableUpload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[bucketKey(upload.ProjectID, upload.Bucket)]; !ok {
+		return fmt.Errorf("%w: bucket %q", ErrNotFound, upload.Bucket)
+	}
+	s.uploads[upload.ProjectID+"/"+upload.Bucket+"/"+upload.ID] = copyResumableUpload(upload)
+	return nil
+}
+
+func (s *MemoryStore) GetResumableUpload(ctx context.Context, projectID, bucket, id string) (*ResumableUpload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	upload, ok := s.uploads[projectID+"/"+bucket+"/"+id]
+	if !ok {
+		return nil, fmt.Errorf("%w: envoi %q", ErrNotFound, id)
+	}
+	return copyResumableUpload(upload), nil
+}
+
+func (s *MemoryStore) UpdateResumableUpload(ctx context.Context, upload *ResumableUpload, previous int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := upload.ProjectID + "/" + upload.Bucket + "/" + upload.ID
+	current, ok := s.uploads[key]
+	if !ok {
+		return fmt.Errorf("%w: envoi %q", ErrNotFound, upload.ID)
+	}
+	if current.Offset != previous {
+		return fmt.Errorf("%w: l'envoi %q a progressé entre-temps", ErrConflict, upload.ID)
+	}
+	s.uploads[key] = copyResumableUpload(upload)
+	return nil
+}
+
+func (s *MemoryStore) DeleteResumableUpload(ctx context.Context, projectID, bucket, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := projectID + "/" + bucket + "/" + id
+	if _, ok := s.uploads[key]; !ok {
+		return fmt.Errorf("%w: envoi %q", ErrNotFound, id)
+	}
+	delete(s.uploads, key)
+	return nil
+}
+
+func (s *MemoryStore) ExpiredResumableUploads(ctx context.Context, before time.Time) ([]*ResumableUpload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var uploads []*ResumableUpload
+	for _, upload := range s.uploads {
+		if upload.ExpiresAt.Before(before) {
+			uploads = append(uploads, copyResumableUpload(upload))
+		}
+	}
+	return uploads, nil
+}
+
+func (s *MemoryStore) PutVariant(ctx context.Context, variant *Variant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *variant
+	s.variants[variant.ProjectID+"/"+variant.Bucket+"/"+variant.ObjectID+"/"+variant.Transform] = &copied
+	return nil
+}
+
+func (s *MemoryStore) GetVariant(ctx context.Context, projectID, bucket, objectID, transform string) (*Variant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	variant, ok := s.variants[projectID+"/"+bucket+"/"+objectID+"/"+transform]
+	if !ok {
+		return nil, fmt.Errorf("%w: variante %q du fichier %q", ErrNotFound, transform, objectID)
+	}
+	copied := *variant
+	return &copied, nil
+}
+
+func (s *MemoryStore) ListVariants(ctx context.Context, projectID, bucket, objectID string) ([]*Variant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var variants []*Variant
+	for _, variant := range s.variants {
+		if variant.ProjectID == projectID && variant.Bucket == bucket && variant.ObjectID == objectID {
+			copied := *variant
+			variants = append(variants, &copied)
+		}
+	}
+	return variants, nil
+}
+
+func (s *MemoryStore) DeleteVariants(ctx context.Context, projectID, bucket, objectID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, variant := range s.variants {
+		if variant.ProjectID == projectID && variant.Bucket == bucket && variant.ObjectID == objectID {
+			delete(s.variants, key)
+		}
+	}
+	return nil
+}
+
+// copyResumableUpload copie un envoi, ses portions et ses métadonnées
+func copyResumableUpload(upload *ResumableUpload) *ResumableUpload {
+	copied := *upload
+	copied.Chunks = append([]Chunk{}, upload.Chunks...)
+	copied.Metadata = make(map[string]any, len(upload.Metadata))
+	for name, value := range upload.Metadata {
+		copied.Metadata[name] = value
+	}
+	return &copied
+}
+
+// copyObject copie un fichier et ses métadonnées, pour que les appelants ne partagent pas l'état du store
+func copyObject(object *Object) *Object {
+	copied := *object
+	copied.Metadata = make(map[string]any, len(object.Metadata))
+	for name, value := range object.Metadata {
+		copied.Metadata[name] = value
+	}
+	return &copied
+}
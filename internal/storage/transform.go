@@ -0,0 +1,465 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Décodage des GIF, dont la première image est transformée
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Limites des transformations d'images, qui protègent la mémoire et le processeur du
+// serveur : une transformation décode l'image entière.
+const (
+	MaxTransformDimension    = 2500       // Largeur et hauteur maximales d'une image transformée
+	MaxTransformSourcePixels = 25_000_000 // Pixels maximaux d'une image à transformer
+	MaxTransformSourceSize   = 25 << 20   // Taille maximale, en octets, d'une image à transformer
+	MaxVariants              = 20         // Variantes conservées par fichier, les suivantes étant recalculées à chaque demande
+	DefaultTransformQuality  = 80
+	maxConcurrentTransforms  = 2 // Transformations simultanées
+)
+
+// Modes de redimensionnement, lorsque la largeur et la hauteur sont données
+const (
+	ResizeCover   = "cover"   // Remplit les dimensions, en rognant l'excédent au centre (défaut)
+	ResizeContain = "contain" // Tient dans les dimensions, en gardant les proportions
+	ResizeFill    = "fill"    // Étire l'image aux dimensions
+)
+
+// Formats des images transformées
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp" // Sans perte
+)
+
+// imageFormats associe les types de contenu lus aux formats d'images
+var imageFormats = map[string]string{
+	"image/jpeg": FormatJPEG,
+	"image/png":  FormatPNG,
+	"image/gif":  FormatPNG, // Les GIF sont convertis en PNG par défaut
+}
+
+// Crop est une zone de l'image d'origine, en pixels
+type Crop struct {
+	X, Y, Width, Height int
+}
+
+// Transform décrit une variante d'une image : la zone conservée, redimensionnée puis
+// convertie dans un format
+type Transform struct {
+	Crop    *Crop  // Zone de l'image d'origine, l'image entière si nil
+	Width   int    // Largeur en pixels, 0 pour la déduire des proportions
+	Height  int    // Hauteur en pixels, 0 pour la déduire des proportions
+	Resize  string // Mode de redimensionnement, ResizeCover par défaut
+	Format  string // Format de l'image d'origine par défaut (PNG pour un GIF)
+	Quality int    // Qualité JPEG de 1 à 100, DefaultTransformQuality par défaut ; refusée pour les autres formats, sans perte
+}
+
+// normalize vérifie la transformation d'une image du type contentType et complète ses
+// valeurs par défaut
+func (t *Transform) normalize(contentType string) error {
+	source, ok := imageFormats[contentType]
+	if !ok {
+		return fmt.Errorf("%w: seules les images JPEG, PNG et GIF peuvent être transformées, pas %q", ErrInvalid, contentType)
+	}
+	if t.Width < 0 || t.Height < 0 || t.Width > MaxTransformDimension || t.Height > MaxTransformDimension {
+		return fmt.Errorf("%w: width et height doivent être compris entre 1 et %d", ErrInvalid, MaxTransformDimension)
+	}
+	// Aucune image à transformer ne dépasse MaxTransformSourcePixels de côté : la borne
+	// supérieure écarte aussi les valeurs dont la somme déborderait
+	if c := t.Crop; c != nil && (c.X < 0 || c.Y < 0 || c.Width < 1 || c.Height < 1 ||
+		c.X > MaxTransformSourcePixels || c.Y > MaxTransformSourcePixels ||
+		c.Width > MaxTransformSourcePixels || c.Height > MaxTransformSourcePixels) {
+		return fmt.Errorf("%w: zone de rognage invalide", ErrInvalid)
+	}
+	switch t.Resize {
+	case "":
+		t.Resize = ResizeCover
+	case ResizeCover, ResizeContain, ResizeFill:
+	default:
+		return fmt.Errorf("%w: resize doit être %s, %s ou %s", ErrInvalid, ResizeCover, ResizeContain, ResizeFill)
+	}
+	switch t.Format {
+	case "":
+		t.Format = source
+	case FormatJPEG, FormatPNG, FormatWebP:
+	default:
+		return fmt.Errorf("%w: format doit être %s, %s ou %s", ErrInvalid, FormatJPEG, FormatPNG, FormatWebP)
+	}
+	if t.Format != FormatJPEG {
+		if t.Quality != 0 {
+			return fmt.Errorf("%w: quality ne s'applique qu'au format %s, les formats %s et %s étant sans perte", ErrInvalid, FormatJPEG, FormatPNG, FormatWebP)
+		}
+		return nil
+	}
+	if t.Quality == 0 {
+		t.Quality = DefaultTransformQuality
+	}
+	if t.Quality < 1 || t.Quality > 100 {
+		return fmt.Errorf("%w: quality doit être comprise entre 1 et 100", ErrInvalid)
+	}
+	return nil
+}
+
+// canonical retourne la forme canonique d'une transformation normalisée, qui identifie sa
+// variante. La qualité ne distingue que les variantes JPEG, les autres formats étant sans perte.
+func (t *Transform) canonical() string {
+	key := "w=" + strconv.Itoa(t.Width) + ",h=" + strconv.Itoa(t.Height) + ",resize=" + t.Resize + ",format=" + t.Format
+	if t.Format == FormatJPEG {
+		key += ",q=" + strconv.Itoa(t.Quality)
+	}
+	if c := t.Crop; c != nil {
+		key += fmt.Sprintf(",crop=%d:%d:%d:%d", c.X, c.Y, c.Width, c.Height)
+	}
+	return key
+}
+
+// Variant est une image transformée d'un fichier, conservée dans le backend pour les
+// demandes suivantes de la même transformation
+type Variant struct {
+	ProjectID   string    `json:"project_id"`
+	Bucket      string    `json:"bucket"`
+	ObjectID    string    `json:"object_id"`
+	Transform   string    `json:"transform"`   // Forme canonique de la transformation
+	SourceETag  string    `json:"source_etag"` // Empreinte du fichier transformé : la variante est périmée s'il a changé
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag"` // Empreinte SHA-256 de la variante
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// key retourne la clé du contenu de la variante, rangé à côté des fichiers du bucket sous
+// un dossier qui ne peut pas être un identifiant de fichier
+func (v *Variant) key() string {
+	sum := sha256.Sum256([]byte(v.Transform))
+	return v.ProjectID + "/" + v.Bucket + "/.variants/" + v.ObjectID + "/" + hex.EncodeToString(sum[:16])
+}
+
+// describe retourne le fichier tel qu'il est servi par la variante : son type, sa taille,
+// son empreinte et sa date sont ceux de l'image transformée
+func (v *Variant) describe(object *Object) *Object {
+	described := *object
+	described.ContentType = v.ContentType
+	described.Size = v.Size
+	described.ETag = v.ETag
+	described.UpdatedAt = v.CreatedAt
+	return &described
+}
+
+// ObjectByName retourne les métadonnées du fichier du bucket portant ce nom
+func (s *Service) ObjectByName(ctx context.Context, projectID, bucket, name string) (*Object, error) {
+	return s.store.FindObject(ctx, projectID, bucket, name)
+}
+
+// OpenVariant retourne l'image transformée du fichier, décrite comme un fichier, et son
+// contenu, à fermer par l'appelant. La variante est calculée à la première demande, puis
+// conservée dans le backend tant que le fichier n'est pas remplacé.
+func (s *Service) OpenVariant(ctx context.Context, object *Object, t Transform) (*Object, io.ReadSeekCloser, error) {
+	if err := t.normalize(object.ContentType); err != nil {
+		return nil, nil, err
+	}
+	transform := t.canonical()
+
+	cached, err := s.store.GetVariant(ctx, object.ProjectID, object.Bucket, object.ID, transform)
+	switch {
+	case err == nil && cached.SourceETag == object.ETag:
+		reader, err := s.backend.Get(ctx, cached.key(), 0)
+		if err == nil {
+			content := &Content{ctx: ctx, backend: s.backend, key: cached.key(), size: cached.Size, reader: reader}
+			return cached.describe(object), content, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, nil, err
+		}
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return nil, nil, err
+	}
+
+	data, err := s.transformImage(ctx, object, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	variant := &Variant{
+		ProjectID:   object.ProjectID,
+		Bucket:      object.Bucket,
+		ObjectID:    object.ID,
+		Transform:   transform,
+		SourceETag:  object.ETag,
+		ContentType: "image/" + t.Format,
+		Size:        int64(len(data)),
+		ETag:        hex.EncodeToString(sum[:]),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.saveVariant(context.WithoutCancel(ctx), variant, data, cached != nil); err != nil {
+		log.Printf("⚠️ storage: variant of %s not cached: %v", object.ID, err)
+	}
+	return variant.describe(object), nopSeekCloser{bytes.NewReader(data)}, nil
+}
+
+// saveVariant conserve la variante, sauf si le fichier en a déjà MaxVariants : une
+// variante périmée (replaced) est remplacée sous la même clé
+func (s *Service) saveVariant(ctx context.Context, variant *Variant, data []byte, replaced bool) error {
+	if !replaced {
+		variants, err := s.store.ListVariants(ctx, variant.ProjectID, variant.Bucket, variant.ObjectID)
+		if err != nil {
+			return err
+		}
+		if len(variants) >= MaxVariants {
+			return nil
+		}
+	}
+	if err := s.backend.Put(ctx, variant.key(), bytes.NewReader(data), variant.Size, variant.ContentType); err != nil {
+		return err
+	}
+	return s.store.PutVariant(ctx, variant)
+}
+
+// discardVariants supprime les variantes du fichier, puis leur contenu
+func (s *Service) discardVariants(ctx context.Context, object *Object) error {
+	variants, err := s.store.ListVariants(ctx, object.ProjectID, object.Bucket, object.ID)
+	if err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+	if err := s.store.DeleteVariants(ctx, object.ProjectID, object.Bucket, object.ID); err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if err := s.backend.Delete(ctx, variant.key()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformImage décode l'image du fichier, la transforme et retourne son encodage. Le
+// nombre de transformations simultanées est limité, chacune décodant l'image entière.
+func (s *Service) transformImage(ctx context.Context, object *Object, t Transform) ([]byte, error) {
+	if object.Size > MaxTransformSourceSize {
+		return nil, fmt.Errorf("%w: seules les images de %d octets au plus peuvent être transformées", ErrTooLarge, MaxTransformSourceSize)
+	}
+	select {
+	case s.transforms <- struct{}{}:
+		defer func() { <-s.transforms }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	content, err := s.backend.Get(ctx, object.key(), 0)
+	if err != nil {
+		return nil, err
+	}
+	source, err := io.ReadAll(io.LimitReader(content, MaxTransformSourceSize))
+	content.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("%w: image illisible: %v", ErrInvalid, err)
+	}
+	if config.Width*config.Height > MaxTransformSourcePixels {
+		return nil, fmt.Errorf("%w: seules les images de %d pixels au plus peuvent être transformées", ErrTooLarge, MaxTransformSourcePixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("%w: image illisible: %v", ErrInvalid, err)
+	}
+
+	result, err := applyTransform(img, t)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	switch t.Format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, flatten(result), &jpeg.Options{Quality: t.Quality})
+	case FormatPNG:
+		err = png.Encode(&buf, result)
+	case FormatWebP:
+		err = encodeWebP(&buf, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// applyTransform rogne puis redimensionne l'image
+func applyTransform(img image.Image, t Transform) (*image.RGBA, error) {
+	area := img.Bounds()
+	if c := t.Crop; c != nil {
+		if c.Width > area.Dx()-c.X || c.Height > area.Dy()-c.Y {
+			return nil, fmt.Errorf("%w: la zone de rognage dépasse l'image de %dx%d pixels", ErrInvalid, area.Dx(), area.Dy())
+		}
+		area = image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height).Add(area.Min)
+	}
+
+	// Dimensions de sortie, et zone conservée par le mode cover
+	sw, sh := area.Dx(), area.Dy()
+	width, height := t.Width, t.Height
+	switch {
+	case width == 0 && height == 0:
+		width, height = sw, sh
+	case height == 0:
+		height = scale(sh, width, sw)
+	case width == 0:
+		width = scale(sw, height, sh)
+	case t.Resize == ResizeContain:
+		if sw*height > sh*width {
+			height = scale(sh, width, sw)
+		} else {
+			width = scale(sw, height, sh)
+		}
+	case t.Resize == ResizeCover:
+		if sw*height > sh*width {
+			kept := scale(sh, width, height)
+			area.Min.X += (sw - kept) / 2
+			area.Max.X = area.Min.X + kept
+		} else {
+			kept := scale(sw, height, width)
+			area.Min.Y += (sh - kept) / 2
+			area.Max.Y = area.Min.Y + kept
+		}
+	}
+	// Une dimension déduite des proportions peut dépasser la limite : l'image est réduite
+	if width > MaxTransformDimension || height > MaxTransformDimension {
+		if width >= height {
+			width, height = MaxTransformDimension, scale(height, MaxTransformDimension, width)
+		} else {
+			width, height = scale(width, MaxTransformDimension, height), MaxTransformDimension
+		}
+	}
+
+	source := image.NewRGBA(image.Rect(0, 0, area.Dx(), area.Dy()))
+	draw.Draw(source, source.Rect, img, area.Min, draw.Src)
+	return resample(source, width, height), nil
+}
+
+// scale retourne value * num / den arrondi, au moins 1
+func scale(value, num, den int) int {
+	return max(1, int(math.Round(float64(value)*float64(num)/float64(den))))
+}
+
+// resample redimensionne l'image par un filtre triangulaire séparable, élargi lors des
+// réductions pour que chaque pixel de sortie moyenne tous les pixels d'origine qu'il couvre
+func resample(src *image.RGBA, width, height int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	// Passe horizontale, de sw x sh vers width x sh
+	horizontal := image.NewRGBA(image.Rect(0, 0, width, sh))
+	taps := filterTaps(sw, width)
+	for y := 0; y < sh; y++ {
+		in := src.Pix[y*src.Stride:]
+		out := horizontal.Pix[y*horizontal.Stride:]
+		for x, weights := range taps {
+			var sum [4]float32
+			for _, tap := range weights {
+				for c := range sum {
+					sum[c] += float32(in[tap.index*4+c]) * tap.weight
+				}
+			}
+			for c := range sum {
+				out[x*4+c] = clampUint8(sum[c])
+			}
+		}
+	}
+
+	// Passe verticale, de width x sh vers width x height
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	taps = filterTaps(sh, height)
+	for y, weights := range taps {
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var sum [4]float32
+			for _, tap := range weights {
+				in := horizontal.Pix[tap.index*horizontal.Stride+x*4:]
+				for c := range sum {
+					sum[c] += float32(in[c]) * tap.weight
+				}
+			}
+			for c := range sum {
+				out[x*4+c] = clampUint8(sum[c])
+			}
+		}
+	}
+	return dst
+}
+
+// filterTap est le poids d'un pixel d'origine dans un pixel de sortie
+type filterTap struct {
+	index  int
+	weight float32
+}
+
+// filterTaps retourne, pour chaque pixel de sortie d'une ligne, les pixels d'origine qui le
+// composent et leurs poids normalisés
+func filterTaps(srcLen, dstLen int) [][]filterTap {
+	ratio := float64(srcLen) / float64(dstLen)
+	support := max(ratio, 1)
+	taps := make([][]filterTap, dstLen)
+	for i := range taps {
+		center := (float64(i)+0.5)*ratio - 0.5
+		first, last := int(math.Floor(center-support)), int(math.Ceil(center+support))
+		var total float64
+		weights := make([]filterTap, 0, last-first+1)
+		for j := first; j <= last; j++ {
+			weight := 1 - math.Abs(float64(j)-center)/support
+			if weight <= 0 {
+				continue
+			}
+			weights = append(weights, filterTap{index: min(max(j, 0), srcLen-1), weight: float32(weight)})
+			total += weight
+		}
+		for k := range weights {
+			weights[k].weight /= float32(total)
+		}
+		taps[i] = weights
+	}
+	return taps
+}
+
+// clampUint8 arrondit une composante dans [0, 255]
+func clampUint8(value float32) uint8 {
+	return uint8(min(max(value+0.5, 0), 255))
+}
+
+// flatten pose l'image sur un fond blanc, JPEG n'ayant pas de transparence
+func flatten(img *image.RGBA) *image.RGBA {
+	if img.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Rect)
+	draw.Draw(dst, dst.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Rect, img, img.Rect.Min, draw.Over)
+	return dst
+}
+
+// nopSeekCloser ajoute une fermeture sans effet à un contenu en mémoire
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
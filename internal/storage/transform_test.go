@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"testing"
+)
+
+// uploadImage envoie une image PNG de width x height pixels, moitié gauche rouge, moitié droite bleue
+func uploadImage(t *testing.T, service *Service, name string, width, height int) *Object {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= width/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	object, err := service.Upload(context.Background(), "proj", "avatars", Upload{
+		Name: name, ContentType: "image/png", Size: int64(buf.Len()), Body: &buf, Upsert: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return object
+}
+
+// openVariant retourne la variante décrite et son image décodée
+func openVariant(t *testing.T, service *Service, object *Object, transform Transform) (*Object, image.Image) {
+	t.Helper()
+	variant, content, err := service.OpenVariant(context.Background(), object, transform)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if int64(len(data)) != variant.Size {
+		t.Errorf("size %d announced for %d bytes", variant.Size, len(data))
+	}
+	if variant.ContentType == "image/webp" {
+		return variant, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return variant, img
+}
+
+func TestOpenVariant_Resize(t *testing.T) {
+	service := newTestService(t)
+	object := uploadImage(t, service, "photo.png", 400, 200)
+
+	tests := []struct {
+		transform     Transform
+		width, height int
+	}{
+		{Transform{Width: 100}, 100, 50},
+		{Transform{Height: 100}, 200, 100},
+		{Transform{Width: 100, Height: 100}, 100, 100},
+		{Transform{Width: 100, Height: 100, Resize: ResizeContain}, 100, 50},
+		{Transform{Width: 100, Height: 100, Resize: ResizeFill}, 100, 100},
+		{Transform{Crop: &Crop{X: 10, Y: 20, Width: 50, Height: 30}}, 50, 30},
+		{Transform{Width: 800}, 800, 400},
+		{Transform{Width: MaxTransformDimension, Height: 0}, MaxTransformDimension, 1250},
+	}
+	for _, test := range tests {
+		variant, img := openVariant(t, service, object, test.transform)
+		if size := img.Bounds().Size(); size.X != test.width || size.Y != test.height {
+			t.Errorf("%+v: unexpected size %v, expected %dx%d", test.transform, size, test.width, test.height)
+		}
+		if variant.ContentType != "image/png" || variant.ID != object.ID {
+			t.Errorf("%+v: unexpected variant %+v", test.transform, variant)
+		}
+	}
+
+	// cover garde le centre : les deux couleurs restent présentes, séparées au milieu
+	_, img := openVariant(t, service, object, Transform{Width: 50, Height: 50})
+	if r, _, b, _ := img.At(2, 25).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("unexpected left color %v", img.At(2, 25))
+	}
+	if r, _, b, _ := img.At(47, 25).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("unexpected right color %v", img.At(47, 25))
+	}
+	// Le rognage ne garde que la moitié gauche, rouge
+	_, img = openVariant(t, service, object, Transform{Crop: &Crop{Width: 100, Height: 100}, Width: 10})
+	if r, _, b, _ := img.At(9, 9).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("unexpected cropped color %v", img.At(9, 9))
+	}
+}
+
+func TestOpenVariant_Formats(t *testing.T) {
+	service := newTestService(t)
+	object := uploadImage(t, service, "photo.png", 64, 48)
+
+	variant, img := openVariant(t, service, object, Transform{Width: 32, Format: FormatJPEG, Quality: 50})
+	if variant.ContentType != "image/jpeg" || img.Bounds().Dx() != 32 {
+		t.Errorf("unexpected JPEG variant %+v", variant)
+	}
+
+	variant, _, err := service.OpenVariant(context.Background(), object, Transform{Width: 32, Format: FormatWebP})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if variant.ContentType != "image/webp" {
+		t.Errorf("unexpected WebP variant %+v", variant)
+	}
+
+	for _, transform := range []Transform{
+		{Width: MaxTransformDimension + 1},
+		{Height: -1},
+		{Format: "tiff"},
+		{Resize: "zoom", Width: 10, Height: 10},
+		{Quality: 101},
+		{Crop: &Crop{X: -1, Width: 10, Height: 10}},
+		{Crop: &Crop{X: 60, Width: 10, Height: 10}},
+		// X + Width déborderait et passerait la comparaison avec la largeur de l'image
+		{Crop: &Crop{X: math.MaxInt, Width: 1, Height: 1}},
+		{Crop: &Crop{X: 1, Width: math.MaxInt, Height: 1}},
+		{Crop: &Crop{Y: 1, Width: 1, Height: math.MaxInt}},
+		{Format: FormatWebP, Quality: 50},
+		{Format: FormatPNG, Quality: 50},
+	} {
+		if _, _, err := service.OpenVariant(context.Background(), object, transform); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%+v: expected ErrInvalid, got %v", transform, err)
+		}
+	}
+
+	text, err := service.Upload(context.Background(), "proj", "avatars", upload("notes.txt", "hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := service.OpenVariant(context.Background(), text, Transform{Width: 10}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a text file, got %v", err)
+	}
+}
+
+func TestApplyTransform_CropOverflow(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for _, crop := range []Crop{
+		{X: math.MaxInt, Width: 1, Height: 1},
+		{Y: math.MaxInt, Width: 1, Height: 1},
+		{X: 5, Width: 6, Height: 1},
+	} {
+		if _, err := applyTransform(img, Transform{Crop: &crop}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%+v: expected ErrInvalid, got %v", crop, err)
+		}
+	}
+}
+
+func TestOpenVariant_Cache(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+	object := uploadImage(t, service, "photo.png", 100, 100)
+
+	first, _ := openVariant(t, service, object, Transform{Width: 10, Format: FormatJPEG})
+	// Même transformation, écrite autrement : la variante conservée est servie
+	second, _ := openVariant(t, service, object, Transform{Width: 10, Format: FormatJPEG, Quality: DefaultTransformQuality})
+	if first.ETag != second.ETag || !first.UpdatedAt.Equal(second.UpdatedAt) {
+		t.Errorf("the cached variant should be served: %+v, %+v", first, second)
+	}
+	variants, _ := service.store.ListVariants(ctx, "proj", "avatars", object.ID)
+	if len(variants) != 1 {
+		t.Fatalf("unexpected variants: %+v", variants)
+	}
+	if _, err := service.backend.Get(ctx, variants[0].key(), 0); err != nil {
+		t.Errorf("the variant should be stored: %v", err)
+	}
+
+	// Remplacer le fichier supprime ses variantes
+	replaced := uploadImage(t, service, "photo.png", 50, 50)
+	if variants, _ := service.store.ListVariants(ctx, "proj", "avatars", object.ID); len(variants) != 0 {
+		t.Errorf("variants of a replaced file should be deleted: %+v", variants)
+	}
+	_, img := openVariant(t, service, replaced, Transform{Width: 10, Height: 20, Resize: ResizeFill, Format: FormatJPEG})
+	if img.Bounds().Dy() != 20 {
+		t.Errorf("unexpected variant of the replaced file: %v", img.Bounds())
+	}
+
+	// Au-delà de MaxVariants, les variantes sont calculées sans être conservées
+	for width := 1; width <= MaxVariants+5; width++ {
+		openVariant(t, service, replaced, Transform{Width: width})
+	}
+	variants, _ = service.store.ListVariants(ctx, "proj", "avatars", object.ID)
+	if len(variants) != MaxVariants {
+		t.Errorf("expected %d variants, got %d", MaxVariants, len(variants))
+	}
+
+	if _, err := service.Delete(ctx, "proj", "avatars", []string{object.ID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if variants, _ := service.store.ListVariants(ctx, "proj", "avatars", object.ID); len(variants) != 0 {
+		t.Errorf("variants of a deleted file should be deleted: %+v", variants)
+	}
+	for _, variant := range variants {
+		if _, err := service.backend.Get(ctx, variant.key(), 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound for the variant content, got %v", err)
+		}
+	}
+}
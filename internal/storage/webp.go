@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// Le format WebP sans perte (VP8L, RFC 9649) est écrit dans sa forme la plus simple : sans
+// transformation ni référence arrière, chaque pixel est codé par quatre codes préfixes
+// (vert, rouge, bleu, alpha) construits sur l'histogramme de l'image. Le résultat est plus
+// lourd que celui d'un encodeur complet, mais lisible par tous les décodeurs WebP.
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 16384
+	vp8lMaxCodeLength = 15 // Longueur maximale d'un code préfixe
+	vp8lMaxLengthCode = 7  // Longueur maximale d'un code des longueurs
+	vp8lGreenAlphabet = 256 + 24
+	vp8lDistAlphabet  = 40
+)
+
+// vp8lCodeLengthOrder est l'ordre d'écriture des longueurs du code des longueurs
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP écrit l'image au format WebP sans perte
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return fmt.Errorf("%w: une image WebP mesure de 1 à %d pixels de côté", ErrInvalid, vp8lMaxDimension)
+	}
+
+	pixels := make([]color.NRGBA, 0, width*height)
+	histograms := [4][]int{make([]int, vp8lGreenAlphabet), make([]int, 256), make([]int, 256), make([]int, 256)}
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixels = append(pixels, c)
+			histograms[0][c.G]++
+			histograms[1][c.R]++
+			histograms[2][c.B]++
+			histograms[3][c.A]++
+			opaque = opaque && c.A == 0xff
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3) // Version
+	bw.write(0, 1) // Aucune transformation
+	bw.write(0, 1) // Aucun cache de couleurs
+	bw.write(0, 1) // Un seul groupe de codes préfixes pour toute l'image
+
+	var codes [4]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = writePrefixCode(bw, histogram)
+	}
+	writePrefixCode(bw, make([]int, vp8lDistAlphabet)) // Distances, inutilisées
+
+	for _, c := range pixels {
+		codes[0].write(bw, int(c.G))
+		codes[1].write(bw, int(c.R))
+		codes[2].write(bw, int(c.B))
+		codes[3].write(bw, int(c.A))
+	}
+	data := bw.bytes()
+
+	// Conteneur RIFF, dont les blocs ont une taille paire
+	padding := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+len(data)+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// prefixCode est un code préfixe canonique : codes contient, pour chaque symbole, ses
+// lengths[symbole] bits dans l'ordre d'écriture
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+}
+
+// write écrit le code du symbole ; un code à un seul symbole n'occupe aucun bit
+func (c prefixCode) write(bw *bitWriter, symbol int) {
+	bw.write(uint32(c.codes[symbol]), uint(c.lengths[symbol]))
+}
+
+// writePrefixCode écrit le code préfixe des symboles comptés dans counts et le retourne
+func writePrefixCode(bw *bitWriter, counts []int) prefixCode {
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	// Un ou deux symboles : code simple, donnant les symboles eux-mêmes
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		code := prefixCode{lengths: make([]uint8, len(counts)), codes: make([]uint16, len(counts))}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	// Code normal : les longueurs de ses codes sont elles-mêmes codées par un code préfixe
+	lengths := huffmanLengths(counts, vp8lMaxCodeLength)
+	lengthCounts := make([]int, 19)
+	for _, length := range lengths {
+		lengthCounts[length]++
+	}
+	lengthCode := canonicalCode(huffmanLengths(lengthCounts, vp8lMaxLengthCode))
+
+	written := len(vp8lCodeLengthOrder)
+	for written > 4 && lengthCode.lengths[vp8lCodeLengthOrder[written-1]] == 0 {
+		written--
+	}
+	bw.write(0, 1)
+	bw.write(uint32(written-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:written] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.write(0, 1) // Une longueur pour chaque symbole de l'alphabet
+	for _, length := range lengths {
+		lengthCode.write(bw, int(length))
+	}
+	return canonicalCode(lengths)
+}
+
+// huffmanLengths retourne les longueurs d'un code de Huffman des symboles comptés, au plus
+// limit bits : les comptes sont réduits jusqu'à ce que l'arbre tienne dans la limite. Un
+// symbole unique reçoit un code d'un bit, complété par un second symbole inutilisé, pour
+// que le code reste complet.
+func huffmanLengths(counts []int, limit int) []uint8 {
+	for {
+		lengths := huffmanDepths(counts)
+		longest := uint8(0)
+		for _, length := range lengths {
+			longest = max(longest, length)
+		}
+		if int(longest) <= limit {
+			return lengths
+		}
+		reduced := make([]int, len(counts))
+		for symbol, count := range counts {
+			if count > 0 {
+				reduced[symbol] = (count + 1) / 2
+			}
+		}
+		counts = reduced
+	}
+}
+
+// huffmanDepths retourne la profondeur de chaque symbole dans l'arbre de Huffman des comptes
+func huffmanDepths(counts []int) []uint8 {
+	type node struct {
+		count       int
+		left, right int // Enfants, -1 pour une feuille
+		symbol      int
+	}
+	var nodes []node
+	var active []int
+	for symbol, count := range counts {
+		if count > 0 {
+			nodes = append(nodes, node{count: count, left: -1, right: -1, symbol: symbol})
+			active = append(active, len(nodes)-1)
+		}
+	}
+
+	depths := make([]uint8, len(counts))
+	if len(active) == 1 {
+		depths[nodes[0].symbol] = 1
+		depths[(nodes[0].symbol+1)%len(counts)] = 1
+		return depths
+	}
+	for len(active) > 1 {
+		sort.Slice(active, func(i, j int) bool {
+			a, b := nodes[active[i]], nodes[active[j]]
+			if a.count != b.count {
+				return a.count < b.count
+			}
+			return active[i] < active[j]
+		})
+		nodes = append(nodes, node{count: nodes[active[0]].count + nodes[active[1]].count, left: active[0], right: active[1]})
+		active = append(active[2:], len(nodes)-1)
+	}
+
+	var walk func(index int, depth uint8)
+	walk = func(index int, depth uint8) {
+		if n := nodes[index]; n.left < 0 {
+			depths[n.symbol] = depth
+		} else {
+			walk(n.left, depth+1)
+			walk(n.right, depth+1)
+		}
+	}
+	if len(active) == 1 {
+		walk(active[0], 0)
+	}
+	return depths
+}
+
+// canonicalCode attribue les codes canoniques des longueurs, dans l'ordre des longueurs puis
+// des symboles, et les retourne renversés : le décodeur lit les bits de poids faible d'abord
+func canonicalCode(lengths []uint8) prefixCode {
+	var lengthCounts [vp8lMaxCodeLength + 1]int
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCounts[length]++
+		}
+	}
+	var next [vp8lMaxCodeLength + 1]int
+	code := 0
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + lengthCounts[length-1]) << 1
+		next[length] = code
+	}
+
+	codes := make([]uint16, len(lengths))
+	for symbol, length := range lengths {
+		if length > 0 {
+			codes[symbol] = bits.Reverse16(uint16(next[length])) >> (16 - length)
+			next[length]++
+		}
+	}
+	return prefixCode{lengths: lengths, codes: codes}
+}
+
+// bitWriter écrit des valeurs bit à bit, en commençant par les bits de poids faible
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+func (b *bitWriter) write(value uint32, n uint) {
+	b.acc |= uint64(value) << b.nacc
+	b.nacc += n
+	for b.nacc >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nacc -= 8
+	}
+}
+
+// bytes retourne les octets écrits, le dernier complété de zéros
+func (b *bitWriter) bytes() []byte {
+	if b.nacc > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nacc = 0, 0
+	}
+	return b.buf
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestHuffmanLengths(t *testing.T) {
+	// Des comptes en suite de Fibonacci donnent un arbre de 24 niveaux, à limiter à 15
+	counts := []int{1, 1}
+	for len(counts) < 25 {
+		counts = append(counts, counts[len(counts)-1]+counts[len(counts)-2])
+	}
+	for _, limit := range []int{vp8lMaxCodeLength, vp8lMaxLengthCode} {
+		lengths := huffmanLengths(counts, limit)
+		// Le code doit être complet : la somme des 2^-longueur vaut 1
+		kraft := 0
+		for _, length := range lengths {
+			if int(length) > limit || length == 0 {
+				t.Fatalf("unexpected length %d for the limit %d", length, limit)
+			}
+			kraft += 1 << (limit - int(length))
+		}
+		if kraft != 1<<limit {
+			t.Errorf("incomplete code for the limit %d: %v", limit, lengths)
+		}
+	}
+
+	// Un symbole unique reçoit un code d'un bit, complété par un second symbole
+	if lengths := huffmanLengths([]int{0, 0, 5, 0}, vp8lMaxLengthCode); lengths[2] != 1 || lengths[3] != 1 {
+		t.Errorf("unexpected lengths for a single symbol: %v", lengths)
+	}
+}
+
+func TestCanonicalCode(t *testing.T) {
+	// Exemple de la RFC 1951 : longueurs (3, 3, 3, 3, 3, 2, 4, 4), codes 010 à 111, 00, 1110 et 1111
+	code := canonicalCode([]uint8{3, 3, 3, 3, 3, 2, 4, 4})
+	expected := []uint16{0b010, 0b011, 0b100, 0b101, 0b110, 0b00, 0b1110, 0b1111}
+	for symbol, want := range expected {
+		length := code.lengths[symbol]
+		var reversed uint16
+		for i := uint8(0); i < length; i++ {
+			reversed |= (code.codes[symbol] >> i & 1) << (length - 1 - i)
+		}
+		if reversed != want {
+			t.Errorf("symbol %d: code %b, expected %b", symbol, reversed, want)
+		}
+	}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 7))
+	for x := 0; x < 300; x++ {
+		img.SetNRGBA(x, 3, color.NRGBA{R: uint8(x), G: uint8(x / 2), B: 7, A: 128})
+	}
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" || len(data)%2 != 0 {
+		t.Fatalf("unexpected header %q", data[:16])
+	}
+	if size := binary.LittleEndian.Uint32(data[4:]); int(size) != len(data)-8 {
+		t.Errorf("RIFF size %d for %d bytes", size, len(data))
+	}
+	if data[20] != vp8lSignature {
+		t.Errorf("unexpected signature %x", data[20])
+	}
+	header := binary.LittleEndian.Uint32(data[21:])
+	width, height := header&0x3fff+1, header>>14&0x3fff+1
+	if width != 300 || height != 7 || header>>28&1 != 1 || header>>29 != 0 {
+		t.Errorf("unexpected image header: %dx%d, alpha %d, version %d", width, height, header>>28&1, header>>29)
+	}
+
+	if err := encodeWebP(&buf, image.NewNRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1))); err == nil {
+		t.Error("expected an error for an image too wide")
+	}
+}
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	// Image opaque de dimensions impaires, aux couleurs variées
+	opaque := image.NewRGBA(image.Rect(0, 0, 37, 23))
+	for i := 0; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i], opaque.Pix[i+1], opaque.Pix[i+2], opaque.Pix[i+3] = uint8(random.Intn(256)), uint8(random.Intn(256)), uint8(random.Intn(256)), 0xff
+	}
+
+	// Image transparente : alpha variable, pixels invisibles compris
+	alpha := image.NewNRGBA(image.Rect(0, 0, 64, 33))
+	for i := 0; i < len(alpha.Pix); i += 4 {
+		alpha.Pix[i], alpha.Pix[i+1], alpha.Pix[i+2], alpha.Pix[i+3] = uint8(random.Intn(256)), uint8(random.Intn(4)), 200, uint8(random.Intn(256))
+	}
+
+	// Peu de couleurs : des codes de Huffman courts, voire d'un seul symbole
+	flat := image.NewNRGBA(image.Rect(0, 0, 301, 7))
+	for x := 0; x < 301; x++ {
+		flat.SetNRGBA(x, 3, color.NRGBA{R: uint8(x), G: uint8(x / 2), B: 7, A: 128})
+	}
+
+	images := map[string]image.Image{
+		"opaque":    opaque,
+		"alpha":     alpha,
+		"flat":      flat,
+		"pixel":     image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+		"sub-image": opaque.SubImage(image.Rect(5, 3, 18, 20)),
+	}
+	for name, img := range images {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeWebP(&buf, img); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			decoded, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("the encoded image cannot be decoded: %v", err)
+			}
+
+			bounds := img.Bounds()
+			if decoded.Bounds().Dx() != bounds.Dx() || decoded.Bounds().Dy() != bounds.Dy() {
+				t.Fatalf("decoded %v, expected %v", decoded.Bounds(), bounds)
+			}
+			for y := 0; y < bounds.Dy(); y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					want := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+					got := color.NRGBAModel.Convert(decoded.At(decoded.Bounds().Min.X+x, decoded.Bounds().Min.Y+y))
+					if got != want {
+						t.Fatalf("pixel (%d, %d): got %v, expected %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}